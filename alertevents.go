@@ -0,0 +1,101 @@
+package gtfs
+
+import (
+	"crypto/sha256"
+)
+
+// AlertEvent is a change to an alert between two consecutive realtime messages, as returned by
+// [DiffAlerts]. The event is one of [AlertCreated], [AlertUpdated] and [AlertClosed].
+type AlertEvent interface {
+	// GetAlert returns the alert the event is about: the alert in the newer message, or in the older
+	// message for an [AlertClosed] event.
+	GetAlert() *Alert
+}
+
+// AlertCreated is emitted for an alert that is in the newer message but not in the older message.
+type AlertCreated struct {
+	Alert *Alert
+}
+
+// AlertUpdated is emitted for an alert whose hash changed between the messages.
+type AlertUpdated struct {
+	PreviousAlert *Alert
+	Alert         *Alert
+}
+
+// AlertClosed is emitted for an alert that is in the older message but not in the newer message.
+type AlertClosed struct {
+	Alert *Alert
+}
+
+func (e AlertCreated) GetAlert() *Alert { return e.Alert }
+func (e AlertUpdated) GetAlert() *Alert { return e.Alert }
+func (e AlertClosed) GetAlert() *Alert  { return e.Alert }
+
+// DiffAlertsOptions configures [DiffAlerts].
+type DiffAlertsOptions struct {
+	// If true, changes to the active periods of an alert are not reported as updates. This is useful
+	// for feeds that rewrite the active periods of every alert relative to the current time.
+	IgnoreActivePeriods bool
+
+	// If true, changes to the informed entities of an alert are not reported as updates.
+	IgnoreInformedEntities bool
+}
+
+// DiffAlerts returns the events in the lifecycle of the alerts that happened between the previous and
+// current realtime messages.
+//
+// Alerts are matched across the messages by ID, and an alert is updated if its hash, as calculated
+// by [Alert.Hash] with the fields ignored by the options cleared, changed. Created and updated events
+// are returned in the order of the alerts in the current message, followed by closed events in the
+// order of the alerts in the previous message.
+func DiffAlerts(previous, current *Realtime, opts DiffAlertsOptions) []AlertEvent {
+	idToPreviousAlert := map[string]*Alert{}
+	if previous != nil {
+		for i := range previous.Alerts {
+			if _, ok := idToPreviousAlert[previous.Alerts[i].ID]; !ok {
+				idToPreviousAlert[previous.Alerts[i].ID] = &previous.Alerts[i]
+			}
+		}
+	}
+	var events []AlertEvent
+	currentIDs := map[string]bool{}
+	for i := range current.Alerts {
+		alert := &current.Alerts[i]
+		if currentIDs[alert.ID] {
+			continue
+		}
+		currentIDs[alert.ID] = true
+		previousAlert := idToPreviousAlert[alert.ID]
+		if previousAlert == nil {
+			events = append(events, AlertCreated{Alert: alert})
+			continue
+		}
+		if alertHash(previousAlert, opts) != alertHash(alert, opts) {
+			events = append(events, AlertUpdated{PreviousAlert: previousAlert, Alert: alert})
+		}
+	}
+	if previous != nil {
+		for i := range previous.Alerts {
+			alert := &previous.Alerts[i]
+			if idToPreviousAlert[alert.ID] != alert || currentIDs[alert.ID] {
+				continue
+			}
+			events = append(events, AlertClosed{Alert: alert})
+		}
+	}
+	return events
+}
+
+func alertHash(alert *Alert, opts DiffAlertsOptions) string {
+	a := *alert
+	if opts.IgnoreActivePeriods {
+		a.ActivePeriods = nil
+	}
+	if opts.IgnoreInformedEntities {
+		a.InformedEntities = nil
+	}
+	h := sha256.New()
+	a.Hash(h)
+	return string(h.Sum(nil))
+}
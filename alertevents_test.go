@@ -0,0 +1,65 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+)
+
+func TestDiffAlerts(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	previous := &Realtime{
+		Alerts: []Alert{
+			{ID: "unchanged", Header: []AlertText{{Text: "Delays"}}},
+			{ID: "updated", Header: []AlertText{{Text: "Delays"}}},
+			{ID: "new_period", ActivePeriods: []AlertActivePeriod{{StartsAt: ptr(t0)}}},
+			{ID: "new_entity", InformedEntities: []AlertInformedEntity{{RouteID: ptr("A")}}},
+			{ID: "closed"},
+		},
+	}
+	current := &Realtime{
+		Alerts: []Alert{
+			{ID: "created"},
+			{ID: "unchanged", Header: []AlertText{{Text: "Delays"}}},
+			{ID: "updated", Header: []AlertText{{Text: "Delays"}}, Effect: gtfsrt.Alert_REDUCED_SERVICE},
+			{ID: "new_period", ActivePeriods: []AlertActivePeriod{{StartsAt: ptr(t0.Add(time.Minute))}}},
+			{ID: "new_entity", InformedEntities: []AlertInformedEntity{{RouteID: ptr("A")}, {RouteID: ptr("C")}}},
+		},
+	}
+
+	for _, tc := range []struct {
+		name string
+		opts DiffAlertsOptions
+		want []AlertEvent
+	}{
+		{
+			name: "default",
+			want: []AlertEvent{
+				AlertCreated{Alert: &current.Alerts[0]},
+				AlertUpdated{PreviousAlert: &previous.Alerts[1], Alert: &current.Alerts[2]},
+				AlertUpdated{PreviousAlert: &previous.Alerts[2], Alert: &current.Alerts[3]},
+				AlertUpdated{PreviousAlert: &previous.Alerts[3], Alert: &current.Alerts[4]},
+				AlertClosed{Alert: &previous.Alerts[4]},
+			},
+		},
+		{
+			name: "ignore active periods and informed entities",
+			opts: DiffAlertsOptions{IgnoreActivePeriods: true, IgnoreInformedEntities: true},
+			want: []AlertEvent{
+				AlertCreated{Alert: &current.Alerts[0]},
+				AlertUpdated{PreviousAlert: &previous.Alerts[1], Alert: &current.Alerts[2]},
+				AlertClosed{Alert: &previous.Alerts[4]},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := DiffAlerts(previous, current, tc.opts)
+			// Pointers are compared by identity so that the diff shows which alert an event is about.
+			if diff := cmp.Diff(got, tc.want, cmp.Comparer(func(a, b *Alert) bool { return a == b })); diff != "" {
+				t.Errorf("DiffAlerts() diff: %s", diff)
+			}
+		})
+	}
+}
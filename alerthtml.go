@@ -0,0 +1,202 @@
+package gtfs
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// AlertLink is a hyperlink that was removed from HTML alert text.
+type AlertLink struct {
+	// The text of the link, as it appears in the plain text.
+	Text string
+	URL  string
+}
+
+// HTMLToPlainText converts HTML, as embedded by some agencies in alert text, to plain text.
+//
+// Tags are removed and entities are unescaped. Whitespace is collapsed as in HTML, and line breaks
+// are inserted for <br> tags and block elements like paragraphs and list items; list items are
+// prefixed with "- ". The content of <script> and <style> elements is dropped. The text of each link
+// is kept in the result and the links are returned separately, in the order they appear.
+//
+// If the string does not contain any HTML tags it is returned unchanged.
+func HTMLToPlainText(s string) (string, []AlertLink) {
+	if !containsHTMLTag(s) {
+		return s, nil
+	}
+	var c htmlConverter
+	for i := 0; i < len(s); {
+		if isHTMLTagStart(s, i) {
+			if j := strings.IndexByte(s[i:], '>'); j >= 0 {
+				c.tag(s[i+1 : i+j])
+				i += j + 1
+				continue
+			}
+		}
+		j := i + 1
+		for j < len(s) && !isHTMLTagStart(s, j) {
+			j++
+		}
+		c.text(s[i:j])
+		i = j
+	}
+	return strings.TrimSpace(string(c.out)), c.links
+}
+
+func isHTMLTagStart(s string, i int) bool {
+	if s[i] != '<' || i+1 >= len(s) {
+		return false
+	}
+	c := s[i+1]
+	return c == '/' || c == '!' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func containsHTMLTag(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if isHTMLTagStart(s, i) && strings.IndexByte(s[i:], '>') >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+type htmlConverter struct {
+	out          []byte
+	pendingSpace bool
+	// Name of the element whose content is being skipped, if any.
+	skipping string
+	link     *pendingLink
+	links    []AlertLink
+}
+
+type pendingLink struct {
+	url   string
+	start int
+}
+
+func (c *htmlConverter) text(raw string) {
+	if c.skipping != "" {
+		return
+	}
+	for _, r := range html.UnescapeString(raw) {
+		if unicode.IsSpace(r) {
+			c.pendingSpace = true
+			continue
+		}
+		if c.pendingSpace && len(c.out) > 0 && c.out[len(c.out)-1] != '\n' {
+			c.out = append(c.out, ' ')
+		}
+		c.pendingSpace = false
+		c.out = utf8.AppendRune(c.out, r)
+	}
+}
+
+func (c *htmlConverter) tag(content string) {
+	if strings.HasPrefix(content, "!") {
+		return
+	}
+	closing := strings.HasPrefix(content, "/")
+	name := strings.TrimPrefix(content, "/")
+	if i := strings.IndexAny(name, " \t\r\n/"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.ToLower(name)
+	if c.skipping != "" {
+		if closing && name == c.skipping {
+			c.skipping = ""
+		}
+		return
+	}
+	switch name {
+	case "script", "style":
+		if !closing {
+			c.skipping = name
+		}
+	case "br":
+		c.lineBreaks(1)
+	case "p", "h1", "h2", "h3", "h4", "h5", "h6":
+		c.lineBreaks(2)
+	case "div", "ul", "ol", "table", "tr", "blockquote":
+		c.lineBreaks(1)
+	case "li":
+		if !closing {
+			c.lineBreaks(1)
+			c.out = append(c.out, "- "...)
+		}
+	case "a":
+		if !closing {
+			c.link = &pendingLink{url: htmlAttribute(content, "href"), start: len(c.out)}
+			return
+		}
+		if c.link != nil && c.link.url != "" {
+			c.links = append(c.links, AlertLink{
+				Text: strings.TrimSpace(string(c.out[c.link.start:])),
+				URL:  c.link.url,
+			})
+		}
+		c.link = nil
+	}
+}
+
+// lineBreaks ensures the output ends with at least n line breaks, unless the output is empty.
+func (c *htmlConverter) lineBreaks(n int) {
+	c.trimTrailingSpace()
+	c.pendingSpace = false
+	if len(c.out) == 0 {
+		return
+	}
+	existing := 0
+	for i := len(c.out) - 1; i >= 0 && c.out[i] == '\n'; i-- {
+		existing++
+	}
+	for ; existing < n; existing++ {
+		c.out = append(c.out, '\n')
+	}
+}
+
+func (c *htmlConverter) trimTrailingSpace() {
+	for len(c.out) > 0 && c.out[len(c.out)-1] == ' ' {
+		c.out = c.out[:len(c.out)-1]
+	}
+}
+
+// htmlAttribute returns the unescaped value of the attribute in the content of a tag, or the empty
+// string if the tag does not have the attribute.
+func htmlAttribute(content, name string) string {
+	lower := strings.ToLower(content)
+	for i := 0; i < len(lower); {
+		j := strings.Index(lower[i:], name)
+		if j < 0 {
+			return ""
+		}
+		j += i
+		i = j + len(name)
+		if j == 0 || !unicode.IsSpace(rune(content[j-1])) {
+			continue
+		}
+		rest := strings.TrimLeftFunc(content[i:], unicode.IsSpace)
+		if !strings.HasPrefix(rest, "=") {
+			continue
+		}
+		rest = strings.TrimLeftFunc(rest[1:], unicode.IsSpace)
+		if rest == "" {
+			return ""
+		}
+		var value string
+		if q := rest[0]; q == '"' || q == '\'' {
+			value = rest[1:]
+			if end := strings.IndexByte(value, q); end >= 0 {
+				value = value[:end]
+			}
+		} else {
+			value = rest
+			if end := strings.IndexFunc(value, unicode.IsSpace); end >= 0 {
+				value = value[:end]
+			}
+		}
+		return html.UnescapeString(value)
+	}
+	return ""
+}
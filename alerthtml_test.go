@@ -0,0 +1,61 @@
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHTMLToPlainText(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		input     string
+		wantText  string
+		wantLinks []AlertLink
+	}{
+		{
+			name:     "plain text",
+			input:    "Trains run  every 10 minutes\nuntil 5 < 6 &amp; later",
+			wantText: "Trains run  every 10 minutes\nuntil 5 < 6 &amp; later",
+		},
+		{
+			name:     "line breaks and paragraphs",
+			input:    "<p>No <b>A</b> trains\n   between stations.</p><p>Take the C instead.<br/>Allow extra time.</p>",
+			wantText: "No A trains between stations.\n\nTake the C instead.\nAllow extra time.",
+		},
+		{
+			name:     "lists",
+			input:    "Affected stops:<ul><li>14 St</li><li>23 St</li></ul>",
+			wantText: "Affected stops:\n- 14 St\n- 23 St",
+		},
+		{
+			name:     "entities",
+			input:    "<div>Fish&nbsp;&amp;&nbsp;Chips &lt;3 &#8212; caf&eacute;</div>",
+			wantText: "Fish & Chips <3 — café",
+		},
+		{
+			name:     "script and style",
+			input:    "<style>p { color: red; }</style><p>Shuttle buses</p><script>alert('<p>');</script>",
+			wantText: "Shuttle buses",
+		},
+		{
+			name:     "links",
+			input:    `See <a class="x" HREF="https://example.com/a?b=1&amp;c=2">the <i>map</i></a> or <a href='/faq'>FAQ</a>.<a>no href</a>`,
+			wantText: "See the map or FAQ.no href",
+			wantLinks: []AlertLink{
+				{Text: "the map", URL: "https://example.com/a?b=1&c=2"},
+				{Text: "FAQ", URL: "/faq"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gotText, gotLinks := HTMLToPlainText(tc.input)
+			if gotText != tc.wantText {
+				t.Errorf("HTMLToPlainText() text = %q, want %q", gotText, tc.wantText)
+			}
+			if diff := cmp.Diff(gotLinks, tc.wantLinks); diff != "" {
+				t.Errorf("HTMLToPlainText() links diff: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package gtfs
+
+import (
+	"runtime/debug"
+
+	"github.com/jamespfennell/gtfs/constants"
+	"github.com/jamespfennell/gtfs/schema"
+)
+
+const modulePath = "github.com/jamespfennell/gtfs"
+
+// GtfsRealtimeVersion is the version of the GTFS realtime specification supported by the realtime parser.
+const GtfsRealtimeVersion = "2.0"
+
+// Version returns the version of this package, as recorded in the build information of the running binary.
+//
+// If the version cannot be determined (for example, when running the package's own tests) "(devel)" is returned.
+func Version() string {
+	return versionFromBuildInfo(debug.ReadBuildInfo())
+}
+
+func versionFromBuildInfo(info *debug.BuildInfo, ok bool) string {
+	if !ok || info == nil {
+		return "(devel)"
+	}
+	if info.Main.Path == modulePath && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != modulePath {
+			continue
+		}
+		if dep.Replace != nil && dep.Replace.Version != "" {
+			return dep.Replace.Version
+		}
+		if dep.Version != "" {
+			return dep.Version
+		}
+	}
+	return "(devel)"
+}
+
+// SupportLevel describes how much of a GTFS feature is supported by the parser.
+type SupportLevel int32
+
+const (
+	SupportLevel_NotSupported       SupportLevel = 0
+	SupportLevel_PartiallySupported SupportLevel = 1
+	SupportLevel_Supported          SupportLevel = 2
+)
+
+func (l SupportLevel) String() string {
+	switch l {
+	case SupportLevel_Supported:
+		return "SUPPORTED"
+	case SupportLevel_PartiallySupported:
+		return "PARTIALLY_SUPPORTED"
+	default:
+		return "NOT_SUPPORTED"
+	}
+}
+
+// Capabilities describes the GTFS files and fields supported by this package.
+type Capabilities struct {
+	// Version of this package; see [Version].
+	Version string
+
+	// Version of the GTFS realtime specification supported by the realtime parser.
+	GtfsRealtimeVersion string
+
+	// Support for each file in the GTFS static specification.
+	StaticFiles []StaticFileCapability
+}
+
+// StaticFileCapability describes the support for a single GTFS static file.
+type StaticFileCapability struct {
+	File    constants.StaticFile
+	Support SupportLevel
+
+	// Columns of the file that are read by the parser.
+	//
+	// This is derived from the schema in the schema package.
+	Columns []string
+}
+
+// GetCapabilities returns the capabilities of this package.
+//
+// This can be used to display supported features dynamically, or in tests to assert coverage.
+func GetCapabilities() Capabilities {
+	return Capabilities{
+		Version:             Version(),
+		GtfsRealtimeVersion: GtfsRealtimeVersion,
+		StaticFiles:         staticFileCapabilities(),
+	}
+}
+
+func staticFileCapabilities() []StaticFileCapability {
+	capabilities := []StaticFileCapability{
+		{
+			File:    constants.AgencyFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.StopsFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.RoutesFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.TripsFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.StopTimesFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.CalendarFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.CalendarDatesFile,
+			Support: SupportLevel_Supported,
+		},
+		{File: constants.FareAttributesFile},
+		{
+			File:    constants.FareRulesFile,
+			Support: SupportLevel_Supported,
+		},
+		{File: constants.TimeframesFile},
+		{
+			File:    constants.FareMediaFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.FareProductsFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.FareLegRulesFile,
+			Support: SupportLevel_Supported,
+		},
+		{File: constants.FareLegJoinRulesFile},
+		{
+			File:    constants.FareTransferRulesFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.AreasFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.StopAreasFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.NetworksFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.RouteNetworksFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.LocationGroupsFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.ShapesFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.FrequenciesFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.TransfersFile,
+			Support: SupportLevel_PartiallySupported,
+		},
+		{
+			File:    constants.PathwaysFile,
+			Support: SupportLevel_Supported,
+		},
+		{File: constants.LevelsFile},
+		{
+			File:    constants.LocationGroupStopsFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.LocationsFile,
+			Support: SupportLevel_Supported,
+		},
+		{
+			File:    constants.BookingRulesFile,
+			Support: SupportLevel_Supported,
+		},
+		{File: constants.TranslationsFile},
+		{
+			File:    constants.FeedInfoFile,
+			Support: SupportLevel_Supported,
+		},
+		{File: constants.AttributionsFile},
+	}
+	for i := range capabilities {
+		if file, ok := schema.Lookup(capabilities[i].File); ok {
+			capabilities[i].Columns = file.ColumnNames()
+		}
+	}
+	return capabilities
+}
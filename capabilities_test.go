@@ -0,0 +1,165 @@
+package gtfs
+
+import (
+	"fmt"
+	"runtime/debug"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs/constants"
+	"github.com/jamespfennell/gtfs/schema"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+func TestCapabilities_ParsedFiles(t *testing.T) {
+	support := map[constants.StaticFile]SupportLevel{}
+	for _, capability := range GetCapabilities().StaticFiles {
+		if _, ok := support[capability.File]; ok {
+			t.Errorf("file %s listed more than once", capability.File)
+		}
+		support[capability.File] = capability.Support
+	}
+	for _, file := range constants.StaticFiles() {
+		file := file
+		t.Run(string(file), func(t *testing.T) {
+			s, ok := support[file]
+			if !ok {
+				t.Fatalf("file %s missing from capabilities", file)
+			}
+			read := isReadByParser(t, file)
+			if read && s == SupportLevel_NotSupported {
+				t.Errorf("file %s is read by the parser but listed as %s", file, s)
+			}
+			if !read && s != SupportLevel_NotSupported {
+				t.Errorf("file %s is not read by the parser but listed as %s", file, s)
+			}
+		})
+	}
+}
+
+// isReadByParser returns whether the static parser reads the provided file, by checking whether
+// replacing the file with invalid content changes the result of parsing.
+func isReadByParser(t *testing.T, file constants.StaticFile) bool {
+	opts := ParseStaticOptions{Flex: true}
+	if file == constants.LocationsFile {
+		static, err := ParseStatic(newZipBuilderWithDefaults().add(string(file), "not json").build(), opts)
+		if err != nil {
+			t.Fatalf("ParseStatic() err = %s, want nil", err)
+		}
+		for _, w := range static.Warnings {
+			if _, ok := w.Kind.(warnings.InvalidGeoJSON); ok && w.File == file {
+				return true
+			}
+		}
+		return false
+	}
+	// An empty CSV file has no header row and fails to open.
+	_, err := ParseStatic(newZipBuilderWithDefaults().add(string(file), "").build(), opts)
+	return err != nil && err.Error() == fmt.Sprintf("failed to read %q: CSV file contains no rows", file)
+}
+
+func TestCapabilities_Columns(t *testing.T) {
+	capabilities := map[constants.StaticFile]StaticFileCapability{}
+	for _, capability := range GetCapabilities().StaticFiles {
+		capabilities[capability.File] = capability
+	}
+	for _, file := range schema.Files() {
+		capability, ok := capabilities[file.File]
+		if !ok {
+			t.Errorf("schema file %s missing from capabilities", file.File)
+			continue
+		}
+		if diff := cmp.Diff(file.ColumnNames(), capability.Columns); diff != "" {
+			t.Errorf("columns of %s got != want, diff=%s", file.File, diff)
+		}
+	}
+	for file, capability := range capabilities {
+		if _, ok := schema.Lookup(file); !ok && capability.Columns != nil {
+			t.Errorf("file %s not in schema but has columns %v", file, capability.Columns)
+		}
+	}
+}
+
+func TestVersion(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		info *debug.BuildInfo
+		ok   bool
+		want string
+	}{
+		{
+			name: "no build info",
+			info: nil,
+			ok:   false,
+			want: "(devel)",
+		},
+		{
+			name: "main module",
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: modulePath, Version: "v1.2.3"},
+			},
+			ok:   true,
+			want: "v1.2.3",
+		},
+		{
+			name: "main module without version",
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: modulePath},
+			},
+			ok:   true,
+			want: "(devel)",
+		},
+		{
+			name: "dependency",
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/main"},
+				Deps: []*debug.Module{
+					{Path: "example.com/other", Version: "v9.9.9"},
+					{Path: modulePath, Version: "v1.2.3"},
+				},
+			},
+			ok:   true,
+			want: "v1.2.3",
+		},
+		{
+			name: "replaced dependency",
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/main"},
+				Deps: []*debug.Module{
+					{
+						Path:    modulePath,
+						Version: "v1.2.3",
+						Replace: &debug.Module{Path: "example.com/fork", Version: "v1.2.4"},
+					},
+				},
+			},
+			ok:   true,
+			want: "v1.2.4",
+		},
+		{
+			name: "not a dependency",
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/main"},
+			},
+			ok:   true,
+			want: "(devel)",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := versionFromBuildInfo(tc.info, tc.ok)
+			if got != tc.want {
+				t.Errorf("versionFromBuildInfo() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGetCapabilities_Version(t *testing.T) {
+	capabilities := GetCapabilities()
+	if capabilities.Version != Version() {
+		t.Errorf("Version = %q, want %q", capabilities.Version, Version())
+	}
+	if capabilities.GtfsRealtimeVersion != GtfsRealtimeVersion {
+		t.Errorf("GtfsRealtimeVersion = %q, want %q", capabilities.GtfsRealtimeVersion, GtfsRealtimeVersion)
+	}
+}
@@ -0,0 +1,442 @@
+package gtfs
+
+// DeepClone returns a copy of the static data that shares no memory with the original.
+//
+// Pointers between entities are re-linked so that, for example, the parent of a stop in the clone
+// is the corresponding stop in the clone's Stops slice, and the agency of a route in the clone is
+// the corresponding agency in the clone's Agencies slice.
+// If the stop times are being streamed, the clone reads them from the same source.
+//
+// The clone is owned by the caller, and modifying it does not affect the original or any other clone.
+// This is the way to obtain a mutable copy of a message that is shared.
+func (s *Static) DeepClone() *Static {
+	if s == nil {
+		return nil
+	}
+	c := staticCloner{
+		agencies:  map[*Agency]*Agency{},
+		stops:     map[*Stop]*Stop{},
+		routes:    map[*Route]*Route{},
+		services:  map[*Service]*Service{},
+		shapes:    map[*Shape]*Shape{},
+		trips:     map[*ScheduledTrip]*ScheduledTrip{},
+		areas:     map[*Area]*Area{},
+		networks:  map[*Network]*Network{},
+		fareMedia: map[*FareMedium]*FareMedium{},
+
+		locationGroups: map[*LocationGroup]*LocationGroup{},
+		flexLocations:  map[*FlexLocation]*FlexLocation{},
+		bookingRules:   map[*BookingRule]*BookingRule{},
+	}
+	// The slices are cloned in dependency order so that pointers into them are re-linked rather
+	// than copied.
+	// Routes and networks point to each other, so the networks are registered before the routes are
+	// cloned.
+	networks := registerSlice(c.networks, s.Networks)
+	clone := &Static{
+		Agencies:        cloneSlice(c.agencies, s.Agencies, c.fillAgency),
+		Stops:           cloneSlice(c.stops, s.Stops, c.fillStop),
+		Routes:          cloneSlice(c.routes, s.Routes, c.fillRoute),
+		Services:        cloneSlice(c.services, s.Services, c.fillService),
+		Shapes:          cloneSlice(c.shapes, s.Shapes, c.fillShape),
+		FeedInfo:        clonePtr(s.FeedInfo),
+		Timezone:        s.Timezone,
+		Warnings:        cloneValues(s.Warnings),
+		stopTimesSource: s.stopTimesSource,
+	}
+	clone.LocationGroups = cloneSlice(c.locationGroups, s.LocationGroups, c.fillLocationGroup)
+	clone.FlexLocations = cloneSlice(c.flexLocations, s.FlexLocations, c.fillFlexLocation)
+	clone.BookingRules = cloneSlice(c.bookingRules, s.BookingRules, c.fillBookingRule)
+	clone.Trips = cloneSlice(c.trips, s.Trips, c.fillTrip)
+	clone.SuppressedWarnings = s.SuppressedWarnings
+	if s.WarningCounts != nil {
+		clone.WarningCounts = make(map[string]int, len(s.WarningCounts))
+		for code, count := range s.WarningCounts {
+			clone.WarningCounts[code] = count
+		}
+	}
+	if s.FareRules != nil {
+		clone.FareRules = make([]FareRule, len(s.FareRules))
+		for i, fareRule := range s.FareRules {
+			fareRule.Route = relink(c.routes, fareRule.Route, c.fillRoute)
+			clone.FareRules[i] = fareRule
+		}
+	}
+	if s.Transfers != nil {
+		clone.Transfers = make([]Transfer, len(s.Transfers))
+		for i := range s.Transfers {
+			c.fillTransfer(&clone.Transfers[i], &s.Transfers[i])
+		}
+	}
+	if s.Pathways != nil {
+		clone.Pathways = make([]Pathway, len(s.Pathways))
+		for i := range s.Pathways {
+			c.fillPathway(&clone.Pathways[i], &s.Pathways[i])
+		}
+	}
+	clone.Areas = cloneSlice(c.areas, s.Areas, c.fillArea)
+	for i := range networks {
+		c.fillNetwork(&networks[i], &s.Networks[i])
+	}
+	clone.Networks = networks
+	clone.FareMedia = cloneSlice(c.fareMedia, s.FareMedia, c.fillFareMedium)
+	if s.FareProducts != nil {
+		clone.FareProducts = make([]FareProduct, len(s.FareProducts))
+		for i, fareProduct := range s.FareProducts {
+			fareProduct.FareMedium = relink(c.fareMedia, fareProduct.FareMedium, c.fillFareMedium)
+			clone.FareProducts[i] = fareProduct
+		}
+	}
+	if s.FareLegRules != nil {
+		clone.FareLegRules = make([]FareLegRule, len(s.FareLegRules))
+		for i, fareLegRule := range s.FareLegRules {
+			fareLegRule.Network = relink(c.networks, fareLegRule.Network, c.fillNetwork)
+			fareLegRule.FromArea = relink(c.areas, fareLegRule.FromArea, c.fillArea)
+			fareLegRule.ToArea = relink(c.areas, fareLegRule.ToArea, c.fillArea)
+			fareLegRule.RulePriority = clonePtr(fareLegRule.RulePriority)
+			clone.FareLegRules[i] = fareLegRule
+		}
+	}
+	if s.FareTransferRules != nil {
+		clone.FareTransferRules = make([]FareTransferRule, len(s.FareTransferRules))
+		for i, fareTransferRule := range s.FareTransferRules {
+			fareTransferRule.TransferCount = clonePtr(fareTransferRule.TransferCount)
+			fareTransferRule.DurationLimit = clonePtr(fareTransferRule.DurationLimit)
+			clone.FareTransferRules[i] = fareTransferRule
+		}
+	}
+	return clone
+}
+
+type staticCloner struct {
+	agencies map[*Agency]*Agency
+	stops    map[*Stop]*Stop
+	routes   map[*Route]*Route
+	services map[*Service]*Service
+	shapes   map[*Shape]*Shape
+	trips    map[*ScheduledTrip]*ScheduledTrip
+
+	areas     map[*Area]*Area
+	networks  map[*Network]*Network
+	fareMedia map[*FareMedium]*FareMedium
+
+	locationGroups map[*LocationGroup]*LocationGroup
+	flexLocations  map[*FlexLocation]*FlexLocation
+	bookingRules   map[*BookingRule]*BookingRule
+}
+
+func (c *staticCloner) fillAgency(dst, src *Agency) {
+	*dst = *src
+}
+
+func (c *staticCloner) fillStop(dst, src *Stop) {
+	*dst = *src
+	dst.Longitude = clonePtr(src.Longitude)
+	dst.Latitude = clonePtr(src.Latitude)
+	dst.Parent = relink(c.stops, src.Parent, c.fillStop)
+}
+
+func (c *staticCloner) fillRoute(dst, src *Route) {
+	*dst = *src
+	dst.Agency = relink(c.agencies, src.Agency, c.fillAgency)
+	dst.SortOrder = clonePtr(src.SortOrder)
+	dst.Network = relink(c.networks, src.Network, c.fillNetwork)
+}
+
+func (c *staticCloner) fillService(dst, src *Service) {
+	*dst = *src
+	dst.AddedDates = cloneValues(src.AddedDates)
+	dst.RemovedDates = cloneValues(src.RemovedDates)
+}
+
+func (c *staticCloner) fillShape(dst, src *Shape) {
+	*dst = *src
+	if src.Points != nil {
+		dst.Points = make([]ShapePoint, len(src.Points))
+		for i, point := range src.Points {
+			point.Distance = clonePtr(point.Distance)
+			dst.Points[i] = point
+		}
+	}
+}
+
+func (c *staticCloner) fillTrip(dst, src *ScheduledTrip) {
+	*dst = *src
+	dst.Route = relink(c.routes, src.Route, c.fillRoute)
+	dst.Service = relink(c.services, src.Service, c.fillService)
+	dst.Shape = relink(c.shapes, src.Shape, c.fillShape)
+	dst.Frequencies = cloneValues(src.Frequencies)
+	if src.StopTimes != nil {
+		dst.StopTimes = make([]ScheduledStopTime, len(src.StopTimes))
+		for i, stopTime := range src.StopTimes {
+			stopTime.Trip = relink(c.trips, stopTime.Trip, c.fillTrip)
+			stopTime.Stop = relink(c.stops, stopTime.Stop, c.fillStop)
+			stopTime.ShapeDistanceTraveled = clonePtr(stopTime.ShapeDistanceTraveled)
+			stopTime.LocationGroup = relink(c.locationGroups, stopTime.LocationGroup, c.fillLocationGroup)
+			stopTime.FlexLocation = relink(c.flexLocations, stopTime.FlexLocation, c.fillFlexLocation)
+			stopTime.PickupBookingRule = relink(c.bookingRules, stopTime.PickupBookingRule, c.fillBookingRule)
+			stopTime.DropOffBookingRule = relink(c.bookingRules, stopTime.DropOffBookingRule, c.fillBookingRule)
+			dst.StopTimes[i] = stopTime
+		}
+	}
+}
+
+func (c *staticCloner) fillTransfer(dst, src *Transfer) {
+	*dst = *src
+	dst.From = relink(c.stops, src.From, c.fillStop)
+	dst.To = relink(c.stops, src.To, c.fillStop)
+	dst.MinTransferTime = clonePtr(src.MinTransferTime)
+}
+
+func (c *staticCloner) fillPathway(dst, src *Pathway) {
+	*dst = *src
+	dst.From = relink(c.stops, src.From, c.fillStop)
+	dst.To = relink(c.stops, src.To, c.fillStop)
+	dst.Length = clonePtr(src.Length)
+	dst.TraversalTime = clonePtr(src.TraversalTime)
+	dst.StairCount = clonePtr(src.StairCount)
+	dst.MaxSlope = clonePtr(src.MaxSlope)
+	dst.MinWidth = clonePtr(src.MinWidth)
+}
+
+func (c *staticCloner) fillArea(dst, src *Area) {
+	*dst = *src
+	if src.Stops != nil {
+		dst.Stops = make([]*Stop, len(src.Stops))
+		for i, stop := range src.Stops {
+			dst.Stops[i] = relink(c.stops, stop, c.fillStop)
+		}
+	}
+}
+
+func (c *staticCloner) fillNetwork(dst, src *Network) {
+	*dst = *src
+	if src.Routes != nil {
+		dst.Routes = make([]*Route, len(src.Routes))
+		for i, route := range src.Routes {
+			dst.Routes[i] = relink(c.routes, route, c.fillRoute)
+		}
+	}
+}
+
+func (c *staticCloner) fillFareMedium(dst, src *FareMedium) {
+	*dst = *src
+}
+
+func (c *staticCloner) fillLocationGroup(dst, src *LocationGroup) {
+	*dst = *src
+	if src.Stops != nil {
+		dst.Stops = make([]*Stop, len(src.Stops))
+		for i, stop := range src.Stops {
+			dst.Stops[i] = relink(c.stops, stop, c.fillStop)
+		}
+	}
+}
+
+func (c *staticCloner) fillFlexLocation(dst, src *FlexLocation) {
+	*dst = *src
+	if src.Polygons != nil {
+		dst.Polygons = make([]Polygon, len(src.Polygons))
+		for i, polygon := range src.Polygons {
+			dst.Polygons[i] = make(Polygon, len(polygon))
+			for j, ring := range polygon {
+				dst.Polygons[i][j] = cloneValues(ring)
+			}
+		}
+	}
+}
+
+func (c *staticCloner) fillBookingRule(dst, src *BookingRule) {
+	*dst = *src
+	dst.PriorNoticeDurationMin = clonePtr(src.PriorNoticeDurationMin)
+	dst.PriorNoticeDurationMax = clonePtr(src.PriorNoticeDurationMax)
+	dst.PriorNoticeLastDay = clonePtr(src.PriorNoticeLastDay)
+	dst.PriorNoticeLastTime = clonePtr(src.PriorNoticeLastTime)
+	dst.PriorNoticeStartDay = clonePtr(src.PriorNoticeStartDay)
+	dst.PriorNoticeStartTime = clonePtr(src.PriorNoticeStartTime)
+	dst.PriorNoticeService = relink(c.services, src.PriorNoticeService, c.fillService)
+}
+
+// DeepClone returns a copy of the realtime data that shares no memory with the original.
+//
+// The links between trips and vehicles are re-linked so that they point to the corresponding
+// entities in the clone. Extension data attached to trips, and the static entities linked to alerts
+// by [Realtime.ResolveAlerts], are not cloned.
+//
+// As with [Static.DeepClone], the clone is owned by the caller and may be modified.
+func (realtime *Realtime) DeepClone() *Realtime {
+	if realtime == nil {
+		return nil
+	}
+	c := realtimeCloner{
+		trips:    map[*Trip]*Trip{},
+		vehicles: map[*Vehicle]*Vehicle{},
+	}
+	clone := &Realtime{
+		CreatedAt: realtime.CreatedAt,
+		Warnings:  cloneValues(realtime.Warnings),
+	}
+	// Trips and vehicles point to each other, so both slices are registered before either is filled.
+	clone.Trips = registerSlice(c.trips, realtime.Trips)
+	clone.Vehicles = registerSlice(c.vehicles, realtime.Vehicles)
+	for i := range realtime.Trips {
+		c.fillTrip(&clone.Trips[i], &realtime.Trips[i])
+	}
+	for i := range realtime.Vehicles {
+		c.fillVehicle(&clone.Vehicles[i], &realtime.Vehicles[i])
+	}
+	if realtime.Alerts != nil {
+		clone.Alerts = make([]Alert, len(realtime.Alerts))
+		for i := range realtime.Alerts {
+			cloneAlert(&clone.Alerts[i], &realtime.Alerts[i])
+		}
+	}
+	return clone
+}
+
+type realtimeCloner struct {
+	trips    map[*Trip]*Trip
+	vehicles map[*Vehicle]*Vehicle
+}
+
+func (c *realtimeCloner) fillTrip(dst, src *Trip) {
+	*dst = *src
+	if src.StopTimeUpdates != nil {
+		dst.StopTimeUpdates = make([]StopTimeUpdate, len(src.StopTimeUpdates))
+		for i, update := range src.StopTimeUpdates {
+			update.StopSequence = clonePtr(update.StopSequence)
+			update.StopID = clonePtr(update.StopID)
+			update.Arrival = cloneStopTimeEvent(update.Arrival)
+			update.Departure = cloneStopTimeEvent(update.Departure)
+			update.AssignedStopID = clonePtr(update.AssignedStopID)
+			update.Track = clonePtr(update.Track)
+			update.NyctTrack = clonePtr(update.NyctTrack)
+			dst.StopTimeUpdates[i] = update
+		}
+	}
+	dst.Vehicle = relink(c.vehicles, src.Vehicle, c.fillVehicle)
+	dst.Timestamp = clonePtr(src.Timestamp)
+}
+
+func (c *realtimeCloner) fillVehicle(dst, src *Vehicle) {
+	*dst = *src
+	dst.ID = clonePtr(src.ID)
+	dst.Trip = relink(c.trips, src.Trip, c.fillTrip)
+	if src.Position != nil {
+		dst.Position = &Position{
+			Latitude:  clonePtr(src.Position.Latitude),
+			Longitude: clonePtr(src.Position.Longitude),
+			Bearing:   clonePtr(src.Position.Bearing),
+			Odometer:  clonePtr(src.Position.Odometer),
+			Speed:     clonePtr(src.Position.Speed),
+		}
+	}
+	dst.CurrentStopSequence = clonePtr(src.CurrentStopSequence)
+	dst.StopID = clonePtr(src.StopID)
+	dst.CurrentStatus = clonePtr(src.CurrentStatus)
+	dst.Timestamp = clonePtr(src.Timestamp)
+	dst.OccupancyStatus = clonePtr(src.OccupancyStatus)
+	dst.OccupancyPercentage = clonePtr(src.OccupancyPercentage)
+	dst.WheelchairAccessible = clonePtr(src.WheelchairAccessible)
+}
+
+func cloneStopTimeEvent(event *StopTimeEvent) *StopTimeEvent {
+	if event == nil {
+		return nil
+	}
+	return &StopTimeEvent{
+		Time:        clonePtr(event.Time),
+		Delay:       clonePtr(event.Delay),
+		Uncertainty: clonePtr(event.Uncertainty),
+	}
+}
+
+func cloneAlert(dst, src *Alert) {
+	*dst = *src
+	if src.ActivePeriods != nil {
+		dst.ActivePeriods = make([]AlertActivePeriod, len(src.ActivePeriods))
+		for i, activePeriod := range src.ActivePeriods {
+			dst.ActivePeriods[i] = AlertActivePeriod{
+				StartsAt: clonePtr(activePeriod.StartsAt),
+				EndsAt:   clonePtr(activePeriod.EndsAt),
+			}
+		}
+	}
+	if src.InformedEntities != nil {
+		dst.InformedEntities = make([]AlertInformedEntity, len(src.InformedEntities))
+		for i, informedEntity := range src.InformedEntities {
+			informedEntity.AgencyID = clonePtr(informedEntity.AgencyID)
+			informedEntity.RouteID = clonePtr(informedEntity.RouteID)
+			informedEntity.TripID = clonePtr(informedEntity.TripID)
+			informedEntity.StopID = clonePtr(informedEntity.StopID)
+			dst.InformedEntities[i] = informedEntity
+		}
+	}
+	dst.Header = cloneAlertTexts(src.Header)
+	dst.Description = cloneAlertTexts(src.Description)
+	dst.URL = cloneAlertTexts(src.URL)
+}
+
+func cloneAlertTexts(texts []AlertText) []AlertText {
+	if texts == nil {
+		return nil
+	}
+	result := make([]AlertText, len(texts))
+	for i, text := range texts {
+		text.Links = cloneValues(text.Links)
+		result[i] = text
+	}
+	return result
+}
+
+// registerSlice allocates a slice for the clones of the elements of src and records, in m, the
+// element of the new slice that each element of src is cloned to. The elements are not filled.
+func registerSlice[T any](m map[*T]*T, src []T) []T {
+	if src == nil {
+		return nil
+	}
+	dst := make([]T, len(src))
+	for i := range src {
+		m[&src[i]] = &dst[i]
+	}
+	return dst
+}
+
+// cloneSlice clones the elements of src using fill, recording each clone in m.
+func cloneSlice[T any](m map[*T]*T, src []T, fill func(dst, src *T)) []T {
+	dst := registerSlice(m, src)
+	for i := range src {
+		fill(&dst[i], &src[i])
+	}
+	return dst
+}
+
+// relink returns the clone of p recorded in m. If p has not been cloned yet, which happens if it
+// does not point into one of the cloned slices, a new clone is created using fill.
+func relink[T any](m map[*T]*T, p *T, fill func(dst, src *T)) *T {
+	if p == nil {
+		return nil
+	}
+	if q, ok := m[p]; ok {
+		return q
+	}
+	q := new(T)
+	m[p] = q
+	fill(q, p)
+	return q
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+func cloneValues[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
@@ -0,0 +1,131 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestStaticDeepClone(t *testing.T) {
+	static := &Static{
+		Agencies: []Agency{{Id: "agency"}},
+		Routes:   []Route{{Id: "route", SortOrder: ptr(int32(1))}},
+		Stops: []Stop{
+			{Id: "station", Latitude: ptr(40.0), Longitude: ptr(-74.0)},
+			{Id: "platform"},
+		},
+		Services: []Service{{Id: "service", AddedDates: []time.Time{time.Date(2022, 5, 4, 0, 0, 0, 0, time.UTC)}}},
+		Shapes:   []Shape{{ID: "shape", Points: []ShapePoint{{Latitude: 40, Distance: ptr(1.0)}}}},
+		Trips:    []ScheduledTrip{{ID: "trip"}},
+		FeedInfo: &FeedInfo{PublisherName: "publisher"},
+
+		SuppressedWarnings: 1,
+		WarningCounts:      map[string]int{"missing_columns": 2},
+	}
+	static.Routes[0].Agency = &static.Agencies[0]
+	static.Stops[1].Parent = &static.Stops[0]
+	static.Transfers = []Transfer{{From: &static.Stops[0], To: &static.Stops[1]}}
+	trip := &static.Trips[0]
+	trip.Route = &static.Routes[0]
+	trip.Service = &static.Services[0]
+	trip.Shape = &static.Shapes[0]
+	trip.StopTimes = []ScheduledStopTime{{Trip: trip, Stop: &static.Stops[1], ShapeDistanceTraveled: ptr(1.0)}}
+
+	clone := static.DeepClone()
+
+	if diff := cmp.Diff(static, clone, cmp.AllowUnexported(Static{}), cmpopts.IgnoreFields(Static{}, "idx")); diff != "" {
+		t.Errorf("DeepClone() diff: %s", diff)
+	}
+	if clone.Routes[0].Agency != &clone.Agencies[0] {
+		t.Errorf("route agency not re-linked")
+	}
+	if clone.Stops[1].Parent != &clone.Stops[0] {
+		t.Errorf("stop parent not re-linked")
+	}
+	if clone.Transfers[0].From != &clone.Stops[0] || clone.Transfers[0].To != &clone.Stops[1] {
+		t.Errorf("transfer stops not re-linked")
+	}
+	cloneTrip := &clone.Trips[0]
+	if cloneTrip.Route != &clone.Routes[0] || cloneTrip.Service != &clone.Services[0] || cloneTrip.Shape != &clone.Shapes[0] {
+		t.Errorf("trip route, service or shape not re-linked")
+	}
+	if cloneTrip.StopTimes[0].Trip != cloneTrip || cloneTrip.StopTimes[0].Stop != &clone.Stops[1] {
+		t.Errorf("stop time trip or stop not re-linked")
+	}
+
+	*clone.Stops[0].Latitude = 41
+	*clone.Routes[0].SortOrder = 2
+	clone.Services[0].AddedDates[0] = time.Time{}
+	*clone.Shapes[0].Points[0].Distance = 2
+	*cloneTrip.StopTimes[0].ShapeDistanceTraveled = 2
+	clone.FeedInfo.PublisherName = "other"
+	if *static.Stops[0].Latitude != 40 || *static.Routes[0].SortOrder != 1 || static.Services[0].AddedDates[0].IsZero() ||
+		*static.Shapes[0].Points[0].Distance != 1 || *trip.StopTimes[0].ShapeDistanceTraveled != 1 ||
+		static.FeedInfo.PublisherName != "publisher" {
+		t.Errorf("mutating the clone mutated the original")
+	}
+}
+
+func TestRealtimeDeepClone(t *testing.T) {
+	realtime := &Realtime{
+		Trips: []Trip{{
+			ID: TripID{ID: "trip"},
+			StopTimeUpdates: []StopTimeUpdate{{
+				StopID:  ptr("stop"),
+				Arrival: &StopTimeEvent{Delay: ptr(time.Minute)},
+			}},
+		}},
+		Vehicles: []Vehicle{{
+			ID:       &VehicleID{ID: "vehicle"},
+			Position: &Position{Latitude: ptr(float32(40))},
+		}},
+		Alerts: []Alert{{
+			ID:               "alert",
+			InformedEntities: []AlertInformedEntity{{RouteID: ptr("route")}},
+			Header:           []AlertText{{Text: "header", Links: []AlertLink{{URL: "url"}}}},
+		}},
+	}
+	realtime.Trips[0].Vehicle = &realtime.Vehicles[0]
+	realtime.Vehicles[0].Trip = &realtime.Trips[0]
+
+	clone := realtime.DeepClone()
+
+	if clone.Trips[0].Vehicle != &clone.Vehicles[0] || clone.Vehicles[0].Trip != &clone.Trips[0] {
+		t.Errorf("trip and vehicle not re-linked")
+	}
+	if clone.Trips[0].ID != realtime.Trips[0].ID || clone.Vehicles[0].GetID() != realtime.Vehicles[0].GetID() {
+		t.Errorf("DeepClone() did not copy IDs")
+	}
+
+	*clone.Trips[0].StopTimeUpdates[0].StopID = "other"
+	*clone.Trips[0].StopTimeUpdates[0].Arrival.Delay = time.Hour
+	*clone.Vehicles[0].Position.Latitude = 41
+	clone.Vehicles[0].ID.ID = "other"
+	*clone.Alerts[0].InformedEntities[0].RouteID = "other"
+	clone.Alerts[0].Header[0].Links[0].URL = "other"
+	want := &Realtime{
+		Trips: []Trip{{
+			ID: TripID{ID: "trip"},
+			StopTimeUpdates: []StopTimeUpdate{{
+				StopID:  ptr("stop"),
+				Arrival: &StopTimeEvent{Delay: ptr(time.Minute)},
+			}},
+		}},
+		Vehicles: []Vehicle{{
+			ID:       &VehicleID{ID: "vehicle"},
+			Position: &Position{Latitude: ptr(float32(40))},
+		}},
+		Alerts: []Alert{{
+			ID:               "alert",
+			InformedEntities: []AlertInformedEntity{{RouteID: ptr("route")}},
+			Header:           []AlertText{{Text: "header", Links: []AlertLink{{URL: "url"}}}},
+		}},
+	}
+	want.Trips[0].Vehicle = &want.Vehicles[0]
+	want.Vehicles[0].Trip = &want.Trips[0]
+	if diff := cmp.Diff(want, realtime); diff != "" {
+		t.Errorf("mutating the clone mutated the original, diff: %s", diff)
+	}
+}
@@ -48,6 +48,44 @@ func main() {
 					return nil
 				},
 			},
+			{
+				Name:      "validate",
+				Usage:     "parse a GTFS static message and print a summary report",
+				ArgsUsage: "path",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Value: "markdown",
+						Usage: "format of the report: markdown, html",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					args := ctx.Args()
+					if args.Len() == 0 {
+						return fmt.Errorf("a path to the GTFS static message was not provided")
+					}
+					var format gtfs.ReportFormat
+					switch rawFormat := ctx.String("format"); rawFormat {
+					case "markdown":
+						format = gtfs.ReportFormat_Markdown
+					case "html":
+						format = gtfs.ReportFormat_HTML
+					default:
+						return fmt.Errorf("unknown report format %q", rawFormat)
+					}
+					path := args.First()
+					b, err := os.ReadFile(path)
+					if err != nil {
+						return fmt.Errorf("failed to read file %s: %w", path, err)
+					}
+					static, err := gtfs.ParseStatic(b, gtfs.ParseStaticOptions{})
+					if err != nil {
+						return fmt.Errorf("failed to parse GTFS static data: %w", err)
+					}
+					fmt.Print(static.Report(format))
+					return nil
+				},
+			},
 			{
 				Name:      "realtime",
 				Usage:     "parse a GTFS realtime message",
@@ -96,6 +134,15 @@ func main() {
 						Aliases: []string{"o"},
 						Usage:   "directory to output the CSV files",
 					},
+					&cli.BoolFlag{
+						Name:  "gtfs-static",
+						Usage: "also output the observed service as a GTFS static feed (observed.zip)",
+					},
+					&cli.StringFlag{
+						Name:  "partition",
+						Value: "none",
+						Usage: "how to split the CSV files by service date: none, date, hive",
+					},
 				},
 				ArgsUsage: "path",
 				Action: func(ctx *cli.Context) error {
@@ -109,6 +156,17 @@ func main() {
 					if err := readGtfsRealtimeExtension(rawExtension, &opts); err != nil {
 						return err
 					}
+					var exportOpts journal.CsvExportOptions
+					switch rawPartition := ctx.String("partition"); rawPartition {
+					case "none":
+						exportOpts.Partitioning = journal.Partitioning_None
+					case "date":
+						exportOpts.Partitioning = journal.Partitioning_ByServiceDate
+					case "hive":
+						exportOpts.Partitioning = journal.Partitioning_HiveByServiceDate
+					default:
+						return fmt.Errorf("unknown partitioning %q", rawPartition)
+					}
 
 					source, err := journal.NewDirectoryGtfsrtSource(path)
 					if err != nil {
@@ -117,27 +175,45 @@ func main() {
 					fmt.Println("Building journal...")
 					j := journal.BuildJournal(source, time.Unix(0, 0), time.Now())
 					fmt.Println("Exporting journal to CSV format...")
-					export, err := j.ExportToCsv()
+					export, err := j.ExportToCsvWithOptions(exportOpts)
 					if err != nil {
 						return fmt.Errorf("failed to export journal: %w", err)
 					}
 
 					outputDir := ctx.String("output")
-					for _, f := range []struct {
+					var files []struct {
 						file string
 						data []byte
-					}{
-						{
-							file: "trips.csv",
-							data: export.TripsCsv,
-						},
-						{
-							file: "stop_times.csv",
-							data: export.StopTimesCsv,
-						},
-					} {
+					}
+					for _, f := range export.Files() {
+						files = append(files, struct {
+							file string
+							data []byte
+						}{
+							file: f.Path,
+							data: f.Data,
+						})
+					}
+					if ctx.Bool("gtfs-static") {
+						fmt.Println("Exporting journal to GTFS static format...")
+						b, err := j.ExportToGtfsStatic(journal.GtfsStaticExportOptions{})
+						if err != nil {
+							return fmt.Errorf("failed to export journal: %w", err)
+						}
+						files = append(files, struct {
+							file string
+							data []byte
+						}{
+							file: "observed.zip",
+							data: b,
+						})
+					}
+					for _, f := range files {
 						fullPath := filepath.Join(outputDir, f.file)
 						fmt.Printf("Writing %s to %s\n", f.file, fullPath)
+						if err := os.MkdirAll(filepath.Dir(fullPath), 0777); err != nil {
+							return fmt.Errorf("failed to create directory for %s: %w", f.file, err)
+						}
 						if err := os.WriteFile(fullPath, f.data, 0666); err != nil {
 							return fmt.Errorf("failed to write %s: %w", f.file, err)
 						}
@@ -206,12 +282,12 @@ func formatTrip(trip gtfs.Trip, indent int, printStopTimes bool) string {
 		fmt.Fprintf(&b, "Stop times (%d):%s", len(trip.StopTimeUpdates), newLine)
 		for _, stopTime := range trip.StopTimeUpdates {
 			fmt.Fprintf(&b,
-				"  StopSeq %s  StopID %s  Arrival %s  Departure %s  NyctTrack %s%s",
+				"  StopSeq %s  StopID %s  Arrival %s  Departure %s  Track %s%s",
 				sc.Sprint(unPtrI(stopTime.StopSequence)),
 				sc.Sprint(unPtr(stopTime.StopID)),
 				unPtrT(stopTime.GetArrival().Time, sc),
 				unPtrT(stopTime.GetDeparture().Time, sc),
-				sc.Sprint(unPtr(stopTime.NyctTrack)),
+				sc.Sprint(unPtr(stopTime.Track)),
 				newLine,
 			)
 		}
@@ -255,7 +331,7 @@ func formatVehicle(vehicle gtfs.Vehicle, indent int) string {
 	sc := color.New(color.FgGreen)
 	newLine := fmt.Sprintf("\n%*s", indent, "")
 	fmt.Fprintf(&b,
-		"VehicleID %s  Label %s  LicencePlate %s%s",
+		"VehicleID %s  Label %s  LicensePlate %s%s",
 		tc.Sprint(vehicle.GetID().ID),
 		tc.Sprint(vehicle.GetID().Label),
 		tc.Sprint(vehicle.GetID().LicensePlate),
@@ -305,6 +381,12 @@ func formatVehicle(vehicle gtfs.Vehicle, indent int) string {
 		fmt.Fprintf(&b, "OccupancyPercentage: <none>%s", newLine)
 	}
 
+	if vehicle.WheelchairAccessible != nil {
+		fmt.Fprintf(&b, "WheelchairAccessible: %s%s", sc.Sprint(*vehicle.WheelchairAccessible), newLine)
+	} else {
+		fmt.Fprintf(&b, "WheelchairAccessible: <none>%s", newLine)
+	}
+
 	fmt.Fprintf(&b, "IsEntityInMessage: %t%s", vehicle.IsEntityInMessage, newLine)
 
 	return b.String()
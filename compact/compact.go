@@ -0,0 +1,330 @@
+// Package compact contains a memory-efficient representation of a GTFS static feed.
+//
+// In a [gtfs.Static] every entity stores its IDs and names as separate strings, and entities reference
+// each other using pointers. In a compact [Static] all strings are stored once in a shared [StringTable]
+// and entities reference strings and each other using integer handles. This makes the representation
+// suitable for embedding large schedules in memory-constrained services.
+package compact
+
+import (
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+)
+
+// Handle identifies a string in a [StringTable].
+type Handle uint32
+
+// StringTable stores each distinct string once.
+//
+// The empty string always has handle 0.
+type StringTable struct {
+	strings []string
+	handles map[string]Handle
+}
+
+// NewStringTable returns a string table containing only the empty string.
+func NewStringTable() *StringTable {
+	return &StringTable{
+		strings: []string{""},
+		handles: map[string]Handle{"": 0},
+	}
+}
+
+// Intern adds the string to the table, if it is not already present, and returns its handle.
+func (t *StringTable) Intern(s string) Handle {
+	if h, ok := t.handles[s]; ok {
+		return h
+	}
+	h := Handle(len(t.strings))
+	t.strings = append(t.strings, s)
+	t.handles[s] = h
+	return h
+}
+
+// Lookup returns the handle of the string, or false if the string is not in the table.
+func (t *StringTable) Lookup(s string) (Handle, bool) {
+	h, ok := t.handles[s]
+	return h, ok
+}
+
+// String returns the string with the provided handle.
+func (t *StringTable) String(h Handle) string {
+	return t.strings[h]
+}
+
+// Len returns the number of strings in the table.
+func (t *StringTable) Len() int {
+	return len(t.strings)
+}
+
+// NoIndex is used for references to entities that do not exist, like the parent of a root stop.
+const NoIndex int32 = -1
+
+// Static is a compact representation of a GTFS static feed.
+//
+// References between entities are indices into the corresponding slices of the Static,
+// or [NoIndex] if there is no referenced entity.
+type Static struct {
+	Strings *StringTable
+
+	Agencies  []Agency
+	Routes    []Route
+	Stops     []Stop
+	Services  []Service
+	Trips     []Trip
+	StopTimes []StopTime
+
+	stopsByID    map[Handle]int32
+	routesByID   map[Handle]int32
+	tripsByID    map[Handle]int32
+	servicesByID map[Handle]int32
+}
+
+type Agency struct {
+	ID       Handle
+	Name     Handle
+	Timezone Handle
+}
+
+type Route struct {
+	ID        Handle
+	Agency    int32
+	ShortName Handle
+	LongName  Handle
+	Color     Handle
+	TextColor Handle
+	Type      gtfs.RouteType
+}
+
+type Stop struct {
+	ID        Handle
+	Code      Handle
+	Name      Handle
+	Type      gtfs.StopType
+	Parent    int32
+	Latitude  float32
+	Longitude float32
+	// Whether the latitude and longitude are set.
+	HasPosition  bool
+	PlatformCode Handle
+}
+
+type Service struct {
+	ID Handle
+	// Days of the week the service runs, with bit i set if the service runs on [time.Weekday] i.
+	Weekdays uint8
+	// Dates are stored as the number of days since the Unix epoch.
+	StartDate    int32
+	EndDate      int32
+	AddedDates   []int32
+	RemovedDates []int32
+}
+
+// RunsOn returns true if the service runs on the provided date.
+//
+// Like [gtfs.Service.RunsOn], only the year, month and day of the date are considered.
+func (service *Service) RunsOn(date time.Time) bool {
+	day := toDays(date)
+	for _, removedDate := range service.RemovedDates {
+		if removedDate == day {
+			return false
+		}
+	}
+	for _, addedDate := range service.AddedDates {
+		if addedDate == day {
+			return true
+		}
+	}
+	if day < service.StartDate || service.EndDate < day {
+		return false
+	}
+	return service.Weekdays&(1<<uint(date.Weekday())) != 0
+}
+
+type Trip struct {
+	ID          Handle
+	Route       int32
+	Service     int32
+	Headsign    Handle
+	DirectionID gtfs.DirectionID
+	// The stop times of the trip are StopTimes[FirstStopTime:FirstStopTime+NumStopTimes].
+	FirstStopTime int32
+	NumStopTimes  int32
+}
+
+type StopTime struct {
+	Stop int32
+	// Arrival and departure times, in seconds since the start of the service day.
+	ArrivalTime   int32
+	DepartureTime int32
+	StopSequence  int32
+}
+
+// Export converts the static feed to its compact representation.
+func Export(static *gtfs.Static) *Static {
+	strs := NewStringTable()
+	result := &Static{
+		Strings:      strs,
+		stopsByID:    map[Handle]int32{},
+		routesByID:   map[Handle]int32{},
+		tripsByID:    map[Handle]int32{},
+		servicesByID: map[Handle]int32{},
+	}
+
+	agencyIndex := map[*gtfs.Agency]int32{}
+	for i := range static.Agencies {
+		agency := &static.Agencies[i]
+		agencyIndex[agency] = int32(len(result.Agencies))
+		result.Agencies = append(result.Agencies, Agency{
+			ID:       strs.Intern(agency.Id),
+			Name:     strs.Intern(agency.Name),
+			Timezone: strs.Intern(agency.Timezone),
+		})
+	}
+
+	routeIndex := map[*gtfs.Route]int32{}
+	for i := range static.Routes {
+		route := &static.Routes[i]
+		routeIndex[route] = int32(len(result.Routes))
+		result.routesByID[strs.Intern(route.Id)] = int32(len(result.Routes))
+		result.Routes = append(result.Routes, Route{
+			ID:        strs.Intern(route.Id),
+			Agency:    indexOr(agencyIndex, route.Agency),
+			ShortName: strs.Intern(route.ShortName),
+			LongName:  strs.Intern(route.LongName),
+			Color:     strs.Intern(route.Color),
+			TextColor: strs.Intern(route.TextColor),
+			Type:      route.Type,
+		})
+	}
+
+	stopIndex := map[*gtfs.Stop]int32{}
+	for i := range static.Stops {
+		stopIndex[&static.Stops[i]] = int32(i)
+	}
+	for i := range static.Stops {
+		stop := &static.Stops[i]
+		compactStop := Stop{
+			ID:           strs.Intern(stop.Id),
+			Code:         strs.Intern(stop.Code),
+			Name:         strs.Intern(stop.Name),
+			Type:         stop.Type,
+			Parent:       indexOr(stopIndex, stop.Parent),
+			PlatformCode: strs.Intern(stop.PlatformCode),
+		}
+		if stop.Latitude != nil && stop.Longitude != nil {
+			compactStop.Latitude = float32(*stop.Latitude)
+			compactStop.Longitude = float32(*stop.Longitude)
+			compactStop.HasPosition = true
+		}
+		result.stopsByID[compactStop.ID] = int32(len(result.Stops))
+		result.Stops = append(result.Stops, compactStop)
+	}
+
+	serviceIndex := map[string]int32{}
+	for i := range static.Services {
+		service := &static.Services[i]
+		serviceIndex[service.Id] = int32(len(result.Services))
+		compactService := Service{
+			ID:        strs.Intern(service.Id),
+			StartDate: toDays(service.StartDate),
+			EndDate:   toDays(service.EndDate),
+		}
+		for day, runs := range []bool{service.Sunday, service.Monday, service.Tuesday, service.Wednesday, service.Thursday, service.Friday, service.Saturday} {
+			if runs {
+				compactService.Weekdays |= 1 << uint(day)
+			}
+		}
+		for _, date := range service.AddedDates {
+			compactService.AddedDates = append(compactService.AddedDates, toDays(date))
+		}
+		for _, date := range service.RemovedDates {
+			compactService.RemovedDates = append(compactService.RemovedDates, toDays(date))
+		}
+		result.servicesByID[compactService.ID] = int32(len(result.Services))
+		result.Services = append(result.Services, compactService)
+	}
+
+	for i := range static.Trips {
+		trip := &static.Trips[i]
+		compactTrip := Trip{
+			ID:            strs.Intern(trip.ID),
+			Route:         indexOr(routeIndex, trip.Route),
+			Service:       NoIndex,
+			Headsign:      strs.Intern(trip.Headsign),
+			DirectionID:   trip.DirectionId,
+			FirstStopTime: int32(len(result.StopTimes)),
+			NumStopTimes:  int32(len(trip.StopTimes)),
+		}
+		if trip.Service != nil {
+			if j, ok := serviceIndex[trip.Service.Id]; ok {
+				compactTrip.Service = j
+			}
+		}
+		for _, stopTime := range trip.StopTimes {
+			result.StopTimes = append(result.StopTimes, StopTime{
+				Stop:          indexOr(stopIndex, stopTime.Stop),
+				ArrivalTime:   int32(stopTime.ArrivalTime / time.Second),
+				DepartureTime: int32(stopTime.DepartureTime / time.Second),
+				StopSequence:  int32(stopTime.StopSequence),
+			})
+		}
+		result.tripsByID[compactTrip.ID] = int32(len(result.Trips))
+		result.Trips = append(result.Trips, compactTrip)
+	}
+	return result
+}
+
+// StopByID returns the index of the stop with the provided ID, or false if there is no such stop.
+func (s *Static) StopByID(id string) (int32, bool) {
+	return lookupByID(s.Strings, s.stopsByID, id)
+}
+
+// RouteByID returns the index of the route with the provided ID, or false if there is no such route.
+func (s *Static) RouteByID(id string) (int32, bool) {
+	return lookupByID(s.Strings, s.routesByID, id)
+}
+
+// TripByID returns the index of the trip with the provided ID, or false if there is no such trip.
+func (s *Static) TripByID(id string) (int32, bool) {
+	return lookupByID(s.Strings, s.tripsByID, id)
+}
+
+// ServiceByID returns the index of the service with the provided ID, or false if there is no such service.
+func (s *Static) ServiceByID(id string) (int32, bool) {
+	return lookupByID(s.Strings, s.servicesByID, id)
+}
+
+// TripStopTimes returns the stop times of the trip at the provided index.
+func (s *Static) TripStopTimes(trip int32) []StopTime {
+	t := &s.Trips[trip]
+	return s.StopTimes[t.FirstStopTime : t.FirstStopTime+t.NumStopTimes]
+}
+
+func lookupByID(strs *StringTable, m map[Handle]int32, id string) (int32, bool) {
+	h, ok := strs.Lookup(id)
+	if !ok {
+		return NoIndex, false
+	}
+	i, ok := m[h]
+	if !ok {
+		return NoIndex, false
+	}
+	return i, true
+}
+
+func indexOr[T any](m map[*T]int32, t *T) int32 {
+	if t == nil {
+		return NoIndex
+	}
+	if i, ok := m[t]; ok {
+		return i
+	}
+	return NoIndex
+}
+
+func toDays(t time.Time) int32 {
+	y, m, d := t.Date()
+	return int32(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60))
+}
@@ -0,0 +1,99 @@
+package compact
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+)
+
+func TestExport(t *testing.T) {
+	agency := gtfs.Agency{Id: "agency", Name: "Agency", Timezone: "America/New_York"}
+	route := gtfs.Route{Id: "A", Agency: &agency, ShortName: "A", Type: gtfs.RouteType_Subway}
+	service := gtfs.Service{
+		Id:         "weekday",
+		Monday:     true,
+		Tuesday:    true,
+		StartDate:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:    time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+		AddedDates: []time.Time{time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC)},
+	}
+	static := &gtfs.Static{
+		Agencies: []gtfs.Agency{agency},
+		Routes:   []gtfs.Route{route},
+		Stops: []gtfs.Stop{
+			{Id: "station", Name: "Station"},
+			{Id: "platform", Name: "Station"},
+		},
+		Services: []gtfs.Service{service},
+	}
+	static.Stops[1].Parent = &static.Stops[0]
+	static.Routes[0].Agency = &static.Agencies[0]
+	static.Trips = []gtfs.ScheduledTrip{
+		{
+			ID:      "trip",
+			Route:   &static.Routes[0],
+			Service: &service,
+			StopTimes: []gtfs.ScheduledStopTime{
+				{Stop: &static.Stops[1], ArrivalTime: time.Hour, DepartureTime: time.Hour + time.Minute, StopSequence: 1},
+			},
+		},
+	}
+
+	c := Export(static)
+
+	if got := c.Strings.String(c.Stops[0].Name); got != "Station" {
+		t.Errorf("stop name = %q, want %q", got, "Station")
+	}
+	if c.Stops[0].Name != c.Stops[1].Name {
+		t.Errorf("identical stop names have different handles %d and %d", c.Stops[0].Name, c.Stops[1].Name)
+	}
+	if c.Stops[0].Parent != NoIndex || c.Stops[1].Parent != 0 {
+		t.Errorf("stop parents = %d, %d, want %d, 0", c.Stops[0].Parent, c.Stops[1].Parent, NoIndex)
+	}
+	if c.Routes[0].Agency != 0 {
+		t.Errorf("route agency = %d, want 0", c.Routes[0].Agency)
+	}
+
+	tripIndex, ok := c.TripByID("trip")
+	if !ok {
+		t.Fatalf("TripByID(trip) not found")
+	}
+	trip := c.Trips[tripIndex]
+	if trip.Route != 0 || trip.Service != 0 {
+		t.Errorf("trip route, service = %d, %d, want 0, 0", trip.Route, trip.Service)
+	}
+	stopTimes := c.TripStopTimes(tripIndex)
+	want := []StopTime{{Stop: 1, ArrivalTime: 3600, DepartureTime: 3660, StopSequence: 1}}
+	if len(stopTimes) != 1 || stopTimes[0] != want[0] {
+		t.Errorf("stop times = %v, want %v", stopTimes, want)
+	}
+
+	if stopIndex, ok := c.StopByID("platform"); !ok || stopIndex != 1 {
+		t.Errorf("StopByID(platform) = %d, %t, want 1, true", stopIndex, ok)
+	}
+	if _, ok := c.StopByID("Station"); ok {
+		t.Errorf("StopByID(Station) found, want not found")
+	}
+	if _, ok := c.RouteByID("missing"); ok {
+		t.Errorf("RouteByID(missing) found, want not found")
+	}
+
+	serviceIndex, ok := c.ServiceByID("weekday")
+	if !ok {
+		t.Fatalf("ServiceByID(weekday) not found")
+	}
+	for _, tc := range []struct {
+		date time.Time
+		want bool
+	}{
+		{time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), true},
+		{time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC), false},
+		{time.Date(2024, 1, 6, 12, 0, 0, 0, time.UTC), true},
+		{time.Date(2024, 2, 5, 12, 0, 0, 0, time.UTC), false},
+	} {
+		if got := c.Services[serviceIndex].RunsOn(tc.date); got != tc.want {
+			t.Errorf("RunsOn(%s) = %t, want %t", tc.date, got, tc.want)
+		}
+	}
+}
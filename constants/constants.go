@@ -1,7 +1,126 @@
+// Package constants contains constants relating to the GTFS static specification.
 package constants
 
+// StaticFile is the name of a file in a GTFS static feed.
 type StaticFile string
 
 const (
-	AgencyFile StaticFile = "agency.txt"
+	AgencyFile             StaticFile = "agency.txt"
+	StopsFile              StaticFile = "stops.txt"
+	RoutesFile             StaticFile = "routes.txt"
+	TripsFile              StaticFile = "trips.txt"
+	StopTimesFile          StaticFile = "stop_times.txt"
+	CalendarFile           StaticFile = "calendar.txt"
+	CalendarDatesFile      StaticFile = "calendar_dates.txt"
+	FareAttributesFile     StaticFile = "fare_attributes.txt"
+	FareRulesFile          StaticFile = "fare_rules.txt"
+	TimeframesFile         StaticFile = "timeframes.txt"
+	FareMediaFile          StaticFile = "fare_media.txt"
+	FareProductsFile       StaticFile = "fare_products.txt"
+	FareLegRulesFile       StaticFile = "fare_leg_rules.txt"
+	FareLegJoinRulesFile   StaticFile = "fare_leg_join_rules.txt"
+	FareTransferRulesFile  StaticFile = "fare_transfer_rules.txt"
+	AreasFile              StaticFile = "areas.txt"
+	StopAreasFile          StaticFile = "stop_areas.txt"
+	NetworksFile           StaticFile = "networks.txt"
+	RouteNetworksFile      StaticFile = "route_networks.txt"
+	ShapesFile             StaticFile = "shapes.txt"
+	FrequenciesFile        StaticFile = "frequencies.txt"
+	TransfersFile          StaticFile = "transfers.txt"
+	PathwaysFile           StaticFile = "pathways.txt"
+	LevelsFile             StaticFile = "levels.txt"
+	LocationGroupsFile     StaticFile = "location_groups.txt"
+	LocationGroupStopsFile StaticFile = "location_group_stops.txt"
+	LocationsFile          StaticFile = "locations.geojson"
+	BookingRulesFile       StaticFile = "booking_rules.txt"
+	TranslationsFile       StaticFile = "translations.txt"
+	FeedInfoFile           StaticFile = "feed_info.txt"
+	AttributionsFile       StaticFile = "attributions.txt"
 )
+
+var staticFiles = []StaticFile{
+	AgencyFile,
+	StopsFile,
+	RoutesFile,
+	TripsFile,
+	StopTimesFile,
+	CalendarFile,
+	CalendarDatesFile,
+	FareAttributesFile,
+	FareRulesFile,
+	TimeframesFile,
+	FareMediaFile,
+	FareProductsFile,
+	FareLegRulesFile,
+	FareLegJoinRulesFile,
+	FareTransferRulesFile,
+	AreasFile,
+	StopAreasFile,
+	NetworksFile,
+	RouteNetworksFile,
+	ShapesFile,
+	FrequenciesFile,
+	TransfersFile,
+	PathwaysFile,
+	LevelsFile,
+	LocationGroupsFile,
+	LocationGroupStopsFile,
+	LocationsFile,
+	BookingRulesFile,
+	TranslationsFile,
+	FeedInfoFile,
+	AttributionsFile,
+}
+
+// StaticFiles returns all of the files in the GTFS static specification, in the order they appear in the
+// specification.
+func StaticFiles() []StaticFile {
+	result := make([]StaticFile, len(staticFiles))
+	copy(result, staticFiles)
+	return result
+}
+
+var requiredColumns = map[StaticFile][]string{
+	AgencyFile:             {"agency_name", "agency_url", "agency_timezone"},
+	StopsFile:              {"stop_id"},
+	RoutesFile:             {"route_id", "route_type"},
+	TripsFile:              {"route_id", "service_id", "trip_id"},
+	StopTimesFile:          {"trip_id", "stop_sequence"},
+	CalendarFile:           {"service_id", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday", "start_date", "end_date"},
+	CalendarDatesFile:      {"service_id", "date", "exception_type"},
+	FareAttributesFile:     {"fare_id", "price", "currency_type", "payment_method", "transfers"},
+	FareRulesFile:          {"fare_id"},
+	TimeframesFile:         {"timeframe_group_id", "service_id"},
+	FareMediaFile:          {"fare_media_id", "fare_media_type"},
+	FareProductsFile:       {"fare_product_id", "amount", "currency"},
+	FareLegRulesFile:       {"fare_product_id"},
+	FareLegJoinRulesFile:   {"from_network_id", "to_network_id"},
+	FareTransferRulesFile:  {"fare_transfer_type"},
+	AreasFile:              {"area_id"},
+	StopAreasFile:          {"area_id", "stop_id"},
+	NetworksFile:           {"network_id"},
+	RouteNetworksFile:      {"network_id", "route_id"},
+	ShapesFile:             {"shape_id", "shape_pt_lat", "shape_pt_lon", "shape_pt_sequence"},
+	FrequenciesFile:        {"trip_id", "start_time", "end_time", "headway_secs"},
+	TransfersFile:          {"transfer_type"},
+	PathwaysFile:           {"pathway_id", "from_stop_id", "to_stop_id", "pathway_mode", "is_bidirectional"},
+	LevelsFile:             {"level_id", "level_index"},
+	LocationGroupsFile:     {"location_group_id"},
+	LocationGroupStopsFile: {"location_group_id", "stop_id"},
+	BookingRulesFile:       {"booking_rule_id", "booking_type"},
+	TranslationsFile:       {"table_name", "field_name", "language", "translation"},
+	FeedInfoFile:           {"feed_publisher_name", "feed_publisher_url", "feed_lang"},
+	AttributionsFile:       {"organization_name"},
+}
+
+// RequiredColumns returns the columns that the GTFS static specification unconditionally requires
+// to be present in the file.
+//
+// Conditionally required columns are not included. The locations.geojson file is not a CSV
+// file and has no columns.
+func RequiredColumns(file StaticFile) []string {
+	columns := requiredColumns[file]
+	result := make([]string, len(columns))
+	copy(result, columns)
+	return result
+}
@@ -5,6 +5,7 @@ package csv
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 
@@ -14,6 +15,9 @@ import (
 	"golang.org/x/text/transform"
 )
 
+// ErrNoCurrentRow is reported when a row is read before NextRow is called or after it returns false.
+var ErrNoCurrentRow = errors.New("no current row: NextRow was not called or returned false")
+
 type File struct {
 	name                   constants.StaticFile
 	csvReader              *csv.Reader
@@ -23,6 +27,7 @@ type File struct {
 	missingRequiredColumns []string
 	currentRow             *row
 	ioErr                  error
+	usageErr               error
 	closer                 func() error
 }
 
@@ -88,12 +93,15 @@ func (p *File) MissingRequiredColumns() []string {
 }
 
 func (c RequiredColumn) Read() string {
-	r := c.f.currentRow
-	if c.i >= len(r.cells) || r.cells[c.i] == "" {
+	r := c.f.checkCurrentRow()
+	if r == nil {
+		return ""
+	}
+	if c.i < 0 || c.i >= len(r.cells) || r.cells[c.i] == "" {
 		r.missingKeys = append(r.missingKeys, c.s)
 		return ""
 	}
-	return c.f.currentRow.cells[c.i]
+	return r.cells[c.i]
 }
 
 type OptionalColumn struct {
@@ -113,8 +121,12 @@ func (c OptionalColumn) Read() string {
 	if c.i < 0 {
 		return ""
 	}
+	r := c.f.checkCurrentRow()
+	if r == nil || c.i >= len(r.cells) {
+		return ""
+	}
 	// We copy the string pointer because the CSV library reuses the byte array across rows.
-	s := c.f.currentRow.cells[c.i]
+	s := r.cells[c.i]
 	return s
 }
 
@@ -122,7 +134,21 @@ func (c OptionalColumn) ReadOr(s string) string {
 	if c.i < 0 {
 		return s
 	}
-	return c.f.currentRow.cells[c.i]
+	r := c.f.checkCurrentRow()
+	if r == nil || c.i >= len(r.cells) {
+		return s
+	}
+	return r.cells[c.i]
+}
+
+// checkCurrentRow returns the current row, or nil if there is no current row.
+//
+// In the latter case the misuse is recorded and reported by Err and Close.
+func (f *File) checkCurrentRow() *row {
+	if f.currentRow == nil && f.usageErr == nil {
+		f.usageErr = fmt.Errorf("%s: %w", f.name, ErrNoCurrentRow)
+	}
+	return f.currentRow
 }
 
 func (f *File) NextRow() bool {
@@ -159,15 +185,30 @@ func (f *File) RowNumber() int {
 	return f.rowNumber
 }
 
+// MissingRowKeys returns the required columns that were empty in the current row.
+//
+// If there is no current row, nil is returned and ErrNoCurrentRow is reported by Err and Close.
 func (f *File) MissingRowKeys() []string {
-	return f.currentRow.missingKeys
+	r := f.checkCurrentRow()
+	if r == nil {
+		return nil
+	}
+	return r.missingKeys
 }
 
-func (f *File) Close() error {
-	closeErr := f.closer()
+// Err returns the first error encountered while reading the file, if any.
+func (f *File) Err() error {
 	if f.ioErr != nil {
 		return f.ioErr
 	}
+	return f.usageErr
+}
+
+func (f *File) Close() error {
+	closeErr := f.closer()
+	if err := f.Err(); err != nil {
+		return err
+	}
 	return closeErr
 }
 
@@ -0,0 +1,44 @@
+package csv
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jamespfennell/gtfs/constants"
+)
+
+func TestReadWithoutCurrentRow(t *testing.T) {
+	f, err := New(constants.StopsFile, io.NopCloser(strings.NewReader("stop_id,stop_name\na,b")))
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+	idColumn := f.RequiredColumn("stop_id")
+	nameColumn := f.OptionalColumn("stop_name")
+
+	if got := idColumn.Read(); got != "" {
+		t.Errorf("RequiredColumn.Read() before NextRow = %q, want empty", got)
+	}
+	if got := f.MissingRowKeys(); got != nil {
+		t.Errorf("MissingRowKeys() before NextRow = %v, want nil", got)
+	}
+	if !f.NextRow() {
+		t.Fatalf("NextRow() = false, want true")
+	}
+	if got := idColumn.Read(); got != "a" {
+		t.Errorf("RequiredColumn.Read() = %q, want %q", got, "a")
+	}
+	if f.NextRow() {
+		t.Fatalf("NextRow() = true, want false")
+	}
+	if got := nameColumn.ReadOr("default"); got != "default" {
+		t.Errorf("OptionalColumn.ReadOr() after last row = %q, want %q", got, "default")
+	}
+	if got := f.MissingRowKeys(); got != nil {
+		t.Errorf("MissingRowKeys() after last row = %v, want nil", got)
+	}
+	if err := f.Close(); !errors.Is(err, ErrNoCurrentRow) {
+		t.Errorf("Close() err = %v, want %v", err, ErrNoCurrentRow)
+	}
+}
@@ -236,6 +236,59 @@ func (t RouteType) String() string {
 	}
 }
 
+// Category returns the broad category of transport mode that the route type belongs to.
+func (t RouteType) Category() RouteTypeCategory {
+	switch t {
+	case RouteType_Tram, RouteType_Subway, RouteType_Rail, RouteType_CableTram, RouteType_Funicular, RouteType_Monorail:
+		return RouteTypeCategory_Rail
+	case RouteType_Bus, RouteType_TrolleyBus:
+		return RouteTypeCategory_Bus
+	case RouteType_Ferry:
+		return RouteTypeCategory_Water
+	case RouteType_AerialLift:
+		return RouteTypeCategory_Aerial
+	default:
+		return RouteTypeCategory_Unknown
+	}
+}
+
+// IsRailLike returns true if vehicles on the route run on rails; i.e., trams, subways, rail, cable trams,
+// funiculars and monorails.
+func (t RouteType) IsRailLike() bool {
+	return t.Category() == RouteTypeCategory_Rail
+}
+
+// IsBusLike returns true if vehicles on the route are buses; i.e., regular buses and trolleybuses.
+func (t RouteType) IsBusLike() bool {
+	return t.Category() == RouteTypeCategory_Bus
+}
+
+// RouteTypeCategory groups route types into broad categories of transport mode.
+type RouteTypeCategory int32
+
+const (
+	RouteTypeCategory_Unknown RouteTypeCategory = 0
+	RouteTypeCategory_Rail    RouteTypeCategory = 1
+	RouteTypeCategory_Bus     RouteTypeCategory = 2
+	RouteTypeCategory_Water   RouteTypeCategory = 3
+	RouteTypeCategory_Aerial  RouteTypeCategory = 4
+)
+
+func (c RouteTypeCategory) String() string {
+	switch c {
+	case RouteTypeCategory_Rail:
+		return "RAIL"
+	case RouteTypeCategory_Bus:
+		return "BUS"
+	case RouteTypeCategory_Water:
+		return "WATER"
+	case RouteTypeCategory_Aerial:
+		return "AERIAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
 // StopType describes the type of a stop.
 //
 // This is a Go representation of the enum described in the `location_type` field of `stops.txt`.
@@ -328,6 +381,64 @@ func (t TransferType) String() string {
 	}
 }
 
+// PathwayMode describes the type of a pathway between two locations of a station.
+//
+// This is a Go representation of the enum described in the `pathway_mode` field of `pathways.txt`.
+type PathwayMode int32
+
+const (
+	PathwayMode_Unknown        PathwayMode = 0
+	PathwayMode_Walkway        PathwayMode = 1
+	PathwayMode_Stairs         PathwayMode = 2
+	PathwayMode_MovingSidewalk PathwayMode = 3
+	PathwayMode_Escalator      PathwayMode = 4
+	PathwayMode_Elevator       PathwayMode = 5
+	PathwayMode_FareGate       PathwayMode = 6
+	PathwayMode_ExitGate       PathwayMode = 7
+)
+
+func parsePathwayMode(s string) PathwayMode {
+	switch s {
+	case "1":
+		return PathwayMode_Walkway
+	case "2":
+		return PathwayMode_Stairs
+	case "3":
+		return PathwayMode_MovingSidewalk
+	case "4":
+		return PathwayMode_Escalator
+	case "5":
+		return PathwayMode_Elevator
+	case "6":
+		return PathwayMode_FareGate
+	case "7":
+		return PathwayMode_ExitGate
+	default:
+		return PathwayMode_Unknown
+	}
+}
+
+func (m PathwayMode) String() string {
+	switch m {
+	case PathwayMode_Walkway:
+		return "WALKWAY"
+	case PathwayMode_Stairs:
+		return "STAIRS"
+	case PathwayMode_MovingSidewalk:
+		return "MOVING_SIDEWALK"
+	case PathwayMode_Escalator:
+		return "ESCALATOR"
+	case PathwayMode_Elevator:
+		return "ELEVATOR"
+	case PathwayMode_FareGate:
+		return "FARE_GATE"
+	case PathwayMode_ExitGate:
+		return "EXIT_GATE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
 // WheelchairBoarding describes whether wheelchair boarding is available at a stop.
 //
 // This is a Go representation of the enum described in the `wheelchair_boarding` field of `stops.txt`
@@ -363,3 +474,167 @@ func (w WheelchairBoarding) String() string {
 		return "UNKNOWN"
 	}
 }
+
+// FareMediaType describes the type of a fare medium.
+//
+// This is a Go representation of the enum described in the `fare_media_type` field of `fare_media.txt`.
+type FareMediaType int32
+
+const (
+	FareMediaType_None                FareMediaType = 0
+	FareMediaType_PhysicalPaperTicket FareMediaType = 1
+	FareMediaType_PhysicalTransitCard FareMediaType = 2
+	FareMediaType_ContactlessEMV      FareMediaType = 3
+	FareMediaType_MobileApp           FareMediaType = 4
+)
+
+func parseFareMediaType(s string) FareMediaType {
+	switch s {
+	case "1":
+		return FareMediaType_PhysicalPaperTicket
+	case "2":
+		return FareMediaType_PhysicalTransitCard
+	case "3":
+		return FareMediaType_ContactlessEMV
+	case "4":
+		return FareMediaType_MobileApp
+	default:
+		return FareMediaType_None
+	}
+}
+
+func (t FareMediaType) String() string {
+	switch t {
+	case FareMediaType_None:
+		return "NONE"
+	case FareMediaType_PhysicalPaperTicket:
+		return "PHYSICAL_PAPER_TICKET"
+	case FareMediaType_PhysicalTransitCard:
+		return "PHYSICAL_TRANSIT_CARD"
+	case FareMediaType_ContactlessEMV:
+		return "CONTACTLESS_EMV"
+	case FareMediaType_MobileApp:
+		return "MOBILE_APP"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// DurationLimitType describes the legs between which the duration limit of a fare transfer rule is measured.
+//
+// This is a Go representation of the enum described in the `duration_limit_type` field of
+// `fare_transfer_rules.txt`.
+type DurationLimitType int32
+
+const (
+	DurationLimitType_DepartureToArrival   DurationLimitType = 0
+	DurationLimitType_DepartureToDeparture DurationLimitType = 1
+	DurationLimitType_ArrivalToDeparture   DurationLimitType = 2
+	DurationLimitType_ArrivalToArrival     DurationLimitType = 3
+)
+
+func parseDurationLimitType(s string) DurationLimitType {
+	switch s {
+	case "1":
+		return DurationLimitType_DepartureToDeparture
+	case "2":
+		return DurationLimitType_ArrivalToDeparture
+	case "3":
+		return DurationLimitType_ArrivalToArrival
+	default:
+		return DurationLimitType_DepartureToArrival
+	}
+}
+
+func (t DurationLimitType) String() string {
+	switch t {
+	case DurationLimitType_DepartureToArrival:
+		return "DEPARTURE_TO_ARRIVAL"
+	case DurationLimitType_DepartureToDeparture:
+		return "DEPARTURE_TO_DEPARTURE"
+	case DurationLimitType_ArrivalToDeparture:
+		return "ARRIVAL_TO_DEPARTURE"
+	case DurationLimitType_ArrivalToArrival:
+		return "ARRIVAL_TO_ARRIVAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// FareTransferType describes how the cost of a transfer between two legs is computed.
+//
+// This is a Go representation of the enum described in the `fare_transfer_type` field of
+// `fare_transfer_rules.txt`.
+type FareTransferType int32
+
+const (
+	// The cost is the fare of the first leg plus the fare of the transfer.
+	FareTransferType_FromLegPlusTransfer FareTransferType = 0
+	// The cost is the fare of the first leg plus the fare of the transfer plus the fare of the second leg.
+	FareTransferType_FromLegPlusTransferPlusToLeg FareTransferType = 1
+	// The cost is the fare of the transfer.
+	FareTransferType_TransferOnly FareTransferType = 2
+)
+
+func parseFareTransferType(s string) FareTransferType {
+	switch s {
+	case "1":
+		return FareTransferType_FromLegPlusTransferPlusToLeg
+	case "2":
+		return FareTransferType_TransferOnly
+	default:
+		return FareTransferType_FromLegPlusTransfer
+	}
+}
+
+func (t FareTransferType) String() string {
+	switch t {
+	case FareTransferType_FromLegPlusTransfer:
+		return "FROM_LEG_PLUS_TRANSFER"
+	case FareTransferType_FromLegPlusTransferPlusToLeg:
+		return "FROM_LEG_PLUS_TRANSFER_PLUS_TO_LEG"
+	case FareTransferType_TransferOnly:
+		return "TRANSFER_ONLY"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// BookingType describes how far in advance a GTFS-Flex service must be booked.
+//
+// This is a Go representation of the enum described in the `booking_type` field of
+// `booking_rules.txt`.
+type BookingType int32
+
+const (
+	// The service can be booked in real time.
+	BookingType_RealTime BookingType = 0
+	// The service must be booked up to the same day with prior notice.
+	BookingType_SameDay BookingType = 1
+	// The service must be booked up to the prior days.
+	BookingType_PriorDays BookingType = 2
+)
+
+func parseBookingType(s string) BookingType {
+	switch s {
+	case "1":
+		return BookingType_SameDay
+	case "2":
+		return BookingType_PriorDays
+	default:
+		return BookingType_RealTime
+	}
+}
+
+func (t BookingType) String() string {
+	switch t {
+	case BookingType_RealTime:
+		return "REAL_TIME"
+	case BookingType_SameDay:
+		return "SAME_DAY"
+	case BookingType_PriorDays:
+		return "PRIOR_DAYS"
+	default:
+		return "UNKNOWN"
+	}
+}
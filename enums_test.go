@@ -0,0 +1,54 @@
+package gtfs
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestRouteType_Category(t *testing.T) {
+	for _, tc := range []struct {
+		routeType  RouteType
+		category   RouteTypeCategory
+		isRailLike bool
+		isBusLike  bool
+	}{
+		{RouteType_Tram, RouteTypeCategory_Rail, true, false},
+		{RouteType_Subway, RouteTypeCategory_Rail, true, false},
+		{RouteType_Rail, RouteTypeCategory_Rail, true, false},
+		{RouteType_Bus, RouteTypeCategory_Bus, false, true},
+		{RouteType_Ferry, RouteTypeCategory_Water, false, false},
+		{RouteType_CableTram, RouteTypeCategory_Rail, true, false},
+		{RouteType_AerialLift, RouteTypeCategory_Aerial, false, false},
+		{RouteType_Funicular, RouteTypeCategory_Rail, true, false},
+		{RouteType_TrolleyBus, RouteTypeCategory_Bus, false, true},
+		{RouteType_Monorail, RouteTypeCategory_Rail, true, false},
+		{RouteType_Unknown, RouteTypeCategory_Unknown, false, false},
+		{RouteType(8), RouteTypeCategory_Unknown, false, false},
+		{RouteType(715), RouteTypeCategory_Unknown, false, false},
+		{RouteType(-1), RouteTypeCategory_Unknown, false, false},
+	} {
+		t.Run(tc.routeType.String(), func(t *testing.T) {
+			if got := tc.routeType.Category(); got != tc.category {
+				t.Errorf("RouteType(%d).Category() = %s, want %s", tc.routeType, got, tc.category)
+			}
+			if got := tc.routeType.IsRailLike(); got != tc.isRailLike {
+				t.Errorf("RouteType(%d).IsRailLike() = %t, want %t", tc.routeType, got, tc.isRailLike)
+			}
+			if got := tc.routeType.IsBusLike(); got != tc.isBusLike {
+				t.Errorf("RouteType(%d).IsBusLike() = %t, want %t", tc.routeType, got, tc.isBusLike)
+			}
+		})
+	}
+}
+
+func TestRouteType_CategoryCoversParsedTypes(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		routeType := parseRouteType_GTFSStatic(strconv.Itoa(i))
+		if routeType == RouteType_Unknown {
+			continue
+		}
+		if routeType.Category() == RouteTypeCategory_Unknown {
+			t.Errorf("route type %s has no category", routeType)
+		}
+	}
+}
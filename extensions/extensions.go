@@ -5,6 +5,12 @@ import (
 )
 
 type Extension interface {
+	// UpdateHeader is called with the feed header before any entities are processed.
+	//
+	// Extensions can use this to correct values like the timestamp, which some feeds populate incorrectly.
+	// The header is never nil.
+	UpdateHeader(header *gtfsrt.FeedHeader)
+
 	// TODO PreprocessTrip etc
 	// TODO remove isAssigned
 	UpdateTrip(trip *gtfsrt.TripUpdate, feedCreatedAt uint64) UpdateTripResult
@@ -19,6 +25,9 @@ type Extension interface {
 type UpdateTripResult struct {
 	// Whether this trip should be skipped.
 	ShouldSkip bool
+
+	// Agency specific data to attach to the parsed trip.
+	Data any
 }
 
 func NoExtension() Extension {
@@ -28,6 +37,9 @@ func NoExtension() Extension {
 type NoExtensionImpl struct {
 }
 
+func (n NoExtensionImpl) UpdateHeader(header *gtfsrt.FeedHeader) {
+}
+
 func (n NoExtensionImpl) UpdateTrip(trip *gtfsrt.TripUpdate, feedCreatedAt uint64) UpdateTripResult {
 	return UpdateTripResult{}
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -65,6 +66,15 @@ type ExtensionOpts struct {
 	// type [Metadata], serialized to a string, and then included in the GTFS realtime message as a description
 	// string with language set to [MetadataLanguage].
 	AddNyctMetadata bool `yaml:"addNyctMetadata"`
+
+	// The MTA publishes station notices (e.g. "Uptown platform closed at 23 St") as alerts that inform
+	// whole routes, with the affected station only mentioned in the alert text. This map from station names,
+	// as they appear in the alert text, to stop IDs is used to find the stations a station notice refers to.
+	//
+	// If non-empty, route-level informed entities of station notices are rewritten into stop-level informed
+	// entities for each referenced station, keeping the route. Station notices that reference no known
+	// station are left unchanged.
+	StationNoticeStopIDs map[string]string `yaml:"stationNoticeStopIDs"`
 }
 
 // Extension returns the NYCT alerts extension with the provided options applied.
@@ -164,11 +174,15 @@ func (e extension) UpdateAlert(ID *string, alert *gtfsrt.Alert) bool {
 	}
 	alert.Cause = &cause
 
+	var isStationNotice bool
 	for _, informedEntity := range alert.GetInformedEntity() {
 		priority, ok := getPriorityFromInformedEntity(informedEntity)
 		if !ok {
 			continue
 		}
+		if priority == gtfsrt.MercuryEntitySelector_PRIORITY_STATION_NOTICE {
+			isStationNotice = true
+		}
 		if effect, ok := priortyToEffect[priority]; ok {
 			alert.Effect = &effect
 		}
@@ -176,6 +190,9 @@ func (e extension) UpdateAlert(ID *string, alert *gtfsrt.Alert) bool {
 			return true
 		}
 	}
+	if isStationNotice && len(e.opts.StationNoticeStopIDs) > 0 {
+		e.informStationNoticeStops(alert)
+	}
 	if e.opts.AddNyctMetadata {
 		if text, ok := buildMetadata(alert); ok {
 			language := MetadataLanguage
@@ -193,6 +210,79 @@ func (e extension) UpdateAlert(ID *string, alert *gtfsrt.Alert) bool {
 	return false
 }
 
+// informStationNoticeStops replaces the route-level informed entities of the alert with stop-level
+// informed entities for each station referenced in the alert text.
+func (e extension) informStationNoticeStops(alert *gtfsrt.Alert) {
+	var texts []string
+	for _, translatedString := range []*gtfsrt.TranslatedString{alert.GetHeaderText(), alert.GetDescriptionText()} {
+		for _, translation := range translatedString.GetTranslation() {
+			texts = append(texts, translation.GetText())
+		}
+	}
+	stopIDs := findStationReferences(strings.Join(texts, "\n"), e.opts.StationNoticeStopIDs)
+	if len(stopIDs) == 0 {
+		return
+	}
+	var informedEntities []*gtfsrt.EntitySelector
+	for _, informedEntity := range alert.GetInformedEntity() {
+		if informedEntity.RouteId == nil || informedEntity.StopId != nil {
+			informedEntities = append(informedEntities, informedEntity)
+			continue
+		}
+		for _, stopID := range stopIDs {
+			stopID := stopID
+			stopEntity := proto.Clone(informedEntity).(*gtfsrt.EntitySelector)
+			stopEntity.StopId = &stopID
+			informedEntities = append(informedEntities, stopEntity)
+		}
+	}
+	alert.InformedEntity = informedEntities
+}
+
+// findStationReferences returns the stop IDs of the stations whose names appear in the text, in order of
+// first appearance. Longer names are matched first so that, e.g., "14 St-Union Sq" is not also matched as "14 St".
+func findStationReferences(text string, stationNameToStopID map[string]string) []string {
+	var names []string
+	for name := range stationNameToStopID {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+	type reference struct {
+		position int
+		stopID   string
+	}
+	var references []reference
+	for _, name := range names {
+		for {
+			i := strings.Index(text, name)
+			if i < 0 {
+				break
+			}
+			references = append(references, reference{position: i, stopID: stationNameToStopID[name]})
+			// Blank out the match so that shorter names contained in it are not matched.
+			text = text[:i] + strings.Repeat("\x00", len(name)) + text[i+len(name):]
+		}
+	}
+	sort.SliceStable(references, func(i, j int) bool { return references[i].position < references[j].position })
+	var stopIDs []string
+	seen := map[string]bool{}
+	for _, reference := range references {
+		if seen[reference.stopID] {
+			continue
+		}
+		seen[reference.stopID] = true
+		stopIDs = append(stopIDs, reference.stopID)
+	}
+	return stopIDs
+}
+
 func buildMetadata(alert *gtfsrt.Alert) (string, bool) {
 	if !proto.HasExtension(alert, gtfsrt.E_MercuryAlert) {
 		return "", false
@@ -192,6 +192,65 @@ func TestElevatorAlerts(t *testing.T) {
 	}
 }
 
+func TestStationNoticeStops(t *testing.T) {
+	newEntity := func(header string) *gtfsrt.FeedEntity {
+		informedEntity := &gtfsrt.EntitySelector{RouteId: ptr("A")}
+		proto.SetExtension(informedEntity, gtfsrt.E_MercuryEntitySelector, &gtfsrt.MercuryEntitySelector{
+			SortOrder: ptr("MTASBWY:A:11"),
+		})
+		return &gtfsrt.FeedEntity{
+			Id: ptr("lmm:alert:1"),
+			Alert: &gtfsrt.Alert{
+				HeaderText: &gtfsrt.TranslatedString{
+					Translation: []*gtfsrt.TranslatedString_Translation{{Text: &header}},
+				},
+				InformedEntity: []*gtfsrt.EntitySelector{informedEntity},
+			},
+		}
+	}
+	opts := nyctalerts.ExtensionOpts{
+		StationNoticeStopIDs: map[string]string{
+			"14 St":          "A31",
+			"14 St-Union Sq": "L03",
+			"23 St":          "A30",
+		},
+	}
+	for _, tc := range []struct {
+		name   string
+		header string
+		want   []gtfs.AlertInformedEntity
+	}{
+		{
+			name:   "stations referenced",
+			header: "Uptown platform closed at 23 St and 14 St-Union Sq",
+			want: []gtfs.AlertInformedEntity{
+				{RouteID: ptr("A"), StopID: ptr("A30"), RouteType: gtfs.RouteType_Unknown},
+				{RouteID: ptr("A"), StopID: ptr("L03"), RouteType: gtfs.RouteType_Unknown},
+			},
+		},
+		{
+			name:   "no stations referenced",
+			header: "Trains are running with delays",
+			want: []gtfs.AlertInformedEntity{
+				{RouteID: ptr("A"), RouteType: gtfs.RouteType_Unknown},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result := testutil.MustParse(t, nil, []*gtfsrt.FeedEntity{newEntity(tc.header)}, &gtfs.ParseRealtimeOptions{
+				Extension: nyctalerts.Extension(opts),
+			})
+
+			if len(result.Alerts) != 1 {
+				t.Fatalf("got %d alerts, want 1", len(result.Alerts))
+			}
+			if got := result.Alerts[0].InformedEntities; !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got != want\n got=%+v\nwant=%+v", got, tc.want)
+			}
+		})
+	}
+}
+
 func ptr(s string) *string {
 	return &s
 }
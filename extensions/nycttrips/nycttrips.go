@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strconv"
 
+	"github.com/jamespfennell/gtfs"
 	"github.com/jamespfennell/gtfs/extensions"
 	gtfsrt "github.com/jamespfennell/gtfs/proto"
 	"google.golang.org/protobuf/proto"
@@ -44,11 +45,45 @@ func (e extension) UpdateTrip(trip *gtfsrt.TripUpdate, feedCreatedAt uint64) ext
 		fixMTrainPlatformsInBushwick(trip)
 	}
 	isAssigned := e.updateTripOrVehicle(trip)
-	shouldSkip := proto.HasExtension(trip.GetTrip(), gtfsrt.E_NyctTripDescriptor) &&
+	hasExtension := proto.HasExtension(trip.GetTrip(), gtfsrt.E_NyctTripDescriptor)
+	shouldSkip := hasExtension &&
 		e.opts.FilterStaleUnassignedTrips && isStaleUnassignedTrip(isAssigned, trip.StopTimeUpdate, feedCreatedAt)
-	return extensions.UpdateTripResult{
+	result := extensions.UpdateTripResult{
 		ShouldSkip: shouldSkip,
 	}
+	if hasExtension {
+		nyctTripDesc, _ := proto.GetExtension(trip.GetTrip(), gtfsrt.E_NyctTripDescriptor).(*gtfsrt.NyctTripDescriptor)
+		result.Data = &TripData{
+			IsAssigned: isAssigned,
+			TrainID:    nyctTripDesc.GetTrainId(),
+		}
+	}
+	return result
+}
+
+// TripData contains the NYCT specific data for a trip.
+type TripData struct {
+	// Whether a train has been assigned to the trip.
+	IsAssigned bool
+
+	// The NYCT train ID.
+	TrainID string
+}
+
+// GetTripData returns the NYCT specific data for a trip, or false if the trip was not parsed
+// using this extension or does not have the NYCT trip descriptor extension.
+func GetTripData(trip *gtfs.Trip) (*TripData, bool) {
+	if trip == nil {
+		return nil, false
+	}
+	data, ok := trip.ExtensionData.(*TripData)
+	return data, ok
+}
+
+// IsAssigned returns whether a train has been assigned to the trip.
+func IsAssigned(trip *gtfs.Trip) bool {
+	data, ok := GetTripData(trip)
+	return ok && data.IsAssigned
 }
 
 func (e extension) UpdateVehicle(vehicle *gtfsrt.VehiclePosition) {
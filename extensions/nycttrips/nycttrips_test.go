@@ -86,6 +86,7 @@ func TestGetTrack(t *testing.T) {
 					},
 					StopTimeUpdates: []gtfs.StopTimeUpdate{
 						{
+							Track:     testCase.ExpectedTrack,
 							NyctTrack: testCase.ExpectedTrack,
 						},
 					},
@@ -175,6 +176,62 @@ func TestFilterStaleUnassignedTrips(t *testing.T) {
 	}
 }
 
+func TestTripData(t *testing.T) {
+	tripDescriptor := &gtfsrt.TripDescriptor{
+		TripId: ptr("tripID"),
+	}
+	isAssigned := true
+	proto.SetExtension(tripDescriptor, gtfsrt.E_NyctTripDescriptor, &gtfsrt.NyctTripDescriptor{
+		IsAssigned: &isAssigned,
+		TrainId:    ptr("trainID"),
+	})
+	entities := []*gtfsrt.FeedEntity{
+		{
+			Id: ptr("1"),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip: tripDescriptor,
+			},
+		},
+		{
+			Id: ptr("2"),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip: &gtfsrt.TripDescriptor{
+					TripId: ptr("tripIDWithoutExtension"),
+				},
+			},
+		},
+	}
+
+	result := testutil.MustParse(t, nil, entities, &gtfs.ParseRealtimeOptions{
+		Extension: nycttrips.Extension(nycttrips.ExtensionOpts{}),
+	})
+
+	for _, trip := range result.Trips {
+		trip := trip
+		data, ok := nycttrips.GetTripData(&trip)
+		switch trip.ID.ID {
+		case "tripID":
+			if !ok {
+				t.Fatalf("GetTripData(%s) not found", trip.ID.ID)
+			}
+			want := nycttrips.TripData{IsAssigned: true, TrainID: "trainID"}
+			if *data != want {
+				t.Errorf("GetTripData(%s) = %+v, want %+v", trip.ID.ID, *data, want)
+			}
+			if !nycttrips.IsAssigned(&trip) {
+				t.Errorf("IsAssigned(%s) = false, want true", trip.ID.ID)
+			}
+		default:
+			if ok {
+				t.Errorf("GetTripData(%s) = %+v, want not found", trip.ID.ID, *data)
+			}
+			if nycttrips.IsAssigned(&trip) {
+				t.Errorf("IsAssigned(%s) = true, want false", trip.ID.ID)
+			}
+		}
+	}
+}
+
 func TestFixMTrainPlatformsInBushwick(t *testing.T) {
 	testCases := []struct {
 		Name         string
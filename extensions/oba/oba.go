@@ -0,0 +1,250 @@
+// Package oba contains an extension for feeds that follow the trip update conventions of OneBusAway
+// and TheTransitClock (formerly Transitime).
+//
+// These feeds often describe predictions using delays only. A trip update may have a trip-level
+// delay and no stop time updates, and stop time events may have a delay but no time. Feeds generated
+// by TheTransitClock also often only have stop time updates for the timepoints of the trip, and rely
+// on consumers propagating the delay to the other stops. This extension uses the GTFS static feed to
+// convert these trip updates into standard trip updates in which every stop time event has a time.
+package oba
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+	"github.com/jamespfennell/gtfs/extensions"
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+)
+
+// ExtensionOpts contains the options for the OneBusAway extension.
+type ExtensionOpts struct {
+	// The GTFS static feed corresponding to the realtime feed. The scheduled stop times of trips are
+	// used to convert delays into times.
+	//
+	// This is required. The static feed must not be parsed with [gtfs.ParseStaticOptions.StreamStopTimes].
+	Static *gtfs.Static
+
+	// By default the delay of each stop time update is propagated to the following stops of the trip
+	// that do not have a stop time update, as described in the GTFS realtime specification, and stop
+	// time updates with times are added for these stops. A trip-level delay is propagated to all of
+	// the stops before the first stop time update. Added stop time updates whose predicted departure
+	// is before the feed was created are skipped.
+	//
+	// If this option is true, stop time updates are not added and only the existing stop time events
+	// are converted.
+	DisablePropagation bool `yaml:"disablePropagation"`
+}
+
+// Extension returns the OneBusAway extension.
+//
+// An error is returned if the static feed is not set.
+func Extension(opts ExtensionOpts) (extensions.Extension, error) {
+	if opts.Static == nil {
+		return nil, fmt.Errorf("the OneBusAway extension requires a GTFS static feed")
+	}
+	location := opts.Static.Timezone
+	if location == nil {
+		location = time.UTC
+	}
+	return extension{
+		opts:     opts,
+		location: location,
+	}, nil
+}
+
+type extension struct {
+	opts     ExtensionOpts
+	location *time.Location
+
+	extensions.NoExtensionImpl
+}
+
+// UpdateTrip converts the delays in the trip update into times.
+//
+// Trip updates for trips that are not scheduled, or that are not in the static feed, are not changed.
+// The service day of the trip is the start date of the trip descriptor if it is set. Otherwise it is
+// the day the feed was created or the day before, whichever the trip is scheduled closest to the
+// creation time on.
+//
+// Stop time updates are matched to the scheduled stop times using the stop sequence if it is set,
+// and otherwise using the stop ID. The stop ID of matched stop time updates is populated if it is not
+// set. Stop time updates that do not match a scheduled stop time are not changed.
+func (e extension) UpdateTrip(trip *gtfsrt.TripUpdate, feedCreatedAt uint64) extensions.UpdateTripResult {
+	tripDesc := trip.GetTrip()
+	if tripDesc.GetScheduleRelationship() != gtfsrt.TripDescriptor_SCHEDULED {
+		return extensions.UpdateTripResult{}
+	}
+	scheduledTrip := e.opts.Static.TripByID(tripDesc.GetTripId())
+	if scheduledTrip == nil || len(scheduledTrip.StopTimes) == 0 {
+		return extensions.UpdateTripResult{}
+	}
+	serviceDay, ok := e.serviceDay(tripDesc, scheduledTrip, feedCreatedAt)
+	if !ok {
+		return extensions.UpdateTripResult{}
+	}
+	c := converter{
+		stopTimes:  scheduledTrip.StopTimes,
+		serviceDay: serviceDay,
+		propagate:  !e.opts.DisablePropagation,
+		delay:      trip.Delay,
+	}
+	if feedCreatedAt != 0 {
+		c.createdAt = time.Unix(int64(feedCreatedAt), 0)
+	}
+	trip.StopTimeUpdate = c.convert(trip.StopTimeUpdate)
+	return extensions.UpdateTripResult{}
+}
+
+// serviceDay returns the "noon minus 12h" time of the service day of the trip, from which the
+// scheduled stop times are measured.
+func (e extension) serviceDay(tripDesc *gtfsrt.TripDescriptor, scheduledTrip *gtfs.ScheduledTrip, feedCreatedAt uint64) (time.Time, bool) {
+	if startDate := tripDesc.GetStartDate(); startDate != "" {
+		date, err := time.ParseInLocation("20060102", startDate, e.location)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return gtfs.ServiceDayStart(date), true
+	}
+	if feedCreatedAt == 0 {
+		return time.Time{}, false
+	}
+	createdAt := time.Unix(int64(feedCreatedAt), 0).In(e.location)
+	first := scheduledTrip.StopTimes[0].DepartureTime
+	last := scheduledTrip.StopTimes[len(scheduledTrip.StopTimes)-1].ArrivalTime
+	var result time.Time
+	var resultDistance time.Duration
+	for _, offset := range []int{0, -1} {
+		date := createdAt.AddDate(0, 0, offset)
+		if scheduledTrip.Service != nil && !scheduledTrip.Service.RunsOn(date) {
+			continue
+		}
+		day := gtfs.ServiceDayStart(date)
+		var distance time.Duration
+		if start := day.Add(first); createdAt.Before(start) {
+			distance = start.Sub(createdAt)
+		} else if end := day.Add(last); createdAt.After(end) {
+			distance = createdAt.Sub(end)
+		}
+		if result.IsZero() || distance < resultDistance {
+			result, resultDistance = day, distance
+		}
+	}
+	if result.IsZero() {
+		result = gtfs.ServiceDayStart(createdAt)
+	}
+	return result, true
+}
+
+type converter struct {
+	stopTimes  []gtfs.ScheduledStopTime
+	serviceDay time.Time
+	createdAt  time.Time
+	propagate  bool
+
+	// The delay in seconds that applies to the next stop time event, or nil if it is not known.
+	delay *int32
+}
+
+func (c *converter) convert(updates []*gtfsrt.TripUpdate_StopTimeUpdate) []*gtfsrt.TripUpdate_StopTimeUpdate {
+	var result []*gtfsrt.TripUpdate_StopTimeUpdate
+	next := 0
+	for _, update := range updates {
+		i, ok := c.match(update, next)
+		if !ok {
+			result = append(result, update)
+			continue
+		}
+		result = c.appendPropagated(result, next, i)
+		next = i + 1
+		if update.StopId == nil && c.stopTimes[i].Stop != nil {
+			stopID := c.stopTimes[i].Stop.Id
+			update.StopId = &stopID
+		}
+		switch update.GetScheduleRelationship() {
+		case gtfsrt.TripUpdate_StopTimeUpdate_NO_DATA:
+			// The delay is not propagated past stops without data.
+			c.delay = nil
+		case gtfsrt.TripUpdate_StopTimeUpdate_SCHEDULED:
+			stopTime := &c.stopTimes[i]
+			update.Arrival = c.convertEvent(update.Arrival, stopTime.ArrivalTime)
+			update.Departure = c.convertEvent(update.Departure, stopTime.DepartureTime)
+		}
+		result = append(result, update)
+	}
+	return c.appendPropagated(result, next, len(c.stopTimes))
+}
+
+// match returns the index of the scheduled stop time of the update, searching from the provided index.
+func (c *converter) match(update *gtfsrt.TripUpdate_StopTimeUpdate, from int) (int, bool) {
+	for i := from; i < len(c.stopTimes); i++ {
+		stopTime := &c.stopTimes[i]
+		if update.StopSequence != nil {
+			if int(update.GetStopSequence()) == stopTime.StopSequence {
+				return i, true
+			}
+		} else if update.StopId != nil {
+			if stopTime.Stop != nil && stopTime.Stop.Id == update.GetStopId() {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// convertEvent sets the time of the event if it only has a delay, and updates the propagated delay.
+// If the event is nil, an event is created using the propagated delay.
+func (c *converter) convertEvent(event *gtfsrt.TripUpdate_StopTimeEvent, scheduled time.Duration) *gtfsrt.TripUpdate_StopTimeEvent {
+	scheduledTime := c.serviceDay.Add(scheduled)
+	switch {
+	case event == nil:
+		if c.delay == nil {
+			return nil
+		}
+		return newStopTimeEvent(scheduledTime, *c.delay)
+	case event.Time != nil:
+		delay := int32(event.GetTime() - scheduledTime.Unix())
+		c.delay = &delay
+	case event.Delay != nil:
+		t := scheduledTime.Unix() + int64(event.GetDelay())
+		event.Time = &t
+		delay := event.GetDelay()
+		c.delay = &delay
+	}
+	return event
+}
+
+// appendPropagated appends stop time updates with the propagated delay for the scheduled stop times
+// in the range [from, to).
+func (c *converter) appendPropagated(updates []*gtfsrt.TripUpdate_StopTimeUpdate, from, to int) []*gtfsrt.TripUpdate_StopTimeUpdate {
+	if !c.propagate || c.delay == nil {
+		return updates
+	}
+	for i := from; i < to; i++ {
+		stopTime := &c.stopTimes[i]
+		if stopTime.Stop == nil {
+			continue
+		}
+		departure := newStopTimeEvent(c.serviceDay.Add(stopTime.DepartureTime), *c.delay)
+		if !c.createdAt.IsZero() && departure.GetTime() < c.createdAt.Unix() {
+			continue
+		}
+		stopID := stopTime.Stop.Id
+		stopSequence := uint32(stopTime.StopSequence)
+		updates = append(updates, &gtfsrt.TripUpdate_StopTimeUpdate{
+			StopSequence: &stopSequence,
+			StopId:       &stopID,
+			Arrival:      newStopTimeEvent(c.serviceDay.Add(stopTime.ArrivalTime), *c.delay),
+			Departure:    departure,
+		})
+	}
+	return updates
+}
+
+func newStopTimeEvent(scheduledTime time.Time, delay int32) *gtfsrt.TripUpdate_StopTimeEvent {
+	t := scheduledTime.Unix() + int64(delay)
+	return &gtfsrt.TripUpdate_StopTimeEvent{
+		Delay: &delay,
+		Time:  &t,
+	}
+}
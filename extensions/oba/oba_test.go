@@ -0,0 +1,229 @@
+package oba_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs"
+	"github.com/jamespfennell/gtfs/extensions/oba"
+	"github.com/jamespfennell/gtfs/internal/testutil"
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+)
+
+var serviceDay = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+func newStatic() *gtfs.Static {
+	static := &gtfs.Static{
+		Services: []gtfs.Service{{Id: "service", AddedDates: []time.Time{serviceDay}}},
+		Stops:    []gtfs.Stop{{Id: "stop_1"}, {Id: "stop_2"}, {Id: "stop_3"}, {Id: "stop_4"}},
+	}
+	static.Trips = []gtfs.ScheduledTrip{
+		{
+			ID:      "trip",
+			Service: &static.Services[0],
+			StopTimes: []gtfs.ScheduledStopTime{
+				{Stop: &static.Stops[0], StopSequence: 1, ArrivalTime: 8 * time.Hour, DepartureTime: 8 * time.Hour},
+				{Stop: &static.Stops[1], StopSequence: 2, ArrivalTime: 8*time.Hour + 10*time.Minute, DepartureTime: 8*time.Hour + 11*time.Minute},
+				{Stop: &static.Stops[2], StopSequence: 3, ArrivalTime: 8*time.Hour + 20*time.Minute, DepartureTime: 8*time.Hour + 20*time.Minute},
+				{Stop: &static.Stops[3], StopSequence: 4, ArrivalTime: 8*time.Hour + 30*time.Minute, DepartureTime: 8*time.Hour + 30*time.Minute},
+			},
+		},
+	}
+	return static
+}
+
+// update is a simplified stop time update. Times are given as the time of day on the service day,
+// or -1 if the event is not set.
+type update struct {
+	StopID    string
+	Arrival   time.Duration
+	Departure time.Duration
+}
+
+func TestExtension(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		opts      oba.ExtensionOpts
+		createdAt time.Duration
+		tripID    string
+		delay     *int32
+		updates   []*gtfsrt.TripUpdate_StopTimeUpdate
+		want      []update
+	}{
+		{
+			name:  "trip delay",
+			delay: ptr(int32(120)),
+			want: []update{
+				{"stop_1", 8*time.Hour + 2*time.Minute, 8*time.Hour + 2*time.Minute},
+				{"stop_2", 8*time.Hour + 12*time.Minute, 8*time.Hour + 13*time.Minute},
+				{"stop_3", 8*time.Hour + 22*time.Minute, 8*time.Hour + 22*time.Minute},
+				{"stop_4", 8*time.Hour + 32*time.Minute, 8*time.Hour + 32*time.Minute},
+			},
+		},
+		{
+			name: "event delay",
+			updates: []*gtfsrt.TripUpdate_StopTimeUpdate{
+				{StopSequence: ptr(uint32(2)), Arrival: &gtfsrt.TripUpdate_StopTimeEvent{Delay: ptr(int32(60))}},
+			},
+			want: []update{
+				{"stop_2", 8*time.Hour + 11*time.Minute, 8*time.Hour + 12*time.Minute},
+				{"stop_3", 8*time.Hour + 21*time.Minute, 8*time.Hour + 21*time.Minute},
+				{"stop_4", 8*time.Hour + 31*time.Minute, 8*time.Hour + 31*time.Minute},
+			},
+		},
+		{
+			name: "timepoints",
+			updates: []*gtfsrt.TripUpdate_StopTimeUpdate{
+				{StopId: ptr("stop_1"), Departure: &gtfsrt.TripUpdate_StopTimeEvent{Time: ptr(unix(8*time.Hour + time.Minute))}},
+				{StopId: ptr("stop_3"), Arrival: &gtfsrt.TripUpdate_StopTimeEvent{Delay: ptr(int32(300))}},
+			},
+			want: []update{
+				{"stop_1", -1, 8*time.Hour + time.Minute},
+				{"stop_2", 8*time.Hour + 11*time.Minute, 8*time.Hour + 12*time.Minute},
+				{"stop_3", 8*time.Hour + 25*time.Minute, 8*time.Hour + 25*time.Minute},
+				{"stop_4", 8*time.Hour + 35*time.Minute, 8*time.Hour + 35*time.Minute},
+			},
+		},
+		{
+			name: "propagation disabled",
+			opts: oba.ExtensionOpts{DisablePropagation: true},
+			updates: []*gtfsrt.TripUpdate_StopTimeUpdate{
+				{StopId: ptr("stop_1"), Departure: &gtfsrt.TripUpdate_StopTimeEvent{Time: ptr(unix(8*time.Hour + time.Minute))}},
+				{StopId: ptr("stop_3"), Arrival: &gtfsrt.TripUpdate_StopTimeEvent{Delay: ptr(int32(300))}},
+			},
+			want: []update{
+				{"stop_1", -1, 8*time.Hour + time.Minute},
+				{"stop_3", 8*time.Hour + 25*time.Minute, 8*time.Hour + 25*time.Minute},
+			},
+		},
+		{
+			name:      "departed stops skipped",
+			createdAt: 8*time.Hour + 15*time.Minute,
+			delay:     ptr(int32(120)),
+			want: []update{
+				{"stop_3", 8*time.Hour + 22*time.Minute, 8*time.Hour + 22*time.Minute},
+				{"stop_4", 8*time.Hour + 32*time.Minute, 8*time.Hour + 32*time.Minute},
+			},
+		},
+		{
+			name: "no data",
+			updates: []*gtfsrt.TripUpdate_StopTimeUpdate{
+				{StopSequence: ptr(uint32(1)), Departure: &gtfsrt.TripUpdate_StopTimeEvent{Delay: ptr(int32(60))}},
+				{StopSequence: ptr(uint32(3)), ScheduleRelationship: gtfsrt.TripUpdate_StopTimeUpdate_NO_DATA.Enum()},
+			},
+			want: []update{
+				{"stop_1", -1, 8*time.Hour + time.Minute},
+				{"stop_2", 8*time.Hour + 11*time.Minute, 8*time.Hour + 12*time.Minute},
+				{"stop_3", -1, -1},
+			},
+		},
+		{
+			name:   "trip not in static feed",
+			tripID: "other_trip",
+			delay:  ptr(int32(120)),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.Static = newStatic()
+			extension, err := oba.Extension(tc.opts)
+			if err != nil {
+				t.Fatalf("Extension() err = %v", err)
+			}
+			if tc.tripID == "" {
+				tc.tripID = "trip"
+			}
+			if tc.createdAt == 0 {
+				tc.createdAt = 7*time.Hour + 55*time.Minute
+			}
+			header := &gtfsrt.FeedHeader{
+				GtfsRealtimeVersion: ptr("2.0"),
+				Timestamp:           ptr(uint64(unix(tc.createdAt))),
+			}
+			entities := []*gtfsrt.FeedEntity{
+				{
+					Id: ptr("1"),
+					TripUpdate: &gtfsrt.TripUpdate{
+						Trip:           &gtfsrt.TripDescriptor{TripId: ptr(tc.tripID)},
+						Delay:          tc.delay,
+						StopTimeUpdate: tc.updates,
+					},
+				},
+			}
+
+			result := testutil.MustParse(t, header, entities, &gtfs.ParseRealtimeOptions{Extension: extension})
+
+			var got []update
+			for _, stopTimeUpdate := range result.Trips[0].StopTimeUpdates {
+				u := update{Arrival: -1, Departure: -1}
+				if stopTimeUpdate.StopID != nil {
+					u.StopID = *stopTimeUpdate.StopID
+				}
+				if e := stopTimeUpdate.Arrival; e != nil && e.Time != nil {
+					u.Arrival = e.Time.Sub(serviceDay)
+				}
+				if e := stopTimeUpdate.Departure; e != nil && e.Time != nil {
+					u.Departure = e.Time.Sub(serviceDay)
+				}
+				got = append(got, u)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("stop time updates diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestExtension_DaylightSavingTime(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("failed to load timezone: %s", err)
+	}
+	// Clocks go forward at 2am on 13 March 2022, so noon minus 12h on that day is 11pm on 12 March.
+	// The service only runs on 12 March, so the trip must not be matched to 13 March.
+	saturday := time.Date(2022, 3, 12, 0, 0, 0, 0, newYork)
+	static := newStatic()
+	static.Timezone = newYork
+	static.Services[0].AddedDates = []time.Time{saturday}
+	extension, err := oba.Extension(oba.ExtensionOpts{Static: static, DisablePropagation: true})
+	if err != nil {
+		t.Fatalf("Extension() err = %v", err)
+	}
+	header := &gtfsrt.FeedHeader{
+		GtfsRealtimeVersion: ptr("2.0"),
+		Timestamp:           ptr(uint64(time.Date(2022, 3, 13, 7, 55, 0, 0, newYork).Unix())),
+	}
+	entities := []*gtfsrt.FeedEntity{
+		{
+			Id: ptr("1"),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip: &gtfsrt.TripDescriptor{TripId: ptr("trip")},
+				StopTimeUpdate: []*gtfsrt.TripUpdate_StopTimeUpdate{
+					{StopSequence: ptr(uint32(1)), Departure: &gtfsrt.TripUpdate_StopTimeEvent{Delay: ptr(int32(60))}},
+				},
+			},
+		},
+	}
+
+	result := testutil.MustParse(t, header, entities, &gtfs.ParseRealtimeOptions{Extension: extension})
+
+	departure := result.Trips[0].StopTimeUpdates[0].Departure
+	want := saturday.Add(8*time.Hour + time.Minute)
+	if departure == nil || departure.Time == nil || !departure.Time.Equal(want) {
+		t.Errorf("departure = %v, want time %s", departure, want)
+	}
+}
+
+func TestExtension_NoStatic(t *testing.T) {
+	if _, err := oba.Extension(oba.ExtensionOpts{}); err == nil {
+		t.Errorf("Extension() err = nil, want error")
+	}
+}
+
+func unix(timeOfDay time.Duration) int64 {
+	return serviceDay.Add(timeOfDay).Unix()
+}
+
+func ptr[T any](t T) *T {
+	return &t
+}
@@ -0,0 +1,117 @@
+package extensions
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// LoadProtoExtensionTypes returns the proto extension types defined in a file descriptor set.
+//
+// This is used to read vendor extensions to GTFS realtime without generating Go code for them.
+// The descriptor set can be created using protoc:
+//
+//	protoc --include_imports --descriptor_set_out=vendor.pb vendor-extension.proto
+//
+// The --include_imports flag is needed so that the set contains the files the vendor extension
+// imports. Files in the set that define the GTFS realtime messages are replaced by the gtfsrt package,
+// so the returned types extend the messages in that package. The returned types are passed to ParseRealtime using the
+// ProtoExtensionTypes field of gtfs.ParseRealtimeOptions, after which a custom [Extension] can read the
+// extension fields using proto.GetExtension. Values of message-typed extension fields are dynamic
+// messages that are accessed using the protoreflect API.
+//
+// Extension types for the NYCT extensions are built in and do not need to be loaded.
+func LoadProtoExtensionTypes(set *descriptorpb.FileDescriptorSet) ([]protoreflect.ExtensionType, error) {
+	r := &descriptorSetResolver{
+		files:   map[string]*descriptorpb.FileDescriptorProto{},
+		aliases: map[string]protoreflect.FileDescriptor{},
+		local:   &protoregistry.Files{},
+	}
+	for _, file := range set.GetFile() {
+		r.files[file.GetName()] = file
+	}
+	var types []protoreflect.ExtensionType
+	for _, file := range set.GetFile() {
+		fd, err := r.FindFileByPath(file.GetName())
+		if err != nil {
+			return nil, fmt.Errorf("invalid file descriptor set: %w", err)
+		}
+		if _, ok := r.aliases[file.GetName()]; ok {
+			continue
+		}
+		types = appendExtensionTypes(types, fd.Extensions())
+		types = appendNestedExtensionTypes(types, fd.Messages())
+	}
+	return types, nil
+}
+
+// descriptorSetResolver builds the files in a descriptor set as they are imported.
+//
+// Files in the set that define messages already linked into the binary, like the set's copy of
+// gtfs-realtime.proto, are replaced by the linked file. Otherwise extensions in the set would extend
+// a copy of the GTFS realtime messages rather than the messages in the gtfsrt package.
+type descriptorSetResolver struct {
+	files   map[string]*descriptorpb.FileDescriptorProto
+	aliases map[string]protoreflect.FileDescriptor
+	local   *protoregistry.Files
+}
+
+func (r *descriptorSetResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if fd, ok := r.aliases[path]; ok {
+		return fd, nil
+	}
+	if fd, err := r.local.FindFileByPath(path); err == nil {
+		return fd, nil
+	}
+	file, ok := r.files[path]
+	if !ok {
+		return protoregistry.GlobalFiles.FindFileByPath(path)
+	}
+	if file == nil {
+		return nil, fmt.Errorf("import cycle in file %q", path)
+	}
+	for _, message := range file.GetMessageType() {
+		name := protoreflect.FullName(file.GetPackage()).Append(protoreflect.Name(message.GetName()))
+		if d, err := protoregistry.GlobalFiles.FindDescriptorByName(name); err == nil {
+			r.aliases[path] = d.ParentFile()
+			return d.ParentFile(), nil
+		}
+	}
+	// Mark the file as in progress while its imports are built, to detect cycles.
+	r.files[path] = nil
+	fd, err := protodesc.NewFile(file, r)
+	r.files[path] = file
+	if err != nil {
+		return nil, err
+	}
+	if err := r.local.RegisterFile(fd); err != nil {
+		return nil, err
+	}
+	return fd, nil
+}
+
+func (r *descriptorSetResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d, err := r.local.FindDescriptorByName(name); err == nil {
+		return d, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+func appendNestedExtensionTypes(types []protoreflect.ExtensionType, messages protoreflect.MessageDescriptors) []protoreflect.ExtensionType {
+	for i := 0; i < messages.Len(); i++ {
+		types = appendExtensionTypes(types, messages.Get(i).Extensions())
+		types = appendNestedExtensionTypes(types, messages.Get(i).Messages())
+	}
+	return types
+}
+
+func appendExtensionTypes(types []protoreflect.ExtensionType, extensions protoreflect.ExtensionDescriptors) []protoreflect.ExtensionType {
+	for i := 0; i < extensions.Len(); i++ {
+		types = append(types, dynamicpb.NewExtensionType(extensions.Get(i)))
+	}
+	return types
+}
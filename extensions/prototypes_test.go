@@ -0,0 +1,126 @@
+package extensions_test
+
+import (
+	"testing"
+
+	"github.com/jamespfennell/gtfs"
+	"github.com/jamespfennell/gtfs/extensions"
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const vendorFieldNumber = 9000
+
+// vendorDescriptorSet is the descriptor set of a vendor extension that adds a string field to trip
+// updates, as would be generated by protoc --include_imports. The set contains the vendor's own copy
+// of gtfs-realtime.proto.
+func vendorDescriptorSet() *descriptorpb.FileDescriptorSet {
+	gtfsRealtime := protodesc.ToFileDescriptorProto(gtfsrt.File_proto_gtfs_realtime_proto)
+	gtfsRealtime.Name = proto.String("gtfs-realtime.proto")
+	return &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			gtfsRealtime,
+			{
+				Name:       proto.String("vendor.proto"),
+				Package:    proto.String("vendor"),
+				Syntax:     proto.String("proto2"),
+				Dependency: []string{"gtfs-realtime.proto"},
+				Extension: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("vendor_note"),
+						Number:   proto.Int32(vendorFieldNumber),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Extendee: proto.String(".transit_realtime.TripUpdate"),
+					},
+				},
+			},
+		},
+	}
+}
+
+type vendorExtension struct {
+	extensions.NoExtensionImpl
+	noteType protoreflect.ExtensionType
+}
+
+func (e vendorExtension) UpdateTrip(trip *gtfsrt.TripUpdate, feedCreatedAt uint64) extensions.UpdateTripResult {
+	if !proto.HasExtension(trip, e.noteType) {
+		return extensions.UpdateTripResult{}
+	}
+	return extensions.UpdateTripResult{Data: proto.GetExtension(trip, e.noteType)}
+}
+
+func TestLoadProtoExtensionTypes(t *testing.T) {
+	types, err := extensions.LoadProtoExtensionTypes(vendorDescriptorSet())
+	if err != nil {
+		t.Fatalf("LoadProtoExtensionTypes() err = %v", err)
+	}
+	if len(types) != 1 {
+		t.Fatalf("LoadProtoExtensionTypes() returned %d types, want 1", len(types))
+	}
+	noteType := types[0]
+	if got := noteType.TypeDescriptor().FullName(); got != "vendor.vendor_note" {
+		t.Errorf("extension name = %s, want vendor.vendor_note", got)
+	}
+
+	tripUpdate := &gtfsrt.TripUpdate{
+		Trip: &gtfsrt.TripDescriptor{TripId: proto.String("trip_id")},
+	}
+	var raw []byte
+	raw = protowire.AppendTag(raw, vendorFieldNumber, protowire.BytesType)
+	raw = protowire.AppendString(raw, "delayed by signals")
+	tripUpdate.ProtoReflect().SetUnknown(raw)
+	message := &gtfsrt.FeedMessage{
+		Header: &gtfsrt.FeedHeader{GtfsRealtimeVersion: proto.String("2.0")},
+		Entity: []*gtfsrt.FeedEntity{
+			{Id: proto.String("1"), TripUpdate: tripUpdate},
+		},
+	}
+	b, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatalf("failed to marshal GTFS-RT message: %s", err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		types []protoreflect.ExtensionType
+		want  any
+	}{
+		{
+			name:  "with types",
+			types: types,
+			want:  "delayed by signals",
+		},
+		{
+			name: "without types",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := gtfs.ParseRealtime(b, &gtfs.ParseRealtimeOptions{
+				Extension:           vendorExtension{noteType: noteType},
+				ProtoExtensionTypes: tc.types,
+			})
+			if err != nil {
+				t.Fatalf("ParseRealtime() err = %v", err)
+			}
+			if len(result.Trips) != 1 {
+				t.Fatalf("got %d trips, want 1", len(result.Trips))
+			}
+			if got := result.Trips[0].ExtensionData; got != tc.want {
+				t.Errorf("ExtensionData = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	_, err = gtfs.ParseRealtime(b, &gtfs.ParseRealtimeOptions{
+		ProtoExtensionTypes: append(types, types[0]),
+	})
+	if err == nil {
+		t.Errorf("ParseRealtime() with duplicate extension types err = nil, want error")
+	}
+}
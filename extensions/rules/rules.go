@@ -0,0 +1,219 @@
+// Package rules contains a generic extension that is configured using declarative rules.
+//
+// The rules can rewrite IDs in the feed using regular expressions and skip trips and alerts.
+// Like the options of the other extensions, the configuration has yaml tags so that it can be
+// loaded from a YAML file, allowing quirky feeds to be adapted without writing Go code:
+//
+//	rewrites:
+//	- field: routeID
+//	  pattern: "^(.*)X$"
+//	  replacement: "$1"
+//	filters:
+//	- entity: trip
+//	  routeIDPattern: "^SHUTTLE"
+package rules
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/jamespfennell/gtfs/extensions"
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+)
+
+// Config contains the rules of the extension.
+type Config struct {
+	// Rewrites are applied in order, before the filters.
+	Rewrites []Rewrite `yaml:"rewrites"`
+
+	// An entity is skipped if it matches any filter.
+	Filters []Filter `yaml:"filters"`
+}
+
+// Field is a kind of ID that can be rewritten.
+type Field string
+
+const (
+	// Trip IDs in trip descriptors.
+	Field_TripID Field = "tripID"
+	// Route IDs in trip descriptors and alert informed entities.
+	Field_RouteID Field = "routeID"
+	// Stop IDs in stop time updates, vehicle positions and alert informed entities.
+	Field_StopID Field = "stopID"
+	// Vehicle IDs in vehicle descriptors.
+	Field_VehicleID Field = "vehicleID"
+)
+
+// Rewrite replaces matches of a regular expression in every ID of a field.
+type Rewrite struct {
+	Field Field `yaml:"field"`
+
+	// Regular expression, in the syntax of the regexp package.
+	Pattern string `yaml:"pattern"`
+
+	// Replacement for each match of the pattern. Inside the replacement, $ signs are interpreted as in
+	// [regexp.Regexp.Expand], so $1 is the submatch of the first group.
+	Replacement string `yaml:"replacement"`
+}
+
+// Entity is a kind of entity that can be skipped.
+type Entity string
+
+const (
+	Entity_Trip  Entity = "trip"
+	Entity_Alert Entity = "alert"
+)
+
+// Filter skips entities that match all of its patterns.
+//
+// Vehicles cannot be skipped by extensions, and so cannot be filtered.
+type Filter struct {
+	Entity Entity `yaml:"entity"`
+
+	// If set, the filter only matches entities whose ID matches this regular expression.
+	// For trips, this is the trip ID. For alerts, this is the ID of the feed entity.
+	IDPattern string `yaml:"idPattern"`
+
+	// If set, the filter only matches entities with a route ID that matches this regular expression.
+	// For trips, this is the route ID of the trip descriptor. For alerts, it is sufficient for one
+	// informed entity to match.
+	RouteIDPattern string `yaml:"routeIDPattern"`
+}
+
+// Extension returns the rules extension.
+//
+// An error is returned if a regular expression is invalid or a field or entity is not recognized.
+func Extension(config Config) (extensions.Extension, error) {
+	e := extension{}
+	for i, rewrite := range config.Rewrites {
+		switch rewrite.Field {
+		case Field_TripID, Field_RouteID, Field_StopID, Field_VehicleID:
+		default:
+			return nil, fmt.Errorf("rewrite %d: unknown field %q", i, rewrite.Field)
+		}
+		regex, err := regexp.Compile(rewrite.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rewrite %d: invalid pattern: %w", i, err)
+		}
+		e.rewrites = append(e.rewrites, compiledRewrite{field: rewrite.Field, regex: regex, replacement: rewrite.Replacement})
+	}
+	for i, filter := range config.Filters {
+		switch filter.Entity {
+		case Entity_Trip, Entity_Alert:
+		default:
+			return nil, fmt.Errorf("filter %d: unknown entity %q", i, filter.Entity)
+		}
+		c := compiledFilter{entity: filter.Entity}
+		var err error
+		if c.id, err = compileOptional(filter.IDPattern); err != nil {
+			return nil, fmt.Errorf("filter %d: invalid ID pattern: %w", i, err)
+		}
+		if c.routeID, err = compileOptional(filter.RouteIDPattern); err != nil {
+			return nil, fmt.Errorf("filter %d: invalid route ID pattern: %w", i, err)
+		}
+		e.filters = append(e.filters, c)
+	}
+	return e, nil
+}
+
+func compileOptional(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+type compiledRewrite struct {
+	field       Field
+	regex       *regexp.Regexp
+	replacement string
+}
+
+type compiledFilter struct {
+	entity  Entity
+	id      *regexp.Regexp
+	routeID *regexp.Regexp
+}
+
+type extension struct {
+	rewrites []compiledRewrite
+	filters  []compiledFilter
+
+	extensions.NoExtensionImpl
+}
+
+func (e extension) UpdateTrip(trip *gtfsrt.TripUpdate, feedCreatedAt uint64) extensions.UpdateTripResult {
+	e.rewriteTripDescriptor(trip.GetTrip())
+	if trip.GetVehicle() != nil {
+		e.rewrite(Field_VehicleID, &trip.Vehicle.Id)
+	}
+	for _, stopTimeUpdate := range trip.GetStopTimeUpdate() {
+		e.rewrite(Field_StopID, &stopTimeUpdate.StopId)
+		if properties := stopTimeUpdate.GetStopTimeProperties(); properties != nil {
+			e.rewrite(Field_StopID, &properties.AssignedStopId)
+		}
+	}
+	for _, filter := range e.filters {
+		if filter.entity == Entity_Trip && matches(filter.id, trip.GetTrip().GetTripId()) && matches(filter.routeID, trip.GetTrip().GetRouteId()) {
+			return extensions.UpdateTripResult{ShouldSkip: true}
+		}
+	}
+	return extensions.UpdateTripResult{}
+}
+
+func (e extension) UpdateVehicle(vehicle *gtfsrt.VehiclePosition) {
+	e.rewriteTripDescriptor(vehicle.GetTrip())
+	if vehicle.GetVehicle() != nil {
+		e.rewrite(Field_VehicleID, &vehicle.Vehicle.Id)
+	}
+	e.rewrite(Field_StopID, &vehicle.StopId)
+}
+
+func (e extension) UpdateAlert(ID *string, alert *gtfsrt.Alert) bool {
+	for _, informedEntity := range alert.GetInformedEntity() {
+		e.rewrite(Field_RouteID, &informedEntity.RouteId)
+		e.rewrite(Field_StopID, &informedEntity.StopId)
+		e.rewriteTripDescriptor(informedEntity.GetTrip())
+	}
+	for _, filter := range e.filters {
+		if filter.entity != Entity_Alert || (filter.id != nil && (ID == nil || !filter.id.MatchString(*ID))) {
+			continue
+		}
+		if filter.routeID == nil {
+			return true
+		}
+		for _, informedEntity := range alert.GetInformedEntity() {
+			if informedEntity.RouteId != nil && filter.routeID.MatchString(*informedEntity.RouteId) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e extension) rewriteTripDescriptor(tripDesc *gtfsrt.TripDescriptor) {
+	if tripDesc == nil {
+		return
+	}
+	e.rewrite(Field_TripID, &tripDesc.TripId)
+	e.rewrite(Field_RouteID, &tripDesc.RouteId)
+}
+
+// rewrite applies the rewrites of the field to the ID, if it is set.
+func (e extension) rewrite(field Field, id **string) {
+	if *id == nil {
+		return
+	}
+	for _, rewrite := range e.rewrites {
+		if rewrite.field != field {
+			continue
+		}
+		newID := rewrite.regex.ReplaceAllString(**id, rewrite.replacement)
+		*id = &newID
+	}
+}
+
+// matches returns true if the regular expression is nil or matches the string.
+func matches(regex *regexp.Regexp, s string) bool {
+	return regex == nil || regex.MatchString(s)
+}
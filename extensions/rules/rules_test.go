@@ -0,0 +1,113 @@
+package rules_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs"
+	"github.com/jamespfennell/gtfs/extensions/rules"
+	"github.com/jamespfennell/gtfs/internal/testutil"
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+)
+
+func TestExtension(t *testing.T) {
+	extension, err := rules.Extension(rules.Config{
+		Rewrites: []rules.Rewrite{
+			{Field: rules.Field_RouteID, Pattern: "^(.*)X$", Replacement: "$1"},
+			{Field: rules.Field_StopID, Pattern: "^0+", Replacement: ""},
+			{Field: rules.Field_TripID, Pattern: "^", Replacement: "agency:"},
+			{Field: rules.Field_VehicleID, Pattern: "^bus-", Replacement: ""},
+		},
+		Filters: []rules.Filter{
+			{Entity: rules.Entity_Trip, RouteIDPattern: "^SHUTTLE"},
+			{Entity: rules.Entity_Alert, IDPattern: "^test:"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Extension() err = %v", err)
+	}
+	entities := []*gtfsrt.FeedEntity{
+		{
+			Id: ptr("1"),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip:           &gtfsrt.TripDescriptor{TripId: ptr("trip1"), RouteId: ptr("AX")},
+				StopTimeUpdate: []*gtfsrt.TripUpdate_StopTimeUpdate{{StopId: ptr("00123")}},
+			},
+		},
+		{
+			Id: ptr("2"),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip: &gtfsrt.TripDescriptor{TripId: ptr("trip2"), RouteId: ptr("SHUTTLE")},
+			},
+		},
+		{
+			Id: ptr("3"),
+			Vehicle: &gtfsrt.VehiclePosition{
+				Vehicle: &gtfsrt.VehicleDescriptor{Id: ptr("bus-7")},
+				StopId:  ptr("0456"),
+			},
+		},
+		{
+			Id: ptr("test:4"),
+			Alert: &gtfsrt.Alert{
+				InformedEntity: []*gtfsrt.EntitySelector{{RouteId: ptr("AX")}},
+			},
+		},
+		{
+			Id: ptr("5"),
+			Alert: &gtfsrt.Alert{
+				InformedEntity: []*gtfsrt.EntitySelector{{RouteId: ptr("AX")}},
+			},
+		},
+	}
+
+	result := testutil.MustParse(t, nil, entities, &gtfs.ParseRealtimeOptions{
+		Extension: extension,
+	})
+
+	var tripIDs []string
+	for _, trip := range result.Trips {
+		tripIDs = append(tripIDs, trip.ID.ID)
+	}
+	if diff := cmp.Diff(tripIDs, []string{"agency:trip1"}); diff != "" {
+		t.Fatalf("trip IDs diff: %s", diff)
+	}
+	if got := result.Trips[0].ID.RouteID; got != "A" {
+		t.Errorf("RouteID = %q, want %q", got, "A")
+	}
+	if got := *result.Trips[0].StopTimeUpdates[0].StopID; got != "123" {
+		t.Errorf("StopID = %q, want %q", got, "123")
+	}
+	if len(result.Vehicles) != 1 {
+		t.Fatalf("len(Vehicles) = %d, want 1", len(result.Vehicles))
+	}
+	if got := result.Vehicles[0].GetID().ID; got != "7" {
+		t.Errorf("vehicle ID = %q, want %q", got, "7")
+	}
+	if got := *result.Vehicles[0].StopID; got != "456" {
+		t.Errorf("vehicle StopID = %q, want %q", got, "456")
+	}
+	if len(result.Alerts) != 1 || result.Alerts[0].ID != "5" {
+		t.Fatalf("Alerts = %+v, want only the alert with ID 5", result.Alerts)
+	}
+	if got := *result.Alerts[0].InformedEntities[0].RouteID; got != "A" {
+		t.Errorf("alert RouteID = %q, want %q", got, "A")
+	}
+}
+
+func TestExtension_InvalidConfig(t *testing.T) {
+	for _, config := range []rules.Config{
+		{Rewrites: []rules.Rewrite{{Field: "agencyID", Pattern: "a"}}},
+		{Rewrites: []rules.Rewrite{{Field: rules.Field_TripID, Pattern: "("}}},
+		{Filters: []rules.Filter{{Entity: "vehicle"}}},
+		{Filters: []rules.Filter{{Entity: rules.Entity_Trip, RouteIDPattern: "("}}},
+	} {
+		if _, err := rules.Extension(config); err == nil {
+			t.Errorf("Extension(%+v) err = nil, want error", config)
+		}
+	}
+}
+
+func ptr[T any](t T) *T {
+	return &t
+}
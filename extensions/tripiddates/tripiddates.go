@@ -0,0 +1,114 @@
+// Package tripiddates contains an extension for feeds whose trip IDs embed the service date of the trip.
+//
+// Many agencies, for example the Toronto Transit Commission, use trip IDs of the form
+// "<date>-<blob>" but do not populate the start date of the trip descriptor. This extension
+// extracts the date from the trip ID and populates the start date with it.
+package tripiddates
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/jamespfennell/gtfs/extensions"
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+)
+
+// DefaultTripIDRegex matches trip IDs of the form "<date>-<blob>" where the date has the format YYYYMMDD.
+const DefaultTripIDRegex = `^([0-9]{8})-`
+
+// DefaultDateLayout is the layout, in the format of the time package, of dates matched by DefaultTripIDRegex.
+const DefaultDateLayout = "20060102"
+
+// ExtensionOpts contains the options for the trip ID dates extension.
+type ExtensionOpts struct {
+	// Regular expression that matches trip IDs with an embedded date. The date is the submatch of the
+	// group named "date" if there is one, and otherwise the submatch of the first group.
+	//
+	// If empty, DefaultTripIDRegex is used.
+	TripIDRegex string `yaml:"tripIDRegex"`
+
+	// Layout of the embedded date, in the format of the time package.
+	//
+	// If empty, DefaultDateLayout is used.
+	DateLayout string `yaml:"dateLayout"`
+
+	// By default the start date is only populated if the trip descriptor does not already have one.
+	// If this option is true, an existing start date is replaced.
+	OverwriteStartDate bool `yaml:"overwriteStartDate"`
+}
+
+// Extension returns the trip ID dates extension.
+//
+// An error is returned if the regular expression is invalid or does not have a group.
+func Extension(opts ExtensionOpts) (extensions.Extension, error) {
+	tripIDRegex := opts.TripIDRegex
+	if tripIDRegex == "" {
+		tripIDRegex = DefaultTripIDRegex
+	}
+	regex, err := regexp.Compile(tripIDRegex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trip ID regex: %w", err)
+	}
+	if regex.NumSubexp() == 0 {
+		return nil, fmt.Errorf("trip ID regex %q does not have a group that matches the date", tripIDRegex)
+	}
+	dateGroup := 1
+	if i := regex.SubexpIndex("date"); i > 0 {
+		dateGroup = i
+	}
+	dateLayout := opts.DateLayout
+	if dateLayout == "" {
+		dateLayout = DefaultDateLayout
+	}
+	return extension{
+		opts:       opts,
+		regex:      regex,
+		dateGroup:  dateGroup,
+		dateLayout: dateLayout,
+	}, nil
+}
+
+type extension struct {
+	opts       ExtensionOpts
+	regex      *regexp.Regexp
+	dateGroup  int
+	dateLayout string
+
+	extensions.NoExtensionImpl
+}
+
+func (e extension) UpdateTrip(trip *gtfsrt.TripUpdate, feedCreatedAt uint64) extensions.UpdateTripResult {
+	e.updateTripDescriptor(trip.GetTrip())
+	return extensions.UpdateTripResult{}
+}
+
+func (e extension) UpdateVehicle(vehicle *gtfsrt.VehiclePosition) {
+	e.updateTripDescriptor(vehicle.GetTrip())
+}
+
+func (e extension) updateTripDescriptor(tripDesc *gtfsrt.TripDescriptor) {
+	if tripDesc == nil || (tripDesc.StartDate != nil && !e.opts.OverwriteStartDate) {
+		return
+	}
+	date, ok := e.parseDate(tripDesc.GetTripId())
+	if !ok {
+		return
+	}
+	startDate := date.Format("20060102")
+	tripDesc.StartDate = &startDate
+}
+
+// parseDate returns the date embedded in the trip ID, or false if the trip ID does not match the
+// regular expression or the embedded date is invalid.
+func (e extension) parseDate(tripID string) (time.Time, bool) {
+	match := e.regex.FindStringSubmatch(tripID)
+	if match == nil {
+		return time.Time{}, false
+	}
+	date, err := time.Parse(e.dateLayout, match[e.dateGroup])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return date, true
+}
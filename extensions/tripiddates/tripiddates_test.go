@@ -0,0 +1,99 @@
+package tripiddates_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+	"github.com/jamespfennell/gtfs/extensions/tripiddates"
+	"github.com/jamespfennell/gtfs/internal/testutil"
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+)
+
+func TestExtension(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		opts      tripiddates.ExtensionOpts
+		tripID    string
+		startDate *string
+		want      time.Time
+	}{
+		{
+			name:   "default regex",
+			tripID: "20220504-48182",
+			want:   time.Date(2022, 5, 4, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "no match",
+			tripID: "48182",
+		},
+		{
+			name:   "invalid date",
+			tripID: "20221304-48182",
+		},
+		{
+			name:      "existing start date",
+			tripID:    "20220504-48182",
+			startDate: ptr("20220505"),
+			want:      time.Date(2022, 5, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "overwrite start date",
+			opts:      tripiddates.ExtensionOpts{OverwriteStartDate: true},
+			tripID:    "20220504-48182",
+			startDate: ptr("20220505"),
+			want:      time.Date(2022, 5, 4, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "custom regex",
+			opts: tripiddates.ExtensionOpts{
+				TripIDRegex: `^([A-Z]+)_(?P<date>[0-9]{2}/[0-9]{2}/[0-9]{4})$`,
+				DateLayout:  "01/02/2006",
+			},
+			tripID: "BUS_05/04/2022",
+			want:   time.Date(2022, 5, 4, 0, 0, 0, 0, time.UTC),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			extension, err := tripiddates.Extension(tc.opts)
+			if err != nil {
+				t.Fatalf("Extension() err = %v", err)
+			}
+			entities := []*gtfsrt.FeedEntity{
+				{
+					Id: ptr("1"),
+					TripUpdate: &gtfsrt.TripUpdate{
+						Trip: &gtfsrt.TripDescriptor{
+							TripId:    ptr(tc.tripID),
+							StartDate: tc.startDate,
+						},
+					},
+				},
+			}
+
+			result := testutil.MustParse(t, nil, entities, &gtfs.ParseRealtimeOptions{
+				Extension: extension,
+				Timezone:  time.UTC,
+			})
+
+			if len(result.Trips) != 1 {
+				t.Fatalf("len(Trips) = %d, want 1", len(result.Trips))
+			}
+			if got := result.Trips[0].ID.StartDate; !got.Equal(tc.want) {
+				t.Errorf("StartDate = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExtension_InvalidRegex(t *testing.T) {
+	for _, regex := range []string{`(`, `^[0-9]{8}-`} {
+		if _, err := tripiddates.Extension(tripiddates.ExtensionOpts{TripIDRegex: regex}); err == nil {
+			t.Errorf("Extension(%q) err = nil, want error", regex)
+		}
+	}
+}
+
+func ptr[T any](t T) *T {
+	return &t
+}
@@ -0,0 +1,50 @@
+package gtfs
+
+// ZoneChain returns the fare zones of the stop and its ancestors, starting with the stop's own zone.
+// Empty and repeated zone IDs are skipped.
+//
+// If neither the stop nor its ancestors are in a fare zone, which is common for stations whose
+// platforms have the zone IDs, the distinct zones of the stop's descendants in the static message
+// are returned instead, in the order of the Stops field.
+func (stop *Stop) ZoneChain(static *Static) []string {
+	var zones []string
+	seen := map[string]bool{}
+	add := func(zone string) {
+		if zone != "" && !seen[zone] {
+			seen[zone] = true
+			zones = append(zones, zone)
+		}
+	}
+	for ancestor := stop; ancestor != nil; ancestor = ancestor.Parent {
+		add(ancestor.ZoneId)
+	}
+	if len(zones) > 0 || static == nil {
+		return zones
+	}
+	for i := range static.Stops {
+		for ancestor := static.Stops[i].Parent; ancestor != nil; ancestor = ancestor.Parent {
+			if ancestor == stop {
+				add(static.Stops[i].ZoneId)
+				break
+			}
+		}
+	}
+	return zones
+}
+
+// StopsByZone returns the stops in each fare zone.
+//
+// A stop without a zone ID is in the zone of its closest ancestor that has one. Stops that are not in
+// a zone are omitted. Within each zone, stops are in the order of the Stops field.
+func (s *Static) StopsByZone() map[string][]*Stop {
+	zoneToStops := map[string][]*Stop{}
+	for i := range s.Stops {
+		for ancestor := &s.Stops[i]; ancestor != nil; ancestor = ancestor.Parent {
+			if ancestor.ZoneId != "" {
+				zoneToStops[ancestor.ZoneId] = append(zoneToStops[ancestor.ZoneId], &s.Stops[i])
+				break
+			}
+		}
+	}
+	return zoneToStops
+}
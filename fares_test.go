@@ -0,0 +1,106 @@
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs/constants"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+func TestZoneChain(t *testing.T) {
+	static := &Static{
+		Stops: []Stop{
+			{Id: "region", ZoneId: "2"},
+			{Id: "station", ZoneId: "1"},
+			{Id: "platform"},
+			{Id: "other_station"},
+			{Id: "other_platform_1", ZoneId: "3"},
+			{Id: "other_platform_2", ZoneId: "4"},
+			{Id: "no_zone"},
+		},
+	}
+	static.Stops[1].Parent = &static.Stops[0]
+	static.Stops[2].Parent = &static.Stops[1]
+	static.Stops[4].Parent = &static.Stops[3]
+	static.Stops[5].Parent = &static.Stops[3]
+
+	for _, tc := range []struct {
+		stop int
+		want []string
+	}{
+		{2, []string{"1", "2"}},
+		{3, []string{"3", "4"}},
+		{6, nil},
+	} {
+		stop := &static.Stops[tc.stop]
+		if got := stop.ZoneChain(static); !cmp.Equal(got, tc.want) {
+			t.Errorf("ZoneChain(%s) got = %v, want = %v", stop.Id, got, tc.want)
+		}
+	}
+
+	gotStopsByZone := map[string][]string{}
+	for zone, stops := range static.StopsByZone() {
+		gotStopsByZone[zone] = stopIDs(stops)
+	}
+	wantStopsByZone := map[string][]string{
+		"1": {"station", "platform"},
+		"2": {"region"},
+		"3": {"other_platform_1"},
+		"4": {"other_platform_2"},
+	}
+	if diff := cmp.Diff(gotStopsByZone, wantStopsByZone); diff != "" {
+		t.Errorf("StopsByZone() diff: %s", diff)
+	}
+}
+
+func TestParse_FareRules(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id,zone_id",
+		"stop_1,zone_1",
+		"stop_2,zone_2",
+	).add(
+		// The default stop time references stops and trips that are not in this feed.
+		"stop_times.txt",
+		"stop_id,trip_id,arrival_time,departure_time,stop_sequence",
+	).add(
+		"fare_rules.txt",
+		"fare_id,route_id,origin_id,destination_id,contains_id",
+		"fare_1,route_id,zone_1,zone_2,",
+		"fare_2,,zone_1,,zone_3",
+		"fare_3,route_2,,,",
+	).build()
+
+	static, err := ParseStatic(content, ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+
+	if len(static.FareRules) != 1 {
+		t.Fatalf("len(FareRules) = %d, want 1", len(static.FareRules))
+	}
+	if got := static.FareRules[0]; got.FareID != "fare_1" || got.Route != &static.Routes[0] || got.OriginID != "zone_1" || got.DestinationID != "zone_2" {
+		t.Errorf("FareRules[0] = %+v, want fare_1 from zone_1 to zone_2 on route_id", got)
+	}
+	header := []string{"fare_id", "route_id", "origin_id", "destination_id", "contains_id"}
+	wantWarnings := []warnings.StaticWarning{
+		{
+			Kind:          warnings.InvalidForeignID{Column: "contains_id", ID: "zone_3"},
+			File:          constants.FareRulesFile,
+			RowNumber:     2,
+			RowContent:    []string{"fare_2", "", "zone_1", "", "zone_3"},
+			HeaderContent: header,
+		},
+		{
+			Kind:          warnings.InvalidForeignID{Column: "route_id", ID: "route_2"},
+			File:          constants.FareRulesFile,
+			RowNumber:     3,
+			RowContent:    []string{"fare_3", "route_2", "", "", ""},
+			HeaderContent: header,
+		},
+	}
+	if diff := cmp.Diff(static.Warnings, wantWarnings); diff != "" {
+		t.Errorf("warnings not the same: %s", diff)
+	}
+}
@@ -0,0 +1,497 @@
+package gtfs
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamespfennell/gtfs/csv"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+// Area corresponds to a single row in the areas.txt file, along with the stops assigned to the area
+// in the stop_areas.txt file.
+type Area struct {
+	ID   string
+	Name string
+	// Stops in the area, in the order they appear in stop_areas.txt.
+	Stops []*Stop
+
+	// Row number of the area in areas.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
+}
+
+// Network corresponds to a single row in the networks.txt file, along with the routes assigned to the
+// network in the route_networks.txt file.
+//
+// Networks that are only defined by the legacy network_id column of routes.txt are also represented
+// by a Network, with an empty name.
+type Network struct {
+	ID   string
+	Name string
+	// Routes in the network, in the order they appear in route_networks.txt.
+	Routes []*Route
+
+	// Row number of the network in networks.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
+}
+
+// FareMedium corresponds to a single row in the fare_media.txt file.
+type FareMedium struct {
+	ID   string
+	Name string
+	Type FareMediaType
+
+	// Row number of the fare medium in fare_media.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
+}
+
+// FareProduct corresponds to a single row in the fare_products.txt file.
+//
+// A fare product with different prices for different fare media appears once per fare medium, so
+// the ID is not unique.
+type FareProduct struct {
+	ID   string
+	Name string
+	// Fare medium the price applies to, or nil if the price does not depend on the fare medium.
+	FareMedium *FareMedium
+	Amount     float64
+	// ISO 4217 currency code of the amount.
+	Currency string
+
+	// Row number of the fare product in fare_products.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
+}
+
+// FareLegRule corresponds to a single row in the fare_leg_rules.txt file.
+type FareLegRule struct {
+	LegGroupID string
+	// Network the rule applies to, or nil if the rule is not restricted to a network.
+	Network *Network
+	// Areas the leg departs from and arrives at. Each is nil if the rule is not restricted by it.
+	FromArea *Area
+	ToArea   *Area
+	// Timeframe groups of the departure and arrival of the leg. Timeframes are not parsed, so
+	// these are not checked. Each is empty if the rule is not restricted by it.
+	FromTimeframeGroupID string
+	ToTimeframeGroupID   string
+	// ID of the fare product, in [Static.FareProducts], needed to travel the leg.
+	FareProductID string
+	RulePriority  *int32
+
+	// Row number of the fare leg rule in fare_leg_rules.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
+}
+
+// FareTransferRule corresponds to a single row in the fare_transfer_rules.txt file.
+type FareTransferRule struct {
+	// Leg groups, as in [FareLegRule.LegGroupID], of the legs before and after the transfer.
+	FromLegGroupID string
+	ToLegGroupID   string
+	// Number of consecutive transfers the rule applies to, or -1 if there is no limit.
+	TransferCount *int32
+	// Time within which the transfer must be made, or nil if there is no limit.
+	DurationLimit     *time.Duration
+	DurationLimitType DurationLimitType
+	FareTransferType  FareTransferType
+	// ID of the fare product, in [Static.FareProducts], needed to make the transfer. Empty if the
+	// transfer is free.
+	FareProductID string
+
+	// Row number of the fare transfer rule in fare_transfer_rules.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
+}
+
+func parseAreas(csv *csv.File, w *warningCollector, recordSourceRows bool) []Area {
+	idColumn := csv.RequiredColumn("area_id")
+	nameColumn := csv.OptionalColumn("area_name")
+
+	if checkForMissingColumns(csv, w) {
+		return nil
+	}
+
+	var areas []Area
+	for csv.NextRow() {
+		area := Area{
+			ID:        idColumn.Read(),
+			Name:      nameColumn.Read(),
+			SourceRow: sourceRow(csv, recordSourceRows),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			log.Printf("Skipping area because of missing keys %s", missingKeys)
+			continue
+		}
+		areas = append(areas, area)
+	}
+	return areas
+}
+
+// parseStopAreas adds the stops to the areas. Rows that reference an area or stop that does not
+// exist are skipped with a warning.
+func parseStopAreas(csv *csv.File, w *warningCollector, areas []Area, stops []Stop) {
+	areaIDColumn := csv.RequiredColumn("area_id")
+	stopIDColumn := csv.RequiredColumn("stop_id")
+
+	if checkForMissingColumns(csv, w) {
+		return
+	}
+
+	areaIDToArea := map[string]*Area{}
+	for i := range areas {
+		areaIDToArea[areas[i].ID] = &areas[i]
+	}
+	stopIDToStop := map[string]*Stop{}
+	for i := range stops {
+		stopIDToStop[stops[i].Id] = &stops[i]
+	}
+	for csv.NextRow() {
+		areaID := areaIDColumn.Read()
+		stopID := stopIDColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			log.Printf("Skipping stop area because of missing keys %s", missingKeys)
+			continue
+		}
+		area, areaOk := areaIDToArea[areaID]
+		stop, stopOk := stopIDToStop[stopID]
+		if !areaOk {
+			w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "area_id", ID: areaID}))
+		}
+		if !stopOk {
+			w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "stop_id", ID: stopID}))
+		}
+		if !areaOk || !stopOk {
+			continue
+		}
+		area.Stops = append(area.Stops, stop)
+	}
+	return
+}
+
+func parseNetworks(csv *csv.File, w *warningCollector, recordSourceRows bool) []Network {
+	idColumn := csv.RequiredColumn("network_id")
+	nameColumn := csv.OptionalColumn("network_name")
+
+	if checkForMissingColumns(csv, w) {
+		return nil
+	}
+
+	var networks []Network
+	for csv.NextRow() {
+		network := Network{
+			ID:        idColumn.Read(),
+			Name:      nameColumn.Read(),
+			SourceRow: sourceRow(csv, recordSourceRows),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			log.Printf("Skipping network because of missing keys %s", missingKeys)
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// parseRouteNetworks adds the routes to the networks. Rows that reference a network or route that
+// does not exist are skipped with a warning.
+func parseRouteNetworks(csv *csv.File, w *warningCollector, networks []Network, routes []Route) {
+	networkIDColumn := csv.RequiredColumn("network_id")
+	routeIDColumn := csv.RequiredColumn("route_id")
+
+	if checkForMissingColumns(csv, w) {
+		return
+	}
+
+	networkIDToNetwork := map[string]*Network{}
+	for i := range networks {
+		networkIDToNetwork[networks[i].ID] = &networks[i]
+	}
+	routeIDToRoute := map[string]*Route{}
+	for i := range routes {
+		routeIDToRoute[routes[i].Id] = &routes[i]
+	}
+	for csv.NextRow() {
+		networkID := networkIDColumn.Read()
+		routeID := routeIDColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			log.Printf("Skipping route network because of missing keys %s", missingKeys)
+			continue
+		}
+		network, networkOk := networkIDToNetwork[networkID]
+		route, routeOk := routeIDToRoute[routeID]
+		if !networkOk {
+			w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "network_id", ID: networkID}))
+		}
+		if !routeOk {
+			w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "route_id", ID: routeID}))
+		}
+		if !networkOk || !routeOk {
+			continue
+		}
+		network.Routes = append(network.Routes, route)
+	}
+	return
+}
+
+// linkRouteNetworks adds routes to networks using the legacy network_id column of routes.txt and
+// then sets the network of each route. Networks that are only defined by the network_id column are
+// appended to the returned slice.
+//
+// A route that has already been added to a network using route_networks.txt keeps that network.
+func linkRouteNetworks(networks []Network, routes []Route, routeNetworkIDs []string) []Network {
+	inNetwork := map[*Route]bool{}
+	networkIDToIndex := map[string]int{}
+	for i := range networks {
+		networkIDToIndex[networks[i].ID] = i
+		for _, route := range networks[i].Routes {
+			inNetwork[route] = true
+		}
+	}
+	for i, networkID := range routeNetworkIDs {
+		route := &routes[i]
+		if networkID == "" || inNetwork[route] {
+			continue
+		}
+		j, ok := networkIDToIndex[networkID]
+		if !ok {
+			j = len(networks)
+			networkIDToIndex[networkID] = j
+			networks = append(networks, Network{ID: networkID})
+		}
+		networks[j].Routes = append(networks[j].Routes, route)
+	}
+	// The networks slice may have been reallocated above, so pointers are only taken once it is final.
+	for i := range networks {
+		for _, route := range networks[i].Routes {
+			route.Network = &networks[i]
+		}
+	}
+	return networks
+}
+
+func parseFareMedia(csv *csv.File, w *warningCollector, recordSourceRows bool) []FareMedium {
+	idColumn := csv.RequiredColumn("fare_media_id")
+	typeColumn := csv.RequiredColumn("fare_media_type")
+	nameColumn := csv.OptionalColumn("fare_media_name")
+
+	if checkForMissingColumns(csv, w) {
+		return nil
+	}
+
+	var fareMedia []FareMedium
+	for csv.NextRow() {
+		fareMedium := FareMedium{
+			ID:        idColumn.Read(),
+			Name:      nameColumn.Read(),
+			Type:      parseFareMediaType(typeColumn.Read()),
+			SourceRow: sourceRow(csv, recordSourceRows),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			log.Printf("Skipping fare medium because of missing keys %s", missingKeys)
+			continue
+		}
+		fareMedia = append(fareMedia, fareMedium)
+	}
+	return fareMedia
+}
+
+// parseFareProducts parses the fare products. Products that reference a fare medium that does not
+// exist are skipped with a warning.
+func parseFareProducts(csv *csv.File, w *warningCollector, fareMedia []FareMedium, recordSourceRows bool) []FareProduct {
+	idColumn := csv.RequiredColumn("fare_product_id")
+	amountColumn := csv.RequiredColumn("amount")
+	currencyColumn := csv.RequiredColumn("currency")
+	nameColumn := csv.OptionalColumn("fare_product_name")
+	fareMediaIDColumn := csv.OptionalColumn("fare_media_id")
+
+	if checkForMissingColumns(csv, w) {
+		return nil
+	}
+
+	fareMediaIDToFareMedium := map[string]*FareMedium{}
+	for i := range fareMedia {
+		fareMediaIDToFareMedium[fareMedia[i].ID] = &fareMedia[i]
+	}
+	var fareProducts []FareProduct
+	for csv.NextRow() {
+		fareProduct := FareProduct{
+			ID:        idColumn.Read(),
+			Name:      nameColumn.Read(),
+			Currency:  currencyColumn.Read(),
+			SourceRow: sourceRow(csv, recordSourceRows),
+		}
+		amount := amountColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			log.Printf("Skipping fare product because of missing keys %s", missingKeys)
+			continue
+		}
+		var err error
+		fareProduct.Amount, err = strconv.ParseFloat(strings.TrimSpace(amount), 64)
+		if err != nil {
+			log.Printf("Skipping fare product because of invalid amount %q", amount)
+			continue
+		}
+		if fareMediaID := fareMediaIDColumn.Read(); fareMediaID != "" {
+			if fareProduct.FareMedium = fareMediaIDToFareMedium[fareMediaID]; fareProduct.FareMedium == nil {
+				w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "fare_media_id", ID: fareMediaID}))
+				continue
+			}
+		}
+		fareProducts = append(fareProducts, fareProduct)
+	}
+	return fareProducts
+}
+
+// parseFareLegRules parses the fare leg rules. Rules that reference a network, area or fare product
+// that does not exist are skipped with a warning.
+func parseFareLegRules(csv *csv.File, w *warningCollector, networks []Network, areas []Area, fareProducts []FareProduct, recordSourceRows bool) []FareLegRule {
+	fareProductIDColumn := csv.RequiredColumn("fare_product_id")
+	legGroupIDColumn := csv.OptionalColumn("leg_group_id")
+	networkIDColumn := csv.OptionalColumn("network_id")
+	fromAreaIDColumn := csv.OptionalColumn("from_area_id")
+	toAreaIDColumn := csv.OptionalColumn("to_area_id")
+	fromTimeframeGroupIDColumn := csv.OptionalColumn("from_timeframe_group_id")
+	toTimeframeGroupIDColumn := csv.OptionalColumn("to_timeframe_group_id")
+	rulePriorityColumn := csv.OptionalColumn("rule_priority")
+
+	if checkForMissingColumns(csv, w) {
+		return nil
+	}
+
+	networkIDToNetwork := map[string]*Network{}
+	for i := range networks {
+		networkIDToNetwork[networks[i].ID] = &networks[i]
+	}
+	areaIDToArea := map[string]*Area{}
+	for i := range areas {
+		areaIDToArea[areas[i].ID] = &areas[i]
+	}
+	fareProductIDs := map[string]bool{}
+	for i := range fareProducts {
+		fareProductIDs[fareProducts[i].ID] = true
+	}
+	var fareLegRules []FareLegRule
+	for csv.NextRow() {
+		fareLegRule := FareLegRule{
+			LegGroupID:           legGroupIDColumn.Read(),
+			FromTimeframeGroupID: fromTimeframeGroupIDColumn.Read(),
+			ToTimeframeGroupID:   toTimeframeGroupIDColumn.Read(),
+			FareProductID:        fareProductIDColumn.Read(),
+			RulePriority:         parseInt32(rulePriorityColumn.Read()),
+			SourceRow:            sourceRow(csv, recordSourceRows),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			log.Printf("Skipping fare leg rule because of missing keys %s", missingKeys)
+			continue
+		}
+		valid := true
+		if networkID := networkIDColumn.Read(); networkID != "" {
+			if fareLegRule.Network = networkIDToNetwork[networkID]; fareLegRule.Network == nil {
+				w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "network_id", ID: networkID}))
+				valid = false
+			}
+		}
+		for _, area := range []struct {
+			column string
+			id     string
+			dst    **Area
+		}{
+			{"from_area_id", fromAreaIDColumn.Read(), &fareLegRule.FromArea},
+			{"to_area_id", toAreaIDColumn.Read(), &fareLegRule.ToArea},
+		} {
+			if area.id == "" {
+				continue
+			}
+			if *area.dst = areaIDToArea[area.id]; *area.dst == nil {
+				w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: area.column, ID: area.id}))
+				valid = false
+			}
+		}
+		if !fareProductIDs[fareLegRule.FareProductID] {
+			w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "fare_product_id", ID: fareLegRule.FareProductID}))
+			valid = false
+		}
+		if valid {
+			fareLegRules = append(fareLegRules, fareLegRule)
+		}
+	}
+	return fareLegRules
+}
+
+// parseFareTransferRules parses the fare transfer rules. Rules that reference a leg group or fare
+// product that does not exist are skipped with a warning.
+func parseFareTransferRules(csv *csv.File, w *warningCollector, fareLegRules []FareLegRule, fareProducts []FareProduct, recordSourceRows bool) []FareTransferRule {
+	fareTransferTypeColumn := csv.RequiredColumn("fare_transfer_type")
+	fromLegGroupIDColumn := csv.OptionalColumn("from_leg_group_id")
+	toLegGroupIDColumn := csv.OptionalColumn("to_leg_group_id")
+	transferCountColumn := csv.OptionalColumn("transfer_count")
+	durationLimitColumn := csv.OptionalColumn("duration_limit")
+	durationLimitTypeColumn := csv.OptionalColumn("duration_limit_type")
+	fareProductIDColumn := csv.OptionalColumn("fare_product_id")
+
+	if checkForMissingColumns(csv, w) {
+		return nil
+	}
+
+	legGroupIDs := map[string]bool{}
+	for i := range fareLegRules {
+		legGroupIDs[fareLegRules[i].LegGroupID] = true
+	}
+	fareProductIDs := map[string]bool{}
+	for i := range fareProducts {
+		fareProductIDs[fareProducts[i].ID] = true
+	}
+	var fareTransferRules []FareTransferRule
+	for csv.NextRow() {
+		fareTransferRule := FareTransferRule{
+			FromLegGroupID:    fromLegGroupIDColumn.Read(),
+			ToLegGroupID:      toLegGroupIDColumn.Read(),
+			TransferCount:     parseInt32(transferCountColumn.Read()),
+			DurationLimitType: parseDurationLimitType(durationLimitTypeColumn.Read()),
+			FareTransferType:  parseFareTransferType(fareTransferTypeColumn.Read()),
+			FareProductID:     fareProductIDColumn.Read(),
+			SourceRow:         sourceRow(csv, recordSourceRows),
+		}
+		if durationLimit := parseInt32(durationLimitColumn.Read()); durationLimit != nil {
+			d := time.Duration(*durationLimit) * time.Second
+			fareTransferRule.DurationLimit = &d
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			log.Printf("Skipping fare transfer rule because of missing keys %s", missingKeys)
+			continue
+		}
+		valid := true
+		for _, legGroup := range []struct {
+			column string
+			id     string
+		}{
+			{"from_leg_group_id", fareTransferRule.FromLegGroupID},
+			{"to_leg_group_id", fareTransferRule.ToLegGroupID},
+		} {
+			if legGroup.id != "" && !legGroupIDs[legGroup.id] {
+				w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: legGroup.column, ID: legGroup.id}))
+				valid = false
+			}
+		}
+		if fareTransferRule.FareProductID != "" && !fareProductIDs[fareTransferRule.FareProductID] {
+			w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "fare_product_id", ID: fareTransferRule.FareProductID}))
+			valid = false
+		}
+		if valid {
+			fareTransferRules = append(fareTransferRules, fareTransferRule)
+		}
+	}
+	return fareTransferRules
+}
@@ -0,0 +1,236 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jamespfennell/gtfs/constants"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+func TestParse_FaresV2(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id\nstop_1\nstop_2",
+	).add(
+		// The default stop time references stops and trips that are not in this feed.
+		"stop_times.txt",
+		"stop_id,trip_id,arrival_time,departure_time,stop_sequence",
+	).add(
+		"areas.txt",
+		"area_id,area_name\narea_1,Downtown\narea_2,",
+	).add(
+		"stop_areas.txt",
+		"area_id,stop_id\narea_1,stop_1\narea_2,stop_2\narea_3,stop_1",
+	).add(
+		"networks.txt",
+		"network_id,network_name\nsubway,Subway",
+	).add(
+		"route_networks.txt",
+		"network_id,route_id\nsubway,route_id",
+	).add(
+		"fare_media.txt",
+		"fare_media_id,fare_media_name,fare_media_type\ncard,Transit card,2",
+	).add(
+		"fare_products.txt",
+		"fare_product_id,fare_product_name,fare_media_id,amount,currency\n"+
+			"single,Single ride,card,2.90,USD\n"+
+			"single,Single ride,,3.25,USD\n"+
+			"transfer,Transfer,,0.50,USD\n"+
+			"day_pass,Day pass,app,15,USD",
+	).add(
+		"fare_leg_rules.txt",
+		"leg_group_id,network_id,from_area_id,to_area_id,fare_product_id,rule_priority\n"+
+			"subway_leg,subway,area_1,area_2,single,1\n"+
+			"bus_leg,,,,single,\n"+
+			"ferry_leg,ferry,,,single,\n"+
+			"pass_leg,,,,day_pass,",
+	).add(
+		"fare_transfer_rules.txt",
+		"from_leg_group_id,to_leg_group_id,transfer_count,duration_limit,duration_limit_type,fare_transfer_type,fare_product_id\n"+
+			"subway_leg,bus_leg,1,7200,1,0,transfer\n"+
+			"bus_leg,bus_leg,-1,,,1,\n"+
+			"ferry_leg,bus_leg,,,,2,",
+	).build()
+
+	static, err := ParseStatic(content, ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+
+	stop1, stop2 := &Stop{Id: "stop_1"}, &Stop{Id: "stop_2"}
+	area1 := &Area{ID: "area_1", Name: "Downtown", Stops: []*Stop{stop1}}
+	area2 := &Area{ID: "area_2", Stops: []*Stop{stop2}}
+	subway := &Network{ID: "subway", Name: "Subway", Routes: []*Route{&static.Routes[0]}}
+	card := &FareMedium{ID: "card", Name: "Transit card", Type: FareMediaType_PhysicalTransitCard}
+	type fares struct {
+		Areas             []Area
+		Networks          []Network
+		FareMedia         []FareMedium
+		FareProducts      []FareProduct
+		FareLegRules      []FareLegRule
+		FareTransferRules []FareTransferRule
+	}
+	want := fares{
+		Areas:     []Area{*area1, *area2},
+		Networks:  []Network{*subway},
+		FareMedia: []FareMedium{*card},
+		FareProducts: []FareProduct{
+			{ID: "single", Name: "Single ride", FareMedium: card, Amount: 2.90, Currency: "USD"},
+			{ID: "single", Name: "Single ride", Amount: 3.25, Currency: "USD"},
+			{ID: "transfer", Name: "Transfer", Amount: 0.50, Currency: "USD"},
+		},
+		FareLegRules: []FareLegRule{
+			{LegGroupID: "subway_leg", Network: subway, FromArea: area1, ToArea: area2, FareProductID: "single", RulePriority: ptr(int32(1))},
+			{LegGroupID: "bus_leg", FareProductID: "single"},
+		},
+		FareTransferRules: []FareTransferRule{
+			{
+				FromLegGroupID:    "subway_leg",
+				ToLegGroupID:      "bus_leg",
+				TransferCount:     ptr(int32(1)),
+				DurationLimit:     ptr(2 * time.Hour),
+				DurationLimitType: DurationLimitType_DepartureToDeparture,
+				FareTransferType:  FareTransferType_FromLegPlusTransfer,
+				FareProductID:     "transfer",
+			},
+			{
+				FromLegGroupID:   "bus_leg",
+				ToLegGroupID:     "bus_leg",
+				TransferCount:    ptr(int32(-1)),
+				FareTransferType: FareTransferType_FromLegPlusTransferPlusToLeg,
+			},
+		},
+	}
+	got := fares{
+		Areas:             static.Areas,
+		Networks:          static.Networks,
+		FareMedia:         static.FareMedia,
+		FareProducts:      static.FareProducts,
+		FareLegRules:      static.FareLegRules,
+		FareTransferRules: static.FareTransferRules,
+	}
+	// Route.Network points back to the network, which cmp cannot compare.
+	if diff := cmp.Diff(got, want, cmpopts.IgnoreFields(Route{}, "Network")); diff != "" {
+		t.Errorf("fares diff: %s", diff)
+	}
+	if static.Routes[0].Network != &static.Networks[0] {
+		t.Errorf("network of route not linked")
+	}
+
+	type warning struct {
+		File   constants.StaticFile
+		Row    int
+		Column string
+		ID     string
+	}
+	var gotWarnings []warning
+	for _, w := range static.Warnings {
+		invalidForeignID, ok := w.Kind.(warnings.InvalidForeignID)
+		if !ok {
+			t.Errorf("unexpected warning %v", w)
+			continue
+		}
+		gotWarnings = append(gotWarnings, warning{w.File, w.RowNumber, invalidForeignID.Column, invalidForeignID.ID})
+	}
+	wantWarnings := []warning{
+		{constants.StopAreasFile, 3, "area_id", "area_3"},
+		{constants.FareProductsFile, 4, "fare_media_id", "app"},
+		{constants.FareLegRulesFile, 3, "network_id", "ferry"},
+		{constants.FareLegRulesFile, 4, "fare_product_id", "day_pass"},
+		{constants.FareTransferRulesFile, 3, "from_leg_group_id", "ferry_leg"},
+	}
+	if diff := cmp.Diff(gotWarnings, wantWarnings); diff != "" {
+		t.Errorf("warnings diff: %s", diff)
+	}
+
+	clone := static.DeepClone()
+	if clone.FareLegRules[0].FromArea != &clone.Areas[0] {
+		t.Errorf("clone: FromArea of fare leg rule not re-linked")
+	}
+	if clone.Areas[0].Stops[0] != &clone.Stops[0] {
+		t.Errorf("clone: stop of area not re-linked")
+	}
+	if clone.FareProducts[0].FareMedium != &clone.FareMedia[0] {
+		t.Errorf("clone: fare medium of fare product not re-linked")
+	}
+}
+
+func TestParse_RouteNetworks(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"routes.txt",
+		"route_id,route_type,network_id",
+		"route_1,3,",
+		"route_2,3,bus",
+		"route_3,3,bus",
+		"route_4,3,express",
+		"route_5,3,",
+	).add(
+		"networks.txt",
+		"network_id,network_name",
+		"subway,Subway",
+		"express,Express",
+	).add(
+		"route_networks.txt",
+		"network_id,route_id",
+		"subway,route_1",
+		"subway,route_3",
+	).add(
+		"fare_products.txt",
+		"fare_product_id,amount,currency",
+		"single,2.90,USD",
+	).add(
+		"fare_leg_rules.txt",
+		"leg_group_id,network_id,fare_product_id",
+		"bus_leg,bus,single",
+	).build()
+
+	static, err := ParseStatic(content, ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+
+	route := func(id string) *Route {
+		for i := range static.Routes {
+			if static.Routes[i].Id == id {
+				return &static.Routes[i]
+			}
+		}
+		t.Fatalf("no route %s", id)
+		return nil
+	}
+	subway, express, bus := &static.Networks[0], &static.Networks[1], &static.Networks[2]
+	want := []Network{
+		{ID: "subway", Name: "Subway", Routes: []*Route{route("route_1"), route("route_3")}},
+		{ID: "express", Name: "Express", Routes: []*Route{route("route_4")}},
+		{ID: "bus", Routes: []*Route{route("route_2")}},
+	}
+	if diff := cmp.Diff(static.Networks, want, cmpopts.IgnoreFields(Route{}, "Network")); diff != "" {
+		t.Errorf("networks diff: %s", diff)
+	}
+	wantRouteNetworks := map[string]*Network{
+		"route_1": subway,
+		"route_2": bus,
+		"route_3": subway,
+		"route_4": express,
+		"route_5": nil,
+	}
+	for id, wantNetwork := range wantRouteNetworks {
+		if got := route(id).Network; got != wantNetwork {
+			t.Errorf("route %s has network %v, want %v", id, got, wantNetwork)
+		}
+	}
+	if len(static.FareLegRules) != 1 || static.FareLegRules[0].Network != bus {
+		t.Errorf("fare leg rule not linked to legacy network: %v", static.FareLegRules)
+	}
+
+	clone := static.DeepClone()
+	if clone.Routes[1].Network != &clone.Networks[2] {
+		t.Errorf("clone: network of route not re-linked")
+	}
+	if clone.Networks[2].Routes[0] != &clone.Routes[1] {
+		t.Errorf("clone: route of network not re-linked")
+	}
+}
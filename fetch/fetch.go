@@ -0,0 +1,147 @@
+// Package fetch contains a hardened HTTP downloader for GTFS feeds.
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Options configures how a feed is downloaded.
+type Options struct {
+	// The HTTP client used to make requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+
+	// Headers added to each request, for example API keys.
+	Header http.Header
+
+	// The maximum number of requests made before giving up. If zero, 3 attempts are made.
+	MaxAttempts int
+
+	// The delay before the first retry. The delay doubles after each failed attempt, up to
+	// MaxBackoff. If zero, 1 second is used.
+	InitialBackoff time.Duration
+
+	// The maximum delay between attempts. If zero, 30 seconds is used.
+	MaxBackoff time.Duration
+
+	// The timeout of each attempt. If zero, attempts are only limited by the deadline of the context.
+	AttemptTimeout time.Duration
+
+	// If non-empty, the hex-encoded SHA256 hash that the downloaded content must have. This can be
+	// used to verify the content against a hash published alongside the feed.
+	ExpectedSHA256 string
+}
+
+// StatusError is returned when the server responds with a non-2xx status code.
+type StatusError struct {
+	StatusCode int
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status %d %s", err.StatusCode, http.StatusText(err.StatusCode))
+}
+
+// ErrChecksumMismatch is returned when the downloaded content does not match [Options.ExpectedSHA256].
+var ErrChecksumMismatch = errors.New("downloaded content does not match the expected SHA256 hash")
+
+// Fetch downloads the content at the URL.
+//
+// Failed requests are retried with exponential backoff. Network errors, 5xx responses and
+// 429 (Too Many Requests) responses are retried; other responses with a non-2xx status code fail
+// immediately. Fetch stops retrying when the context is cancelled or its deadline is exceeded.
+// If the content does not match [Options.ExpectedSHA256], an error wrapping [ErrChecksumMismatch]
+// is returned without retrying.
+func Fetch(ctx context.Context, url string, opts Options) ([]byte, error) {
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	backoff := opts.InitialBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	maxBackoff := opts.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 30 * time.Second
+	}
+	var err error
+	for attempt := 1; ; attempt++ {
+		var b []byte
+		var retryable bool
+		b, retryable, err = fetchOnce(ctx, url, opts)
+		if err == nil {
+			if err := verifyChecksum(b, opts.ExpectedSHA256); err != nil {
+				return nil, err
+			}
+			return b, nil
+		}
+		if !retryable || attempt >= maxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to fetch %s: %w (last error: %s)", url, ctx.Err(), err)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+	return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+}
+
+// fetchOnce makes a single request and returns the content, or an error and whether the request can be retried.
+func fetchOnce(ctx context.Context, url string, opts Options) ([]byte, bool, error) {
+	if opts.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.AttemptTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	for key, values := range opts.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retryable, &StatusError{StatusCode: resp.StatusCode}
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, err
+	}
+	return b, false, nil
+}
+
+func verifyChecksum(b []byte, expectedSHA256 string) error {
+	if expectedSHA256 == "" {
+		return nil
+	}
+	sum := sha256.Sum256(b)
+	actual := hex.EncodeToString(sum[:])
+	if !strings.EqualFold(actual, strings.TrimSpace(expectedSHA256)) {
+		return fmt.Errorf("%w: got %s, want %s", ErrChecksumMismatch, actual, expectedSHA256)
+	}
+	return nil
+}
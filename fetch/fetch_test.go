@@ -0,0 +1,124 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const content = "feed content"
+
+func TestFetch(t *testing.T) {
+	sum := sha256.Sum256([]byte(content))
+	for _, tc := range []struct {
+		name         string
+		statusCodes  []int
+		opts         Options
+		wantErr      bool
+		wantAttempts int
+	}{
+		{
+			name:         "success",
+			statusCodes:  []int{http.StatusOK},
+			wantAttempts: 1,
+		},
+		{
+			name:         "retries server errors",
+			statusCodes:  []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			wantAttempts: 3,
+		},
+		{
+			name:         "gives up after max attempts",
+			statusCodes:  []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK},
+			opts:         Options{MaxAttempts: 2},
+			wantErr:      true,
+			wantAttempts: 2,
+		},
+		{
+			name:         "does not retry client errors",
+			statusCodes:  []int{http.StatusNotFound, http.StatusOK},
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "checksum matches",
+			statusCodes:  []int{http.StatusOK},
+			opts:         Options{ExpectedSHA256: hex.EncodeToString(sum[:])},
+			wantAttempts: 1,
+		},
+		{
+			name:         "checksum does not match",
+			statusCodes:  []int{http.StatusOK},
+			opts:         Options{ExpectedSHA256: "abc"},
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-Api-Key") != "key" {
+					t.Errorf("request missing API key header")
+				}
+				statusCode := tc.statusCodes[attempts]
+				attempts++
+				w.WriteHeader(statusCode)
+				w.Write([]byte(content))
+			}))
+			defer server.Close()
+			opts := tc.opts
+			opts.InitialBackoff = time.Millisecond
+			opts.Header = http.Header{"X-Api-Key": []string{"key"}}
+
+			b, err := Fetch(context.Background(), server.URL, opts)
+
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Fetch() err = nil, want error")
+				}
+			} else if err != nil || string(b) != content {
+				t.Errorf("Fetch() = %q, %v, want %q", b, err, content)
+			}
+			if attempts != tc.wantAttempts {
+				t.Errorf("Fetch() made %d attempts, want %d", attempts, tc.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestFetch_ChecksumMismatchError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	_, err := Fetch(context.Background(), server.URL, Options{ExpectedSHA256: "abc"})
+
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Fetch() err = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestFetch_ContextDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := Fetch(ctx, server.URL, Options{MaxAttempts: 10, InitialBackoff: time.Hour})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Fetch() err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Fetch() took %s, want it to stop at the context deadline", elapsed)
+	}
+}
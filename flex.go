@@ -0,0 +1,311 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/jamespfennell/gtfs/csv"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+// LocationGroup corresponds to a single row in the location_groups.txt file, along with the stops
+// assigned to the group in the location_group_stops.txt file.
+//
+// A location group is a set of stops at which a GTFS-Flex service may pick up or drop off riders.
+type LocationGroup struct {
+	ID   string
+	Name string
+	// Stops in the group, in the order they appear in location_group_stops.txt.
+	Stops []*Stop
+
+	// Row number of the location group in location_groups.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
+}
+
+// FlexLocation corresponds to a single feature in the locations.geojson file.
+//
+// A flex location is a zone in which a GTFS-Flex service picks up or drops off riders anywhere.
+type FlexLocation struct {
+	ID          string
+	Name        string
+	Description string
+	// Polygons making up the zone. A zone with a GeoJSON Polygon geometry has one polygon and a zone
+	// with a MultiPolygon geometry has one or more.
+	Polygons []Polygon
+}
+
+// Polygon is an area on the earth's surface bounded by linear rings. The first ring is the exterior
+// of the polygon and the remaining rings, if any, are holes in it. Each ring is closed, so its first
+// and last points are the same.
+type Polygon [][]Coordinate
+
+// Coordinate is a point on the earth's surface.
+type Coordinate struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// BookingRule corresponds to a single row in the booking_rules.txt file.
+//
+// A booking rule describes how riders book a pickup or drop off with a GTFS-Flex service.
+type BookingRule struct {
+	ID   string
+	Type BookingType
+
+	// Minimum and maximum time between booking and the pickup or drop off, for same day bookings.
+	PriorNoticeDurationMin *time.Duration
+	PriorNoticeDurationMax *time.Duration
+	// Latest day before travel the booking can be made, and the latest time on that day, for prior
+	// day bookings.
+	PriorNoticeLastDay  *int32
+	PriorNoticeLastTime *time.Duration
+	// Earliest day before travel the booking can be made, and the earliest time on that day.
+	PriorNoticeStartDay  *int32
+	PriorNoticeStartTime *time.Duration
+	// Service whose active days are used to count the prior notice days, or nil if calendar days
+	// are used.
+	PriorNoticeService *Service
+
+	Message        string
+	PickupMessage  string
+	DropOffMessage string
+	PhoneNumber    string
+	InfoURL        string
+	BookingURL     string
+
+	// Row number of the booking rule in booking_rules.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
+}
+
+func parseLocationGroups(csv *csv.File, w *warningCollector, recordSourceRows bool) []LocationGroup {
+	idColumn := csv.RequiredColumn("location_group_id")
+	nameColumn := csv.OptionalColumn("location_group_name")
+
+	if checkForMissingColumns(csv, w) {
+		return nil
+	}
+
+	var locationGroups []LocationGroup
+	for csv.NextRow() {
+		locationGroup := LocationGroup{
+			ID:        idColumn.Read(),
+			Name:      nameColumn.Read(),
+			SourceRow: sourceRow(csv, recordSourceRows),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			log.Printf("Skipping location group because of missing keys %s", missingKeys)
+			continue
+		}
+		locationGroups = append(locationGroups, locationGroup)
+	}
+	return locationGroups
+}
+
+// parseLocationGroupStops adds the stops to the location groups. Rows that reference a location
+// group or stop that does not exist are skipped with a warning.
+func parseLocationGroupStops(csv *csv.File, w *warningCollector, locationGroups []LocationGroup, stops []Stop) {
+	locationGroupIDColumn := csv.RequiredColumn("location_group_id")
+	stopIDColumn := csv.RequiredColumn("stop_id")
+
+	if checkForMissingColumns(csv, w) {
+		return
+	}
+
+	idToLocationGroup := map[string]*LocationGroup{}
+	for i := range locationGroups {
+		idToLocationGroup[locationGroups[i].ID] = &locationGroups[i]
+	}
+	idToStop := map[string]*Stop{}
+	for i := range stops {
+		idToStop[stops[i].Id] = &stops[i]
+	}
+	for csv.NextRow() {
+		locationGroupID := locationGroupIDColumn.Read()
+		stopID := stopIDColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			log.Printf("Skipping location group stop because of missing keys %s", missingKeys)
+			continue
+		}
+		locationGroup, locationGroupOk := idToLocationGroup[locationGroupID]
+		stop, stopOk := idToStop[stopID]
+		if !locationGroupOk {
+			w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "location_group_id", ID: locationGroupID}))
+		}
+		if !stopOk {
+			w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "stop_id", ID: stopID}))
+		}
+		if !locationGroupOk || !stopOk {
+			continue
+		}
+		locationGroup.Stops = append(locationGroup.Stops, stop)
+	}
+	return
+}
+
+// parseBookingRules parses the booking rules. A reference to a service that does not exist is
+// dropped with a warning, and the rule is otherwise kept.
+func parseBookingRules(csv *csv.File, w *warningCollector, services []Service, recordSourceRows bool) []BookingRule {
+	idColumn := csv.RequiredColumn("booking_rule_id")
+	typeColumn := csv.RequiredColumn("booking_type")
+	priorNoticeDurationMinColumn := csv.OptionalColumn("prior_notice_duration_min")
+	priorNoticeDurationMaxColumn := csv.OptionalColumn("prior_notice_duration_max")
+	priorNoticeLastDayColumn := csv.OptionalColumn("prior_notice_last_day")
+	priorNoticeLastTimeColumn := csv.OptionalColumn("prior_notice_last_time")
+	priorNoticeStartDayColumn := csv.OptionalColumn("prior_notice_start_day")
+	priorNoticeStartTimeColumn := csv.OptionalColumn("prior_notice_start_time")
+	priorNoticeServiceIDColumn := csv.OptionalColumn("prior_notice_service_id")
+	messageColumn := csv.OptionalColumn("message")
+	pickupMessageColumn := csv.OptionalColumn("pickup_message")
+	dropOffMessageColumn := csv.OptionalColumn("drop_off_message")
+	phoneNumberColumn := csv.OptionalColumn("phone_number")
+	infoURLColumn := csv.OptionalColumn("info_url")
+	bookingURLColumn := csv.OptionalColumn("booking_url")
+
+	if checkForMissingColumns(csv, w) {
+		return nil
+	}
+
+	idToService := map[string]*Service{}
+	for i := range services {
+		idToService[services[i].Id] = &services[i]
+	}
+	var bookingRules []BookingRule
+	for csv.NextRow() {
+		bookingRule := BookingRule{
+			ID:                     idColumn.Read(),
+			Type:                   parseBookingType(typeColumn.Read()),
+			PriorNoticeDurationMin: parseMinutes(priorNoticeDurationMinColumn.Read()),
+			PriorNoticeDurationMax: parseMinutes(priorNoticeDurationMaxColumn.Read()),
+			PriorNoticeLastDay:     parseInt32(priorNoticeLastDayColumn.Read()),
+			PriorNoticeLastTime:    parseOptionalGtfsTime(priorNoticeLastTimeColumn.Read()),
+			PriorNoticeStartDay:    parseInt32(priorNoticeStartDayColumn.Read()),
+			PriorNoticeStartTime:   parseOptionalGtfsTime(priorNoticeStartTimeColumn.Read()),
+			Message:                messageColumn.Read(),
+			PickupMessage:          pickupMessageColumn.Read(),
+			DropOffMessage:         dropOffMessageColumn.Read(),
+			PhoneNumber:            phoneNumberColumn.Read(),
+			InfoURL:                infoURLColumn.Read(),
+			BookingURL:             bookingURLColumn.Read(),
+			SourceRow:              sourceRow(csv, recordSourceRows),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			log.Printf("Skipping booking rule because of missing keys %s", missingKeys)
+			continue
+		}
+		if serviceID := priorNoticeServiceIDColumn.Read(); serviceID != "" {
+			if bookingRule.PriorNoticeService = idToService[serviceID]; bookingRule.PriorNoticeService == nil {
+				w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "prior_notice_service_id", ID: serviceID}))
+			}
+		}
+		bookingRules = append(bookingRules, bookingRule)
+	}
+	return bookingRules
+}
+
+// parseFlexLocations parses the locations.geojson file. Features without an ID or with a geometry
+// that is not a Polygon or MultiPolygon are skipped.
+func parseFlexLocations(zipFile *zip.File) ([]FlexLocation, error) {
+	content, err := zipFile.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	b, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+	var featureCollection struct {
+		Features []struct {
+			ID         any `json:"id"`
+			Properties struct {
+				StopName string `json:"stop_name"`
+				StopDesc string `json:"stop_desc"`
+			} `json:"properties"`
+			Geometry struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(b, &featureCollection); err != nil {
+		return nil, err
+	}
+	var flexLocations []FlexLocation
+	for i, feature := range featureCollection.Features {
+		var id string
+		switch rawID := feature.ID.(type) {
+		case string:
+			id = rawID
+		case float64:
+			id = strconv.FormatFloat(rawID, 'f', -1, 64)
+		}
+		if id == "" {
+			log.Printf("Skipping flex location %d because it has no ID", i)
+			continue
+		}
+		var rawPolygons [][][][]float64
+		var err error
+		switch feature.Geometry.Type {
+		case "Polygon":
+			var rawPolygon [][][]float64
+			err = json.Unmarshal(feature.Geometry.Coordinates, &rawPolygon)
+			rawPolygons = [][][][]float64{rawPolygon}
+		case "MultiPolygon":
+			err = json.Unmarshal(feature.Geometry.Coordinates, &rawPolygons)
+		default:
+			err = fmt.Errorf("unsupported geometry type %q", feature.Geometry.Type)
+		}
+		if err != nil {
+			log.Printf("Skipping flex location %s because of invalid geometry: %s", id, err)
+			continue
+		}
+		flexLocation := FlexLocation{
+			ID:          id,
+			Name:        feature.Properties.StopName,
+			Description: feature.Properties.StopDesc,
+		}
+		for _, rawPolygon := range rawPolygons {
+			polygon := make(Polygon, 0, len(rawPolygon))
+			for _, rawRing := range rawPolygon {
+				ring := make([]Coordinate, 0, len(rawRing))
+				for _, position := range rawRing {
+					// GeoJSON positions are longitude first.
+					if len(position) < 2 {
+						continue
+					}
+					ring = append(ring, Coordinate{Latitude: position[1], Longitude: position[0]})
+				}
+				polygon = append(polygon, ring)
+			}
+			flexLocation.Polygons = append(flexLocation.Polygons, polygon)
+		}
+		flexLocations = append(flexLocations, flexLocation)
+	}
+	return flexLocations, nil
+}
+
+func parseMinutes(s string) *time.Duration {
+	minutes := parseInt32(s)
+	if minutes == nil {
+		return nil
+	}
+	d := time.Duration(*minutes) * time.Minute
+	return &d
+}
+
+func parseOptionalGtfsTime(s string) *time.Duration {
+	d, ok := parseGtfsTimeToDuration(s)
+	if !ok {
+		return nil
+	}
+	return &d
+}
@@ -0,0 +1,263 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jamespfennell/gtfs/constants"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+func TestParse_Flex(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id\nstop_1\nstop_2",
+	).add(
+		"location_groups.txt",
+		"location_group_id,location_group_name\nzone_1,Downtown",
+	).add(
+		"location_group_stops.txt",
+		"location_group_id,stop_id\nzone_1,stop_1\nzone_1,stop_2\nzone_2,stop_1",
+	).add(
+		"booking_rules.txt",
+		"booking_rule_id,booking_type,prior_notice_duration_min,prior_notice_last_day,prior_notice_last_time,prior_notice_service_id,phone_number\n"+
+			"same_day,1,60,,,,555-0100\n"+
+			"prior_day,2,,1,17:00:00,service_2,",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,location_group_id,arrival_time,departure_time,start_pickup_drop_off_window,end_pickup_drop_off_window,stop_sequence,pickup_booking_rule_id,drop_off_booking_rule_id\n"+
+			"trip_id,stop_1,,08:00:00,08:00:00,,,1,,\n"+
+			"trip_id,,zone_1,,,08:00:00,12:00:00,2,same_day,prior_day\n"+
+			"trip_id,,zone_2,,,08:00:00,12:00:00,3,,\n"+
+			"trip_id,,,,,08:00:00,12:00:00,4,,\n"+
+			"trip_id,stop_2,,12:00:00,12:00:00,,,5,,unknown_rule",
+	).build()
+
+	stop1, stop2 := &Stop{Id: "stop_1"}, &Stop{Id: "stop_2"}
+	zone1 := &LocationGroup{ID: "zone_1", Name: "Downtown", Stops: []*Stop{stop1, stop2}}
+	sameDay := &BookingRule{
+		ID:                     "same_day",
+		Type:                   BookingType_SameDay,
+		PriorNoticeDurationMin: ptr(time.Hour),
+		PhoneNumber:            "555-0100",
+	}
+	priorDay := &BookingRule{
+		ID:                  "prior_day",
+		Type:                BookingType_PriorDays,
+		PriorNoticeLastDay:  ptr(int32(1)),
+		PriorNoticeLastTime: ptr(17 * time.Hour),
+	}
+	type stopTime struct {
+		Stop               *Stop
+		LocationGroup      *LocationGroup
+		StopSequence       int
+		PickupBookingRule  *BookingRule
+		DropOffBookingRule *BookingRule
+	}
+
+	for _, tc := range []struct {
+		name          string
+		flex          bool
+		wantStopTimes []stopTime
+		wantWarnings  []string
+	}{
+		{
+			name: "flex",
+			flex: true,
+			wantStopTimes: []stopTime{
+				{Stop: stop1, StopSequence: 1},
+				{LocationGroup: zone1, StopSequence: 2, PickupBookingRule: sameDay, DropOffBookingRule: priorDay},
+				{Stop: stop2, StopSequence: 5},
+			},
+			wantWarnings: []string{"zone_2", "service_2", "zone_2", "unknown_rule"},
+		},
+		{
+			name: "not flex",
+			flex: false,
+			wantStopTimes: []stopTime{
+				{Stop: stop1, StopSequence: 1},
+				{Stop: stop2, StopSequence: 5},
+			},
+			wantWarnings: []string{"zone_2", "service_2", "unknown_rule"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			static, err := ParseStatic(content, ParseStaticOptions{Flex: tc.flex})
+			if err != nil {
+				t.Fatalf("error when parsing: %s", err)
+			}
+			if diff := cmp.Diff(static.LocationGroups, []LocationGroup{*zone1}); diff != "" {
+				t.Errorf("location groups diff: %s", diff)
+			}
+			if diff := cmp.Diff(static.BookingRules, []BookingRule{*sameDay, *priorDay}); diff != "" {
+				t.Errorf("booking rules diff: %s", diff)
+			}
+			var gotStopTimes []stopTime
+			for _, st := range static.Trips[0].StopTimes {
+				gotStopTimes = append(gotStopTimes, stopTime{st.Stop, st.LocationGroup, st.StopSequence, st.PickupBookingRule, st.DropOffBookingRule})
+			}
+			if diff := cmp.Diff(gotStopTimes, tc.wantStopTimes); diff != "" {
+				t.Errorf("stop times diff: %s", diff)
+			}
+
+			var gotWarnings []string
+			for _, w := range static.Warnings {
+				invalidForeignID, ok := w.Kind.(warnings.InvalidForeignID)
+				if !ok {
+					t.Errorf("unexpected warning %v", w)
+					continue
+				}
+				gotWarnings = append(gotWarnings, invalidForeignID.ID)
+			}
+			if diff := cmp.Diff(gotWarnings, tc.wantWarnings); diff != "" {
+				t.Errorf("warnings diff: %s", diff)
+			}
+
+			clone := static.DeepClone()
+			if clone.LocationGroups[0].Stops[0] != &clone.Stops[0] {
+				t.Errorf("clone: stop of location group not re-linked")
+			}
+			if clone.Trips[0].StopTimes[0].Stop != &clone.Stops[0] {
+				t.Errorf("clone: stop of stop time not re-linked")
+			}
+			if tc.flex && clone.Trips[0].StopTimes[1].LocationGroup != &clone.LocationGroups[0] {
+				t.Errorf("clone: location group of stop time not re-linked")
+			}
+		})
+	}
+}
+
+func TestParse_FlexStreamStopTimes(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"location_groups.txt",
+		"location_group_id\nzone_1",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,location_group_id,start_pickup_drop_off_window,end_pickup_drop_off_window,stop_sequence\n"+
+			"trip_id,,zone_1,08:00:00,12:00:00,1",
+	).build()
+
+	static, err := ParseStatic(content, ParseStaticOptions{Flex: true, StreamStopTimes: true})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	var got []*LocationGroup
+	if err := static.ForEachStopTime(func(_ ScheduledTrip, stopTime ScheduledStopTime) bool {
+		got = append(got, stopTime.LocationGroup)
+		return true
+	}); err != nil {
+		t.Fatalf("ForEachStopTime() err = %s", err)
+	}
+	if len(got) != 1 || got[0] != &static.LocationGroups[0] {
+		t.Errorf("ForEachStopTime() location groups = %v, want [%v]", got, &static.LocationGroups[0])
+	}
+}
+
+func TestParse_FlexLocations(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"locations.geojson",
+		`{
+			"type": "FeatureCollection",
+			"features": [
+				{
+					"id": "zone_1",
+					"type": "Feature",
+					"properties": {"stop_name": "Downtown", "stop_desc": "Inside the ring road"},
+					"geometry": {"type": "Polygon", "coordinates": [[[-74.0, 40.0], [-73.9, 40.0], [-73.9, 40.1], [-74.0, 40.0]]]}
+				},
+				{
+					"id": "zone_2",
+					"type": "Feature",
+					"properties": {},
+					"geometry": {"type": "MultiPolygon", "coordinates": [
+						[[[-74.0, 40.0], [-73.9, 40.0], [-73.9, 40.1], [-74.0, 40.0]]],
+						[[[-75.0, 41.0], [-74.9, 41.0], [-74.9, 41.1], [-75.0, 41.0]]]
+					]}
+				},
+				{
+					"id": "point",
+					"type": "Feature",
+					"properties": {},
+					"geometry": {"type": "Point", "coordinates": [-74.0, 40.0]}
+				}
+			]
+		}`,
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,location_id,start_pickup_drop_off_window,end_pickup_drop_off_window,stop_sequence\n"+
+			"trip_id,,zone_1,08:00:00,12:00:00,1\n"+
+			"trip_id,,point,08:00:00,12:00:00,2",
+	).build()
+
+	static, err := ParseStatic(content, ParseStaticOptions{Flex: true})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	triangle := func(lat, lon float64) Polygon {
+		return Polygon{{
+			{Latitude: lat, Longitude: lon},
+			{Latitude: lat, Longitude: lon + 0.1},
+			{Latitude: lat + 0.1, Longitude: lon + 0.1},
+			{Latitude: lat, Longitude: lon},
+		}}
+	}
+	want := []FlexLocation{
+		{
+			ID:          "zone_1",
+			Name:        "Downtown",
+			Description: "Inside the ring road",
+			Polygons:    []Polygon{triangle(40.0, -74.0)},
+		},
+		{
+			ID:       "zone_2",
+			Polygons: []Polygon{triangle(40.0, -74.0), triangle(41.0, -75.0)},
+		},
+	}
+	if diff := cmp.Diff(static.FlexLocations, want, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("flex locations diff: %s", diff)
+	}
+	stopTimes := static.Trips[0].StopTimes
+	if len(stopTimes) != 1 || stopTimes[0].FlexLocation != &static.FlexLocations[0] {
+		t.Errorf("stop times = %+v, want one stop time at zone_1", stopTimes)
+	}
+	if len(static.Warnings) != 1 || static.Warnings[0].Kind != (warnings.InvalidForeignID{Column: "location_id", ID: "point"}) {
+		t.Errorf("warnings = %v, want invalid location_id point", static.Warnings)
+	}
+	clone := static.DeepClone()
+	if clone.Trips[0].StopTimes[0].FlexLocation != &clone.FlexLocations[0] {
+		t.Errorf("clone: flex location of stop time not re-linked")
+	}
+}
+
+func TestParse_InvalidFlexLocations(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"locations.geojson",
+		`{"type": "FeatureCollection", "features": [`,
+	).build()
+
+	for _, flex := range []bool{false, true} {
+		static, err := ParseStatic(content, ParseStaticOptions{Flex: flex})
+		if err != nil {
+			t.Fatalf("Flex=%t: error when parsing: %s", flex, err)
+		}
+		var gotCodes []string
+		for _, w := range static.Warnings {
+			if w.File == constants.LocationsFile {
+				gotCodes = append(gotCodes, w.Kind.Code())
+			}
+		}
+		// Without flex parsing the file is not read at all.
+		var wantCodes []string
+		if flex {
+			wantCodes = []string{"invalid_geojson"}
+		}
+		if diff := cmp.Diff(gotCodes, wantCodes); diff != "" {
+			t.Errorf("Flex=%t: warnings diff: %s", flex, diff)
+		}
+		if len(static.FlexLocations) != 0 {
+			t.Errorf("Flex=%t: FlexLocations = %v, want none", flex, static.FlexLocations)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package gtfs
+
+import (
+	"fmt"
+	"time"
+)
+
+// TripInstance is a single run of a trip that is defined using frequencies.txt.
+//
+// Frequency-based trips describe many runs of the same trip, which share a trip ID. An instance
+// identifies one of these runs using its start time.
+type TripInstance struct {
+	Trip *ScheduledTrip
+	// Start time of the run, measured from "noon minus 12h" of the service day.
+	StartTime time.Duration
+}
+
+// ID returns a synthetic identifier for the instance that is stable across parses of the same feed.
+//
+// The identifier is the trip ID followed by the start time in HH:MM:SS format, for example
+// "trip_1@08:30:00".
+func (instance TripInstance) ID() string {
+	return fmt.Sprintf("%s@%s", instance.Trip.ID, FormatGTFSTime(instance.StartTime))
+}
+
+// Instances returns the instances of a frequency-based trip, in order of start time.
+//
+// For frequencies with exact_times=1 these are the exact scheduled runs. For frequencies with
+// exact_times=0 the actual start times may differ, and the returned instances are the nominal runs at
+// the start of each headway. If the trip is not frequency-based, nil is returned.
+func (trip *ScheduledTrip) Instances() []TripInstance {
+	var instances []TripInstance
+	for _, frequency := range trip.Frequencies {
+		if frequency.Headway <= 0 {
+			continue
+		}
+		for start := frequency.StartTime; start < frequency.EndTime; start += frequency.Headway {
+			instances = append(instances, TripInstance{Trip: trip, StartTime: start})
+		}
+	}
+	return instances
+}
+
+// MatchTripInstance returns the instance of a frequency-based trip that the realtime trip ID refers to.
+//
+// Frequency-based trips cannot be matched using the trip ID alone, so the realtime trip ID must have a
+// start time. If the start time is within a frequency with exact_times=0, the instance with that start
+// time is returned. If the frequency has exact_times=1, the start time must be one of the scheduled
+// start times. If the realtime trip ID has a start date, the trip's service must run on that date.
+// The second return value is false if there is no matching instance.
+func (s *Static) MatchTripInstance(tripID TripID) (TripInstance, bool) {
+	trip := s.TripByID(tripID.ID)
+	if trip == nil || !tripID.HasStartTime {
+		return TripInstance{}, false
+	}
+	if tripID.HasStartDate && (trip.Service == nil || !trip.Service.RunsOn(tripID.StartDate)) {
+		return TripInstance{}, false
+	}
+	for _, frequency := range trip.Frequencies {
+		if tripID.StartTime < frequency.StartTime || frequency.EndTime <= tripID.StartTime {
+			continue
+		}
+		if frequency.ExactTimes == ScheduleBased {
+			if frequency.Headway <= 0 || (tripID.StartTime-frequency.StartTime)%frequency.Headway != 0 {
+				continue
+			}
+		}
+		return TripInstance{Trip: trip, StartTime: tripID.StartTime}, true
+	}
+	return TripInstance{}, false
+}
@@ -0,0 +1,106 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTripInstances(t *testing.T) {
+	static := &Static{
+		Services: []Service{{
+			Id:        "weekday",
+			Monday:    true,
+			StartDate: time.Date(2022, 5, 2, 0, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2022, 5, 2, 0, 0, 0, 0, time.UTC),
+		}},
+		Trips: []ScheduledTrip{
+			{
+				ID: "exact",
+				Frequencies: []Frequency{
+					{StartTime: 8 * time.Hour, EndTime: 9 * time.Hour, Headway: 20 * time.Minute, ExactTimes: ScheduleBased},
+					{StartTime: 25 * time.Hour, EndTime: 25*time.Hour + 10*time.Minute, Headway: 10 * time.Minute, ExactTimes: ScheduleBased},
+				},
+			},
+			{
+				ID: "headway",
+				Frequencies: []Frequency{
+					{StartTime: 8 * time.Hour, EndTime: 9 * time.Hour, Headway: 30 * time.Minute, ExactTimes: FrequencyBased},
+				},
+			},
+			{ID: "scheduled"},
+		},
+	}
+	for i := range static.Trips {
+		static.Trips[i].Service = &static.Services[0]
+	}
+
+	var gotIDs []string
+	for _, instance := range static.Trips[0].Instances() {
+		gotIDs = append(gotIDs, instance.ID())
+	}
+	wantIDs := []string{"exact@08:00:00", "exact@08:20:00", "exact@08:40:00", "exact@25:00:00"}
+	if diff := cmp.Diff(gotIDs, wantIDs); diff != "" {
+		t.Errorf("Instances() diff: %s", diff)
+	}
+	if got := static.Trips[2].Instances(); got != nil {
+		t.Errorf("Instances() for scheduled trip got = %v, want nil", got)
+	}
+
+	monday := time.Date(2022, 5, 2, 0, 0, 0, 0, time.UTC)
+	tuesday := time.Date(2022, 5, 3, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name   string
+		tripID TripID
+		wantOk bool
+		wantID string
+	}{
+		{
+			name:   "exact times match",
+			tripID: TripID{ID: "exact", HasStartTime: true, StartTime: 8*time.Hour + 20*time.Minute},
+			wantOk: true,
+			wantID: "exact@08:20:00",
+		},
+		{
+			name:   "exact times between runs",
+			tripID: TripID{ID: "exact", HasStartTime: true, StartTime: 8*time.Hour + 5*time.Minute},
+		},
+		{
+			name:   "headway based",
+			tripID: TripID{ID: "headway", HasStartTime: true, StartTime: 8*time.Hour + 7*time.Minute, HasStartDate: true, StartDate: monday},
+			wantOk: true,
+			wantID: "headway@08:07:00",
+		},
+		{
+			name:   "headway based outside window",
+			tripID: TripID{ID: "headway", HasStartTime: true, StartTime: 9 * time.Hour},
+		},
+		{
+			name:   "service not running",
+			tripID: TripID{ID: "headway", HasStartTime: true, StartTime: 8 * time.Hour, HasStartDate: true, StartDate: tuesday},
+		},
+		{
+			name:   "no start time",
+			tripID: TripID{ID: "headway"},
+		},
+		{
+			name:   "not frequency based",
+			tripID: TripID{ID: "scheduled", HasStartTime: true, StartTime: 8 * time.Hour},
+		},
+		{
+			name:   "unknown trip",
+			tripID: TripID{ID: "unknown", HasStartTime: true, StartTime: 8 * time.Hour},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			instance, ok := static.MatchTripInstance(tc.tripID)
+			if ok != tc.wantOk {
+				t.Fatalf("MatchTripInstance() ok = %t, want %t", ok, tc.wantOk)
+			}
+			if ok && instance.ID() != tc.wantID {
+				t.Errorf("MatchTripInstance() ID = %s, want %s", instance.ID(), tc.wantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package gtfs
+
+import (
+	"io"
+	"log"
+	"os"
+	"testing"
+
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// FuzzParseStatic fuzzes the CSV content of the static files. Fuzzing the raw zip archive is
+// ineffective because almost all mutations are rejected by the zip checksum.
+func FuzzParseStatic(f *testing.F) {
+	// The parser logs every skipped row, which slows fuzzing down considerably.
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	f.Add(
+		"agency_id,agency_name,agency_url,agency_timezone\na,b,c,America/New_York",
+		"route_id,route_type,agency_id,route_sort_order\nroute_id,3,a,5",
+		"stop_id,parent_station,location_type,stop_lat,stop_lon,wheelchair_boarding\na,b,0,1.5,2.5,1\nb,,1,,,",
+		"from_stop_id,to_stop_id,transfer_type,min_transfer_time\na,b,2,300",
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n"+
+			"service_id,1,0,0,0,0,0,1,20220504,20220507",
+		"service_id,date,exception_type\nservice_id,20220505,1\nother,20220505,2",
+		"route_id,service_id,trip_id,shape_id,direction_id\nroute_id,service_id,trip_id,s,1",
+		"stop_id,trip_id,arrival_time,departure_time,stop_sequence\na,trip_id,04:05:06,25:14:15,50",
+		"trip_id,start_time,end_time,headway_secs,exact_times\ntrip_id,05:00:00,25:00:00,600,1",
+		"shape_id,shape_pt_lat,shape_pt_lon,shape_pt_sequence\ns,1.5,2.5,1",
+	)
+	f.Fuzz(func(t *testing.T, agency, routes, stops, transfers, calendar, calendarDates, trips, stopTimes, frequencies, shapes string) {
+		b := newZipBuilder().
+			add("agency.txt", agency).
+			add("routes.txt", routes).
+			add("stops.txt", stops).
+			add("transfers.txt", transfers).
+			add("calendar.txt", calendar).
+			add("calendar_dates.txt", calendarDates).
+			add("trips.txt", trips).
+			add("stop_times.txt", stopTimes).
+			add("frequencies.txt", frequencies).
+			add("shapes.txt", shapes).
+			build()
+		for _, opts := range []ParseStaticOptions{
+			{},
+			{InheritWheelchairBoarding: true, RecordSourceRows: true},
+			{StreamStopTimes: true},
+		} {
+			static, err := ParseStatic(b, opts)
+			if err != nil {
+				continue
+			}
+			_ = static.ForEachStopTime(func(ScheduledTrip, ScheduledStopTime) bool { return true })
+			if _, err := static.ExportToCsv(); err != nil {
+				t.Errorf("failed to export parsed feed: %s", err)
+			}
+		}
+	})
+}
+
+// FuzzParseStaticZip fuzzes the raw zip archive to check that malformed archives are rejected cleanly.
+func FuzzParseStaticZip(f *testing.F) {
+	f.Add(newZipBuilderWithDefaults().build())
+	f.Add([]byte{})
+	f.Add([]byte("PK\x03\x04"))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		_, _ = ParseStatic(b, ParseStaticOptions{})
+	})
+}
+
+func FuzzParseRealtime(f *testing.F) {
+	version := "2.0"
+	tripID := "trip"
+	stopID := "stop"
+	startDate := "20240101"
+	startTime := "25:00:00"
+	for _, message := range []*gtfsrt.FeedMessage{
+		{
+			Header: &gtfsrt.FeedHeader{GtfsRealtimeVersion: &version},
+		},
+		{
+			Header: &gtfsrt.FeedHeader{GtfsRealtimeVersion: &version},
+			Entity: []*gtfsrt.FeedEntity{
+				{
+					Id: &tripID,
+					TripUpdate: &gtfsrt.TripUpdate{
+						Trip: &gtfsrt.TripDescriptor{
+							TripId:    &tripID,
+							StartDate: &startDate,
+							StartTime: &startTime,
+						},
+						StopTimeUpdate: []*gtfsrt.TripUpdate_StopTimeUpdate{
+							{StopId: &stopID},
+						},
+						Vehicle: &gtfsrt.VehicleDescriptor{Id: &tripID},
+					},
+				},
+				{
+					Id: &stopID,
+					Vehicle: &gtfsrt.VehiclePosition{
+						Trip:   &gtfsrt.TripDescriptor{TripId: &tripID},
+						StopId: &stopID,
+					},
+				},
+				{
+					Id: &startDate,
+					Alert: &gtfsrt.Alert{
+						InformedEntity: []*gtfsrt.EntitySelector{
+							{StopId: &stopID, Trip: &gtfsrt.TripDescriptor{TripId: &tripID}},
+						},
+					},
+				},
+			},
+		},
+	} {
+		b, err := proto.Marshal(message)
+		if err != nil {
+			f.Fatalf("failed to marshal seed message: %s", err)
+		}
+		f.Add(b)
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		for _, opts := range []*ParseRealtimeOptions{
+			{},
+			{InferVehicleTrips: true, DeduplicateAlerts: true, VehicleKey: VehicleKeyByID},
+		} {
+			realtime, err := ParseRealtime(b, opts)
+			if err != nil {
+				continue
+			}
+			if _, err := realtime.ExportToCsv(); err != nil {
+				t.Errorf("failed to export parsed message: %s", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package gtfs
+
+import (
+	"fmt"
+	"time"
+)
+
+// ServiceDayStart returns "noon minus 12h" on the date in its location, from which the GTFS times of
+// the service day are measured.
+//
+// This is midnight except on days with a daylight saving time change. For example, when clocks go
+// forward at 2am, it is 11pm on the previous day.
+func ServiceDayStart(date time.Time) time.Time {
+	y, m, d := date.Date()
+	return time.Date(y, m, d, 12, 0, 0, 0, date.Location()).Add(-12 * time.Hour)
+}
+
+// FormatGTFSTime formats a duration since "noon minus 12h" of the service day in the HH:MM:SS format
+// used by GTFS static feeds, for example "08:30:00" or "25:30:00".
+//
+// Hours are not wrapped at 24, as trips that run past midnight are represented using times later
+// than 24:00:00. Fractional seconds are truncated and negative durations are formatted with a
+// leading minus sign.
+func FormatGTFSTime(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	seconds := int64(d / time.Second)
+	return fmt.Sprintf("%s%02d:%02d:%02d", sign, seconds/3600, (seconds/60)%60, seconds%60)
+}
+
+// ParseGTFSTime parses a time in the HH:MM:SS format used by GTFS static feeds, returning the duration
+// since "noon minus 12h" of the service day.
+//
+// This is the parser used for times in stop_times.txt and frequencies.txt. Hours may exceed 24 and
+// single-digit hours like "8:30:00" are accepted.
+func ParseGTFSTime(s string) (time.Duration, error) {
+	d, ok := parseGtfsTimeToDuration(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid GTFS time %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,58 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGTFSTime(t *testing.T) {
+	for _, tc := range []struct {
+		s string
+		d time.Duration
+	}{
+		{"00:00:00", 0},
+		{"08:05:09", 8*time.Hour + 5*time.Minute + 9*time.Second},
+		{"25:30:00", 25*time.Hour + 30*time.Minute},
+		{"100:00:00", 100 * time.Hour},
+	} {
+		if got := FormatGTFSTime(tc.d); got != tc.s {
+			t.Errorf("FormatGTFSTime(%s) = %q, want %q", tc.d, got, tc.s)
+		}
+		got, err := ParseGTFSTime(tc.s)
+		if err != nil || got != tc.d {
+			t.Errorf("ParseGTFSTime(%q) = %s, %v, want %s", tc.s, got, err, tc.d)
+		}
+	}
+	if got := FormatGTFSTime(-90 * time.Second); got != "-00:01:30" {
+		t.Errorf("FormatGTFSTime(-90s) = %q, want %q", got, "-00:01:30")
+	}
+	if got, err := ParseGTFSTime("8:30:00"); err != nil || got != 8*time.Hour+30*time.Minute {
+		t.Errorf("ParseGTFSTime(%q) = %s, %v, want 8h30m0s", "8:30:00", got, err)
+	}
+	for _, s := range []string{"", "08:30:00:00", "8h30"} {
+		if _, err := ParseGTFSTime(s); err == nil {
+			t.Errorf("ParseGTFSTime(%q) err = nil, want error", s)
+		}
+	}
+}
+
+func TestServiceDayStart(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("failed to load timezone: %s", err)
+	}
+	for _, tc := range []struct {
+		date time.Time
+		want time.Time
+	}{
+		{time.Date(2022, 3, 12, 15, 0, 0, 0, newYork), time.Date(2022, 3, 12, 0, 0, 0, 0, newYork)},
+		// Clocks go forward at 2am.
+		{time.Date(2022, 3, 13, 15, 0, 0, 0, newYork), time.Date(2022, 3, 12, 23, 0, 0, 0, newYork)},
+		// Clocks go back at 2am.
+		{time.Date(2022, 11, 6, 15, 0, 0, 0, newYork), time.Date(2022, 11, 6, 5, 0, 0, 0, time.UTC)},
+	} {
+		if got := ServiceDayStart(tc.date); !got.Equal(tc.want) {
+			t.Errorf("ServiceDayStart(%s) = %s, want %s", tc.date, got, tc.want)
+		}
+	}
+}
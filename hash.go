@@ -1,18 +1,19 @@
 package gtfs
 
 import (
-	"bytes"
-	"encoding/binary"
-	"fmt"
+	"crypto/sha256"
 	"hash"
+	"io"
+	"math"
+	"sort"
 	"time"
 )
 
 // Hash calculates a hash of a trip using the provided hash function.
 //
-// The Vehicle and IsEntityInFeed fields are ignored for the purposes of hashing.
+// The Vehicle, Timestamp and IsEntityInFeed fields are ignored for the purposes of hashing.
 func (t *Trip) Hash(h hash.Hash) {
-	s := hasher{h: h}
+	s := newHasher(h)
 	s.trip(t)
 	s.flush()
 }
@@ -21,39 +22,86 @@ func (t *Trip) Hash(h hash.Hash) {
 //
 // The Trip and IsEntityInFeed fields are ignored for the purposes of hashing.
 func (v *Vehicle) Hash(h hash.Hash) {
-	s := hasher{h: h}
+	s := newHasher(h)
 	s.vehicle(v)
 	s.flush()
 }
 
+// Hash calculates a hash of an alert using the provided hash function.
+//
+// Informed entities are hashed in order.
+func (a *Alert) Hash(h hash.Hash) {
+	s := newHasher(h)
+	s.alert(a)
+	s.flush()
+}
+
+// Hash calculates a hash of the parsed content of the static message using the provided hash function.
+//
+// The hash only depends on the content of the message, so it can be used to detect whether a feed
+// changed even if the bytes of the zip archive differ. Services are hashed in order of their IDs,
+// as the order they are parsed in is not deterministic; all other entities are hashed in order.
+// References between entities are hashed using the referenced entity's ID. The Warnings and SourceRow
+// fields are ignored. Applying [transform.Normalize] before hashing makes the hash insensitive to
+// cosmetic differences like trailing whitespace.
+//
+// If the message was parsed with [ParseStaticOptions.StreamStopTimes], stop times are read using
+// [Static.ForEachStopTime] and any error reading them is returned.
+//
+// [transform.Normalize]: https://pkg.go.dev/github.com/jamespfennell/gtfs/transform#Normalize
+func (s *Static) Hash(h hash.Hash) error {
+	sh := newHasher(h)
+	err := sh.static(s)
+	sh.flush()
+	return err
+}
+
 type hasher struct {
 	h hash.Hash
-	b bytes.Buffer
+	// Data that has not been written to the hash function yet. Values are encoded directly into this
+	// buffer, rather than using encoding/binary, to avoid allocating for every value.
+	b []byte
+}
+
+// hasherBufferSize is the size of the buffer at which it is flushed to the hash function.
+const hasherBufferSize = 512
+
+func newHasher(h hash.Hash) hasher {
+	return hasher{h: h, b: make([]byte, 0, hasherBufferSize)}
 }
 
 func (h *hasher) flush() {
-	h.h.Write(h.b.Bytes())
-	h.b.Reset()
+	h.h.Write(h.b)
+	h.b = h.b[:0]
+}
+
+// reserve flushes the buffer if it does not have space for n more bytes.
+func (h *hasher) reserve(n int) {
+	if len(h.b)+n > hasherBufferSize {
+		h.flush()
+	}
 }
 
 func (h *hasher) trip(t *Trip) {
 	h.string(t.ID.ID)
 	h.string(t.ID.RouteID)
-	h.number(t.ID.DirectionID)
-	h.number(t.ID.HasStartDate)
-	h.number(t.ID.StartDate.Unix())
-	h.number(t.ID.HasStartTime)
-	h.number(t.ID.StartTime)
-	h.number(int64(len(t.StopTimeUpdates)))
-	h.number(t.ID.ScheduleRelationship)
+	h.uint8(uint8(t.ID.DirectionID))
+	h.bool(t.ID.HasStartDate)
+	h.int64(t.ID.StartDate.Unix())
+	h.bool(t.ID.HasStartTime)
+	h.int64(int64(t.ID.StartTime))
+	h.int64(int64(len(t.StopTimeUpdates)))
+	h.int32(int32(t.ID.ScheduleRelationship))
 	for i := range t.StopTimeUpdates {
 		stu := &t.StopTimeUpdates[i]
-		hashNumberPtr(h, stu.StopSequence)
+		h.uint32Ptr(stu.StopSequence)
 		h.stringPtr(stu.StopID)
 		h.stringPtr(stu.NyctTrack)
-		h.number(stu.ScheduleRelationship)
+		h.int32(int32(stu.ScheduleRelationship))
+		h.stringPtr(stu.AssignedStopID)
+		h.stringPtr(stu.Track)
 		for _, event := range []*StopTimeEvent{stu.Arrival, stu.Departure} {
-			h.number(event == nil)
+			h.bool(event == nil)
 			if event == nil {
 				continue
 			}
@@ -63,72 +111,501 @@ func (h *hasher) trip(t *Trip) {
 				d := int64(*event.Delay)
 				dp = &d
 			}
-			hashNumberPtr(h, dp)
-			hashNumberPtr(h, event.Uncertainty)
+			h.int64Ptr(dp)
+			h.int32Ptr(event.Uncertainty)
 		}
 	}
 }
 
 func (h *hasher) vehicle(v *Vehicle) {
-	h.number(v.ID == nil)
+	h.bool(v.ID == nil)
 	if v.ID != nil {
 		h.string(v.ID.ID)
 		h.string(v.ID.Label)
 		h.string(v.ID.LicensePlate)
+		h.bool(v.ID.HasID)
+		h.bool(v.ID.HasLabel)
+		h.bool(v.ID.HasLicensePlate)
 	}
-	h.number(v.Trip == nil)
+	h.bool(v.Trip == nil)
 	if v.Trip != nil {
 		h.trip(v.Trip)
 	}
-	h.number(v.Position == nil)
+	h.bool(v.Position == nil)
 	if v.Position != nil {
-		hashNumberPtr(h, v.Position.Latitude)
-		hashNumberPtr(h, v.Position.Longitude)
-		hashNumberPtr(h, v.Position.Bearing)
-		hashNumberPtr(h, v.Position.Odometer)
-		hashNumberPtr(h, v.Position.Speed)
+		h.float32Ptr(v.Position.Latitude)
+		h.float32Ptr(v.Position.Longitude)
+		h.float32Ptr(v.Position.Bearing)
+		h.float64Ptr(v.Position.Odometer)
+		h.float32Ptr(v.Position.Speed)
 	}
-	hashNumberPtr(h, v.CurrentStopSequence)
+	h.uint32Ptr(v.CurrentStopSequence)
 	h.stringPtr(v.StopID)
-	hashNumberPtr(h, v.CurrentStatus)
+	h.bool(v.CurrentStatus == nil)
+	if v.CurrentStatus != nil {
+		h.int32(int32(*v.CurrentStatus))
+	}
 	h.timePtr(v.Timestamp)
-	h.number(v.CongestionLevel)
-	hashNumberPtr(h, v.OccupancyStatus)
-	hashNumberPtr(h, v.OccupancyPercentage)
+	h.int32(int32(v.CongestionLevel))
+	h.bool(v.OccupancyStatus == nil)
+	if v.OccupancyStatus != nil {
+		h.int32(int32(*v.OccupancyStatus))
+	}
+	h.uint32Ptr(v.OccupancyPercentage)
+	// The wheelchair accessibility is only hashed if present so that hashes of vehicles without it
+	// are unchanged.
+	if v.WheelchairAccessible != nil {
+		h.int32(int32(*v.WheelchairAccessible))
+	}
 }
 
-func (h *hasher) string(s string) {
-	h.number(uint64(len(s)))
-	h.flush()
-	h.h.Write([]byte(s))
+func (h *hasher) static(s *Static) error {
+	h.int64(int64(len(s.Agencies)))
+	for i := range s.Agencies {
+		agency := &s.Agencies[i]
+		for _, v := range []string{agency.Id, agency.Name, agency.Url, agency.Timezone, agency.Language, agency.Phone, agency.FareUrl, agency.Email} {
+			h.string(v)
+		}
+	}
+	h.int64(int64(len(s.Routes)))
+	for i := range s.Routes {
+		route := &s.Routes[i]
+		h.string(route.Id)
+		h.string(idOrEmpty(route.Agency, func(a *Agency) string { return a.Id }))
+		for _, v := range []string{route.Color, route.TextColor, route.ShortName, route.LongName, route.Description, route.Url} {
+			h.string(v)
+		}
+		h.int32(int32(route.Type))
+		h.int32Ptr(route.SortOrder)
+		h.int32(int32(route.ContinuousPickup))
+		h.int32(int32(route.ContinuousDropOff))
+	}
+	h.int64(int64(len(s.Stops)))
+	for i := range s.Stops {
+		stop := &s.Stops[i]
+		for _, v := range []string{stop.Id, stop.Code, stop.Name, stop.Description, stop.ZoneId, stop.Url, stop.Timezone, stop.PlatformCode} {
+			h.string(v)
+		}
+		h.float64Ptr(stop.Longitude)
+		h.float64Ptr(stop.Latitude)
+		h.int32(int32(stop.Type))
+		h.string(idOrEmpty(stop.Parent, func(s *Stop) string { return s.Id }))
+		h.int32(int32(stop.WheelchairBoarding))
+	}
+	h.int64(int64(len(s.Transfers)))
+	for i := range s.Transfers {
+		transfer := &s.Transfers[i]
+		h.string(idOrEmpty(transfer.From, func(s *Stop) string { return s.Id }))
+		h.string(idOrEmpty(transfer.To, func(s *Stop) string { return s.Id }))
+		h.int32(int32(transfer.Type))
+		h.int32Ptr(transfer.MinTransferTime)
+	}
+	services := make([]*Service, len(s.Services))
+	for i := range s.Services {
+		services[i] = &s.Services[i]
+	}
+	sort.SliceStable(services, func(i, j int) bool { return services[i].Id < services[j].Id })
+	h.int64(int64(len(services)))
+	for _, service := range services {
+		h.string(service.Id)
+		for _, v := range []bool{service.Monday, service.Tuesday, service.Wednesday, service.Thursday, service.Friday, service.Saturday, service.Sunday} {
+			h.bool(v)
+		}
+		h.int64(service.StartDate.Unix())
+		h.int64(service.EndDate.Unix())
+		for _, dates := range [][]time.Time{service.AddedDates, service.RemovedDates} {
+			h.int64(int64(len(dates)))
+			for _, date := range dates {
+				h.int64(date.Unix())
+			}
+		}
+	}
+	h.int64(int64(len(s.Shapes)))
+	for i := range s.Shapes {
+		shape := &s.Shapes[i]
+		h.string(shape.ID)
+		h.int64(int64(len(shape.Points)))
+		for _, point := range shape.Points {
+			h.float64(point.Latitude)
+			h.float64(point.Longitude)
+			h.float64Ptr(point.Distance)
+		}
+		// The flag is only hashed if set so that hashes of feeds without synthetic shapes are unchanged.
+		if shape.Synthetic {
+			h.bool(true)
+		}
+	}
+	h.int64(int64(len(s.Trips)))
+	for i := range s.Trips {
+		trip := &s.Trips[i]
+		h.string(idOrEmpty(trip.Route, func(r *Route) string { return r.Id }))
+		h.string(idOrEmpty(trip.Service, func(s *Service) string { return s.Id }))
+		for _, v := range []string{trip.ID, trip.Headsign, trip.ShortName, trip.BlockID} {
+			h.string(v)
+		}
+		h.uint8(uint8(trip.DirectionId))
+		h.int32(int32(trip.WheelchairAccessible))
+		h.int32(int32(trip.BikesAllowed))
+		h.string(idOrEmpty(trip.Shape, func(s *Shape) string { return s.ID }))
+		h.int64(int64(len(trip.Frequencies)))
+		for _, frequency := range trip.Frequencies {
+			h.int64(int64(frequency.StartTime))
+			h.int64(int64(frequency.EndTime))
+			h.int64(int64(frequency.Headway))
+			h.int32(int32(frequency.ExactTimes))
+		}
+	}
+	err := s.ForEachStopTime(func(trip ScheduledTrip, stopTime ScheduledStopTime) bool {
+		h.string(trip.ID)
+		h.string(idOrEmpty(stopTime.Stop, func(s *Stop) string { return s.Id }))
+		h.int64(int64(stopTime.ArrivalTime))
+		h.int64(int64(stopTime.DepartureTime))
+		h.int64(int64(stopTime.StopSequence))
+		h.string(stopTime.Headsign)
+		h.int32(int32(stopTime.PickupType))
+		h.int32(int32(stopTime.DropOffType))
+		h.int32(int32(stopTime.ContinuousPickup))
+		h.int32(int32(stopTime.ContinuousDropOff))
+		h.float64Ptr(stopTime.ShapeDistanceTraveled)
+		h.bool(stopTime.ExactTimes)
+		// The window is only hashed if present so that hashes of feeds without windows are unchanged.
+		if stopTime.HasPickupDropOffWindow {
+			h.int64(int64(stopTime.StartPickupDropOffWindow))
+			h.int64(int64(stopTime.EndPickupDropOffWindow))
+		}
+		// Likewise the GTFS-Flex references are only hashed if present.
+		if stopTime.LocationGroup != nil || stopTime.FlexLocation != nil || stopTime.PickupBookingRule != nil || stopTime.DropOffBookingRule != nil {
+			h.string(idOrEmpty(stopTime.LocationGroup, func(g *LocationGroup) string { return g.ID }))
+			h.string(idOrEmpty(stopTime.FlexLocation, func(l *FlexLocation) string { return l.ID }))
+			h.string(idOrEmpty(stopTime.PickupBookingRule, func(r *BookingRule) string { return r.ID }))
+			h.string(idOrEmpty(stopTime.DropOffBookingRule, func(r *BookingRule) string { return r.ID }))
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	h.bool(s.FeedInfo == nil)
+	if feedInfo := s.FeedInfo; feedInfo != nil {
+		for _, v := range []string{feedInfo.PublisherName, feedInfo.PublisherUrl, feedInfo.Language, feedInfo.DefaultLanguage, feedInfo.ContactEmail, feedInfo.ContactUrl} {
+			h.string(v)
+		}
+		// The version and dates are only hashed if present so that hashes of feeds without them are
+		// unchanged.
+		if feedInfo.Version != "" || !feedInfo.StartDate.IsZero() || !feedInfo.EndDate.IsZero() {
+			h.string(feedInfo.Version)
+			h.int64(feedInfo.StartDate.Unix())
+			h.int64(feedInfo.EndDate.Unix())
+		}
+	}
+	// Fare rules are only hashed if present so that hashes of feeds without fare rules are unchanged.
+	if len(s.FareRules) > 0 {
+		h.int64(int64(len(s.FareRules)))
+		for i := range s.FareRules {
+			fareRule := &s.FareRules[i]
+			h.string(fareRule.FareID)
+			h.string(idOrEmpty(fareRule.Route, func(r *Route) string { return r.Id }))
+			h.string(fareRule.OriginID)
+			h.string(fareRule.DestinationID)
+			h.string(fareRule.ContainsID)
+		}
+	}
+	// Fares V2 entities are only hashed if present so that hashes of feeds without them are unchanged.
+	if len(s.Areas)+len(s.Networks)+len(s.FareMedia)+len(s.FareProducts)+len(s.FareLegRules)+len(s.FareTransferRules) > 0 {
+		h.int64(int64(len(s.Areas)))
+		for i := range s.Areas {
+			area := &s.Areas[i]
+			h.string(area.ID)
+			h.string(area.Name)
+			h.int64(int64(len(area.Stops)))
+			for _, stop := range area.Stops {
+				h.string(idOrEmpty(stop, func(s *Stop) string { return s.Id }))
+			}
+		}
+		h.int64(int64(len(s.Networks)))
+		for i := range s.Networks {
+			network := &s.Networks[i]
+			h.string(network.ID)
+			h.string(network.Name)
+			h.int64(int64(len(network.Routes)))
+			for _, route := range network.Routes {
+				h.string(idOrEmpty(route, func(r *Route) string { return r.Id }))
+			}
+		}
+		h.int64(int64(len(s.FareMedia)))
+		for i := range s.FareMedia {
+			fareMedium := &s.FareMedia[i]
+			h.string(fareMedium.ID)
+			h.string(fareMedium.Name)
+			h.int32(int32(fareMedium.Type))
+		}
+		h.int64(int64(len(s.FareProducts)))
+		for i := range s.FareProducts {
+			fareProduct := &s.FareProducts[i]
+			h.string(fareProduct.ID)
+			h.string(fareProduct.Name)
+			h.string(idOrEmpty(fareProduct.FareMedium, func(m *FareMedium) string { return m.ID }))
+			h.float64(fareProduct.Amount)
+			h.string(fareProduct.Currency)
+		}
+		h.int64(int64(len(s.FareLegRules)))
+		for i := range s.FareLegRules {
+			fareLegRule := &s.FareLegRules[i]
+			h.string(fareLegRule.LegGroupID)
+			h.string(idOrEmpty(fareLegRule.Network, func(n *Network) string { return n.ID }))
+			h.string(idOrEmpty(fareLegRule.FromArea, func(a *Area) string { return a.ID }))
+			h.string(idOrEmpty(fareLegRule.ToArea, func(a *Area) string { return a.ID }))
+			h.string(fareLegRule.FromTimeframeGroupID)
+			h.string(fareLegRule.ToTimeframeGroupID)
+			h.string(fareLegRule.FareProductID)
+			h.int32Ptr(fareLegRule.RulePriority)
+		}
+		h.int64(int64(len(s.FareTransferRules)))
+		for i := range s.FareTransferRules {
+			fareTransferRule := &s.FareTransferRules[i]
+			h.string(fareTransferRule.FromLegGroupID)
+			h.string(fareTransferRule.ToLegGroupID)
+			h.int32Ptr(fareTransferRule.TransferCount)
+			h.bool(fareTransferRule.DurationLimit == nil)
+			if fareTransferRule.DurationLimit != nil {
+				h.int64(int64(*fareTransferRule.DurationLimit))
+			}
+			h.int32(int32(fareTransferRule.DurationLimitType))
+			h.int32(int32(fareTransferRule.FareTransferType))
+			h.string(fareTransferRule.FareProductID)
+		}
+	}
+	// GTFS-Flex entities are only hashed if present so that hashes of feeds without them are unchanged.
+	if len(s.LocationGroups)+len(s.FlexLocations)+len(s.BookingRules) > 0 {
+		h.int64(int64(len(s.LocationGroups)))
+		for i := range s.LocationGroups {
+			locationGroup := &s.LocationGroups[i]
+			h.string(locationGroup.ID)
+			h.string(locationGroup.Name)
+			h.int64(int64(len(locationGroup.Stops)))
+			for _, stop := range locationGroup.Stops {
+				h.string(idOrEmpty(stop, func(s *Stop) string { return s.Id }))
+			}
+		}
+		h.int64(int64(len(s.FlexLocations)))
+		for i := range s.FlexLocations {
+			flexLocation := &s.FlexLocations[i]
+			h.string(flexLocation.ID)
+			h.string(flexLocation.Name)
+			h.string(flexLocation.Description)
+			h.int64(int64(len(flexLocation.Polygons)))
+			for _, polygon := range flexLocation.Polygons {
+				h.int64(int64(len(polygon)))
+				for _, ring := range polygon {
+					h.int64(int64(len(ring)))
+					for _, coordinate := range ring {
+						h.float64(coordinate.Latitude)
+						h.float64(coordinate.Longitude)
+					}
+				}
+			}
+		}
+		h.int64(int64(len(s.BookingRules)))
+		for i := range s.BookingRules {
+			bookingRule := &s.BookingRules[i]
+			h.string(bookingRule.ID)
+			h.int32(int32(bookingRule.Type))
+			h.durationPtr(bookingRule.PriorNoticeDurationMin)
+			h.durationPtr(bookingRule.PriorNoticeDurationMax)
+			h.int32Ptr(bookingRule.PriorNoticeLastDay)
+			h.durationPtr(bookingRule.PriorNoticeLastTime)
+			h.int32Ptr(bookingRule.PriorNoticeStartDay)
+			h.durationPtr(bookingRule.PriorNoticeStartTime)
+			h.string(idOrEmpty(bookingRule.PriorNoticeService, func(s *Service) string { return s.Id }))
+			for _, v := range []string{bookingRule.Message, bookingRule.PickupMessage, bookingRule.DropOffMessage, bookingRule.PhoneNumber, bookingRule.InfoURL, bookingRule.BookingURL} {
+				h.string(v)
+			}
+		}
+	}
+	// Pathways are only hashed if present so that hashes of feeds without pathways are unchanged.
+	if len(s.Pathways) > 0 {
+		h.int64(int64(len(s.Pathways)))
+		for i := range s.Pathways {
+			pathway := &s.Pathways[i]
+			h.string(pathway.ID)
+			h.string(idOrEmpty(pathway.From, func(s *Stop) string { return s.Id }))
+			h.string(idOrEmpty(pathway.To, func(s *Stop) string { return s.Id }))
+			h.int32(int32(pathway.Mode))
+			h.bool(pathway.IsBidirectional)
+			h.float64Ptr(pathway.Length)
+			h.int32Ptr(pathway.TraversalTime)
+			h.int32Ptr(pathway.StairCount)
+			h.float64Ptr(pathway.MaxSlope)
+			h.float64Ptr(pathway.MinWidth)
+			h.string(pathway.SignpostedAs)
+			h.string(pathway.ReversedSignpostedAs)
+		}
+	}
+	return nil
 }
 
-func hashNumberPtr[T any](h *hasher, a *T) {
-	h.number(a == nil)
-	if a != nil {
-		h.number(*a)
+func idOrEmpty[T any](t *T, id func(*T) string) string {
+	if t == nil {
+		return ""
+	}
+	return id(t)
+}
+
+func (h *hasher) alert(a *Alert) {
+	h.string(a.ID)
+	h.alertContent(a)
+	h.int64(int64(len(a.InformedEntities)))
+	for i := range a.InformedEntities {
+		h.alertInformedEntity(&a.InformedEntities[i])
+	}
+}
+
+// alertContent hashes the content of an alert, ignoring its ID and informed entities.
+func (h *hasher) alertContent(a *Alert) {
+	h.int32(int32(a.Cause))
+	h.int32(int32(a.Effect))
+	h.int64(int64(len(a.ActivePeriods)))
+	for _, activePeriod := range a.ActivePeriods {
+		h.timePtr(activePeriod.StartsAt)
+		h.timePtr(activePeriod.EndsAt)
+	}
+	for _, texts := range [][]AlertText{a.Header, a.Description, a.URL} {
+		h.int64(int64(len(texts)))
+		for _, text := range texts {
+			h.string(text.Text)
+			h.string(text.Language)
+			h.int64(int64(len(text.Links)))
+			for _, link := range text.Links {
+				h.string(link.Text)
+				h.string(link.URL)
+			}
+		}
+	}
+}
+
+func (h *hasher) alertInformedEntity(e *AlertInformedEntity) {
+	h.stringPtr(e.AgencyID)
+	h.stringPtr(e.RouteID)
+	h.int32(int32(e.RouteType))
+	h.uint8(uint8(e.DirectionID))
+	h.stringPtr(e.StopID)
+	h.bool(e.TripID == nil)
+	if e.TripID != nil {
+		h.trip(&Trip{ID: *e.TripID})
 	}
 }
 
+// hashToString returns a SHA-256 hash of the data written by f, as a string suitable for use as a map key.
+func hashToString(f func(h *hasher)) string {
+	s := newHasher(sha256.New())
+	f(&s)
+	s.flush()
+	return string(s.h.Sum(nil))
+}
+
+func (h *hasher) string(s string) {
+	h.uint64(uint64(len(s)))
+	if len(s) > hasherBufferSize {
+		h.flush()
+		io.WriteString(h.h, s)
+		return
+	}
+	h.reserve(len(s))
+	h.b = append(h.b, s...)
+}
+
 func (h *hasher) stringPtr(a *string) {
-	h.number(a == nil)
+	h.bool(a == nil)
 	if a != nil {
 		h.string(*a)
 	}
 }
 
-func (h *hasher) number(a any) {
-	err := binary.Write(&h.b, binary.LittleEndian, a)
-	if err != nil {
-		panic(fmt.Sprintf("failed to hash %T", a))
+func (h *hasher) bool(a bool) {
+	h.reserve(1)
+	if a {
+		h.b = append(h.b, 1)
+	} else {
+		h.b = append(h.b, 0)
+	}
+}
+
+func (h *hasher) uint8(a uint8) {
+	h.reserve(1)
+	h.b = append(h.b, a)
+}
+
+func (h *hasher) uint32(a uint32) {
+	h.reserve(4)
+	h.b = append(h.b, byte(a), byte(a>>8), byte(a>>16), byte(a>>24))
+}
+
+func (h *hasher) uint64(a uint64) {
+	h.reserve(8)
+	h.b = append(h.b, byte(a), byte(a>>8), byte(a>>16), byte(a>>24), byte(a>>32), byte(a>>40), byte(a>>48), byte(a>>56))
+}
+
+func (h *hasher) int32(a int32) {
+	h.uint32(uint32(a))
+}
+
+func (h *hasher) int64(a int64) {
+	h.uint64(uint64(a))
+}
+
+func (h *hasher) float32(a float32) {
+	h.uint32(math.Float32bits(a))
+}
+
+func (h *hasher) float64(a float64) {
+	h.uint64(math.Float64bits(a))
+}
+
+func (h *hasher) uint32Ptr(a *uint32) {
+	h.bool(a == nil)
+	if a != nil {
+		h.uint32(*a)
+	}
+}
+
+func (h *hasher) int32Ptr(a *int32) {
+	h.bool(a == nil)
+	if a != nil {
+		h.int32(*a)
+	}
+}
+
+func (h *hasher) int64Ptr(a *int64) {
+	h.bool(a == nil)
+	if a != nil {
+		h.int64(*a)
+	}
+}
+
+func (h *hasher) float32Ptr(a *float32) {
+	h.bool(a == nil)
+	if a != nil {
+		h.float32(*a)
+	}
+}
+
+func (h *hasher) float64Ptr(a *float64) {
+	h.bool(a == nil)
+	if a != nil {
+		h.float64(*a)
+	}
+}
+
+func (h *hasher) durationPtr(d *time.Duration) {
+	h.bool(d == nil)
+	if d != nil {
+		h.int64(int64(*d))
 	}
 }
 
 func (h *hasher) timePtr(t *time.Time) {
-	var up *int64
+	h.bool(t == nil)
 	if t != nil {
-		u := t.Unix()
-		up = &u
+		h.int64(t.Unix())
 	}
-	hashNumberPtr(h, up)
 }
@@ -11,6 +11,7 @@ import (
 
 func BenchmarkHashTrip(b *testing.B) {
 	trip := mkTrip(0)
+	b.ReportAllocs()
 	for n := 0; n < b.N; n++ {
 		h := md5.New()
 		trip.Hash(h)
@@ -18,6 +19,48 @@ func BenchmarkHashTrip(b *testing.B) {
 	}
 }
 
+func BenchmarkHashVehicle(b *testing.B) {
+	vehicle := mkVehicle()
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		h := md5.New()
+		vehicle.Hash(h)
+		_ = h.Sum(nil)
+	}
+}
+
+// TestHashStability checks that hashes do not change between versions, as they may be persisted.
+func TestHashStability(t *testing.T) {
+	trip := mkTrip(0)
+	vehicle := mkVehicle()
+	alert := Alert{
+		Cause:         gtfsrt.Alert_STRIKE,
+		ActivePeriods: []AlertActivePeriod{{StartsAt: ptr(mkTime(1))}},
+		Header:        []AlertText{{Text: "header", Language: "en", Links: []AlertLink{{Text: "a", URL: "b"}}}},
+	}
+	informedEntity := AlertInformedEntity{RouteID: ptr("route"), DirectionID: DirectionID_True, TripID: &trip.ID}
+	for _, tc := range []struct {
+		name string
+		f    func(h *hasher)
+		want string
+	}{
+		{"trip", func(h *hasher) { h.trip(&trip) }, "bef90501fdb4ecddd499bc76ed499612f4231053a919ba900948bb9e652bae6a"},
+		{"vehicle", func(h *hasher) { h.vehicle(&vehicle) }, "b6169043733ac05bf9aad2e293d7e6b7dbf28deac9e38d5a27df39ef628f3ddf"},
+		{"alert", func(h *hasher) { h.alertContent(&alert) }, "18ffa45da9da548810dd0981ad9d7c4a96d69c0d41f93b6ef4932a2bddd99cce"},
+		{"informed_entity", func(h *hasher) { h.alertInformedEntity(&informedEntity) }, "e065631cb13701f3d50509546b5b1ea889bf3b5e5743cedba5b6700d515e3092"},
+		{"alert_with_id", func(h *hasher) {
+			a := alert
+			a.ID = "alert"
+			a.InformedEntities = []AlertInformedEntity{informedEntity}
+			h.alert(&a)
+		}, "7bbd140a2d40bd92521ffcdf31503a10be9f05847698e1ea8bc40d8c3bced07e"},
+	} {
+		if got := fmt.Sprintf("%x", hashToString(tc.f)); got != tc.want {
+			t.Errorf("hash of %s got = %s, want = %s", tc.name, got, tc.want)
+		}
+	}
+}
+
 func TestHashTrip(t *testing.T) {
 	for _, tc := range []struct {
 		field  string
@@ -83,6 +126,18 @@ func TestHashTrip(t *testing.T) {
 				return &t.StopTimeUpdates[0].NyctTrack
 			},
 		},
+		{
+			"stop_time_updates[0].assigned_stop_id",
+			func(t *Trip) any {
+				return &t.StopTimeUpdates[0].AssignedStopID
+			},
+		},
+		{
+			"stop_time_updates[0].track",
+			func(t *Trip) any {
+				return &t.StopTimeUpdates[0].Track
+			},
+		},
 		{
 			"stop_time_updates[0].arrival",
 			func(t *Trip) any {
@@ -182,11 +237,29 @@ func TestHashVehicle(t *testing.T) {
 			},
 		},
 		{
-			"id.licence_plate",
+			"id.license_plate",
 			func(v *Vehicle) any {
 				return &v.ID.LicensePlate
 			},
 		},
+		{
+			"id.has_id",
+			func(v *Vehicle) any {
+				return &v.ID.HasID
+			},
+		},
+		{
+			"id.has_label",
+			func(v *Vehicle) any {
+				return &v.ID.HasLabel
+			},
+		},
+		{
+			"id.has_license_plate",
+			func(v *Vehicle) any {
+				return &v.ID.HasLicensePlate
+			},
+		},
 		{
 			"trip",
 			func(v *Vehicle) any {
@@ -316,7 +389,9 @@ func mkTrip(i int) Trip {
 					Delay:       ptr(time.Hour * mkDuration(i+8)),
 					Uncertainty: ptr(int32(i + 9)),
 				},
-				NyctTrack: ptr("stop_time_updates.0.nyct_track"),
+				AssignedStopID: ptr("stop_time_updates.0.assigned_stop_id"),
+				Track:          ptr("stop_time_updates.0.track"),
+				NyctTrack:      ptr("stop_time_updates.0.nyct_track"),
 			},
 		},
 	}
@@ -325,9 +400,12 @@ func mkTrip(i int) Trip {
 func mkVehicle() Vehicle {
 	return Vehicle{
 		ID: &VehicleID{
-			ID:           "vehicle.id.id",
-			Label:        "vehicle.id.label",
-			LicensePlate: "vehicle.id.licence_plate",
+			ID:              "vehicle.id.id",
+			Label:           "vehicle.id.label",
+			LicensePlate:    "vehicle.id.license_plate",
+			HasID:           true,
+			HasLabel:        true,
+			HasLicensePlate: true,
 		},
 		Trip:                ptr(mkTrip(0)),
 		Position:            ptr(mkPosition(9)),
@@ -529,3 +607,50 @@ func combinations(m []modifier) [][2]modifier {
 	}
 	return c
 }
+
+func TestHashStatic(t *testing.T) {
+	newBuilder := func(stopName string) *zipBuilder {
+		return newZipBuilderWithDefaults().add(
+			"stops.txt",
+			"stop_id,stop_name\nstop_id,"+stopName,
+		).add(
+			"calendar.txt",
+			"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n"+
+				"service_id,1,1,1,1,1,0,0,20220504,20220507\n"+
+				"service_id_2,0,0,0,0,0,1,1,20220504,20220507\n"+
+				"service_id_3,0,0,0,0,0,1,0,20220504,20220507",
+		)
+	}
+	hashStatic := func(content []byte, opts ParseStaticOptions) string {
+		static, err := ParseStatic(content, opts)
+		if err != nil {
+			t.Fatalf("ParseStatic() err = %v", err)
+		}
+		h := md5.New()
+		if err := static.Hash(h); err != nil {
+			t.Fatalf("Hash() err = %v", err)
+		}
+		return fmt.Sprintf("%x", h.Sum(nil))
+	}
+
+	// Each build writes the files in a random order.
+	want := hashStatic(newBuilder("a").build(), ParseStaticOptions{})
+	// Hashes may be persisted, so they should not change between versions.
+	if golden := "d283996dfc5135056e9499da8bc2468b"; want != golden {
+		t.Errorf("Hash() got = %s, want = %s", want, golden)
+	}
+	for i := 0; i < 5; i++ {
+		if got := hashStatic(newBuilder("a").build(), ParseStaticOptions{}); got != want {
+			t.Errorf("Hash() of same content got = %s, want = %s", got, want)
+		}
+	}
+	if got := hashStatic(newBuilder("a").build(), ParseStaticOptions{StreamStopTimes: true}); got != want {
+		t.Errorf("Hash() with streamed stop times got = %s, want = %s", got, want)
+	}
+	if got := hashStatic(newBuilder("a").build(), ParseStaticOptions{RecordSourceRows: true}); got != want {
+		t.Errorf("Hash() with source rows got = %s, want = %s", got, want)
+	}
+	if got := hashStatic(newBuilder("b").build(), ParseStaticOptions{}); got == want {
+		t.Errorf("Hash() of different content got = %s, want different hash", got)
+	}
+}
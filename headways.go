@@ -0,0 +1,186 @@
+package gtfs
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// TimeWindow is a period of a service day.
+//
+// Like the times of a [ScheduledStopTime], the times are measured from the start of the service day.
+// The start is inclusive and the end is exclusive.
+type TimeWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Contains returns true if the time is in the window.
+func (w TimeWindow) Contains(t time.Duration) bool {
+	return w.Start <= t && t < w.End
+}
+
+// HeadwayStats contains statistics about the headways between consecutive departures.
+//
+// If there are fewer than two departures there are no headways and all durations are zero.
+type HeadwayStats struct {
+	NumDepartures int
+	// The headways between consecutive departures, in order.
+	Headways          []time.Duration
+	Min               time.Duration
+	Max               time.Duration
+	Mean              time.Duration
+	Median            time.Duration
+	StandardDeviation time.Duration
+}
+
+// CoefficientOfVariation returns the standard deviation of the headways divided by their mean.
+//
+// This is a common measure of service regularity: it is 0 for perfectly even headways and grows as
+// vehicles bunch together. If there are no headways, 0 is returned.
+func (stats *HeadwayStats) CoefficientOfVariation() float64 {
+	if stats.Mean == 0 {
+		return 0
+	}
+	return float64(stats.StandardDeviation) / float64(stats.Mean)
+}
+
+// NewHeadwayStats calculates headway statistics from a list of departure times.
+//
+// The departure times do not need to be sorted.
+func NewHeadwayStats(departures []time.Duration) HeadwayStats {
+	sorted := append([]time.Duration(nil), departures...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	stats := HeadwayStats{NumDepartures: len(sorted)}
+	for i := 1; i < len(sorted); i++ {
+		stats.Headways = append(stats.Headways, sorted[i]-sorted[i-1])
+	}
+	if len(stats.Headways) == 0 {
+		return stats
+	}
+	var sum time.Duration
+	stats.Min, stats.Max = stats.Headways[0], stats.Headways[0]
+	for _, headway := range stats.Headways {
+		sum += headway
+		if headway < stats.Min {
+			stats.Min = headway
+		}
+		if headway > stats.Max {
+			stats.Max = headway
+		}
+	}
+	n := len(stats.Headways)
+	stats.Mean = sum / time.Duration(n)
+	byLength := append([]time.Duration(nil), stats.Headways...)
+	sort.Slice(byLength, func(i, j int) bool { return byLength[i] < byLength[j] })
+	if n%2 == 1 {
+		stats.Median = byLength[n/2]
+	} else {
+		stats.Median = (byLength[n/2-1] + byLength[n/2]) / 2
+	}
+	var variance float64
+	for _, headway := range stats.Headways {
+		d := float64(headway - stats.Mean)
+		variance += d * d
+	}
+	stats.StandardDeviation = time.Duration(math.Sqrt(variance / float64(n)))
+	return stats
+}
+
+// Headways calculates scheduled headway statistics for the route in the provided direction on the provided date.
+//
+// Headways are measured at the first stop of each trip, and only departures within the window are
+// considered. Trips generated by frequencies.txt are included.
+func (s *Static) Headways(route *Route, direction DirectionID, date time.Time, window TimeWindow) (HeadwayStats, error) {
+	return s.headways(route, direction, date, nil, window)
+}
+
+// HeadwaysAtStop is like [Static.Headways] except that headways are measured at the provided stop.
+//
+// Trips that do not call at the stop are ignored.
+func (s *Static) HeadwaysAtStop(route *Route, direction DirectionID, date time.Time, stop *Stop, window TimeWindow) (HeadwayStats, error) {
+	return s.headways(route, direction, date, stop, window)
+}
+
+func (s *Static) headways(route *Route, direction DirectionID, date time.Time, stop *Stop, window TimeWindow) (HeadwayStats, error) {
+	departures, err := s.scheduledDepartures(route, direction, date, stop)
+	if err != nil {
+		return HeadwayStats{}, err
+	}
+	var inWindow []time.Duration
+	for _, departure := range departures {
+		if window.Contains(departure) {
+			inWindow = append(inWindow, departure)
+		}
+	}
+	return NewHeadwayStats(inWindow), nil
+}
+
+// scheduledDepartures returns the departure times of the route's trips at the stop on the date.
+//
+// If the stop is nil, the departure from the first stop of each trip is used.
+func (s *Static) scheduledDepartures(route *Route, direction DirectionID, date time.Time, stop *Stop) ([]time.Duration, error) {
+	type tripDepartures struct {
+		trip *ScheduledTrip
+		// Departure time and stop sequence of the first stop time.
+		first         time.Duration
+		firstSequence int
+		// Departure time and stop sequence of the first stop time at the stop.
+		atStop         time.Duration
+		atStopSequence int
+	}
+	tripIDToDepartures := map[string]*tripDepartures{}
+	for i := range s.Trips {
+		trip := &s.Trips[i]
+		if trip.Route != route || trip.DirectionId != direction || trip.Service == nil || !trip.Service.RunsOn(date) {
+			continue
+		}
+		tripIDToDepartures[trip.ID] = &tripDepartures{trip: trip, firstSequence: -1, atStopSequence: -1}
+	}
+	if len(tripIDToDepartures) == 0 {
+		return nil, nil
+	}
+	err := s.ForEachStopTime(func(trip ScheduledTrip, stopTime ScheduledStopTime) bool {
+		d, ok := tripIDToDepartures[trip.ID]
+		if !ok {
+			return true
+		}
+		sequence := stopTime.StopSequence
+		if d.firstSequence < 0 || sequence < d.firstSequence {
+			d.first, d.firstSequence = stopTime.DepartureTime, sequence
+		}
+		if stop != nil && stopTime.Stop == stop && (d.atStopSequence < 0 || sequence < d.atStopSequence) {
+			d.atStop, d.atStopSequence = stopTime.DepartureTime, sequence
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var departures []time.Duration
+	for _, d := range tripIDToDepartures {
+		departure, sequence := d.first, d.firstSequence
+		if stop != nil {
+			departure, sequence = d.atStop, d.atStopSequence
+		}
+		if sequence < 0 {
+			continue
+		}
+		if len(d.trip.Frequencies) == 0 {
+			departures = append(departures, departure)
+			continue
+		}
+		offset := departure - d.first
+		for _, frequency := range d.trip.Frequencies {
+			if frequency.Headway <= 0 {
+				continue
+			}
+			for start := frequency.StartTime; start < frequency.EndTime; start += frequency.Headway {
+				departures = append(departures, start+offset)
+			}
+		}
+	}
+	sort.Slice(departures, func(i, j int) bool { return departures[i] < departures[j] })
+	return departures, nil
+}
@@ -0,0 +1,126 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewHeadwayStats(t *testing.T) {
+	for _, tc := range []struct {
+		desc       string
+		departures []time.Duration
+		want       HeadwayStats
+		wantCV     float64
+	}{
+		{
+			desc: "no departures",
+		},
+		{
+			desc:       "one departure",
+			departures: []time.Duration{time.Hour},
+			want:       HeadwayStats{NumDepartures: 1},
+		},
+		{
+			desc:       "even headways",
+			departures: []time.Duration{20 * time.Minute, 0, 10 * time.Minute},
+			want: HeadwayStats{
+				NumDepartures: 3,
+				Headways:      []time.Duration{10 * time.Minute, 10 * time.Minute},
+				Min:           10 * time.Minute,
+				Max:           10 * time.Minute,
+				Mean:          10 * time.Minute,
+				Median:        10 * time.Minute,
+			},
+		},
+		{
+			desc:       "bunched",
+			departures: []time.Duration{0, 2 * time.Minute, 20 * time.Minute},
+			want: HeadwayStats{
+				NumDepartures:     3,
+				Headways:          []time.Duration{2 * time.Minute, 18 * time.Minute},
+				Min:               2 * time.Minute,
+				Max:               18 * time.Minute,
+				Mean:              10 * time.Minute,
+				Median:            10 * time.Minute,
+				StandardDeviation: 8 * time.Minute,
+			},
+			wantCV: 0.8,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := NewHeadwayStats(tc.departures)
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("NewHeadwayStats() got = %v, want = %v, diff: %s", got, tc.want, diff)
+			}
+			if cv := got.CoefficientOfVariation(); cv != tc.wantCV {
+				t.Errorf("CoefficientOfVariation() got = %v, want = %v", cv, tc.wantCV)
+			}
+		})
+	}
+}
+
+func TestHeadways(t *testing.T) {
+	content := newZipBuilder().add(
+		"agency.txt",
+		"agency_name,agency_url,agency_timezone\na,b,UTC",
+	).add(
+		"routes.txt",
+		"route_id,route_type\nroute,3",
+	).add(
+		"stops.txt",
+		"stop_id\nstop1\nstop2",
+	).add(
+		"calendar.txt",
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n"+
+			"weekday,1,1,1,1,1,0,0,20220502,20220508",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id,direction_id\n"+
+			"route,weekday,trip1,0\n"+
+			"route,weekday,trip2,0\n"+
+			"route,weekday,trip3,0\n"+
+			"route,weekday,other_direction,1\n"+
+			"route,weekday,frequency,0",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,arrival_time,departure_time,stop_sequence\n"+
+			"trip1,stop1,05:50:00,05:50:00,1\n"+
+			"trip2,stop1,06:00:00,06:00:00,1\n"+
+			"trip3,stop1,06:05:00,06:05:00,1\n"+
+			"other_direction,stop1,06:02:00,06:02:00,1\n"+
+			"frequency,stop1,00:00:00,00:00:00,1\n"+
+			"frequency,stop2,00:10:00,00:10:00,2",
+	).add(
+		"frequencies.txt",
+		"trip_id,start_time,end_time,headway_secs\n"+
+			"frequency,06:15:00,06:45:00,900",
+	).build()
+	wednesday := time.Date(2022, 5, 4, 0, 0, 0, 0, time.UTC)
+
+	for _, streamStopTimes := range []bool{false, true} {
+		static, err := ParseStatic(content, ParseStaticOptions{StreamStopTimes: streamStopTimes})
+		if err != nil {
+			t.Fatalf("ParseStatic() err = %v", err)
+		}
+
+		got, err := static.Headways(&static.Routes[0], DirectionID_False, wednesday, TimeWindow{Start: 6 * time.Hour, End: 7 * time.Hour})
+		if err != nil {
+			t.Fatalf("Headways() err = %v", err)
+		}
+
+		want := HeadwayStats{
+			NumDepartures:     4,
+			Headways:          []time.Duration{5 * time.Minute, 10 * time.Minute, 15 * time.Minute},
+			Min:               5 * time.Minute,
+			Max:               15 * time.Minute,
+			Mean:              10 * time.Minute,
+			Median:            10 * time.Minute,
+			StandardDeviation: time.Duration(244948974278),
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("Headways() got = %v, want = %v, diff: %s", got, want, diff)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package gtfs
+
+import (
+	"github.com/jamespfennell/gtfs/constants"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+// IntegrityReport summarizes the references between files of a static feed to entities that do
+// not exist.
+type IntegrityReport struct {
+	// Dangling references in each column, in the order in which the columns were first reported.
+	DanglingReferences []DanglingReferences
+}
+
+// DanglingReferences summarizes the references in one column of a file to entities that do not exist.
+type DanglingReferences struct {
+	File   constants.StaticFile
+	Column string
+	// Number of rows with a dangling reference in the column.
+	Count int
+	// Distinct IDs referenced by the rows, in order of first appearance.
+	IDs []string
+}
+
+// TotalCount returns the total number of dangling references in the report.
+func (r *IntegrityReport) TotalCount() int {
+	var n int
+	for _, d := range r.DanglingReferences {
+		n += d.Count
+	}
+	return n
+}
+
+// IntegrityReport returns a summary of the dangling references in the feed: trips that reference
+// routes, services or shapes that do not exist, stop times that reference stops or trips that do
+// not exist, transfers and pathways that reference stops that do not exist, fare rules that
+// reference routes or fare zones that do not exist, Fares V2 entities that reference areas,
+// networks, fare media, fare products or leg groups that do not exist, and GTFS-Flex entities that
+// reference location groups, flex locations, booking rules or services that do not exist.
+//
+// The report is built from the [warnings.InvalidForeignID] warnings raised during parsing, so it
+// does not include warnings dropped because of [ParseStaticOptions.DeduplicateWarnings] or
+// [ParseStaticOptions.MaxWarnings]. Stop times of trips that were skipped, for example because
+// their route does not exist, are reported as referencing trips that do not exist.
+func (s *Static) IntegrityReport() *IntegrityReport {
+	type key struct {
+		file   constants.StaticFile
+		column string
+	}
+	report := &IntegrityReport{}
+	keyToIndex := map[key]int{}
+	seenIDs := map[key]map[string]bool{}
+	for _, w := range s.Warnings {
+		invalidForeignID, ok := w.Kind.(warnings.InvalidForeignID)
+		if !ok {
+			continue
+		}
+		k := key{file: w.File, column: invalidForeignID.Column}
+		i, ok := keyToIndex[k]
+		if !ok {
+			i = len(report.DanglingReferences)
+			keyToIndex[k] = i
+			seenIDs[k] = map[string]bool{}
+			report.DanglingReferences = append(report.DanglingReferences, DanglingReferences{File: w.File, Column: k.column})
+		}
+		d := &report.DanglingReferences[i]
+		d.Count++
+		if !seenIDs[k][invalidForeignID.ID] {
+			seenIDs[k][invalidForeignID.ID] = true
+			d.IDs = append(d.IDs, invalidForeignID.ID)
+		}
+	}
+	return report
+}
@@ -0,0 +1,66 @@
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs/constants"
+)
+
+func TestIntegrityReport(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id,zone_id",
+		"stop_1,zone_1",
+		"stop_2,zone_1",
+	).add(
+		"transfers.txt",
+		"from_stop_id,to_stop_id",
+		"stop_1,stop_3",
+		"stop_4,stop_2",
+	).add(
+		"fare_rules.txt",
+		"fare_id,origin_id",
+		"fare_1,zone_2",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id,shape_id",
+		"route_id,service_id,trip_1,shape_1",
+		"route_2,service_id,trip_2,",
+		"route_2,service_id,trip_3,",
+		"route_3,service_2,trip_4,",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,arrival_time,departure_time,stop_sequence",
+		"trip_1,stop_1,08:00:00,08:00:00,1",
+		"trip_1,stop_5,08:10:00,08:10:00,2",
+		"trip_2,stop_1,08:00:00,08:00:00,1",
+		"trip_2,stop_2,08:10:00,08:10:00,2",
+	).build()
+
+	static, err := ParseStatic(content, ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+
+	got := static.IntegrityReport()
+
+	want := &IntegrityReport{
+		DanglingReferences: []DanglingReferences{
+			{File: constants.TransfersFile, Column: "to_stop_id", Count: 1, IDs: []string{"stop_3"}},
+			{File: constants.TransfersFile, Column: "from_stop_id", Count: 1, IDs: []string{"stop_4"}},
+			{File: constants.FareRulesFile, Column: "origin_id", Count: 1, IDs: []string{"zone_2"}},
+			{File: constants.TripsFile, Column: "shape_id", Count: 1, IDs: []string{"shape_1"}},
+			{File: constants.TripsFile, Column: "route_id", Count: 3, IDs: []string{"route_2", "route_3"}},
+			{File: constants.TripsFile, Column: "service_id", Count: 1, IDs: []string{"service_2"}},
+			{File: constants.StopTimesFile, Column: "stop_id", Count: 1, IDs: []string{"stop_5"}},
+			{File: constants.StopTimesFile, Column: "trip_id", Count: 2, IDs: []string{"trip_2"}},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("IntegrityReport() diff: %s", diff)
+	}
+	if got.TotalCount() != 11 {
+		t.Errorf("TotalCount() = %d, want 11", got.TotalCount())
+	}
+}
@@ -0,0 +1,30 @@
+//go:build go1.23
+
+package gtfs
+
+import "iter"
+
+// AllStopTimes returns an iterator over the scheduled stop times in the feed, along with the trip
+// each belongs to. Stop times are visited in the same order as [Static.ForEachStopTime].
+//
+// If the feed was parsed with [ParseStaticOptions.StreamStopTimes] set to true and stop_times.txt
+// cannot be read, iteration stops early. Use [Static.ForEachStopTime] to detect this error.
+func (s *Static) AllStopTimes() iter.Seq2[ScheduledTrip, ScheduledStopTime] {
+	return func(yield func(ScheduledTrip, ScheduledStopTime) bool) {
+		_ = s.ForEachStopTime(yield)
+	}
+}
+
+// AllStopTimeUpdates returns an iterator over the stop time updates of all trips in the message,
+// along with the trip each belongs to.
+func (realtime *Realtime) AllStopTimeUpdates() iter.Seq2[Trip, StopTimeUpdate] {
+	return func(yield func(Trip, StopTimeUpdate) bool) {
+		for i := range realtime.Trips {
+			for j := range realtime.Trips[i].StopTimeUpdates {
+				if !yield(realtime.Trips[i], realtime.Trips[i].StopTimeUpdates[j]) {
+					return
+				}
+			}
+		}
+	}
+}
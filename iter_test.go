@@ -0,0 +1,71 @@
+//go:build go1.23
+
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAllStopTimes(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"route_id,service_id,a",
+		"route_id,service_id,b",
+	).add(
+		"stop_times.txt",
+		"stop_id,trip_id,arrival_time,stop_sequence",
+		"stop_id,b,00:00:02,2",
+		"stop_id,b,00:00:01,1",
+		"stop_id,a,00:00:03,3",
+	).build()
+	type visit struct {
+		TripID       string
+		StopSequence int
+	}
+	for _, streamStopTimes := range []bool{false, true} {
+		static, err := ParseStatic(content, ParseStaticOptions{StreamStopTimes: streamStopTimes})
+		if err != nil {
+			t.Fatalf("error when parsing: %s", err)
+		}
+		var want []visit
+		static.ForEachStopTime(func(trip ScheduledTrip, stopTime ScheduledStopTime) bool {
+			want = append(want, visit{trip.ID, stopTime.StopSequence})
+			return true
+		})
+
+		var got []visit
+		for trip, stopTime := range static.AllStopTimes() {
+			got = append(got, visit{trip.ID, stopTime.StopSequence})
+			if len(got) == 2 {
+				break
+			}
+		}
+
+		if diff := cmp.Diff(got, want[:2]); diff != "" {
+			t.Errorf("AllStopTimes() got = %v, want = %v", got, want[:2])
+		}
+	}
+}
+
+func TestAllStopTimeUpdates(t *testing.T) {
+	stopID1, stopID2, stopID3 := "1", "2", "3"
+	realtime := &Realtime{
+		Trips: []Trip{
+			{ID: TripID{ID: "a"}, StopTimeUpdates: []StopTimeUpdate{{StopID: &stopID1}, {StopID: &stopID2}}},
+			{ID: TripID{ID: "b"}},
+			{ID: TripID{ID: "c"}, StopTimeUpdates: []StopTimeUpdate{{StopID: &stopID3}}},
+		},
+	}
+
+	var got []string
+	for trip, stopTimeUpdate := range realtime.AllStopTimeUpdates() {
+		got = append(got, trip.ID.ID+*stopTimeUpdate.StopID)
+	}
+
+	if want := []string{"a1", "a2", "c3"}; !cmp.Equal(got, want) {
+		t.Errorf("AllStopTimeUpdates() got = %v, want = %v", got, want)
+	}
+}
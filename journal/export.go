@@ -4,10 +4,10 @@ import (
 	"bytes"
 	_ "embed"
 	"fmt"
+	"path"
+	"sort"
 	"text/template"
 	"time"
-
-	"github.com/jamespfennell/gtfs"
 )
 
 //go:embed trips.csv.tmpl
@@ -29,41 +29,165 @@ var funcMap = template.FuncMap{
 		}
 		return fmt.Sprintf("%d", t.Unix())
 	},
-	"FormatDirectionID": func(d gtfs.DirectionID) string {
-		switch d {
-		case gtfs.DirectionID_False:
-			return "0"
-		case gtfs.DirectionID_True:
-			return "1"
-		default:
-			return ""
-		}
-	},
+	"FormatDirectionID": formatDirectionID,
 }
 
 var tripsCsv *template.Template = template.Must(template.New("trips.csv.tmpl").Funcs(funcMap).Parse(tripsCsvTmpl))
 var stopTimesCsv *template.Template = template.Must(template.New("stop_times.csv.tmpl").Funcs(funcMap).Parse(stopTimesCsvTmpl))
 
+// Partitioning describes how the CSV export of a journal is split into files.
+type Partitioning int32
+
+const (
+	// All trips are exported to trips.csv and stop_times.csv.
+	Partitioning_None Partitioning = 0
+	// The trips of each service date are exported to files named like trips_20240102.csv and
+	// stop_times_20240102.csv.
+	Partitioning_ByServiceDate Partitioning = 1
+	// The trips of each service date are exported to Hive-style directories, like
+	// service_date=2024-01-02/trips.csv and service_date=2024-01-02/stop_times.csv.
+	Partitioning_HiveByServiceDate Partitioning = 2
+)
+
+func (p Partitioning) String() string {
+	switch p {
+	case Partitioning_None:
+		return "NONE"
+	case Partitioning_ByServiceDate:
+		return "BY_SERVICE_DATE"
+	case Partitioning_HiveByServiceDate:
+		return "HIVE_BY_SERVICE_DATE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// CsvExportOptions configures the CSV export of a journal.
+type CsvExportOptions struct {
+	// How the export is split into files.
+	Partitioning Partitioning
+
+	// Timezone used to compute the service date of each trip, which is the date of its start time
+	// in this timezone. Defaults to UTC.
+	Timezone *time.Location
+}
+
 // CsvExport contains CSV exports of a journal
 type CsvExport struct {
 	TripsCsv     []byte
 	StopTimesCsv []byte
+
+	// Exports of the trips of each service date, in increasing order of service date.
+	//
+	// This is only populated if the export is partitioned, in which case TripsCsv and StopTimesCsv
+	// are empty.
+	Partitions []CsvPartition
+}
+
+// CsvPartition contains the CSV exports of the trips of a single service date.
+type CsvPartition struct {
+	ServiceDate time.Time
+	// Paths of the files relative to the output directory.
+	TripsPath     string
+	StopTimesPath string
+	TripsCsv      []byte
+	StopTimesCsv  []byte
+}
+
+// CsvFile is a single file of a CSV export.
+type CsvFile struct {
+	// Path of the file relative to the output directory.
+	Path string
+	Data []byte
+}
+
+// Files returns the files of the export. If the export is partitioned, the paths of files in
+// Hive-style partitions include the partition directory.
+func (export *CsvExport) Files() []CsvFile {
+	if len(export.Partitions) == 0 {
+		return []CsvFile{
+			{Path: "trips.csv", Data: export.TripsCsv},
+			{Path: "stop_times.csv", Data: export.StopTimesCsv},
+		}
+	}
+	var files []CsvFile
+	for _, partition := range export.Partitions {
+		files = append(files,
+			CsvFile{Path: partition.TripsPath, Data: partition.TripsCsv},
+			CsvFile{Path: partition.StopTimesPath, Data: partition.StopTimesCsv},
+		)
+	}
+	return files
 }
 
 func (journal *Journal) ExportToCsv() (*CsvExport, error) {
+	return journal.ExportToCsvWithOptions(CsvExportOptions{})
+}
+
+// ExportToCsvWithOptions is the same as ExportToCsv, but with options.
+func (journal *Journal) ExportToCsvWithOptions(opts CsvExportOptions) (*CsvExport, error) {
+	if opts.Partitioning == Partitioning_None {
+		tripsB, stopTimesB, err := exportTripsToCsv(journal.Trips)
+		if err != nil {
+			return nil, err
+		}
+		return &CsvExport{
+			TripsCsv:     tripsB,
+			StopTimesCsv: stopTimesB,
+		}, nil
+	}
+
+	timezone := opts.Timezone
+	if timezone == nil {
+		timezone = time.UTC
+	}
+	serviceDateToTrips := map[time.Time][]Trip{}
+	var serviceDates []time.Time
+	for _, trip := range journal.Trips {
+		serviceDate := startOfDay(trip.StartTime.In(timezone))
+		if _, ok := serviceDateToTrips[serviceDate]; !ok {
+			serviceDates = append(serviceDates, serviceDate)
+		}
+		serviceDateToTrips[serviceDate] = append(serviceDateToTrips[serviceDate], trip)
+	}
+	sort.Slice(serviceDates, func(i, j int) bool { return serviceDates[i].Before(serviceDates[j]) })
+
+	export := &CsvExport{}
+	for _, serviceDate := range serviceDates {
+		tripsB, stopTimesB, err := exportTripsToCsv(serviceDateToTrips[serviceDate])
+		if err != nil {
+			return nil, err
+		}
+		partition := CsvPartition{
+			ServiceDate:  serviceDate,
+			TripsCsv:     tripsB,
+			StopTimesCsv: stopTimesB,
+		}
+		if opts.Partitioning == Partitioning_HiveByServiceDate {
+			dir := "service_date=" + serviceDate.Format("2006-01-02")
+			partition.TripsPath = path.Join(dir, "trips.csv")
+			partition.StopTimesPath = path.Join(dir, "stop_times.csv")
+		} else {
+			suffix := serviceDate.Format("20060102")
+			partition.TripsPath = fmt.Sprintf("trips_%s.csv", suffix)
+			partition.StopTimesPath = fmt.Sprintf("stop_times_%s.csv", suffix)
+		}
+		export.Partitions = append(export.Partitions, partition)
+	}
+	return export, nil
+}
+
+func exportTripsToCsv(trips []Trip) ([]byte, []byte, error) {
 	var tripsB bytes.Buffer
-	err := tripsCsv.Execute(&tripsB, journal.Trips)
+	err := tripsCsv.Execute(&tripsB, trips)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var stopTimesB bytes.Buffer
-	err = stopTimesCsv.Execute(&stopTimesB, journal.Trips)
+	err = stopTimesCsv.Execute(&stopTimesB, trips)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return &CsvExport{
-		TripsCsv:     tripsB.Bytes(),
-		StopTimesCsv: stopTimesB.Bytes(),
-	}, nil
+	return tripsB.Bytes(), stopTimesB.Bytes(), nil
 }
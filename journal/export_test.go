@@ -1,9 +1,11 @@
 package journal
 
 import (
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/jamespfennell/gtfs"
 )
 
@@ -70,3 +72,151 @@ func TestCsvExport(t *testing.T) {
 		t.Errorf("Stop times file actual:\n%s\n!= expected:\n%s\n", got, want)
 	}
 }
+
+func TestCsvExport_Partitioned(t *testing.T) {
+	day1 := trip
+	day1.TripUID = "Day1"
+	day1.StartTime = time.Date(2024, 1, 2, 23, 0, 0, 0, time.UTC)
+	day2 := trip
+	day2.TripUID = "Day2"
+	day2.StartTime = time.Date(2024, 1, 3, 1, 0, 0, 0, time.UTC)
+	journal := Journal{Trips: []Trip{day2, day1}}
+
+	for _, tc := range []struct {
+		opts      CsvExportOptions
+		wantPaths []string
+		wantTrips [][]string
+	}{
+		{
+			opts:      CsvExportOptions{Partitioning: Partitioning_ByServiceDate},
+			wantPaths: []string{"trips_20240102.csv", "stop_times_20240102.csv", "trips_20240103.csv", "stop_times_20240103.csv"},
+			wantTrips: [][]string{{"Day1"}, {"Day2"}},
+		},
+		{
+			opts:      CsvExportOptions{Partitioning: Partitioning_HiveByServiceDate},
+			wantPaths: []string{"service_date=2024-01-02/trips.csv", "service_date=2024-01-02/stop_times.csv", "service_date=2024-01-03/trips.csv", "service_date=2024-01-03/stop_times.csv"},
+			wantTrips: [][]string{{"Day1"}, {"Day2"}},
+		},
+		{
+			opts: CsvExportOptions{
+				Partitioning: Partitioning_ByServiceDate,
+				Timezone:     time.FixedZone("UTC-5", -5*60*60),
+			},
+			wantPaths: []string{"trips_20240102.csv", "stop_times_20240102.csv"},
+			wantTrips: [][]string{{"Day2", "Day1"}},
+		},
+	} {
+		t.Run(tc.opts.Partitioning.String(), func(t *testing.T) {
+			result, err := journal.ExportToCsvWithOptions(tc.opts)
+			if err != nil {
+				t.Fatalf("ExportToCsvWithOptions() failed: %s", err)
+			}
+			var gotPaths []string
+			for _, f := range result.Files() {
+				gotPaths = append(gotPaths, f.Path)
+			}
+			if diff := cmp.Diff(gotPaths, tc.wantPaths); diff != "" {
+				t.Errorf("paths diff: %s", diff)
+			}
+			var gotTrips [][]string
+			for _, partition := range result.Partitions {
+				var tripUIDs []string
+				for _, line := range strings.Split(strings.TrimSpace(string(partition.TripsCsv)), "\n")[1:] {
+					tripUIDs = append(tripUIDs, strings.Split(line, ",")[0])
+				}
+				gotTrips = append(gotTrips, tripUIDs)
+			}
+			if diff := cmp.Diff(gotTrips, tc.wantTrips); diff != "" {
+				t.Errorf("trips diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestGtfsStaticExport(t *testing.T) {
+	journal := Journal{Trips: []Trip{trip}}
+
+	b, err := journal.ExportToGtfsStatic(GtfsStaticExportOptions{})
+	if err != nil {
+		t.Fatalf("ExportToGtfsStatic function failed: %s", err)
+	}
+	static, err := gtfs.ParseStatic(b, gtfs.ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse exported feed: %s", err)
+	}
+
+	if len(static.Trips) != 1 {
+		t.Fatalf("got %d trips, want 1", len(static.Trips))
+	}
+	scheduledTrip := static.Trips[0]
+	if scheduledTrip.ID != "TripUID" || scheduledTrip.Route.Id != "RouteID" || scheduledTrip.DirectionId != gtfs.DirectionID_True {
+		t.Errorf("got trip %+v, want ID=TripUID Route=RouteID DirectionId=True", scheduledTrip)
+	}
+	if scheduledTrip.Route.Type != gtfs.RouteType_Subway {
+		t.Errorf("got route type %s, want %s", scheduledTrip.Route.Type, gtfs.RouteType_Subway)
+	}
+	if !scheduledTrip.Service.RunsOn(time.Unix(100, 0).UTC()) {
+		t.Errorf("service %s does not run on the trip start date", scheduledTrip.Service.Id)
+	}
+	type stopTime struct {
+		stopID    string
+		arrival   time.Duration
+		departure time.Duration
+	}
+	var got []stopTime
+	for _, st := range scheduledTrip.StopTimes {
+		got = append(got, stopTime{st.Stop.Id, st.ArrivalTime, st.DepartureTime})
+	}
+	want := []stopTime{
+		{"StopID1", 200 * time.Second, 200 * time.Second},
+		{"StopID2", 300 * time.Second, 400 * time.Second},
+		{"StopID3", 500 * time.Second, 500 * time.Second},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got stop times %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("stop time %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGtfsStaticExport_DaylightSavingTime(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("failed to load timezone: %s", err)
+	}
+	// Clocks go forward at 2am on 13 March 2022, so 8am is 7 hours after midnight but 8 hours after
+	// noon minus 12h.
+	departure := time.Date(2022, 3, 13, 8, 0, 0, 0, newYork)
+	journal := Journal{Trips: []Trip{
+		{
+			TripUID:   "TripUID",
+			RouteID:   "RouteID",
+			StartTime: departure,
+			StopTimes: []StopTime{{StopID: "StopID", DepartureTime: &departure}},
+		},
+	}}
+
+	b, err := journal.ExportToGtfsStatic(GtfsStaticExportOptions{Timezone: newYork})
+	if err != nil {
+		t.Fatalf("ExportToGtfsStatic function failed: %s", err)
+	}
+	static, err := gtfs.ParseStatic(b, gtfs.ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse exported feed: %s", err)
+	}
+
+	if got := static.Trips[0].StopTimes[0].DepartureTime; got != 8*time.Hour {
+		t.Errorf("got departure time %s, want 8h", got)
+	}
+	service := static.Trips[0].Service
+	if service.Id != "20220313" {
+		t.Errorf("got service ID %s, want 20220313", service.Id)
+	}
+	wantDate := time.Date(2022, 3, 13, 0, 0, 0, 0, newYork)
+	if len(service.AddedDates) != 1 || !service.AddedDates[0].Equal(wantDate) {
+		t.Errorf("got service dates %v, want [%s]", service.AddedDates, wantDate)
+	}
+}
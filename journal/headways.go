@@ -0,0 +1,94 @@
+package journal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+)
+
+// HeadwayReport compares the scheduled and observed headways of a route at a reference stop.
+type HeadwayReport struct {
+	RouteID     string
+	DirectionID gtfs.DirectionID
+	StopID      string
+	Date        time.Time
+	Window      gtfs.TimeWindow
+
+	Scheduled gtfs.HeadwayStats
+	Observed  gtfs.HeadwayStats
+}
+
+// BunchingIndex returns the coefficient of variation of the observed headways divided by that of the
+// scheduled headways.
+//
+// A value close to 1 means service was as regular as planned; larger values indicate bunching.
+// If the scheduled headways are perfectly even, the observed coefficient of variation is returned.
+func (report *HeadwayReport) BunchingIndex() float64 {
+	observed := report.Observed.CoefficientOfVariation()
+	scheduled := report.Scheduled.CoefficientOfVariation()
+	if scheduled == 0 {
+		return observed
+	}
+	return observed / scheduled
+}
+
+// HeadwayReport builds a report of the scheduled and observed headways of the route at the stop.
+//
+// Observed headways are calculated from the departure times of the journal's trips at the stop, falling
+// back to the arrival time if no departure time was observed. Times are measured from midnight of the
+// date in the date's location, which should be the timezone of the static feed.
+func (journal *Journal) HeadwayReport(static *gtfs.Static, routeID string, direction gtfs.DirectionID, stopID string, date time.Time, window gtfs.TimeWindow) (*HeadwayReport, error) {
+	var route *gtfs.Route
+	for i := range static.Routes {
+		if static.Routes[i].Id == routeID {
+			route = &static.Routes[i]
+			break
+		}
+	}
+	if route == nil {
+		return nil, fmt.Errorf("no route with ID %q in the static feed", routeID)
+	}
+	stop := static.StopByID(stopID)
+	if stop == nil {
+		return nil, fmt.Errorf("no stop with ID %q in the static feed", stopID)
+	}
+	scheduled, err := static.HeadwaysAtStop(route, direction, date, stop, window)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceDay := gtfs.ServiceDayStart(date)
+	var departures []time.Duration
+	for i := range journal.Trips {
+		trip := &journal.Trips[i]
+		if trip.RouteID != routeID || trip.DirectionID != direction {
+			continue
+		}
+		for _, stopTime := range trip.StopTimes {
+			if stopTime.StopID != stopID {
+				continue
+			}
+			t := stopTime.DepartureTime
+			if t == nil {
+				t = stopTime.ArrivalTime
+			}
+			if t == nil {
+				continue
+			}
+			if departure := t.Sub(serviceDay); window.Contains(departure) {
+				departures = append(departures, departure)
+			}
+			break
+		}
+	}
+	return &HeadwayReport{
+		RouteID:     routeID,
+		DirectionID: direction,
+		StopID:      stopID,
+		Date:        date,
+		Window:      window,
+		Scheduled:   scheduled,
+		Observed:    gtfs.NewHeadwayStats(departures),
+	}, nil
+}
@@ -0,0 +1,61 @@
+package journal
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+)
+
+func TestHeadwayReport(t *testing.T) {
+	date := time.Date(2022, 5, 4, 0, 0, 0, 0, time.UTC)
+	static := &gtfs.Static{
+		Routes:   []gtfs.Route{{Id: routeID1}},
+		Stops:    []gtfs.Stop{{Id: stopID1}, {Id: stopID2}},
+		Services: []gtfs.Service{{Id: "service", AddedDates: []time.Time{date}}},
+	}
+	for i, departure := range []time.Duration{0, 10 * time.Minute, 20 * time.Minute, 30 * time.Minute} {
+		static.Trips = append(static.Trips, gtfs.ScheduledTrip{
+			ID:          fmt.Sprintf("trip%d", i),
+			Route:       &static.Routes[0],
+			Service:     &static.Services[0],
+			DirectionId: gtfs.DirectionID_True,
+			StopTimes: []gtfs.ScheduledStopTime{
+				{Stop: &static.Stops[0], DepartureTime: 6*time.Hour + departure, StopSequence: 1},
+				{Stop: &static.Stops[1], DepartureTime: 6*time.Hour + 5*time.Minute + departure, StopSequence: 2},
+			},
+		})
+	}
+	journal := &Journal{}
+	for _, departure := range []time.Duration{5 * time.Minute, 13 * time.Minute, 15 * time.Minute, 35 * time.Minute} {
+		departureTime := date.Add(6*time.Hour + departure)
+		journal.Trips = append(journal.Trips, Trip{
+			RouteID:     routeID1,
+			DirectionID: gtfs.DirectionID_True,
+			StopTimes: []StopTime{
+				{StopID: stopID2, ArrivalTime: &departureTime},
+			},
+		})
+	}
+	journal.Trips = append(journal.Trips, Trip{RouteID: routeID1, DirectionID: gtfs.DirectionID_False})
+
+	report, err := journal.HeadwayReport(static, routeID1, gtfs.DirectionID_True, stopID2, date, gtfs.TimeWindow{Start: 6 * time.Hour, End: 7 * time.Hour})
+	if err != nil {
+		t.Fatalf("HeadwayReport() err = %v", err)
+	}
+
+	if report.Scheduled.NumDepartures != 4 || report.Scheduled.Mean != 10*time.Minute || report.Scheduled.StandardDeviation != 0 {
+		t.Errorf("HeadwayReport() scheduled = %+v, want 4 departures every 10 minutes", report.Scheduled)
+	}
+	if report.Observed.NumDepartures != 4 || report.Observed.Min != 2*time.Minute || report.Observed.Max != 20*time.Minute {
+		t.Errorf("HeadwayReport() observed = %+v, want 4 departures with headways between 2 and 20 minutes", report.Observed)
+	}
+	if got := report.BunchingIndex(); got <= 0 {
+		t.Errorf("BunchingIndex() = %v, want > 0", got)
+	}
+
+	if _, err := journal.HeadwayReport(static, "unknown", gtfs.DirectionID_True, stopID2, date, gtfs.TimeWindow{}); err == nil {
+		t.Errorf("HeadwayReport() with unknown route err = nil, want error")
+	}
+}
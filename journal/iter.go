@@ -0,0 +1,19 @@
+//go:build go1.23
+
+package journal
+
+import "iter"
+
+// AllStopTimes returns an iterator over the stop times of all trips in the journal, along with the
+// trip each belongs to.
+func (j *Journal) AllStopTimes() iter.Seq2[Trip, StopTime] {
+	return func(yield func(Trip, StopTime) bool) {
+		for i := range j.Trips {
+			for k := range j.Trips[i].StopTimes {
+				if !yield(j.Trips[i], j.Trips[i].StopTimes[k]) {
+					return
+				}
+			}
+		}
+	}
+}
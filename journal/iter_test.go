@@ -0,0 +1,28 @@
+//go:build go1.23
+
+package journal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAllStopTimes(t *testing.T) {
+	j := &Journal{
+		Trips: []Trip{
+			{TripUID: "a", StopTimes: []StopTime{{StopID: stopID1}, {StopID: stopID2}}},
+			{TripUID: "b", StopTimes: []StopTime{{StopID: stopID3}}},
+		},
+	}
+
+	var got []string
+	for trip, stopTime := range j.AllStopTimes() {
+		got = append(got, trip.TripUID+":"+stopTime.StopID)
+	}
+
+	want := []string{"a:" + stopID1, "a:" + stopID2, "b:" + stopID3}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("AllStopTimes() diff: %s", diff)
+	}
+}
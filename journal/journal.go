@@ -2,6 +2,7 @@
 package journal
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -116,40 +117,81 @@ func (s *DirectoryGtfsrtSource) Next() *gtfs.Realtime {
 }
 
 func BuildJournal(source GtfsrtSource, startTime, endTime time.Time) *Journal {
-	trips := map[string]*Trip{}
-	activeTrips := map[string]bool{}
-	i := 0
+	builder := NewBuilder()
 	for feedMessage := source.Next(); feedMessage != nil; feedMessage = source.Next() {
-		feedMessage := feedMessage
-		createdAt := feedMessage.CreatedAt
-		newActiveTrips := map[string]bool{}
-		for _, tripUpdate := range feedMessage.Trips {
-			startTime := tripUpdate.ID.StartDate.Add(tripUpdate.ID.StartTime)
-			tripUID := fmt.Sprintf("%d%s", startTime.Unix(), tripUpdate.ID.ID[6:])
-			if existingTrip, ok := trips[tripUID]; ok {
-				existingTrip.update(&tripUpdate, createdAt)
-			} else {
-				trip := Trip{
-					// One rewrite+change is expected at the start
-					NumScheduleChanges:  -1,
-					NumScheduleRewrites: -1,
-				}
-				trip.update(&tripUpdate, createdAt)
-				trips[tripUID] = &trip
+		builder.Add(feedMessage)
+	}
+	return builder.Build(startTime, endTime)
+}
+
+// Builder builds a journal incrementally from a stream of GTFS Realtime messages.
+//
+// This is useful when the messages come from polling a feed, as each message can be added as soon
+// as it is received rather than being stored until the journal is built.
+type Builder struct {
+	trips       map[string]*Trip
+	activeTrips map[string]bool
+	// Hash of each active trip as of the last message, used to skip trips that did not change.
+	tripHashes map[string][sha256.Size]byte
+}
+
+func NewBuilder() *Builder {
+	return &Builder{
+		trips:       map[string]*Trip{},
+		activeTrips: map[string]bool{},
+		tripHashes:  map[string][sha256.Size]byte{},
+	}
+}
+
+// Add adds the next GTFS Realtime message to the journal.
+//
+// Messages must be added in the order they were created. A trip that is identical to the same trip
+// in the previous message, as determined by [gtfs.Trip.Hash] and the ID of the trip's vehicle, is
+// not processed again; only its observation metadata is updated. Consecutive messages from a feed
+// typically contain many unchanged trips, so this makes adding a message much cheaper.
+func (b *Builder) Add(feedMessage *gtfs.Realtime) {
+	createdAt := feedMessage.CreatedAt
+	newActiveTrips := map[string]bool{}
+	for i := range feedMessage.Trips {
+		tripUpdate := &feedMessage.Trips[i]
+		startTime := tripUpdate.ID.StartDate.Add(tripUpdate.ID.StartTime)
+		tripUID := fmt.Sprintf("%d%s", startTime.Unix(), tripUpdate.ID.ID[6:])
+		newActiveTrips[tripUID] = true
+		tripHash := hashTrip(tripUpdate)
+		existingTrip, ok := b.trips[tripUID]
+		if !ok {
+			existingTrip = &Trip{
+				// One rewrite+change is expected at the start
+				NumScheduleChanges:  -1,
+				NumScheduleRewrites: -1,
 			}
-			newActiveTrips[tripUID] = true
+			b.trips[tripUID] = existingTrip
+		} else if previousHash, ok := b.tripHashes[tripUID]; ok && previousHash == tripHash && len(tripUpdate.StopTimeUpdates) > 0 {
+			existingTrip.markObserved(len(tripUpdate.StopTimeUpdates), createdAt)
+			continue
 		}
-		for tripUID := range activeTrips {
-			if newActiveTrips[tripUID] {
-				continue
-			}
-			trips[tripUID].markPast(createdAt)
+		if existingTrip.update(tripUpdate, createdAt) {
+			b.tripHashes[tripUID] = tripHash
+		} else {
+			delete(b.tripHashes, tripUID)
+		}
+	}
+	for tripUID := range b.activeTrips {
+		if newActiveTrips[tripUID] {
+			continue
 		}
-		activeTrips = newActiveTrips
-		i++
+		b.trips[tripUID].markPast(createdAt)
+		delete(b.tripHashes, tripUID)
 	}
+	b.activeTrips = newActiveTrips
+}
+
+// Build returns a journal containing the assigned trips that start within the provided time range.
+//
+// More messages can be added after calling Build.
+func (b *Builder) Build(startTime, endTime time.Time) *Journal {
 	var tripIDs []string
-	for tripID, trip := range trips {
+	for tripID, trip := range b.trips {
 		if trip.StartTime.Before(startTime) || endTime.Before(trip.StartTime) {
 			continue
 		}
@@ -163,16 +205,52 @@ func BuildJournal(source GtfsrtSource, startTime, endTime time.Time) *Journal {
 	sort.Strings(tripIDs)
 	j := &Journal{}
 	for _, tripID := range tripIDs {
-		j.Trips = append(j.Trips, *trips[tripID])
+		j.Trips = append(j.Trips, copyTrip(b.trips[tripID]))
 	}
 	return j
 }
 
-func (trip *Trip) update(tripUpdate *gtfs.Trip, feedCreatedAt time.Time) {
+// copyTrip returns a copy of the trip that does not share stop times with the original, which may
+// still be updated by the builder.
+func copyTrip(trip *Trip) Trip {
+	c := *trip
+	c.StopTimes = append([]StopTime(nil), trip.StopTimes...)
+	return c
+}
+
+// hashTrip hashes the parts of the trip update that are used to update the journal.
+func hashTrip(tripUpdate *gtfs.Trip) [sha256.Size]byte {
+	h := sha256.New()
+	tripUpdate.Hash(h)
+	if tripUpdate.Vehicle != nil {
+		h.Write([]byte{1})
+		h.Write([]byte(tripUpdate.Vehicle.GetID().ID))
+	}
+	var result [sha256.Size]byte
+	copy(result[:], h.Sum(nil))
+	return result
+}
+
+// markObserved records that the trip was observed again without changes.
+//
+// This has the same effect as updating the trip with the same update it was last updated with.
+// In this case the last numStopTimeUpdates stop times are exactly the stop times that were updated.
+func (trip *Trip) markObserved(numStopTimeUpdates int, feedCreatedAt time.Time) {
+	trip.LastObserved = feedCreatedAt
+	trip.MarkedPast = nil
+	trip.NumUpdates += 1
+	for i := len(trip.StopTimes) - numStopTimeUpdates; i < len(trip.StopTimes); i++ {
+		trip.StopTimes[i].LastObserved = feedCreatedAt
+		trip.StopTimes[i].MarkedPast = nil
+	}
+}
+
+// update updates the trip using the trip update and returns whether the update was applied.
+func (trip *Trip) update(tripUpdate *gtfs.Trip, feedCreatedAt time.Time) bool {
 	if trip.IsAssigned && tripUpdate.Vehicle == nil {
 		// TODO: this seems to happen a lot, would be nice to figure out what's happening.
 		// log.Printf("skipping unassigned update for assigned trip %s\n", trip.TripUID)
-		return
+		return false
 	}
 	startTime := tripUpdate.ID.StartDate.Add(tripUpdate.ID.StartTime)
 	vehicle := tripUpdate.GetVehicle()
@@ -218,6 +296,7 @@ func (trip *Trip) update(tripUpdate *gtfs.Trip, feedCreatedAt time.Time) {
 	if len(p.new) != 0 {
 		trip.NumScheduleChanges += 1
 	}
+	return true
 }
 
 func (trip *Trip) markPast(feedCreatedAt time.Time) {
@@ -284,7 +363,7 @@ func (stopTime *StopTime) update(stopTimeUpdate *gtfs.StopTimeUpdate, feedCreate
 	stopTime.StopID = *stopTimeUpdate.StopID
 	stopTime.ArrivalTime = stopTimeUpdate.GetArrival().Time
 	stopTime.DepartureTime = stopTimeUpdate.GetDeparture().Time
-	stopTime.Track = stopTimeUpdate.NyctTrack
+	stopTime.Track = stopTimeUpdate.Track
 	stopTime.LastObserved = feedCreatedAt
 	stopTime.MarkedPast = nil
 }
@@ -160,6 +160,71 @@ func TestJournal(t *testing.T) {
 	}
 }
 
+func TestBuilderUnchangedTrip(t *testing.T) {
+	gtfsTrip := addStopTimes(
+		gtfs.Trip{
+			ID: gtfs.TripID{
+				ID:        tripID1,
+				RouteID:   routeID1,
+				StartTime: 100 * time.Second,
+				StartDate: mt(0),
+			},
+			Vehicle: &gtfs.Vehicle{ID: &gtfs.VehicleID{ID: trainID1}},
+		},
+		gtfs.StopTimeUpdate{
+			StopID:  ptr(stopID1),
+			Arrival: &gtfs.StopTimeEvent{Time: mtp(5)},
+		},
+		gtfs.StopTimeUpdate{
+			StopID:  ptr(stopID2),
+			Arrival: &gtfs.StopTimeEvent{Time: mtp(10)},
+		},
+	)
+	laterTrip := addStopTimes(gtfsTrip, gtfsTrip.StopTimeUpdates[1])
+
+	builder := NewBuilder()
+	builder.Add(&gtfs.Realtime{CreatedAt: mt(0), Trips: []gtfs.Trip{gtfsTrip}})
+	builder.Add(&gtfs.Realtime{CreatedAt: mt(1), Trips: []gtfs.Trip{gtfsTrip}})
+	first := builder.Build(time.Unix(0, 0), time.Unix(10000, 0))
+	builder.Add(&gtfs.Realtime{CreatedAt: mt(2), Trips: []gtfs.Trip{laterTrip}})
+	builder.Add(&gtfs.Realtime{CreatedAt: mt(3), Trips: []gtfs.Trip{laterTrip}})
+	second := builder.Build(time.Unix(0, 0), time.Unix(10000, 0))
+
+	trip := Trip{
+		TripUID:    "100_L_1",
+		TripID:     tripID1,
+		RouteID:    routeID1,
+		StartTime:  time.Unix(100, 0).UTC(),
+		VehicleID:  trainID1,
+		IsAssigned: true,
+		StopTimes: []StopTime{
+			{
+				StopID:       stopID1,
+				ArrivalTime:  mtp(5),
+				LastObserved: mt(1),
+			},
+			{
+				StopID:       stopID2,
+				ArrivalTime:  mtp(10),
+				LastObserved: mt(1),
+			},
+		},
+		LastObserved: mt(1),
+		NumUpdates:   2,
+	}
+	if diff := cmp.Diff(first, &Journal{Trips: []Trip{trip}}); diff != "" {
+		t.Errorf("Build() after unchanged message diff: %s", diff)
+	}
+
+	trip.StopTimes[0].MarkedPast = mtp(2)
+	trip.StopTimes[1].LastObserved = mt(3)
+	trip.LastObserved = mt(3)
+	trip.NumUpdates = 4
+	if diff := cmp.Diff(second, &Journal{Trips: []Trip{trip}}); diff != "" {
+		t.Errorf("Build() after changed message diff: %s", diff)
+	}
+}
+
 type testGtfsrtSource struct {
 	feeds []*gtfs.Realtime
 }
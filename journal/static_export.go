@@ -0,0 +1,167 @@
+package journal
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+	"github.com/jamespfennell/gtfs/constants"
+)
+
+// GtfsStaticExportOptions configures the GTFS static export of a journal.
+type GtfsStaticExportOptions struct {
+	// Timezone of the synthetic agency. Observed times are written relative to midnight
+	// of each trip's start date in this timezone. Defaults to UTC.
+	Timezone *time.Location
+
+	// Route type written to routes.txt. If nil, subway is used, as journals are currently
+	// built from NYC subway feeds.
+	RouteType *gtfs.RouteType
+}
+
+const (
+	observedAgencyID   = "observed"
+	observedAgencyName = "Observed service"
+	observedAgencyURL  = "https://gtfs.org"
+)
+
+// ExportToGtfsStatic converts the journal into a synthetic GTFS static feed in which the scheduled
+// times are the observed times. The result is a zip archive that can be compared with the planned
+// schedule using standard GTFS tooling.
+//
+// Each journal trip becomes a trip in trips.txt whose ID is the trip UID, and each observed stop time
+// becomes a row in stop_times.txt. If only one of the arrival and departure times of a stop time was
+// observed it is used for both. A service is created in calendar_dates.txt for each trip start date.
+// Stops and routes are populated with IDs only.
+func (journal *Journal) ExportToGtfsStatic(opts GtfsStaticExportOptions) ([]byte, error) {
+	timezone := opts.Timezone
+	if timezone == nil {
+		timezone = time.UTC
+	}
+	routeType := gtfs.RouteType_Subway
+	if opts.RouteType != nil {
+		routeType = *opts.RouteType
+	}
+
+	routeIDs := map[string]bool{}
+	stopIDs := map[string]bool{}
+	serviceIDs := map[string]bool{}
+	tripRows := [][]string{{"route_id", "service_id", "trip_id", "direction_id"}}
+	stopTimeRows := [][]string{{"trip_id", "arrival_time", "departure_time", "stop_id", "stop_sequence"}}
+	for _, trip := range journal.Trips {
+		startTime := trip.StartTime.In(timezone)
+		serviceID := startOfDay(startTime).Format("20060102")
+		serviceDay := gtfs.ServiceDayStart(startTime)
+		routeIDs[trip.RouteID] = true
+		serviceIDs[serviceID] = true
+		tripRows = append(tripRows, []string{
+			trip.RouteID,
+			serviceID,
+			trip.TripUID,
+			formatDirectionID(trip.DirectionID),
+		})
+		for i, stopTime := range trip.StopTimes {
+			stopIDs[stopTime.StopID] = true
+			arrivalTime, departureTime := stopTime.ArrivalTime, stopTime.DepartureTime
+			if arrivalTime == nil {
+				arrivalTime = departureTime
+			}
+			if departureTime == nil {
+				departureTime = arrivalTime
+			}
+			stopTimeRows = append(stopTimeRows, []string{
+				trip.TripUID,
+				formatGtfsTime(serviceDay, arrivalTime),
+				formatGtfsTime(serviceDay, departureTime),
+				stopTime.StopID,
+				strconv.Itoa(i + 1),
+			})
+		}
+	}
+
+	agencyRows := [][]string{
+		{"agency_id", "agency_name", "agency_url", "agency_timezone"},
+		{observedAgencyID, observedAgencyName, observedAgencyURL, timezone.String()},
+	}
+	routeRows := [][]string{{"route_id", "agency_id", "route_type"}}
+	for _, routeID := range sortedKeys(routeIDs) {
+		routeRows = append(routeRows, []string{routeID, observedAgencyID, strconv.Itoa(int(routeType))})
+	}
+	stopRows := [][]string{{"stop_id", "stop_name"}}
+	for _, stopID := range sortedKeys(stopIDs) {
+		stopRows = append(stopRows, []string{stopID, stopID})
+	}
+	calendarDateRows := [][]string{{"service_id", "date", "exception_type"}}
+	for _, serviceID := range sortedKeys(serviceIDs) {
+		calendarDateRows = append(calendarDateRows, []string{serviceID, serviceID, "1"})
+	}
+
+	var b bytes.Buffer
+	zipWriter := zip.NewWriter(&b)
+	for _, f := range []struct {
+		file constants.StaticFile
+		rows [][]string
+	}{
+		{constants.AgencyFile, agencyRows},
+		{constants.RoutesFile, routeRows},
+		{constants.StopsFile, stopRows},
+		{constants.CalendarDatesFile, calendarDateRows},
+		{constants.TripsFile, tripRows},
+		{constants.StopTimesFile, stopTimeRows},
+	} {
+		w, err := zipWriter.Create(string(f.file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", f.file, err)
+		}
+		csvWriter := csv.NewWriter(w)
+		if err := csvWriter.WriteAll(f.rows); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", f.file, err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// formatGtfsTime formats the time as a GTFS time relative to the service day, which may exceed 24:00:00.
+func formatGtfsTime(serviceDay time.Time, t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	d := t.Sub(serviceDay)
+	if d < 0 {
+		d = 0
+	}
+	return gtfs.FormatGTFSTime(d)
+}
+
+func formatDirectionID(d gtfs.DirectionID) string {
+	switch d {
+	case gtfs.DirectionID_False:
+		return "0"
+	case gtfs.DirectionID_True:
+		return "1"
+	default:
+		return ""
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,151 @@
+package journal
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+)
+
+// TrajectoryPoint is a position of a vehicle at a point in time.
+type TrajectoryPoint struct {
+	Time      time.Time
+	Latitude  float64
+	Longitude float64
+	// True if the point was not observed but was interpolated between two observed points.
+	Interpolated bool
+}
+
+// TrajectoryOptions configures how trajectories are cleaned.
+type TrajectoryOptions struct {
+	// Maximum speed of the vehicle, in meters per second. Observed points that could only be reached
+	// from the previous point by moving faster than this are rejected as GPS outliers.
+	//
+	// If zero, DefaultMaxSpeed is used.
+	MaxSpeed float64
+
+	// If positive, points are interpolated between consecutive observed points that are further
+	// apart in time than this interval, so that the trajectory has at least one point per interval.
+	InterpolationInterval time.Duration
+
+	// If positive, gaps longer than this are not interpolated. This avoids inventing positions
+	// for vehicles that were, for example, out of service.
+	MaxInterpolationGap time.Duration
+}
+
+// DefaultMaxSpeed is the maximum speed used if [TrajectoryOptions.MaxSpeed] is not set, in meters
+// per second. It is comfortably above the top speed of most transit vehicles.
+const DefaultMaxSpeed = 50.0
+
+// BuildTrajectories builds the cleaned trajectories of all vehicles in the messages of the source.
+//
+// The result is keyed by vehicle ID. Vehicles without an ID or position are skipped. The time of each
+// point is the vehicle's timestamp, or the creation time of the message if the vehicle has none.
+// Repeated observations with the same time are only counted once.
+func BuildTrajectories(source GtfsrtSource, opts TrajectoryOptions) map[string][]TrajectoryPoint {
+	vehicleIDToPoints := map[string][]TrajectoryPoint{}
+	for feedMessage := source.Next(); feedMessage != nil; feedMessage = source.Next() {
+		for i := range feedMessage.Vehicles {
+			vehicle := &feedMessage.Vehicles[i]
+			point, ok := newTrajectoryPoint(vehicle, feedMessage.CreatedAt)
+			if !ok {
+				continue
+			}
+			vehicleIDToPoints[vehicle.ID.ID] = append(vehicleIDToPoints[vehicle.ID.ID], point)
+		}
+	}
+	for vehicleID, points := range vehicleIDToPoints {
+		vehicleIDToPoints[vehicleID] = CleanTrajectory(points, opts)
+	}
+	return vehicleIDToPoints
+}
+
+func newTrajectoryPoint(vehicle *gtfs.Vehicle, feedCreatedAt time.Time) (TrajectoryPoint, bool) {
+	if vehicle.ID == nil || vehicle.Position == nil || vehicle.Position.Latitude == nil || vehicle.Position.Longitude == nil {
+		return TrajectoryPoint{}, false
+	}
+	t := feedCreatedAt
+	if vehicle.Timestamp != nil {
+		t = *vehicle.Timestamp
+	}
+	return TrajectoryPoint{
+		Time:      t,
+		Latitude:  float64(*vehicle.Position.Latitude),
+		Longitude: float64(*vehicle.Position.Longitude),
+	}, true
+}
+
+// CleanTrajectory sorts the points by time, rejects outliers and interpolates gaps.
+//
+// Points with the same time as an earlier point are dropped. Outliers are rejected greedily: the
+// first point is assumed to be valid, and each subsequent point is kept only if it can be reached
+// from the last kept point without exceeding the maximum speed.
+func CleanTrajectory(points []TrajectoryPoint, opts TrajectoryOptions) []TrajectoryPoint {
+	maxSpeed := opts.MaxSpeed
+	if maxSpeed <= 0 {
+		maxSpeed = DefaultMaxSpeed
+	}
+	sorted := append([]TrajectoryPoint(nil), points...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	var result []TrajectoryPoint
+	for _, point := range sorted {
+		if len(result) == 0 {
+			result = append(result, point)
+			continue
+		}
+		last := result[len(result)-1]
+		elapsed := point.Time.Sub(last.Time)
+		if elapsed <= 0 {
+			continue
+		}
+		if distance(last.Latitude, last.Longitude, point.Latitude, point.Longitude) > maxSpeed*elapsed.Seconds() {
+			continue
+		}
+		result = appendInterpolated(result, last, point, opts)
+		result = append(result, point)
+	}
+	return result
+}
+
+// appendInterpolated appends the points interpolated strictly between from and to.
+func appendInterpolated(result []TrajectoryPoint, from, to TrajectoryPoint, opts TrajectoryOptions) []TrajectoryPoint {
+	gap := to.Time.Sub(from.Time)
+	if opts.InterpolationInterval <= 0 || gap <= opts.InterpolationInterval {
+		return result
+	}
+	if opts.MaxInterpolationGap > 0 && gap > opts.MaxInterpolationGap {
+		return result
+	}
+	for offset := opts.InterpolationInterval; offset < gap; offset += opts.InterpolationInterval {
+		f := float64(offset) / float64(gap)
+		result = append(result, TrajectoryPoint{
+			Time:         from.Time.Add(offset),
+			Latitude:     from.Latitude + f*(to.Latitude-from.Latitude),
+			Longitude:    from.Longitude + f*(to.Longitude-from.Longitude),
+			Interpolated: true,
+		})
+	}
+	return result
+}
+
+// distance returns the great-circle distance in meters between two locations.
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusMeters = 6371000.0
+	toRadians := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// TrajectoryPolyline returns the positions of the trajectory encoded using [gtfs.EncodePolyline].
+func TrajectoryPolyline(points []TrajectoryPoint) string {
+	shapePoints := make([]gtfs.ShapePoint, len(points))
+	for i, point := range points {
+		shapePoints[i] = gtfs.ShapePoint{Latitude: point.Latitude, Longitude: point.Longitude}
+	}
+	return gtfs.EncodePolyline(shapePoints)
+}
@@ -0,0 +1,83 @@
+package journal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jamespfennell/gtfs"
+)
+
+func TestBuildTrajectories(t *testing.T) {
+	vehicle := func(latitude, longitude float32, timestamp *time.Time) gtfs.Vehicle {
+		return gtfs.Vehicle{
+			ID: &gtfs.VehicleID{ID: trainID1},
+			Position: &gtfs.Position{
+				Latitude:  ptr(latitude),
+				Longitude: ptr(longitude),
+			},
+			Timestamp: timestamp,
+		}
+	}
+	source := &testGtfsrtSource{
+		feeds: []*gtfs.Realtime{
+			{CreatedAt: mt(0), Vehicles: []gtfs.Vehicle{vehicle(40.70, -74.00, nil)}},
+			// Repeated observation
+			{CreatedAt: mt(1), Vehicles: []gtfs.Vehicle{vehicle(40.70, -74.00, mtp(0))}},
+			// Outlier: roughly 110km in 10 minutes
+			{CreatedAt: mt(2), Vehicles: []gtfs.Vehicle{vehicle(41.70, -74.00, nil)}},
+			{CreatedAt: mt(3), Vehicles: []gtfs.Vehicle{vehicle(40.72, -74.00, nil)}},
+			// No position
+			{CreatedAt: mt(4), Vehicles: []gtfs.Vehicle{{ID: &gtfs.VehicleID{ID: trainID1}}}},
+		},
+	}
+
+	got := BuildTrajectories(source, TrajectoryOptions{
+		InterpolationInterval: 10 * time.Minute,
+	})
+
+	want := map[string][]TrajectoryPoint{
+		trainID1: {
+			{Time: mt(0), Latitude: 40.70, Longitude: -74.00},
+			{Time: mt(1), Latitude: 40.7066, Longitude: -74.00, Interpolated: true},
+			{Time: mt(2), Latitude: 40.7133, Longitude: -74.00, Interpolated: true},
+			{Time: mt(3), Latitude: 40.72, Longitude: -74.00},
+		},
+	}
+	if diff := cmp.Diff(got, want, cmpopts.EquateApprox(0, 0.001)); diff != "" {
+		t.Errorf("BuildTrajectories() diff: %s", diff)
+	}
+}
+
+func TestCleanTrajectory_MaxInterpolationGap(t *testing.T) {
+	points := []TrajectoryPoint{
+		{Time: mt(3), Latitude: 40.71, Longitude: -74.00},
+		{Time: mt(0), Latitude: 40.70, Longitude: -74.00},
+	}
+
+	got := CleanTrajectory(points, TrajectoryOptions{
+		InterpolationInterval: 10 * time.Minute,
+		MaxInterpolationGap:   20 * time.Minute,
+	})
+
+	want := []TrajectoryPoint{
+		{Time: mt(0), Latitude: 40.70, Longitude: -74.00},
+		{Time: mt(3), Latitude: 40.71, Longitude: -74.00},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("CleanTrajectory() diff: %s", diff)
+	}
+}
+
+func TestTrajectoryPolyline(t *testing.T) {
+	points := []TrajectoryPoint{
+		{Time: mt(0), Latitude: 38.5, Longitude: -120.2},
+		{Time: mt(1), Latitude: 40.7, Longitude: -120.95},
+		{Time: mt(2), Latitude: 43.252, Longitude: -126.453},
+	}
+
+	if got, want := TrajectoryPolyline(points), "_p~iF~ps|U_ulLnnqC_mqNvxq`@"; got != want {
+		t.Errorf("TrajectoryPolyline() got = %q, want = %q", got, want)
+	}
+}
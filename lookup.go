@@ -0,0 +1,150 @@
+package gtfs
+
+import "sync"
+
+// staticIndex contains lookup maps for entities in a Static message.
+type staticIndex struct {
+	stopsByID   map[string]*Stop
+	stopsByCode map[string]*Stop
+
+	// Map from a root station to all stops descended from it.
+	stopsByRoot map[*Stop][]*Stop
+
+	// Map from a stop to the stops whose parent it is.
+	stopsByParent map[*Stop][]*Stop
+
+	transfersByFromStop map[*Stop][]*Transfer
+
+	// Map from a stop to the pathways that can be used to leave it.
+	pathwaysByFromStop map[*Stop][]*Pathway
+
+	tripsByID map[string]*ScheduledTrip
+
+	// Spatial index of stops with a location.
+	stopsByCell map[spatialCell][]*Stop
+
+	// Map from a stop to the routes whose trips call at it. This requires reading all stop times,
+	// so it is built separately on first use.
+	routesByStopOnce sync.Once
+	routesByStop     map[*Stop][]*Route
+	routesByStopErr  error
+}
+
+// index returns the lookup indices, building them if needed.
+//
+// If the indices are built concurrently by multiple goroutines, each builds its own copy and one of
+// them is kept. The copies are equivalent, so this is only wasted work.
+func (s *Static) index() *staticIndex {
+	if idx, ok := s.idx.Load().(*staticIndex); ok && idx != nil {
+		return idx
+	}
+	idx := &staticIndex{
+		stopsByID:           make(map[string]*Stop, len(s.Stops)),
+		stopsByCode:         map[string]*Stop{},
+		stopsByRoot:         map[*Stop][]*Stop{},
+		stopsByParent:       map[*Stop][]*Stop{},
+		transfersByFromStop: map[*Stop][]*Transfer{},
+		pathwaysByFromStop:  map[*Stop][]*Pathway{},
+		stopsByCell:         map[spatialCell][]*Stop{},
+		tripsByID:           make(map[string]*ScheduledTrip, len(s.Trips)),
+	}
+	for i := range s.Stops {
+		stop := &s.Stops[i]
+		idx.stopsByID[stop.Id] = stop
+		if lat, lon, ok := stop.position(); ok {
+			cell := newSpatialCell(lat, lon)
+			idx.stopsByCell[cell] = append(idx.stopsByCell[cell], stop)
+		}
+		if stop.Parent != nil {
+			root := stop.Root()
+			idx.stopsByRoot[root] = append(idx.stopsByRoot[root], stop)
+			idx.stopsByParent[stop.Parent] = append(idx.stopsByParent[stop.Parent], stop)
+		}
+		if stop.Code == "" {
+			continue
+		}
+		if _, ok := idx.stopsByCode[stop.Code]; !ok {
+			idx.stopsByCode[stop.Code] = stop
+		}
+	}
+	for i := range s.Transfers {
+		transfer := &s.Transfers[i]
+		idx.transfersByFromStop[transfer.From] = append(idx.transfersByFromStop[transfer.From], transfer)
+	}
+	for i := range s.Pathways {
+		pathway := &s.Pathways[i]
+		idx.pathwaysByFromStop[pathway.From] = append(idx.pathwaysByFromStop[pathway.From], pathway)
+		if pathway.IsBidirectional {
+			idx.pathwaysByFromStop[pathway.To] = append(idx.pathwaysByFromStop[pathway.To], pathway)
+		}
+	}
+	for i := range s.Trips {
+		trip := &s.Trips[i]
+		idx.tripsByID[trip.ID] = trip
+	}
+	s.idx.Store(idx)
+	return idx
+}
+
+// InvalidateIndex discards the lookup indices used by methods like [Static.StopByID], so that they
+// are rebuilt on next use.
+//
+// This must be called after modifying a message whose lookup methods have already been called,
+// and must not be called concurrently with the lookup methods.
+func (s *Static) InvalidateIndex() {
+	s.idx.Store((*staticIndex)(nil))
+}
+
+// StopByID returns the stop with the provided stop ID, or nil if there is no such stop.
+//
+// Like the other lookup methods on Static, the lookup maps are built on first use and
+// subsequent changes to the Static are not reflected in them until [Static.InvalidateIndex] is called.
+func (s *Static) StopByID(id string) *Stop {
+	return s.index().stopsByID[id]
+}
+
+// StopByCode returns the stop with the provided stop code, or nil if there is no such stop.
+//
+// Stop codes are the short rider-facing identifiers used in SMS and phone systems, and
+// are not required to be unique. If multiple stops have the same code, the first one
+// in the Stops field is returned.
+func (s *Static) StopByCode(code string) *Stop {
+	if code == "" {
+		return nil
+	}
+	return s.index().stopsByCode[code]
+}
+
+// Children returns the stops whose parent is the stop, in the order they appear in the Stops field
+// of the message.
+//
+// For a station these are its platforms, entrances and exits and generic nodes, and for a platform
+// these are its boarding areas.
+func (stop *Stop) Children(static *Static) []*Stop {
+	return static.index().stopsByParent[stop]
+}
+
+// Entrances returns the entrances and exits of the stop, which is typically a station.
+func (stop *Stop) Entrances(static *Static) []*Stop {
+	return stop.childrenOfType(static, StopType_EntranceOrExit)
+}
+
+// Platforms returns the platforms of the stop, which is typically a station.
+func (stop *Stop) Platforms(static *Static) []*Stop {
+	return stop.childrenOfType(static, StopType_Platform)
+}
+
+func (stop *Stop) childrenOfType(static *Static, stopType StopType) []*Stop {
+	var result []*Stop
+	for _, child := range stop.Children(static) {
+		if child.Type == stopType {
+			result = append(result, child)
+		}
+	}
+	return result
+}
+
+// TripByID returns the trip with the provided trip ID, or nil if there is no such trip.
+func (s *Static) TripByID(id string) *ScheduledTrip {
+	return s.index().tripsByID[id]
+}
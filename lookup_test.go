@@ -0,0 +1,101 @@
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStopLookups(t *testing.T) {
+	static := &Static{
+		Stops: []Stop{
+			{Id: "a", Code: "100"},
+			{Id: "b", Code: "200"},
+			{Id: "c", Code: "200"},
+			{Id: "d"},
+		},
+	}
+	for _, tc := range []struct {
+		desc   string
+		lookup func() *Stop
+		wantID string
+	}{
+		{"by ID", func() *Stop { return static.StopByID("b") }, "b"},
+		{"by ID, missing", func() *Stop { return static.StopByID("e") }, ""},
+		{"by code", func() *Stop { return static.StopByCode("100") }, "a"},
+		{"by code, duplicated", func() *Stop { return static.StopByCode("200") }, "b"},
+		{"by code, empty", func() *Stop { return static.StopByCode("") }, ""},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			stop := tc.lookup()
+			var gotID string
+			if stop != nil {
+				gotID = stop.Id
+			}
+			if gotID != tc.wantID {
+				t.Errorf("got stop %q, want %q", gotID, tc.wantID)
+			}
+		})
+	}
+}
+
+func TestInvalidateIndex(t *testing.T) {
+	static := &Static{
+		Stops: []Stop{{Id: "a"}},
+	}
+	if static.StopByID("a") == nil {
+		t.Fatalf("StopByID(a) = nil, want stop a")
+	}
+	copied := *static
+	copied.Stops = []Stop{{Id: "b"}}
+	copied.InvalidateIndex()
+
+	if got := copied.StopByID("b"); got != &copied.Stops[0] {
+		t.Errorf("StopByID(b) on the copy = %v, want stop b", got)
+	}
+	if got := static.StopByID("a"); got != &static.Stops[0] {
+		t.Errorf("StopByID(a) on the original = %v, want stop a", got)
+	}
+}
+
+func TestStopHierarchy(t *testing.T) {
+	static := &Static{
+		Stops: []Stop{
+			{Id: "station", Type: StopType_Station},
+			{Id: "other_station", Type: StopType_Station},
+		},
+	}
+	station := &static.Stops[0]
+	static.AppendStops(
+		Stop{Id: "platform_1", Type: StopType_Platform, Parent: station},
+		Stop{Id: "entrance_1", Type: StopType_EntranceOrExit, Parent: station},
+		Stop{Id: "node", Type: StopType_GenericNode, Parent: station},
+		Stop{Id: "platform_2", Type: StopType_Platform, Parent: station},
+		Stop{Id: "entrance_2", Type: StopType_EntranceOrExit, Parent: station},
+	)
+	platform := static.StopByID("platform_1")
+	static.AppendStops(
+		Stop{Id: "boarding_area", Type: StopType_BoardingArea, Parent: platform},
+	)
+	station = static.StopByID("station")
+	otherStation := static.StopByID("other_station")
+
+	for _, tc := range []struct {
+		desc string
+		got  []*Stop
+		want []string
+	}{
+		{"children", station.Children(static), []string{"platform_1", "entrance_1", "node", "platform_2", "entrance_2"}},
+		{"children of platform", static.StopByID("platform_1").Children(static), []string{"boarding_area"}},
+		{"entrances", station.Entrances(static), []string{"entrance_1", "entrance_2"}},
+		{"platforms", station.Platforms(static), []string{"platform_1", "platform_2"}},
+		{"no children", otherStation.Children(static), nil},
+		{"no platforms", otherStation.Platforms(static), nil},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if diff := cmp.Diff(stopIDs(tc.got), tc.want); diff != "" {
+				t.Errorf("stop IDs diff: %s", diff)
+			}
+		})
+	}
+}
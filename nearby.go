@@ -0,0 +1,208 @@
+package gtfs
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	earthRadiusMeters = 6371000.0
+	metersPerDegree   = earthRadiusMeters * math.Pi / 180
+	// Size of the cells of the spatial index, in degrees of latitude and longitude.
+	spatialIndexCellSize = 0.01
+)
+
+type spatialCell struct {
+	lat, lon int32
+}
+
+func newSpatialCell(latitude, longitude float64) spatialCell {
+	return spatialCell{
+		lat: int32(math.Floor(latitude / spatialIndexCellSize)),
+		lon: int32(math.Floor(longitude / spatialIndexCellSize)),
+	}
+}
+
+// position returns the location of the stop, or of its closest ancestor with a location.
+func (stop *Stop) position() (float64, float64, bool) {
+	for ; stop != nil; stop = stop.Parent {
+		if stop.Latitude != nil && stop.Longitude != nil {
+			return *stop.Latitude, *stop.Longitude, true
+		}
+	}
+	return 0, 0, false
+}
+
+// NearbyStop is a stop returned by [Static.StopsNear].
+type NearbyStop struct {
+	Stop *Stop
+	// Distance from the queried location to the stop, in meters.
+	Distance float64
+}
+
+// StopsNear returns all stops within the radius, in meters, of the location, ordered by distance.
+//
+// Stops without a location use the location of their closest ancestor station.
+// The stops are found using a spatial index that is built on first use.
+func (s *Static) StopsNear(latitude, longitude, radius float64) []NearbyStop {
+	idx := s.index()
+	latSpan := radius / metersPerDegree
+	lonSpan := 360.0
+	if c := math.Cos(latitude * math.Pi / 180); c > 1e-6 {
+		lonSpan = math.Min(latSpan/c, 360)
+	}
+	minCell := newSpatialCell(latitude-latSpan, longitude-lonSpan)
+	maxCell := newSpatialCell(latitude+latSpan, longitude+lonSpan)
+	var result []NearbyStop
+	// Iterating over the index's cells is faster than iterating over the search area's cells
+	// when the radius is very large.
+	if int64(maxCell.lat-minCell.lat+1)*int64(maxCell.lon-minCell.lon+1) > int64(len(idx.stopsByCell)) {
+		for cell, stops := range idx.stopsByCell {
+			if minCell.lat <= cell.lat && cell.lat <= maxCell.lat && minCell.lon <= cell.lon && cell.lon <= maxCell.lon {
+				result = appendStopsWithin(result, stops, latitude, longitude, radius)
+			}
+		}
+	} else {
+		for lat := minCell.lat; lat <= maxCell.lat; lat++ {
+			for lon := minCell.lon; lon <= maxCell.lon; lon++ {
+				result = appendStopsWithin(result, idx.stopsByCell[spatialCell{lat: lat, lon: lon}], latitude, longitude, radius)
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Distance < result[j].Distance })
+	return result
+}
+
+func appendStopsWithin(result []NearbyStop, stops []*Stop, latitude, longitude, radius float64) []NearbyStop {
+	for _, stop := range stops {
+		lat, lon, _ := stop.position()
+		if d := distance(latitude, longitude, lat, lon); d <= radius {
+			result = append(result, NearbyStop{Stop: stop, Distance: d})
+		}
+	}
+	return result
+}
+
+// distance returns the great-circle distance in meters between two locations.
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	toRadians := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// NearbyDeparture is a scheduled departure returned by [Static.NearbyDepartures].
+type NearbyDeparture struct {
+	Stop *Stop
+	// Distance from the queried location to the stop, in meters.
+	Distance float64
+	Trip     *ScheduledTrip
+	// Stop sequence of the departure within the trip.
+	StopSequence int
+	Time         time.Time
+}
+
+// NearbyDepartures returns the next n scheduled departures, at or after time t, from stops within
+// the radius, in meters, of the location. Departures are ordered by time and then by distance.
+//
+// Scheduled times are interpreted relative to noon minus 12h of the service day in the location of t,
+// which should be the timezone of the feed. Departures of the previous, current and next service days are considered,
+// so trips that run past midnight are included. Trips generated by frequencies.txt are expanded,
+// and stop times at which pickup is not available are skipped.
+func (s *Static) NearbyDepartures(latitude, longitude, radius float64, t time.Time, n int) ([]NearbyDeparture, error) {
+	nearbyStops := s.StopsNear(latitude, longitude, radius)
+	if len(nearbyStops) == 0 || n <= 0 {
+		return nil, nil
+	}
+	stopToDistance := map[*Stop]float64{}
+	for _, nearbyStop := range nearbyStops {
+		stopToDistance[nearbyStop.Stop] = nearbyStop.Distance
+	}
+	y, m, d := t.Date()
+	var serviceDates []time.Time
+	for offset := -1; offset <= 1; offset++ {
+		serviceDates = append(serviceDates, time.Date(y, m, d+offset, 0, 0, 0, 0, t.Location()))
+	}
+	type candidate struct {
+		trip     *ScheduledTrip
+		stopTime ScheduledStopTime
+	}
+	tripIDToTrip := map[string]*ScheduledTrip{}
+	for i := range s.Trips {
+		tripIDToTrip[s.Trips[i].ID] = &s.Trips[i]
+	}
+	var candidates []candidate
+	// First departure of each frequency-based trip, used to convert stop times into offsets.
+	tripToFirstDeparture := map[*ScheduledTrip]time.Duration{}
+	err := s.ForEachStopTime(func(trip ScheduledTrip, stopTime ScheduledStopTime) bool {
+		tripPtr := tripIDToTrip[trip.ID]
+		if tripPtr == nil {
+			return true
+		}
+		if len(tripPtr.Frequencies) > 0 {
+			if first, ok := tripToFirstDeparture[tripPtr]; !ok || stopTime.DepartureTime < first {
+				tripToFirstDeparture[tripPtr] = stopTime.DepartureTime
+			}
+		}
+		if _, ok := stopToDistance[stopTime.Stop]; !ok || stopTime.PickupType == PickupDropOffPolicy_No {
+			return true
+		}
+		candidates = append(candidates, candidate{trip: tripPtr, stopTime: stopTime})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []NearbyDeparture
+	for _, c := range candidates {
+		if c.trip.Service == nil {
+			continue
+		}
+		offsets := []time.Duration{c.stopTime.DepartureTime}
+		if len(c.trip.Frequencies) > 0 {
+			offsets = nil
+			relative := c.stopTime.DepartureTime - tripToFirstDeparture[c.trip]
+			for _, frequency := range c.trip.Frequencies {
+				if frequency.Headway <= 0 {
+					continue
+				}
+				for start := frequency.StartTime; start < frequency.EndTime; start += frequency.Headway {
+					offsets = append(offsets, start+relative)
+				}
+			}
+		}
+		for _, serviceDate := range serviceDates {
+			if !c.trip.Service.RunsOn(serviceDate) {
+				continue
+			}
+			serviceDay := ServiceDayStart(serviceDate)
+			for _, offset := range offsets {
+				departureTime := serviceDay.Add(offset)
+				if departureTime.Before(t) {
+					continue
+				}
+				result = append(result, NearbyDeparture{
+					Stop:         c.stopTime.Stop,
+					Distance:     stopToDistance[c.stopTime.Stop],
+					Trip:         c.trip,
+					StopSequence: c.stopTime.StopSequence,
+					Time:         departureTime,
+				})
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].Time.Equal(result[j].Time) {
+			return result[i].Time.Before(result[j].Time)
+		}
+		return result[i].Distance < result[j].Distance
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result, nil
+}
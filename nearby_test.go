@@ -0,0 +1,148 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStopsNear(t *testing.T) {
+	static := &Static{
+		Stops: []Stop{
+			{Id: "station", Latitude: ptr(40.7359), Longitude: ptr(-73.9911)},
+			{Id: "platform"},
+			{Id: "near", Latitude: ptr(40.7368), Longitude: ptr(-73.9911)},
+			{Id: "far", Latitude: ptr(40.7527), Longitude: ptr(-73.9772)},
+			{Id: "no_location"},
+		},
+	}
+	static.Stops[1].Parent = &static.Stops[0]
+
+	got := static.StopsNear(40.7359, -73.9911, 500)
+
+	var gotIDs []string
+	for _, nearbyStop := range got {
+		gotIDs = append(gotIDs, nearbyStop.Stop.Id)
+	}
+	if want := []string{"station", "platform", "near"}; !cmp.Equal(gotIDs, want) {
+		t.Errorf("StopsNear() got = %v, want = %v", gotIDs, want)
+	}
+	if d := got[2].Distance; d < 95 || d > 105 {
+		t.Errorf("StopsNear() distance got = %f, want approximately 100", d)
+	}
+	if got := static.StopsNear(40.7359, -73.9911, 10000); len(got) != 4 {
+		t.Errorf("StopsNear() with large radius got %d stops, want 4", len(got))
+	}
+}
+
+func TestNearbyDepartures(t *testing.T) {
+	content := newZipBuilder().add(
+		"agency.txt",
+		"agency_name,agency_url,agency_timezone\na,b,UTC",
+	).add(
+		"routes.txt",
+		"route_id,route_type\nroute,3",
+	).add(
+		"stops.txt",
+		"stop_id,stop_lat,stop_lon\n"+
+			"near,40.7359,-73.9911\n"+
+			"nearer,40.7360,-73.9911\n"+
+			"far,40.7527,-73.9772",
+	).add(
+		"calendar.txt",
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n"+
+			"weekday,1,1,1,1,1,0,0,20220502,20220508",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id\n"+
+			"route,weekday,late_night\n"+
+			"route,weekday,morning\n"+
+			"route,weekday,far_away\n"+
+			"route,weekday,frequency",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,arrival_time,departure_time,stop_sequence,pickup_type\n"+
+			"late_night,near,24:30:00,24:30:00,1,0\n"+
+			"morning,near,08:00:00,08:00:00,1,0\n"+
+			"morning,nearer,08:05:00,08:05:00,2,1\n"+
+			"far_away,far,00:45:00,00:45:00,1,0\n"+
+			"frequency,far,00:00:00,00:00:00,1,0\n"+
+			"frequency,nearer,00:10:00,00:10:00,2,0",
+	).add(
+		"frequencies.txt",
+		"trip_id,start_time,end_time,headway_secs\n"+
+			"frequency,00:00:00,01:00:00,1800",
+	).build()
+
+	for _, streamStopTimes := range []bool{false, true} {
+		static, err := ParseStatic(content, ParseStaticOptions{StreamStopTimes: streamStopTimes})
+		if err != nil {
+			t.Fatalf("ParseStatic() err = %v", err)
+		}
+
+		got, err := static.NearbyDepartures(40.7359, -73.9911, 200, time.Date(2022, 5, 4, 0, 10, 0, 0, time.UTC), 3)
+		if err != nil {
+			t.Fatalf("NearbyDepartures() err = %v", err)
+		}
+
+		type departure struct {
+			TripID string
+			StopID string
+			Time   time.Time
+		}
+		var gotDepartures []departure
+		for _, d := range got {
+			gotDepartures = append(gotDepartures, departure{d.Trip.ID, d.Stop.Id, d.Time})
+		}
+		want := []departure{
+			{"frequency", "nearer", time.Date(2022, 5, 4, 0, 10, 0, 0, time.UTC)},
+			// Departs at 24:30:00 on the Tuesday service day.
+			{"late_night", "near", time.Date(2022, 5, 4, 0, 30, 0, 0, time.UTC)},
+			{"frequency", "nearer", time.Date(2022, 5, 4, 0, 40, 0, 0, time.UTC)},
+		}
+		if diff := cmp.Diff(gotDepartures, want); diff != "" {
+			t.Errorf("NearbyDepartures() got = %v, want = %v, diff: %s", gotDepartures, want, diff)
+		}
+	}
+}
+
+func TestNearbyDepartures_DaylightSavingTime(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("failed to load timezone: %s", err)
+	}
+	static, err := ParseStatic(newZipBuilder().add(
+		"agency.txt",
+		"agency_name,agency_url,agency_timezone\na,b,America/New_York",
+	).add(
+		"routes.txt",
+		"route_id,route_type\nroute,3",
+	).add(
+		"stops.txt",
+		"stop_id,stop_lat,stop_lon\nstop,40.7359,-73.9911",
+	).add(
+		"calendar_dates.txt",
+		"service_id,date,exception_type\nsunday,20220313,1",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id\nroute,sunday,trip",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,arrival_time,departure_time,stop_sequence,pickup_type\ntrip,stop,08:00:00,08:00:00,1,0",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("ParseStatic() err = %v", err)
+	}
+
+	// Clocks go forward at 2am on 13 March 2022, so 08:00:00 is 8 hours after noon minus 12h but only
+	// 7 hours after midnight.
+	got, err := static.NearbyDepartures(40.7359, -73.9911, 200, time.Date(2022, 3, 13, 7, 0, 0, 0, newYork), 1)
+	if err != nil {
+		t.Fatalf("NearbyDepartures() err = %v", err)
+	}
+	want := time.Date(2022, 3, 13, 8, 0, 0, 0, newYork)
+	if len(got) != 1 || !got[0].Time.Equal(want) {
+		t.Errorf("NearbyDepartures() got = %v, want one departure at %s", got, want)
+	}
+}
@@ -0,0 +1,191 @@
+package gtfs
+
+import (
+	"fmt"
+	"time"
+
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// PlannedChangeKind is the kind of a planned change to service.
+type PlannedChangeKind int32
+
+const (
+	PlannedChangeKind_Unknown PlannedChangeKind = 0
+	// There is no service on a route.
+	PlannedChangeKind_RouteSuspension PlannedChangeKind = 1
+	// A stop is closed, so no trips serve it.
+	PlannedChangeKind_StopClosure PlannedChangeKind = 2
+)
+
+func (k PlannedChangeKind) String() string {
+	switch k {
+	case PlannedChangeKind_RouteSuspension:
+		return "ROUTE_SUSPENSION"
+	case PlannedChangeKind_StopClosure:
+		return "STOP_CLOSURE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// PlannedChange is a high-level description of a planned change to service, such as one entered
+// into a content management system.
+type PlannedChange struct {
+	// ID of the alert generated for the change. It must be unique within the feed.
+	ID   string
+	Kind PlannedChangeKind
+	// Route that is suspended. Required for route suspensions.
+	RouteID string
+	// Stop that is closed. Required for stop closures.
+	StopID string
+	// The change is in effect between these times, inclusive. A zero start time means the change
+	// is already in effect, and a zero end time means it is in effect until further notice.
+	StartsAt time.Time
+	EndsAt   time.Time
+	// Cause of the change. If unset, UNKNOWN_CAUSE is used.
+	Cause AlertCause
+	// Header of the alert. At least one translation is required.
+	Header      []AlertText
+	Description []AlertText
+	URL         []AlertText
+}
+
+// AlertFeedBuilder converts planned changes to service into a GTFS realtime feed of alerts.
+type AlertFeedBuilder struct {
+	changes []PlannedChange
+}
+
+// NewAlertFeedBuilder returns a builder with no planned changes.
+func NewAlertFeedBuilder() *AlertFeedBuilder {
+	return &AlertFeedBuilder{}
+}
+
+// Add adds planned changes to the builder.
+func (b *AlertFeedBuilder) Add(changes ...PlannedChange) *AlertFeedBuilder {
+	b.changes = append(b.changes, changes...)
+	return b
+}
+
+// SuspendRoute adds a planned change in which there is no service on the route between the
+// provided times.
+func (b *AlertFeedBuilder) SuspendRoute(ID, routeID string, startsAt, endsAt time.Time, header string) *AlertFeedBuilder {
+	return b.Add(PlannedChange{
+		ID:       ID,
+		Kind:     PlannedChangeKind_RouteSuspension,
+		RouteID:  routeID,
+		StartsAt: startsAt,
+		EndsAt:   endsAt,
+		Header:   []AlertText{{Text: header}},
+	})
+}
+
+// CloseStop adds a planned change in which the stop is closed between the provided times.
+func (b *AlertFeedBuilder) CloseStop(ID, stopID string, startsAt, endsAt time.Time, header string) *AlertFeedBuilder {
+	return b.Add(PlannedChange{
+		ID:       ID,
+		Kind:     PlannedChangeKind_StopClosure,
+		StopID:   stopID,
+		StartsAt: startsAt,
+		EndsAt:   endsAt,
+		Header:   []AlertText{{Text: header}},
+	})
+}
+
+// Build returns a full dataset GTFS realtime message with one alert entity per planned change, in
+// the order the changes were added. Both kinds of change have the NO_SERVICE effect.
+//
+// An error is returned if a change is not valid: its ID is empty or repeated, its kind is unknown,
+// the route or stop ID its kind requires is empty, it has no header, or it ends before it starts.
+func (b *AlertFeedBuilder) Build(createdAt time.Time) (*gtfsrt.FeedMessage, error) {
+	feedMessage := &gtfsrt.FeedMessage{
+		Header: &gtfsrt.FeedHeader{
+			GtfsRealtimeVersion: proto.String("2.0"),
+			Incrementality:      gtfsrt.FeedHeader_FULL_DATASET.Enum(),
+			Timestamp:           proto.Uint64(uint64(createdAt.Unix())),
+		},
+	}
+	seenIDs := map[string]bool{}
+	for i := range b.changes {
+		change := &b.changes[i]
+		if change.ID == "" {
+			return nil, fmt.Errorf("planned change %d has no ID", i)
+		}
+		if seenIDs[change.ID] {
+			return nil, fmt.Errorf("planned change %q: ID is repeated", change.ID)
+		}
+		seenIDs[change.ID] = true
+		alert, err := change.alert()
+		if err != nil {
+			return nil, fmt.Errorf("planned change %q: %w", change.ID, err)
+		}
+		feedMessage.Entity = append(feedMessage.Entity, &gtfsrt.FeedEntity{
+			Id:    proto.String(change.ID),
+			Alert: alert,
+		})
+	}
+	return feedMessage, nil
+}
+
+func (change *PlannedChange) alert() (*gtfsrt.Alert, error) {
+	var informedEntity gtfsrt.EntitySelector
+	switch change.Kind {
+	case PlannedChangeKind_RouteSuspension:
+		if change.RouteID == "" {
+			return nil, fmt.Errorf("route suspension has no route ID")
+		}
+		informedEntity.RouteId = proto.String(change.RouteID)
+	case PlannedChangeKind_StopClosure:
+		if change.StopID == "" {
+			return nil, fmt.Errorf("stop closure has no stop ID")
+		}
+		informedEntity.StopId = proto.String(change.StopID)
+	default:
+		return nil, fmt.Errorf("unknown kind %s", change.Kind)
+	}
+	if len(change.Header) == 0 {
+		return nil, fmt.Errorf("no header")
+	}
+	if !change.StartsAt.IsZero() && !change.EndsAt.IsZero() && change.EndsAt.Before(change.StartsAt) {
+		return nil, fmt.Errorf("ends at %s, before it starts at %s", change.EndsAt, change.StartsAt)
+	}
+	cause := change.Cause
+	if cause == 0 {
+		cause = UnknownCause
+	}
+	alert := &gtfsrt.Alert{
+		InformedEntity:  []*gtfsrt.EntitySelector{&informedEntity},
+		Cause:           cause.Enum(),
+		Effect:          NoService.Enum(),
+		HeaderText:      buildTranslatedString(change.Header),
+		DescriptionText: buildTranslatedString(change.Description),
+		Url:             buildTranslatedString(change.URL),
+	}
+	if !change.StartsAt.IsZero() || !change.EndsAt.IsZero() {
+		var activePeriod gtfsrt.TimeRange
+		if !change.StartsAt.IsZero() {
+			activePeriod.Start = proto.Uint64(uint64(change.StartsAt.Unix()))
+		}
+		if !change.EndsAt.IsZero() {
+			activePeriod.End = proto.Uint64(uint64(change.EndsAt.Unix()))
+		}
+		alert.ActivePeriod = []*gtfsrt.TimeRange{&activePeriod}
+	}
+	return alert, nil
+}
+
+func buildTranslatedString(texts []AlertText) *gtfsrt.TranslatedString {
+	if len(texts) == 0 {
+		return nil
+	}
+	ts := &gtfsrt.TranslatedString{}
+	for _, text := range texts {
+		translation := &gtfsrt.TranslatedString_Translation{Text: proto.String(text.Text)}
+		if text.Language != "" {
+			translation.Language = proto.String(text.Language)
+		}
+		ts.Translation = append(ts.Translation, translation)
+	}
+	return ts
+}
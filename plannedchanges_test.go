@@ -0,0 +1,122 @@
+package gtfs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestAlertFeedBuilder(t *testing.T) {
+	feedMessage, err := gtfs.NewAlertFeedBuilder().
+		SuspendRoute("suspension", "A", time1, time2, "No A trains").
+		CloseStop("closure", stopID1, time1, time.Time{}, "Station closed").
+		Add(gtfs.PlannedChange{
+			ID:          "construction",
+			Kind:        gtfs.PlannedChangeKind_StopClosure,
+			StopID:      stopID2,
+			Cause:       gtfs.Construction,
+			Header:      []gtfs.AlertText{{Text: "Closed", Language: "en"}, {Text: "Cerrada", Language: "es"}},
+			Description: []gtfs.AlertText{{Text: "Closed for construction"}},
+		}).
+		Build(createTime)
+	if err != nil {
+		t.Fatalf("Build() err = %v", err)
+	}
+	b, err := proto.Marshal(feedMessage)
+	if err != nil {
+		t.Fatalf("failed to marshal feed message: %s", err)
+	}
+	realtime, err := gtfs.ParseRealtime(b, &gtfs.ParseRealtimeOptions{Strictness: gtfs.Strictness_Strict})
+	if err != nil {
+		t.Fatalf("failed to parse feed message: %s", err)
+	}
+
+	if !realtime.CreatedAt.Equal(createTime) {
+		t.Errorf("CreatedAt = %s, want %s", realtime.CreatedAt, createTime)
+	}
+	want := []gtfs.Alert{
+		{
+			ID:            "suspension",
+			Cause:         gtfs.UnknownCause,
+			Effect:        gtfs.NoService,
+			ActivePeriods: []gtfs.AlertActivePeriod{{StartsAt: ptr(time1), EndsAt: ptr(time2)}},
+			InformedEntities: []gtfs.AlertInformedEntity{
+				{RouteID: ptr("A"), RouteType: gtfs.RouteType_Unknown},
+			},
+			Header: []gtfs.AlertText{{Text: "No A trains"}},
+		},
+		{
+			ID:            "closure",
+			Cause:         gtfs.UnknownCause,
+			Effect:        gtfs.NoService,
+			ActivePeriods: []gtfs.AlertActivePeriod{{StartsAt: ptr(time1)}},
+			InformedEntities: []gtfs.AlertInformedEntity{
+				{StopID: ptr(stopID1), RouteType: gtfs.RouteType_Unknown},
+			},
+			Header: []gtfs.AlertText{{Text: "Station closed"}},
+		},
+		{
+			ID:     "construction",
+			Cause:  gtfs.Construction,
+			Effect: gtfs.NoService,
+			InformedEntities: []gtfs.AlertInformedEntity{
+				{StopID: ptr(stopID2), RouteType: gtfs.RouteType_Unknown},
+			},
+			Header:      []gtfs.AlertText{{Text: "Closed", Language: "en"}, {Text: "Cerrada", Language: "es"}},
+			Description: []gtfs.AlertText{{Text: "Closed for construction"}},
+		},
+	}
+	if diff := cmp.Diff(realtime.Alerts, want); diff != "" {
+		t.Errorf("alerts diff: %s", diff)
+	}
+}
+
+func TestAlertFeedBuilder_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		changes []gtfs.PlannedChange
+	}{
+		{
+			name:    "no ID",
+			changes: []gtfs.PlannedChange{{Kind: gtfs.PlannedChangeKind_RouteSuspension, RouteID: "A", Header: []gtfs.AlertText{{Text: "A"}}}},
+		},
+		{
+			name: "repeated ID",
+			changes: []gtfs.PlannedChange{
+				{ID: "1", Kind: gtfs.PlannedChangeKind_RouteSuspension, RouteID: "A", Header: []gtfs.AlertText{{Text: "A"}}},
+				{ID: "1", Kind: gtfs.PlannedChangeKind_RouteSuspension, RouteID: "B", Header: []gtfs.AlertText{{Text: "B"}}},
+			},
+		},
+		{
+			name:    "unknown kind",
+			changes: []gtfs.PlannedChange{{ID: "1", RouteID: "A", Header: []gtfs.AlertText{{Text: "A"}}}},
+		},
+		{
+			name:    "no route ID",
+			changes: []gtfs.PlannedChange{{ID: "1", Kind: gtfs.PlannedChangeKind_RouteSuspension, Header: []gtfs.AlertText{{Text: "A"}}}},
+		},
+		{
+			name:    "no stop ID",
+			changes: []gtfs.PlannedChange{{ID: "1", Kind: gtfs.PlannedChangeKind_StopClosure, Header: []gtfs.AlertText{{Text: "A"}}}},
+		},
+		{
+			name:    "no header",
+			changes: []gtfs.PlannedChange{{ID: "1", Kind: gtfs.PlannedChangeKind_RouteSuspension, RouteID: "A"}},
+		},
+		{
+			name: "ends before it starts",
+			changes: []gtfs.PlannedChange{
+				{ID: "1", Kind: gtfs.PlannedChangeKind_RouteSuspension, RouteID: "A", StartsAt: time2, EndsAt: time1, Header: []gtfs.AlertText{{Text: "A"}}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := gtfs.NewAlertFeedBuilder().Add(tc.changes...).Build(createTime); err == nil {
+				t.Errorf("Build() err = nil, want error")
+			}
+		})
+	}
+}
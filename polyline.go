@@ -0,0 +1,102 @@
+package gtfs
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// polylinePrecision is the factor by which coordinates are multiplied before being encoded,
+// corresponding to 5 decimal places.
+const polylinePrecision = 1e5
+
+// EncodePolyline encodes the latitudes and longitudes of the points using Google's encoded polyline
+// algorithm format, which is used by most map SDKs and by the shape entity of GTFS realtime.
+//
+// Coordinates are rounded to 5 decimal places. The distances of the points are not encoded.
+func EncodePolyline(points []ShapePoint) string {
+	var b strings.Builder
+	var previousLat, previousLon int64
+	for _, point := range points {
+		lat := int64(math.Round(point.Latitude * polylinePrecision))
+		lon := int64(math.Round(point.Longitude * polylinePrecision))
+		encodePolylineValue(&b, lat-previousLat)
+		encodePolylineValue(&b, lon-previousLon)
+		previousLat, previousLon = lat, lon
+	}
+	return b.String()
+}
+
+func encodePolylineValue(b *strings.Builder, v int64) {
+	u := uint64(v) << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		b.WriteByte(byte(0x20|(u&0x1f)) + 63)
+		u >>= 5
+	}
+	b.WriteByte(byte(u) + 63)
+}
+
+// DecodePolyline decodes points encoded using Google's encoded polyline algorithm format.
+//
+// The distances of the returned points are nil.
+func DecodePolyline(s string) ([]ShapePoint, error) {
+	var points []ShapePoint
+	var lat, lon int64
+	for i := 0; i < len(s); {
+		var dLat, dLon int64
+		var err error
+		if dLat, i, err = decodePolylineValue(s, i); err != nil {
+			return nil, err
+		}
+		if i == len(s) {
+			return nil, fmt.Errorf("polyline ends with a latitude but no longitude")
+		}
+		if dLon, i, err = decodePolylineValue(s, i); err != nil {
+			return nil, err
+		}
+		lat += dLat
+		lon += dLon
+		points = append(points, ShapePoint{
+			Latitude:  float64(lat) / polylinePrecision,
+			Longitude: float64(lon) / polylinePrecision,
+		})
+	}
+	return points, nil
+}
+
+// decodePolylineValue decodes the value starting at index i and returns it along with the index of
+// the next value.
+func decodePolylineValue(s string, i int) (int64, int, error) {
+	var u uint64
+	for shift := uint(0); ; shift += 5 {
+		if i >= len(s) {
+			return 0, i, fmt.Errorf("polyline ends in the middle of a value")
+		}
+		c := s[i]
+		if c < 63 || c > 63+0x3f {
+			return 0, i, fmt.Errorf("invalid character %q at index %d of polyline", c, i)
+		}
+		if shift > 60 {
+			return 0, i, fmt.Errorf("value at index %d of polyline is too large", i)
+		}
+		i++
+		chunk := uint64(c - 63)
+		u |= (chunk & 0x1f) << shift
+		if chunk < 0x20 {
+			break
+		}
+	}
+	v := int64(u >> 1)
+	if u&1 != 0 {
+		v = ^v
+	}
+	return v, i, nil
+}
+
+// EncodedPolyline returns the points of the shape encoded using [EncodePolyline].
+func (shape *Shape) EncodedPolyline() string {
+	return EncodePolyline(shape.Points)
+}
@@ -0,0 +1,44 @@
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPolyline(t *testing.T) {
+	// Example from the documentation of the encoded polyline algorithm format.
+	points := []ShapePoint{
+		{Latitude: 38.5, Longitude: -120.2},
+		{Latitude: 40.7, Longitude: -120.95},
+		{Latitude: 43.252, Longitude: -126.453},
+	}
+	want := "_p~iF~ps|U_ulLnnqC_mqNvxq`@"
+
+	shape := Shape{Points: points}
+	if got := shape.EncodedPolyline(); got != want {
+		t.Errorf("EncodedPolyline() got = %q, want = %q", got, want)
+	}
+	got, err := DecodePolyline(want)
+	if err != nil {
+		t.Fatalf("DecodePolyline() err = %v", err)
+	}
+	if diff := cmp.Diff(got, points); diff != "" {
+		t.Errorf("DecodePolyline() diff: %s", diff)
+	}
+	if got := EncodePolyline(nil); got != "" {
+		t.Errorf("EncodePolyline(nil) got = %q, want empty", got)
+	}
+}
+
+func TestDecodePolyline_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"_p~iF",
+		"_p~i",
+		"_p~iF~ps| ",
+	} {
+		if _, err := DecodePolyline(s); err == nil {
+			t.Errorf("DecodePolyline(%q) err = nil, want error", s)
+		}
+	}
+}
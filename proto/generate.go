@@ -0,0 +1,10 @@
+package proto
+
+// The Go code in this package is generated from the .proto files using buf, configured by the
+// buf.yaml and buf.gen.yaml files in the repository root. To regenerate it after changing a .proto
+// file, install buf and protoc-gen-go and run go generate in this directory.
+//
+// Vendor extensions that are not in this package can be read at runtime without regenerating; see
+// extensions.LoadProtoExtensionTypes.
+
+//go:generate sh -c "cd .. && buf generate"
@@ -5,14 +5,22 @@ import (
 	"regexp"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jamespfennell/gtfs/extensions"
 	gtfsrt "github.com/jamespfennell/gtfs/proto"
+	"github.com/jamespfennell/gtfs/warnings"
+	"golang.org/x/text/language"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
 // Realtime contains the parsed content for a single GTFS realtime message.
+//
+// A message returned by [ParseRealtime] is owned by the caller and may be modified. As with [Static],
+// a message that is shared must not be modified; use [Realtime.DeepClone] to take a private copy.
 type Realtime struct {
 	CreatedAt time.Time
 
@@ -21,6 +29,9 @@ type Realtime struct {
 	Vehicles []Vehicle
 
 	Alerts []Alert
+
+	// Warnings raised during GTFS realtime parsing.
+	Warnings []warnings.RealtimeWarning
 }
 
 type Trip struct {
@@ -29,7 +40,22 @@ type Trip struct {
 
 	Vehicle *Vehicle
 
+	// Time at which the trip update was measured, if the feed provides it.
+	//
+	// If the trip also has a vehicle, the vehicle has its own timestamp. Use [Trip.LatestTimestamp]
+	// to get the time of the freshest data about the trip.
+	Timestamp *time.Time
+
 	IsEntityInMessage bool
+
+	// True if the link to the vehicle was inferred using [ParseRealtimeOptions.InferVehicleTrips].
+	IsVehicleInferred bool
+
+	// Agency specific data attached to the trip by the GTFS realtime extension.
+	//
+	// The type of the data depends on the extension; extension packages provide typed accessors.
+	// For example, see nycttrips.GetTripData.
+	ExtensionData any
 }
 
 func (trip *Trip) GetVehicle() Vehicle {
@@ -39,6 +65,22 @@ func (trip *Trip) GetVehicle() Vehicle {
 	return Vehicle{}
 }
 
+// LatestTimestamp returns the later of the timestamps of the trip update and its vehicle, or false if
+// neither has a timestamp.
+func (trip *Trip) LatestTimestamp() (time.Time, bool) {
+	var latest *time.Time
+	if trip != nil {
+		latest = trip.Timestamp
+		if trip.Vehicle != nil && trip.Vehicle.Timestamp != nil && (latest == nil || trip.Vehicle.Timestamp.After(*latest)) {
+			latest = trip.Vehicle.Timestamp
+		}
+	}
+	if latest == nil {
+		return time.Time{}, false
+	}
+	return *latest, true
+}
+
 type TripScheduleRelationship = gtfsrt.TripDescriptor_ScheduleRelationship
 
 type TripID struct {
@@ -81,6 +123,66 @@ func (t1 TripID) Less(t2 TripID) bool {
 	return t1.ScheduleRelationship < t2.ScheduleRelationship
 }
 
+// IsFullySpecified returns true if the trip ID uniquely identifies a trip instance.
+//
+// This is the case if either the trip ID field is populated, or the route ID, direction ID,
+// start time and start date are all populated.
+func (t TripID) IsFullySpecified() bool {
+	return t.ID != "" || (t.RouteID != "" && t.DirectionID != DirectionID_Unspecified && t.HasStartTime && t.HasStartDate)
+}
+
+// MatchesScheduled returns true if the trip ID is consistent with the provided scheduled trip running
+// on the provided service date.
+//
+// Each populated field of the trip ID is checked against the scheduled trip; unpopulated fields match anything.
+// If the trip ID has a start date, it is used as the service date instead of the provided date.
+// If the trip ID has a start time, it must be the first departure time of the scheduled trip or,
+// for frequency-based trips, lie within one of the trip's frequency windows.
+func (t TripID) MatchesScheduled(trip *ScheduledTrip, date time.Time) bool {
+	if trip == nil {
+		return false
+	}
+	if t.ID != "" && t.ID != trip.ID {
+		return false
+	}
+	if t.RouteID != "" && (trip.Route == nil || t.RouteID != trip.Route.Id) {
+		return false
+	}
+	if t.DirectionID != DirectionID_Unspecified && trip.DirectionId != DirectionID_Unspecified && t.DirectionID != trip.DirectionId {
+		return false
+	}
+	if t.HasStartDate {
+		date = t.StartDate
+	}
+	if trip.Service != nil && !trip.Service.RunsOn(date) {
+		return false
+	}
+	if t.HasStartTime && !scheduledTripStartsAt(trip, t.StartTime) {
+		return false
+	}
+	return true
+}
+
+func scheduledTripStartsAt(trip *ScheduledTrip, startTime time.Duration) bool {
+	if len(trip.Frequencies) > 0 {
+		for _, frequency := range trip.Frequencies {
+			if startTime < frequency.StartTime || frequency.EndTime < startTime {
+				continue
+			}
+			if frequency.ExactTimes == ScheduleBased && frequency.Headway > 0 && (startTime-frequency.StartTime)%frequency.Headway != 0 {
+				continue
+			}
+			return true
+		}
+		return false
+	}
+	if len(trip.StopTimes) == 0 {
+		// Without stop times there is nothing to check against.
+		return true
+	}
+	return trip.StopTimes[0].DepartureTime == startTime
+}
+
 type StopTimeUpdateScheduleRelationship = gtfsrt.TripUpdate_StopTimeUpdate_ScheduleRelationship
 
 // TODO: shouldn't this just be StopTime?
@@ -89,8 +191,20 @@ type StopTimeUpdate struct {
 	StopID               *string
 	Arrival              *StopTimeEvent
 	Departure            *StopTimeEvent
-	NyctTrack            *string
 	ScheduleRelationship StopTimeUpdateScheduleRelationship
+
+	// ID of the stop the vehicle has been assigned to, if it differs from the scheduled stop.
+	AssignedStopID *string
+
+	// Track or platform at which the vehicle will stop.
+	//
+	// This is populated by the GTFS realtime extension, if it provides tracks. Otherwise, if the stop time
+	// update has an assigned stop and [ParseRealtimeOptions.Static] is set, it is the platform code of
+	// the assigned stop.
+	Track *string
+
+	// Deprecated: use Track. This field is populated with the same value.
+	NyctTrack *string
 }
 
 func (stopTimeUpdate *StopTimeUpdate) GetArrival() StopTimeEvent {
@@ -113,10 +227,77 @@ type StopTimeEvent struct {
 	Uncertainty *int32
 }
 
+// TripUncertainty summarizes the uncertainty of the predictions in a trip.
+type TripUncertainty struct {
+	// Number of stop time events with an uncertainty value.
+	NumEvents int
+	// Largest uncertainty value. Zero if NumEvents is zero.
+	Max int32
+	// Mean uncertainty value. Zero if NumEvents is zero.
+	Mean float64
+}
+
+// Uncertainty returns aggregate statistics of the uncertainty values of the arrival and departure
+// events of the trip. Events without an uncertainty value are ignored.
+func (trip *Trip) Uncertainty() TripUncertainty {
+	var result TripUncertainty
+	var sum int64
+	for i := range trip.StopTimeUpdates {
+		for _, event := range []*StopTimeEvent{trip.StopTimeUpdates[i].Arrival, trip.StopTimeUpdates[i].Departure} {
+			if event == nil || event.Uncertainty == nil {
+				continue
+			}
+			if result.NumEvents == 0 || *event.Uncertainty > result.Max {
+				result.Max = *event.Uncertainty
+			}
+			sum += int64(*event.Uncertainty)
+			result.NumEvents++
+		}
+	}
+	if result.NumEvents > 0 {
+		result.Mean = float64(sum) / float64(result.NumEvents)
+	}
+	return result
+}
+
+// StopTimeUpdatesWithinUncertainty returns the stop time updates of the trip with all arrival and departure
+// events whose uncertainty exceeds maxUncertainty removed.
+//
+// Events without an uncertainty value are kept. Stop time updates left with no events are dropped
+// unless they carry other information, like a skipped stop. The trip itself is not modified.
+func (trip *Trip) StopTimeUpdatesWithinUncertainty(maxUncertainty int32) []StopTimeUpdate {
+	exceeds := func(event *StopTimeEvent) bool {
+		return event != nil && event.Uncertainty != nil && *event.Uncertainty > maxUncertainty
+	}
+	var result []StopTimeUpdate
+	for _, stopTimeUpdate := range trip.StopTimeUpdates {
+		hadEvent := stopTimeUpdate.Arrival != nil || stopTimeUpdate.Departure != nil
+		if exceeds(stopTimeUpdate.Arrival) {
+			stopTimeUpdate.Arrival = nil
+		}
+		if exceeds(stopTimeUpdate.Departure) {
+			stopTimeUpdate.Departure = nil
+		}
+		if hadEvent && stopTimeUpdate.Arrival == nil && stopTimeUpdate.Departure == nil &&
+			stopTimeUpdate.ScheduleRelationship == gtfsrt.TripUpdate_StopTimeUpdate_SCHEDULED {
+			continue
+		}
+		result = append(result, stopTimeUpdate)
+	}
+	return result
+}
+
 type VehicleID struct {
 	ID           string
 	Label        string
 	LicensePlate string
+
+	// Whether each field was present in the vehicle descriptor.
+	//
+	// These distinguish a field that is absent from one that is present but empty.
+	HasID           bool
+	HasLabel        bool
+	HasLicensePlate bool
 }
 
 type Position struct {
@@ -139,6 +320,7 @@ type Position struct {
 type CurrentStatus = gtfsrt.VehiclePosition_VehicleStopStatus
 type CongestionLevel = gtfsrt.VehiclePosition_CongestionLevel
 type OccupancyStatus = gtfsrt.VehiclePosition_OccupancyStatus
+type WheelchairAccessible = gtfsrt.VehicleDescriptor_WheelchairAccessible
 
 type Vehicle struct {
 	ID *VehicleID
@@ -161,7 +343,14 @@ type Vehicle struct {
 
 	OccupancyPercentage *uint32
 
+	// Wheelchair accessibility of the vehicle, from the vehicle descriptor of either a vehicle
+	// position or a trip update. This is nil if no descriptor of the vehicle specifies it.
+	WheelchairAccessible *WheelchairAccessible
+
 	IsEntityInMessage bool
+
+	// True if the link to the trip was inferred using [ParseRealtimeOptions.InferVehicleTrips].
+	IsTripInferred bool
 }
 
 func (vehicle *Vehicle) GetID() VehicleID {
@@ -188,6 +377,38 @@ type Alert struct {
 	URL              []AlertText
 }
 
+// IsActiveAt returns true if the alert is active at the provided time.
+//
+// An alert with no active periods is always active. Otherwise the alert is active if the time lies
+// within one of its active periods. An active period with no start time extends infinitely into the past,
+// and one with no end time extends infinitely into the future. Both ends of a period are inclusive.
+func (alert *Alert) IsActiveAt(t time.Time) bool {
+	if len(alert.ActivePeriods) == 0 {
+		return true
+	}
+	for _, activePeriod := range alert.ActivePeriods {
+		if activePeriod.StartsAt != nil && t.Before(*activePeriod.StartsAt) {
+			continue
+		}
+		if activePeriod.EndsAt != nil && t.After(*activePeriod.EndsAt) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// ActiveAlerts returns the alerts in the message that are active at the provided time.
+func (realtime *Realtime) ActiveAlerts(t time.Time) []Alert {
+	var alerts []Alert
+	for i := range realtime.Alerts {
+		if realtime.Alerts[i].IsActiveAt(t) {
+			alerts = append(alerts, realtime.Alerts[i])
+		}
+	}
+	return alerts
+}
+
 type AlertCause = gtfsrt.Alert_Cause
 
 const (
@@ -234,11 +455,241 @@ type AlertInformedEntity struct {
 	DirectionID DirectionID
 	TripID      *TripID
 	StopID      *string
+
+	// Static entities referenced by the IDs above, or nil if the ID is not set or not in the static message.
+	//
+	// These are only populated by [Realtime.ResolveAlerts].
+	Agency *Agency
+	Route  *Route
+	Stop   *Stop
+	Trip   *ScheduledTrip
+}
+
+// InformedEntityScope describes the entities that an informed entity of an alert applies to.
+type InformedEntityScope int32
+
+const (
+	// The informed entity does not specify any entity.
+	InformedEntityScope_Unknown InformedEntityScope = 0
+	// The informed entity only specifies an agency, and so applies to all service of the agency.
+	InformedEntityScope_AgencyWide InformedEntityScope = 1
+	// The informed entity specifies a route type but no route, stop or trip, and so applies to all
+	// routes of that type.
+	InformedEntityScope_RouteTypeScoped InformedEntityScope = 2
+	// The informed entity specifies a route but no stop or trip.
+	InformedEntityScope_RouteScoped InformedEntityScope = 3
+	// The informed entity specifies a stop but no trip. It may also specify a route, in which case it
+	// only applies to the stop when served by the route.
+	InformedEntityScope_StopScoped InformedEntityScope = 4
+	// The informed entity specifies a trip.
+	InformedEntityScope_TripScoped InformedEntityScope = 5
+)
+
+func (s InformedEntityScope) String() string {
+	switch s {
+	case InformedEntityScope_AgencyWide:
+		return "AGENCY_WIDE"
+	case InformedEntityScope_RouteTypeScoped:
+		return "ROUTE_TYPE_SCOPED"
+	case InformedEntityScope_RouteScoped:
+		return "ROUTE_SCOPED"
+	case InformedEntityScope_StopScoped:
+		return "STOP_SCOPED"
+	case InformedEntityScope_TripScoped:
+		return "TRIP_SCOPED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Scope returns the most specific kind of entity that the informed entity specifies.
+//
+// In GTFS Realtime the fields of an informed entity are combined using a logical AND, so an
+// informed entity that only has an agency ID applies to everything the agency operates.
+// A trip descriptor that does not uniquely identify a trip is ignored.
+func (e *AlertInformedEntity) Scope() InformedEntityScope {
+	switch {
+	case tripIDUniquelyIdentifiesTrip(e.TripID):
+		return InformedEntityScope_TripScoped
+	case e.StopID != nil:
+		return InformedEntityScope_StopScoped
+	case e.RouteID != nil:
+		return InformedEntityScope_RouteScoped
+	case e.RouteType != RouteType_Unknown:
+		return InformedEntityScope_RouteTypeScoped
+	case e.AgencyID != nil:
+		return InformedEntityScope_AgencyWide
+	default:
+		return InformedEntityScope_Unknown
+	}
+}
+
+// IsAgencyWide returns true if the informed entity applies to all service of an agency.
+func (e *AlertInformedEntity) IsAgencyWide() bool {
+	return e.Scope() == InformedEntityScope_AgencyWide
+}
+
+// AppliesToRoute returns true if the informed entity applies to service on the route.
+//
+// The agency ID, route ID and route type of the informed entity must match the route if they are
+// set, and at least one of them must be set. In particular, an informed entity with an agency ID
+// and a route type applies to all routes of that type operated by the agency. Informed entities
+// that also specify a direction, stop or trip apply to part of the route's service and so match too.
+func (e *AlertInformedEntity) AppliesToRoute(route *Route) bool {
+	if e.AgencyID == nil && e.RouteID == nil && e.RouteType == RouteType_Unknown {
+		return false
+	}
+	if e.AgencyID != nil && (route.Agency == nil || route.Agency.Id != *e.AgencyID) {
+		return false
+	}
+	if e.RouteID != nil && *e.RouteID != route.Id {
+		return false
+	}
+	if e.RouteType != RouteType_Unknown && e.RouteType != route.Type {
+		return false
+	}
+	if e.TripID != nil && e.TripID.RouteID != "" && e.TripID.RouteID != route.Id {
+		return false
+	}
+	return true
+}
+
+// AlertsForRoute returns the alerts in the message that have an informed entity that applies to the route.
+//
+// See [AlertInformedEntity.AppliesToRoute] for how informed entities are matched.
+func (realtime *Realtime) AlertsForRoute(route *Route) []Alert {
+	var alerts []Alert
+	for i := range realtime.Alerts {
+		for j := range realtime.Alerts[i].InformedEntities {
+			if realtime.Alerts[i].InformedEntities[j].AppliesToRoute(route) {
+				alerts = append(alerts, realtime.Alerts[i])
+				break
+			}
+		}
+	}
+	return alerts
+}
+
+// SystemWideAlerts returns the alerts in the message that have an agency-wide informed entity.
+func (realtime *Realtime) SystemWideAlerts() []Alert {
+	var alerts []Alert
+	for i := range realtime.Alerts {
+		for j := range realtime.Alerts[i].InformedEntities {
+			if realtime.Alerts[i].InformedEntities[j].IsAgencyWide() {
+				alerts = append(alerts, realtime.Alerts[i])
+				break
+			}
+		}
+	}
+	return alerts
+}
+
+// ResolveAlerts links the informed entities of the alerts in the message to the entities of the
+// static message that they reference.
+//
+// A warning is returned for each agency, route, stop or trip ID that is not in the static message.
+// The EntityID of each warning is the ID of the alert.
+func (realtime *Realtime) ResolveAlerts(static *Static) []warnings.RealtimeWarning {
+	agencies := map[string]*Agency{}
+	for i := range static.Agencies {
+		agencies[static.Agencies[i].Id] = &static.Agencies[i]
+	}
+	routes := map[string]*Route{}
+	for i := range static.Routes {
+		routes[static.Routes[i].Id] = &static.Routes[i]
+	}
+	var result []warnings.RealtimeWarning
+	for i := range realtime.Alerts {
+		alert := &realtime.Alerts[i]
+		unresolved := func(entityType, id string) {
+			result = append(result, warnings.RealtimeWarning{
+				Kind:     warnings.UnresolvedInformedEntity{EntityType: entityType, ID: id},
+				EntityID: alert.ID,
+			})
+		}
+		for j := range alert.InformedEntities {
+			informedEntity := &alert.InformedEntities[j]
+			informedEntity.Agency, informedEntity.Route, informedEntity.Stop, informedEntity.Trip = nil, nil, nil, nil
+			if informedEntity.AgencyID != nil {
+				if informedEntity.Agency = agencies[*informedEntity.AgencyID]; informedEntity.Agency == nil {
+					unresolved("agency", *informedEntity.AgencyID)
+				}
+			}
+			if informedEntity.RouteID != nil {
+				if informedEntity.Route = routes[*informedEntity.RouteID]; informedEntity.Route == nil {
+					unresolved("route", *informedEntity.RouteID)
+				}
+			}
+			if informedEntity.StopID != nil {
+				if informedEntity.Stop = static.StopByID(*informedEntity.StopID); informedEntity.Stop == nil {
+					unresolved("stop", *informedEntity.StopID)
+				}
+			}
+			if informedEntity.TripID != nil && informedEntity.TripID.ID != "" {
+				if informedEntity.Trip = static.TripByID(informedEntity.TripID.ID); informedEntity.Trip == nil {
+					unresolved("trip", informedEntity.TripID.ID)
+				}
+			}
+		}
+	}
+	return result
 }
 
 type AlertText struct {
 	Text     string
 	Language string
+
+	// Links that were removed from the text when it was converted from HTML to plain text.
+	//
+	// This is only populated if [ParseRealtimeOptions.ConvertAlertHTML] is true.
+	Links []AlertLink
+}
+
+// HeaderIn returns the header of the alert in the language that best matches the BCP-47 language tag.
+//
+// See [SelectAlertText] for how the language is matched.
+func (alert *Alert) HeaderIn(lang string) string {
+	return SelectAlertText(alert.Header, lang)
+}
+
+// DescriptionIn returns the description of the alert in the language that best matches the BCP-47 language tag.
+//
+// See [SelectAlertText] for how the language is matched.
+func (alert *Alert) DescriptionIn(lang string) string {
+	return SelectAlertText(alert.Description, lang)
+}
+
+// SelectAlertText returns the translation whose language best matches the BCP-47 language tag.
+//
+// Languages are matched using [language.Matcher], so for example a request for "en-US" matches a
+// translation in "en" and a request for "zh-TW" prefers "zh-Hant" over "zh-Hans". If no translation
+// matches, the translation without a language is returned if there is one, and otherwise the first
+// translation. If the tag cannot be parsed the first translation is returned, and if there are no
+// translations the empty string is returned.
+func SelectAlertText(texts []AlertText, lang string) string {
+	if len(texts) == 0 {
+		return ""
+	}
+	desired, err := language.Parse(lang)
+	if err != nil {
+		return texts[0].Text
+	}
+	supported := make([]language.Tag, len(texts))
+	untranslated := -1
+	for i, text := range texts {
+		supported[i] = language.Make(text.Language)
+		if text.Language == "" && untranslated < 0 {
+			untranslated = i
+		}
+	}
+	_, i, confidence := language.NewMatcher(supported).Match(desired)
+	if confidence == language.No {
+		if untranslated >= 0 {
+			return texts[untranslated].Text
+		}
+		return texts[0].Text
+	}
+	return texts[i].Text
 }
 
 type ParseRealtimeOptions struct {
@@ -251,6 +702,160 @@ type ParseRealtimeOptions struct {
 	//
 	// This can be nil, in which case no extension is used.
 	Extension extensions.Extension
+
+	// If true, vehicles that are not linked to a trip in the message are heuristically matched to trips
+	// that are not linked to a vehicle.
+	//
+	// A vehicle matches a trip if the vehicle's current stop is the first stop in the trip's stop time
+	// updates, the stop sequences agree (if both are set), and the routes agree (if the vehicle's trip
+	// descriptor specifies a route). Links are only made when the match is unique in both directions.
+	// Inferred links are flagged using the IsTripInferred and IsVehicleInferred fields.
+	//
+	// This is useful for feeds that publish vehicle positions and trip updates separately without
+	// shared vehicle IDs.
+	InferVehicleTrips bool
+
+	// If true, alerts with the same cause, effect, text and active periods are merged into a single alert.
+	//
+	// The merged alert has the ID of the first such alert in the message, and the union of the informed
+	// entities of all of the alerts. This handles feeds that publish the same alert under multiple
+	// entity IDs, for example one alert per affected stop.
+	DeduplicateAlerts bool
+
+	// If true, HTML in the header and description text of alerts is converted to plain text using
+	// [HTMLToPlainText], and the links in the text are recorded in the Links field of each [AlertText].
+	//
+	// Many agencies embed HTML in alert descriptions, which otherwise needs to be handled by every consumer.
+	ConvertAlertHTML bool
+
+	// The GTFS static feed corresponding to the realtime feed.
+	//
+	// This can be nil. If set, it is used to populate [StopTimeUpdate.Track] using the platform
+	// codes of assigned stops.
+	Static *Static
+
+	// Function used to identify vehicles. Vehicle descriptors with the same key are treated as
+	// the same vehicle.
+	//
+	// This can be nil, in which case the full vehicle ID is used as the key. For feeds in which
+	// labels or license plates are inconsistent across entities, use [VehicleKeyByID]. The presence
+	// flags of the key are ignored, so a field that is empty and a field that is absent are the same.
+	VehicleKey func(VehicleID) VehicleID
+
+	// How to handle a trip that appears in multiple trip update entities in the message.
+	//
+	// The default is [DuplicateTripPolicy_KeepLast].
+	DuplicateTripPolicy DuplicateTripPolicy
+
+	// Function applied to every parsed trip descriptor, including those in vehicle positions and alerts.
+	//
+	// This can be nil. It can be used to align realtime trip IDs with static trip IDs, for example by
+	// stripping agency prefixes, without writing an extension. See [TrimTripIDPrefix].
+	TripIDNormalizer func(TripID) TripID
+
+	// Function applied to the stop IDs of stop time updates (including assigned stop IDs), vehicles
+	// and alert informed entities.
+	//
+	// This can be nil. It can be used to align realtime stop IDs with static stop IDs, for example
+	// for agencies whose realtime stop IDs have leading zeros.
+	StopIDNormalizer func(string) string
+
+	// How to handle malformed entities, like trip updates without a trip descriptor or trip
+	// descriptors with an invalid start date or start time.
+	//
+	// The default is [Strictness_Standard].
+	Strictness Strictness
+
+	// The latest valid start time of a trip descriptor. Start times after this are treated as invalid.
+	//
+	// If zero, 48 hours is used.
+	MaxStartTime time.Duration
+
+	// If positive, the stop time updates of each trip are truncated at the first update whose
+	// predicted arrival or departure time is more than this duration after the timestamp in the
+	// feed header. Updates without a predicted time do not cause truncation.
+	//
+	// This reduces memory usage for feeds that publish predictions for the rest of the day for every
+	// trip. If the feed header has no timestamp, no updates are truncated.
+	StopTimeUpdateHorizon time.Duration
+
+	// Additional proto extension types used when decoding the message.
+	//
+	// Extension fields in the message are only decoded if their type is known; other extension fields
+	// are kept as unknown fields. Types for the NYCT extensions, and for any other extensions whose
+	// generated Go code is linked into the binary, are always known. This option is used to decode
+	// other vendor extensions so that a custom [extensions.Extension] can read them using
+	// proto.GetExtension. Types can be loaded from a descriptor set at runtime using
+	// [extensions.LoadProtoExtensionTypes].
+	//
+	// If one of these types has the same field number as a built in type, this type is used.
+	ProtoExtensionTypes []protoreflect.ExtensionType
+}
+
+// Strictness specifies how malformed entities in a GTFS realtime message are handled.
+type Strictness int32
+
+const (
+	// Malformed entities are dropped and a warning is added to the Warnings field of the result.
+	// Parsing fails with an error if the message is missing required fields.
+	Strictness_Standard Strictness = 0
+	// Malformed entities are dropped silently. Messages with missing required fields are accepted,
+	// in which case a warning is added to the Warnings field of the result.
+	Strictness_Lenient Strictness = 1
+	// Parsing fails with an error if the message contains a malformed entity or is missing
+	// required fields.
+	Strictness_Strict Strictness = 2
+)
+
+func (s Strictness) String() string {
+	switch s {
+	case Strictness_Standard:
+		return "STANDARD"
+	case Strictness_Lenient:
+		return "LENIENT"
+	case Strictness_Strict:
+		return "STRICT"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// DuplicateTripPolicy specifies how to handle a trip that appears in multiple trip update entities.
+type DuplicateTripPolicy int32
+
+const (
+	// The trip update in the last entity is used.
+	DuplicateTripPolicy_KeepLast DuplicateTripPolicy = 0
+	// The trip update in the first entity is used.
+	DuplicateTripPolicy_KeepFirst DuplicateTripPolicy = 1
+	// The stop time updates of all of the entities are merged. If multiple entities contain an
+	// update for the same stop, the last one is used. All other fields come from the last entity.
+	DuplicateTripPolicy_MergeStopTimeUpdates DuplicateTripPolicy = 2
+	// Like [DuplicateTripPolicy_KeepLast], but a [warnings.DuplicateTrip] warning is also added
+	// to the Warnings field of the result.
+	DuplicateTripPolicy_Warn DuplicateTripPolicy = 3
+)
+
+func (p DuplicateTripPolicy) String() string {
+	switch p {
+	case DuplicateTripPolicy_KeepFirst:
+		return "KEEP_FIRST"
+	case DuplicateTripPolicy_MergeStopTimeUpdates:
+		return "MERGE_STOP_TIME_UPDATES"
+	case DuplicateTripPolicy_Warn:
+		return "WARN"
+	case DuplicateTripPolicy_KeepLast:
+		fallthrough
+	default:
+		return "KEEP_LAST"
+	}
+}
+
+func (opts *ParseRealtimeOptions) maxStartTime() time.Duration {
+	if opts.MaxStartTime <= 0 {
+		return 48 * time.Hour
+	}
+	return opts.MaxStartTime
 }
 
 func (opts *ParseRealtimeOptions) timezoneOrUTC() *time.Location {
@@ -260,25 +865,89 @@ func (opts *ParseRealtimeOptions) timezoneOrUTC() *time.Location {
 	return time.UTC
 }
 
+func (opts *ParseRealtimeOptions) normalizeStopID(stopID *string) *string {
+	if stopID == nil || opts.StopIDNormalizer == nil {
+		return stopID
+	}
+	normalized := opts.StopIDNormalizer(*stopID)
+	return &normalized
+}
+
+// vehicleKey returns the key used to identify the vehicle in the message.
+//
+// The presence flags are cleared so that a descriptor with an empty field and a descriptor without the
+// field identify the same vehicle.
+func (opts *ParseRealtimeOptions) vehicleKey(vehicleID VehicleID) VehicleID {
+	if opts.VehicleKey != nil {
+		vehicleID = opts.VehicleKey(vehicleID)
+	}
+	vehicleID.HasID = false
+	vehicleID.HasLabel = false
+	vehicleID.HasLicensePlate = false
+	return vehicleID
+}
+
+// VehicleKeyByID is a vehicle key function that identifies vehicles by their ID only,
+// ignoring labels and license plates. Vehicles without an ID are identified by their full vehicle ID.
+//
+// See [ParseRealtimeOptions.VehicleKey].
+func VehicleKeyByID(vehicleID VehicleID) VehicleID {
+	if vehicleID.ID == "" {
+		return vehicleID
+	}
+	return VehicleID{
+		ID:    vehicleID.ID,
+		HasID: true,
+	}
+}
+
 func ParseRealtime(content []byte, opts *ParseRealtimeOptions) (*Realtime, error) {
 	if opts.Extension == nil {
 		opts.Extension = extensions.NoExtension()
 	}
 	feedMessage := &gtfsrt.FeedMessage{}
-	if err := proto.Unmarshal(content, feedMessage); err != nil {
+	// When parsing is lenient, missing required fields are handled like other malformed data rather
+	// than causing the whole message to be rejected.
+	unmarshalOpts := proto.UnmarshalOptions{AllowPartial: opts.Strictness == Strictness_Lenient}
+	if len(opts.ProtoExtensionTypes) > 0 {
+		resolver, err := newProtoExtensionResolver(opts.ProtoExtensionTypes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proto extension types: %w", err)
+		}
+		unmarshalOpts.Resolver = resolver
+	}
+	if err := unmarshalOpts.Unmarshal(content, feedMessage); err != nil {
 		return nil, fmt.Errorf("failed to parse input as a GTFS Realtime message: %s", err)
 	}
 	var result Realtime
+	if unmarshalOpts.AllowPartial {
+		if err := proto.CheckInitialized(feedMessage); err != nil {
+			result.Warnings = append(result.Warnings, warnings.RealtimeWarning{
+				Kind: warnings.MissingRequiredFields{Err: err.Error()},
+			})
+		}
+	}
+	if feedMessage.Header == nil {
+		feedMessage.Header = &gtfsrt.FeedHeader{}
+	}
+	opts.Extension.UpdateHeader(feedMessage.Header)
+	var horizonEnd *time.Time
 	if t := feedMessage.GetHeader().Timestamp; t != nil {
 		createdAt := time.Unix(int64(*t), 0).In(opts.timezoneOrUTC())
 		result.CreatedAt = createdAt
+		if opts.StopTimeUpdateHorizon > 0 {
+			end := createdAt.Add(opts.StopTimeUpdateHorizon)
+			horizonEnd = &end
+		}
 	}
 
 	shouldSkip := make([]bool, len(feedMessage.GetEntity()))
+	tripExtensionData := make([]any, len(feedMessage.GetEntity()))
 	for i, entity := range feedMessage.Entity {
 		if tripUpdate := entity.GetTripUpdate(); tripUpdate != nil {
 			r := opts.Extension.UpdateTrip(tripUpdate, feedMessage.GetHeader().GetTimestamp())
 			shouldSkip[i] = r.ShouldSkip
+			tripExtensionData[i] = r.Data
 		} else if vehiclePosition := entity.GetVehicle(); vehiclePosition != nil {
 			opts.Extension.UpdateVehicle(vehiclePosition)
 		} else if alert := entity.Alert; alert != nil {
@@ -295,6 +964,18 @@ func ParseRealtime(content []byte, opts *ParseRealtimeOptions) (*Realtime, error
 		if shouldSkip[i] {
 			continue
 		}
+		if kind := validateEntity(entity, opts); kind != nil {
+			if opts.Strictness == Strictness_Strict {
+				return nil, fmt.Errorf("entity %q is malformed: %w", entity.GetId(), kind)
+			}
+			if opts.Strictness == Strictness_Standard {
+				result.Warnings = append(result.Warnings, warnings.RealtimeWarning{
+					Kind:     kind,
+					EntityID: entity.GetId(),
+				})
+			}
+			continue
+		}
 		var trip *Trip
 		var vehicle *Vehicle
 		var alert *Alert
@@ -302,7 +983,10 @@ func ParseRealtime(content []byte, opts *ParseRealtimeOptions) (*Realtime, error
 		var ok bool
 
 		if tripUpdate := entity.TripUpdate; tripUpdate != nil {
-			trip, vehicle, ok = parseTripUpdate(tripUpdate, opts)
+			trip, vehicle, ok = parseTripUpdate(tripUpdate, opts, horizonEnd)
+			if ok {
+				trip.ExtensionData = tripExtensionData[i]
+			}
 		} else if vehiclePosition := entity.Vehicle; vehiclePosition != nil {
 			trip, vehicle = parseVehicle(vehiclePosition, opts)
 			ok = true
@@ -326,34 +1010,58 @@ func ParseRealtime(content []byte, opts *ParseRealtimeOptions) (*Realtime, error
 				mergeTrip(tripsById[trip.ID], trip)
 			}
 		}
+		// Whether the trip is a duplicate that is discarded by the duplicate trip policy, in which case
+		// it is not linked to its vehicle.
+		var discarded bool
 		if trip != nil {
-			if _, ok := tripsById[trip.ID]; !ok {
-				tripsById[trip.ID] = &Trip{}
+			existing, ok := tripsById[trip.ID]
+			if !ok {
+				existing = &Trip{}
+				tripsById[trip.ID] = existing
 			}
-			mergeTrip(tripsById[trip.ID], *trip)
+			if existing.IsEntityInMessage && trip.IsEntityInMessage {
+				discarded = opts.DuplicateTripPolicy == DuplicateTripPolicy_KeepFirst
+				mergeDuplicateTrip(existing, *trip, opts.DuplicateTripPolicy)
+				if opts.DuplicateTripPolicy == DuplicateTripPolicy_Warn {
+					result.Warnings = append(result.Warnings, warnings.RealtimeWarning{
+						Kind:     warnings.DuplicateTrip{TripID: trip.ID.ID},
+						EntityID: entity.GetId(),
+					})
+				}
+			} else {
+				mergeTrip(existing, *trip)
+			}
+		}
+		var vehicleKey VehicleID
+		if vehicle != nil && vehicle.ID != nil {
+			vehicleKey = opts.vehicleKey(*vehicle.ID)
 		}
 		if vehicle != nil {
 			if vehicle.ID != nil {
-				if _, ok := vehiclesByID[*vehicle.ID]; !ok {
-					vehiclesByID[*vehicle.ID] = &Vehicle{}
+				if _, ok := vehiclesByID[vehicleKey]; !ok {
+					vehiclesByID[vehicleKey] = &Vehicle{}
 				}
-				mergeVehicle(vehiclesByID[*vehicle.ID], *vehicle)
+				mergeVehicle(vehiclesByID[vehicleKey], *vehicle)
 			} else {
 				vehiclesWithNoID = append(vehiclesWithNoID, *vehicle)
 			}
 		}
-		if trip != nil && vehicle != nil {
+		if trip != nil && vehicle != nil && !discarded {
 			if vehicle.ID != nil {
 				// TODO: what if these already exist?
 				// Maybe we should also return a Diagnostics message
-				tripIDToVehicleID[trip.ID] = *vehicle.ID
-				vehicleIDToTripID[*vehicle.ID] = trip.ID
+				tripIDToVehicleID[trip.ID] = vehicleKey
+				vehicleIDToTripID[vehicleKey] = trip.ID
 			} else {
 				trip.Vehicle = vehicle
 			}
 		}
 	}
 
+	if opts.InferVehicleTrips {
+		inferVehicleTrips(tripsById, vehiclesByID, tripIDToVehicleID, vehicleIDToTripID)
+	}
+
 	for tripID, trip := range tripsById {
 		if vehicleID, ok := tripIDToVehicleID[tripID]; ok {
 			trip.Vehicle = vehiclesByID[vehicleID]
@@ -372,15 +1080,98 @@ func ParseRealtime(content []byte, opts *ParseRealtimeOptions) (*Realtime, error
 		result.Vehicles = append(result.Vehicles, *vehicle)
 	}
 	result.Vehicles = append(result.Vehicles, vehiclesWithNoID...)
+	if opts.DeduplicateAlerts {
+		result.Alerts = deduplicateAlerts(result.Alerts)
+	}
 	return &result, nil
 }
 
-func parseTripUpdate(tripUpdate *gtfsrt.TripUpdate, opts *ParseRealtimeOptions) (*Trip, *Vehicle, bool) {
+// validateEntity returns the problem with the entity if it is malformed, or nil otherwise.
+func validateEntity(entity *gtfsrt.FeedEntity, opts *ParseRealtimeOptions) warnings.RealtimeWarningKind {
+	var tripDesc *gtfsrt.TripDescriptor
+	if tripUpdate := entity.TripUpdate; tripUpdate != nil {
+		if tripUpdate.Trip == nil {
+			return warnings.MissingTripDescriptor{}
+		}
+		tripDesc = tripUpdate.Trip
+	} else if vehiclePosition := entity.Vehicle; vehiclePosition != nil {
+		tripDesc = vehiclePosition.Trip
+	}
+	if tripDesc == nil {
+		return nil
+	}
+	if ok, _ := parseStartDate(tripDesc.StartDate, time.UTC); tripDesc.StartDate != nil && !ok {
+		return warnings.InvalidStartDate{StartDate: *tripDesc.StartDate}
+	}
+	if ok, _ := parseStartTime(tripDesc.StartTime, opts.maxStartTime()); tripDesc.StartTime != nil && !ok {
+		return warnings.InvalidStartTime{StartTime: *tripDesc.StartTime}
+	}
+	return nil
+}
+
+func inferVehicleTrips(tripsById map[TripID]*Trip, vehiclesByID map[VehicleID]*Vehicle, tripIDToVehicleID map[TripID]VehicleID, vehicleIDToTripID map[VehicleID]TripID) {
+	vehicleToCandidates := map[VehicleID][]TripID{}
+	tripToCandidates := map[TripID][]VehicleID{}
+	for vehicleID, vehicle := range vehiclesByID {
+		var routeID string
+		if tripID, ok := vehicleIDToTripID[vehicleID]; ok {
+			if tripID.IsFullySpecified() {
+				continue
+			}
+			routeID = tripID.RouteID
+		}
+		if vehicle.StopID == nil {
+			continue
+		}
+		for tripID, trip := range tripsById {
+			if !trip.IsEntityInMessage || !tripID.IsFullySpecified() || len(trip.StopTimeUpdates) == 0 {
+				continue
+			}
+			if _, ok := tripIDToVehicleID[tripID]; ok {
+				continue
+			}
+			if routeID != "" && routeID != tripID.RouteID {
+				continue
+			}
+			next := trip.StopTimeUpdates[0]
+			if next.StopID == nil || *next.StopID != *vehicle.StopID {
+				continue
+			}
+			if next.StopSequence != nil && vehicle.CurrentStopSequence != nil && *next.StopSequence != *vehicle.CurrentStopSequence {
+				continue
+			}
+			vehicleToCandidates[vehicleID] = append(vehicleToCandidates[vehicleID], tripID)
+			tripToCandidates[tripID] = append(tripToCandidates[tripID], vehicleID)
+		}
+	}
+	for vehicleID, tripIDs := range vehicleToCandidates {
+		if len(tripIDs) != 1 || len(tripToCandidates[tripIDs[0]]) != 1 {
+			continue
+		}
+		tripID := tripIDs[0]
+		// Remove the placeholder trip built from the vehicle's partial trip descriptor.
+		if oldTripID, ok := vehicleIDToTripID[vehicleID]; ok {
+			if oldTrip := tripsById[oldTripID]; oldTrip != nil && !oldTrip.IsEntityInMessage {
+				delete(tripsById, oldTripID)
+			}
+			delete(tripIDToVehicleID, oldTripID)
+		}
+		tripIDToVehicleID[tripID] = vehicleID
+		vehicleIDToTripID[vehicleID] = tripID
+		tripsById[tripID].IsVehicleInferred = true
+		vehiclesByID[vehicleID].IsTripInferred = true
+	}
+}
+
+// parseTripUpdate parses the trip update. If horizonEnd is not nil, stop time updates are truncated
+// at the first update predicted after it.
+func parseTripUpdate(tripUpdate *gtfsrt.TripUpdate, opts *ParseRealtimeOptions, horizonEnd *time.Time) (*Trip, *Vehicle, bool) {
 	if tripUpdate.Trip == nil {
 		return nil, nil, false
 	}
 	trip := &Trip{
 		ID:                parseTripDescriptor(tripUpdate.Trip, opts),
+		Timestamp:         convertOptionalTimestamp(tripUpdate.Timestamp, opts.timezoneOrUTC()),
 		IsEntityInMessage: true,
 	}
 	convertStopTimeEvent := func(stopTimeEvent *gtfsrt.TripUpdate_StopTimeEvent) *StopTimeEvent {
@@ -401,25 +1192,53 @@ func parseTripUpdate(tripUpdate *gtfsrt.TripUpdate, opts *ParseRealtimeOptions)
 		return &result
 	}
 	for _, stopTimeUpdate := range tripUpdate.StopTimeUpdate {
+		if horizonEnd != nil && isAfterHorizon(stopTimeUpdate, *horizonEnd) {
+			break
+		}
+		var assignedStopID *string
+		if properties := stopTimeUpdate.GetStopTimeProperties(); properties != nil {
+			assignedStopID = opts.normalizeStopID(properties.AssignedStopId)
+		}
+		track := opts.Extension.GetTrack(stopTimeUpdate)
+		if track == nil && assignedStopID != nil && opts.Static != nil {
+			if stop := opts.Static.StopByID(*assignedStopID); stop != nil && stop.PlatformCode != "" {
+				platformCode := stop.PlatformCode
+				track = &platformCode
+			}
+		}
 		trip.StopTimeUpdates = append(trip.StopTimeUpdates, StopTimeUpdate{
 			StopSequence:         stopTimeUpdate.StopSequence,
-			StopID:               stopTimeUpdate.StopId,
+			StopID:               opts.normalizeStopID(stopTimeUpdate.StopId),
 			Arrival:              convertStopTimeEvent(stopTimeUpdate.Arrival),
 			Departure:            convertStopTimeEvent(stopTimeUpdate.Departure),
-			NyctTrack:            opts.Extension.GetTrack(stopTimeUpdate),
 			ScheduleRelationship: stopTimeUpdate.GetScheduleRelationship(),
+			AssignedStopID:       assignedStopID,
+			Track:                track,
+			NyctTrack:            track,
 		})
 	}
 	if tripUpdate.Vehicle == nil {
 		return trip, nil, true
 	}
 	vehicle := &Vehicle{
-		ID:                parseVehicleDescriptor(tripUpdate.Vehicle),
-		IsEntityInMessage: false,
+		ID:                   parseVehicleDescriptor(tripUpdate.Vehicle),
+		WheelchairAccessible: tripUpdate.Vehicle.WheelchairAccessible,
+		IsEntityInMessage:    false,
 	}
 	return trip, vehicle, true
 }
 
+// isAfterHorizon returns true if the earliest predicted time of the stop time update is after the
+// horizon end.
+func isAfterHorizon(stopTimeUpdate *gtfsrt.TripUpdate_StopTimeUpdate, horizonEnd time.Time) bool {
+	for _, event := range []*gtfsrt.TripUpdate_StopTimeEvent{stopTimeUpdate.Arrival, stopTimeUpdate.Departure} {
+		if event != nil && event.Time != nil {
+			return *event.Time > horizonEnd.Unix()
+		}
+	}
+	return false
+}
+
 func parseVehicle(vehiclePosition *gtfsrt.VehiclePosition, opts *ParseRealtimeOptions) (*Trip, *Vehicle) {
 	var congestionLevel = gtfsrt.VehiclePosition_UNKNOWN_CONGESTION_LEVEL
 	if vehiclePosition.CongestionLevel != nil {
@@ -429,7 +1248,7 @@ func parseVehicle(vehiclePosition *gtfsrt.VehiclePosition, opts *ParseRealtimeOp
 		ID:                  parseVehicleDescriptor(vehiclePosition.Vehicle),
 		Position:            convertVehiclePosition(vehiclePosition),
 		CurrentStopSequence: vehiclePosition.CurrentStopSequence,
-		StopID:              vehiclePosition.StopId,
+		StopID:              opts.normalizeStopID(vehiclePosition.StopId),
 		CurrentStatus:       vehiclePosition.CurrentStatus,
 		Timestamp:           convertOptionalTimestamp(vehiclePosition.Timestamp, opts.timezoneOrUTC()),
 		CongestionLevel:     congestionLevel,
@@ -437,6 +1256,9 @@ func parseVehicle(vehiclePosition *gtfsrt.VehiclePosition, opts *ParseRealtimeOp
 		OccupancyPercentage: vehiclePosition.OccupancyPercentage,
 		IsEntityInMessage:   true,
 	}
+	if vehiclePosition.Vehicle != nil {
+		vehicle.WheelchairAccessible = vehiclePosition.Vehicle.WheelchairAccessible
+	}
 	if vehiclePosition.Trip == nil {
 		return nil, vehicle
 	}
@@ -472,12 +1294,57 @@ func mergeTrip(t *Trip, new Trip) {
 	*t = new
 }
 
+// mergeDuplicateTrip merges a trip into a trip with the same ID from an earlier entity in the message.
+func mergeDuplicateTrip(t *Trip, new Trip, policy DuplicateTripPolicy) {
+	switch policy {
+	case DuplicateTripPolicy_KeepFirst:
+		return
+	case DuplicateTripPolicy_MergeStopTimeUpdates:
+		stopTimeUpdates := append([]StopTimeUpdate(nil), t.StopTimeUpdates...)
+		for _, update := range new.StopTimeUpdates {
+			replaced := false
+			for i := range stopTimeUpdates {
+				if isSameStop(&stopTimeUpdates[i], &update) {
+					stopTimeUpdates[i] = update
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				stopTimeUpdates = append(stopTimeUpdates, update)
+			}
+		}
+		*t = new
+		t.StopTimeUpdates = stopTimeUpdates
+	default:
+		*t = new
+	}
+}
+
+// isSameStop returns true if the stop time updates are for the same stop of the trip.
+//
+// Stop sequences are compared if both updates have them, and otherwise stop IDs are compared.
+func isSameStop(a, b *StopTimeUpdate) bool {
+	if a.StopSequence != nil && b.StopSequence != nil {
+		return *a.StopSequence == *b.StopSequence
+	}
+	if a.StopID != nil && b.StopID != nil {
+		return *a.StopID == *b.StopID
+	}
+	return false
+}
+
 func mergeVehicle(v *Vehicle, new Vehicle) {
 	v.ID = new.ID
-	if !new.IsEntityInMessage {
-		return
+	// The wheelchair accessibility can be in the descriptor of any entity that references the vehicle.
+	wheelchairAccessible := v.WheelchairAccessible
+	if new.WheelchairAccessible != nil {
+		wheelchairAccessible = new.WheelchairAccessible
 	}
-	*v = new
+	if new.IsEntityInMessage {
+		*v = new
+	}
+	v.WheelchairAccessible = wheelchairAccessible
 }
 
 var startTimeRegex *regexp.Regexp = regexp.MustCompile(`^([0-9]{2}):([0-9]{2}):([0-9]{2})$`)
@@ -498,15 +1365,29 @@ func parseTripDescriptor(tripDesc *gtfsrt.TripDescriptor, opts *ParseRealtimeOpt
 		DirectionID:          parseDirectionID_GTFSRealtime(tripDesc.DirectionId),
 		ScheduleRelationship: tripDesc.GetScheduleRelationship(),
 	}
-	id.HasStartTime, id.StartTime = parseStartTime(tripDesc.StartTime)
+	id.HasStartTime, id.StartTime = parseStartTime(tripDesc.StartTime, opts.maxStartTime())
 	id.HasStartDate, id.StartDate = parseStartDate(tripDesc.StartDate, opts.timezoneOrUTC())
+	if opts.TripIDNormalizer != nil {
+		id = opts.TripIDNormalizer(id)
+	}
 	return id
 }
 
+// TrimTripIDPrefix returns a trip ID normalizer that removes the prefix from trip IDs that have it.
+//
+// See [ParseRealtimeOptions.TripIDNormalizer].
+func TrimTripIDPrefix(prefix string) func(TripID) TripID {
+	return func(id TripID) TripID {
+		id.ID = strings.TrimPrefix(id.ID, prefix)
+		return id
+	}
+}
+
 // parseStartTime parses a start time of the form HH:MM:SS into a Duration.
 //
-// It does not handle daylight saving time currently.
-func parseStartTime(startTime *string) (bool, time.Duration) {
+// Start times with minutes or seconds of 60 or more, or that are later than the maximum start time,
+// are invalid. It does not handle daylight saving time currently.
+func parseStartTime(startTime *string, maxStartTime time.Duration) (bool, time.Duration) {
 	if startTime == nil {
 		return false, 0
 	}
@@ -517,9 +1398,19 @@ func parseStartTime(startTime *string) (bool, time.Duration) {
 	h, _ := strconv.Atoi(startTimeMatch[1])
 	m, _ := strconv.Atoi(startTimeMatch[2])
 	s, _ := strconv.Atoi(startTimeMatch[3])
-	return true, time.Duration((h*60+m)*60+s) * time.Second
+	d := time.Duration((h*60+m)*60+s) * time.Second
+	if m >= 60 || s >= 60 || d > maxStartTime {
+		return false, 0
+	}
+	return true, d
 }
 
+// Start dates outside of this range of years are considered invalid.
+const (
+	minStartDateYear = 1900
+	maxStartDateYear = 2199
+)
+
 func parseStartDate(startDate *string, timezone *time.Location) (bool, time.Time) {
 	if startDate == nil {
 		return false, time.Time{}
@@ -531,7 +1422,15 @@ func parseStartDate(startDate *string, timezone *time.Location) (bool, time.Time
 	y, _ := strconv.Atoi(startDateMatch[1])
 	m, _ := strconv.Atoi(startDateMatch[2])
 	d, _ := strconv.Atoi(startDateMatch[3])
-	return true, time.Date(y, time.Month(m), d, 0, 0, 0, 0, timezone)
+	if y < minStartDateYear || y > maxStartDateYear {
+		return false, time.Time{}
+	}
+	date := time.Date(y, time.Month(m), d, 0, 0, 0, 0, timezone)
+	// time.Date normalizes out-of-range months and days, e.g. February 30 becomes March 2.
+	if date.Month() != time.Month(m) || date.Day() != d {
+		return false, time.Time{}
+	}
+	return true, date
 }
 
 func parseVehicleDescriptor(vehicleDesc *gtfsrt.VehicleDescriptor) *VehicleID {
@@ -545,12 +1444,15 @@ func parseVehicleDescriptor(vehicleDesc *gtfsrt.VehicleDescriptor) *VehicleID {
 		return *s
 	}
 	vehicleID := VehicleID{
-		ID:           valOrEmpty(vehicleDesc.Id),
-		Label:        valOrEmpty(vehicleDesc.Label),
-		LicensePlate: valOrEmpty(vehicleDesc.LicensePlate),
+		ID:              valOrEmpty(vehicleDesc.Id),
+		Label:           valOrEmpty(vehicleDesc.Label),
+		LicensePlate:    valOrEmpty(vehicleDesc.LicensePlate),
+		HasID:           vehicleDesc.Id != nil,
+		HasLabel:        vehicleDesc.Label != nil,
+		HasLicensePlate: vehicleDesc.LicensePlate != nil,
 	}
-	var zeroVehicleID VehicleID
-	if vehicleID == zeroVehicleID {
+	// A descriptor with only empty fields does not identify a vehicle.
+	if vehicleID.ID == "" && vehicleID.Label == "" && vehicleID.LicensePlate == "" {
 		return nil
 	}
 	return &vehicleID
@@ -598,7 +1500,7 @@ func parseAlert(ID string, alert *gtfsrt.Alert, opts *ParseRealtimeOptions) (*Al
 			RouteType:   parseRouteType_GTFSRealtime(entity.RouteType),
 			DirectionID: parseDirectionID_GTFSRealtime(entity.DirectionId),
 			TripID:      tripIDOrNil,
-			StopID:      entity.StopId,
+			StopID:      opts.normalizeStopID(entity.StopId),
 		}
 
 		// Ensure at least one entity is informed
@@ -658,9 +1560,41 @@ func parseAlert(ID string, alert *gtfsrt.Alert, opts *ParseRealtimeOptions) (*Al
 		Description:      buildAlertText(alert.GetDescriptionText()),
 		URL:              buildAlertText(alert.GetUrl()),
 	}
+	if opts.ConvertAlertHTML {
+		convertAlertHTML(gtfsAlert.Header)
+		convertAlertHTML(gtfsAlert.Description)
+	}
 	return gtfsAlert, trips
 }
 
+func deduplicateAlerts(alerts []Alert) []Alert {
+	var result []Alert
+	keyToIndex := map[string]int{}
+	var entityKeys []map[string]bool
+	for _, alert := range alerts {
+		key := hashToString(func(h *hasher) { h.alertContent(&alert) })
+		informedEntities := alert.InformedEntities
+		i, ok := keyToIndex[key]
+		if !ok {
+			keyToIndex[key] = len(result)
+			alert.InformedEntities = nil
+			result = append(result, alert)
+			entityKeys = append(entityKeys, map[string]bool{})
+			i = len(result) - 1
+		}
+		for j := range informedEntities {
+			entity := &informedEntities[j]
+			entityKey := hashToString(func(h *hasher) { h.alertInformedEntity(entity) })
+			if entityKeys[i][entityKey] {
+				continue
+			}
+			entityKeys[i][entityKey] = true
+			result[i].InformedEntities = append(result[i].InformedEntities, *entity)
+		}
+	}
+	return result
+}
+
 func buildAlertText(ts *gtfsrt.TranslatedString) []AlertText {
 	var texts []AlertText
 	for _, s := range ts.GetTranslation() {
@@ -672,6 +1606,12 @@ func buildAlertText(ts *gtfsrt.TranslatedString) []AlertText {
 	return texts
 }
 
+func convertAlertHTML(texts []AlertText) {
+	for i := range texts {
+		texts[i].Text, texts[i].Links = HTMLToPlainText(texts[i].Text)
+	}
+}
+
 func convertOptionalTimestamp(in *uint64, timezone *time.Location) *time.Time {
 	if in == nil {
 		return nil
@@ -687,6 +1627,35 @@ func alertInformedEntityInformsAtLeastOneEntity(alertInformedEntity AlertInforme
 }
 
 func tripIDUniquelyIdentifiesTrip(tripID *TripID) bool {
-	return tripID != nil &&
-		(tripID.ID != "" || (tripID.RouteID != "" && tripID.DirectionID != DirectionID_Unspecified && tripID.HasStartTime && tripID.HasStartDate))
+	return tripID != nil && tripID.IsFullySpecified()
+}
+
+// protoExtensionResolver finds proto extension types in a registry, and otherwise falls back to the
+// types linked into the binary.
+type protoExtensionResolver struct {
+	registry *protoregistry.Types
+}
+
+func newProtoExtensionResolver(types []protoreflect.ExtensionType) (protoExtensionResolver, error) {
+	registry := &protoregistry.Types{}
+	for _, xt := range types {
+		if err := registry.RegisterExtension(xt); err != nil {
+			return protoExtensionResolver{}, err
+		}
+	}
+	return protoExtensionResolver{registry: registry}, nil
+}
+
+func (r protoExtensionResolver) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	if xt, err := r.registry.FindExtensionByName(field); err != protoregistry.NotFound {
+		return xt, err
+	}
+	return protoregistry.GlobalTypes.FindExtensionByName(field)
+}
+
+func (r protoExtensionResolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	if xt, err := r.registry.FindExtensionByNumber(message, field); err != protoregistry.NotFound {
+		return xt, err
+	}
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
 }
@@ -0,0 +1,218 @@
+package gtfs
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RealtimeCsvExport contains flat CSV exports of a realtime message.
+type RealtimeCsvExport struct {
+	TripsCsv           []byte
+	StopTimeUpdatesCsv []byte
+	VehiclesCsv        []byte
+	AlertsCsv          []byte
+}
+
+// ExportToCsv flattens the realtime message into CSV files for archival analytics.
+//
+// The column conventions follow the journal CSV export: times are Unix timestamps, durations
+// are in seconds, direction IDs are 0 or 1, and unset values are empty. Start dates are
+// written as YYYY-MM-DD. Stop time updates and vehicles reference trips using the trip_id and
+// start_date columns. Alerts are written with one row per active period; the route and stop IDs
+// of the informed entities are space separated.
+func (r *Realtime) ExportToCsv() (*RealtimeCsvExport, error) {
+	return r.ExportToCsvWithOptions(CsvExportOptions{})
+}
+
+// ExportToCsvWithOptions is the same as ExportToCsv, but with options.
+func (r *Realtime) ExportToCsvWithOptions(opts CsvExportOptions) (*RealtimeCsvExport, error) {
+	var err error
+	result := &RealtimeCsvExport{}
+
+	trips := [][]string{{"trip_id", "route_id", "direction_id", "start_date", "start_time", "schedule_relationship", "vehicle_id"}}
+	stopTimeUpdates := [][]string{{
+		"trip_id", "start_date", "stop_sequence", "stop_id", "track",
+		"arrival_time", "arrival_delay", "arrival_uncertainty",
+		"departure_time", "departure_delay", "departure_uncertainty",
+		"schedule_relationship",
+	}}
+	for i := range r.Trips {
+		trip := &r.Trips[i]
+		startDate := formatStartDate(trip.ID)
+		var startTime string
+		if trip.ID.HasStartTime {
+			startTime = strconv.FormatInt(int64(trip.ID.StartTime/time.Second), 10)
+		}
+		vehicle := trip.GetVehicle()
+		trips = append(trips, []string{
+			trip.ID.ID,
+			trip.ID.RouteID,
+			formatDirectionID(trip.ID.DirectionID),
+			startDate,
+			startTime,
+			trip.ID.ScheduleRelationship.String(),
+			vehicle.GetID().ID,
+		})
+		for _, stopTimeUpdate := range trip.StopTimeUpdates {
+			arrival, departure := stopTimeUpdate.GetArrival(), stopTimeUpdate.GetDeparture()
+			var stopSequence string
+			if stopTimeUpdate.StopSequence != nil {
+				stopSequence = strconv.FormatUint(uint64(*stopTimeUpdate.StopSequence), 10)
+			}
+			stopTimeUpdates = append(stopTimeUpdates, []string{
+				trip.ID.ID,
+				startDate,
+				stopSequence,
+				unPtr(stopTimeUpdate.StopID),
+				unPtr(stopTimeUpdate.Track),
+				formatUnix(arrival.Time),
+				formatSeconds(arrival.Delay),
+				formatInt32Ptr(arrival.Uncertainty),
+				formatUnix(departure.Time),
+				formatSeconds(departure.Delay),
+				formatInt32Ptr(departure.Uncertainty),
+				stopTimeUpdate.ScheduleRelationship.String(),
+			})
+		}
+	}
+	if result.TripsCsv, err = writeCsv(trips); err != nil {
+		return nil, err
+	}
+	if result.StopTimeUpdatesCsv, err = writeCsv(stopTimeUpdates); err != nil {
+		return nil, err
+	}
+
+	vehicles := [][]string{{
+		"vehicle_id", "vehicle_label", "license_plate", "trip_id", "start_date",
+		"latitude", "longitude", "bearing", "speed",
+		"stop_id", "current_stop_sequence", "current_status", "timestamp", "occupancy_status",
+	}}
+	for i := range r.Vehicles {
+		vehicle := &r.Vehicles[i]
+		vehicleID := vehicle.GetID()
+		var tripID, startDate string
+		if vehicle.Trip != nil {
+			tripID, startDate = vehicle.Trip.ID.ID, formatStartDate(vehicle.Trip.ID)
+		}
+		var position Position
+		if vehicle.Position != nil {
+			position = *vehicle.Position
+		}
+		var currentStopSequence, currentStatus, occupancyStatus string
+		if vehicle.CurrentStopSequence != nil {
+			currentStopSequence = strconv.FormatUint(uint64(*vehicle.CurrentStopSequence), 10)
+		}
+		if vehicle.CurrentStatus != nil {
+			currentStatus = vehicle.CurrentStatus.String()
+		}
+		if vehicle.OccupancyStatus != nil {
+			occupancyStatus = vehicle.OccupancyStatus.String()
+		}
+		vehicles = append(vehicles, []string{
+			vehicleID.ID,
+			vehicleID.Label,
+			vehicleID.LicensePlate,
+			tripID,
+			startDate,
+			formatFloat32Ptr(position.Latitude, opts.CoordinatePrecision),
+			formatFloat32Ptr(position.Longitude, opts.CoordinatePrecision),
+			formatFloat32Ptr(position.Bearing, opts.DecimalPrecision),
+			formatFloat32Ptr(position.Speed, opts.DecimalPrecision),
+			unPtr(vehicle.StopID),
+			currentStopSequence,
+			currentStatus,
+			formatUnix(vehicle.Timestamp),
+			occupancyStatus,
+		})
+	}
+	if result.VehiclesCsv, err = writeCsv(vehicles); err != nil {
+		return nil, err
+	}
+
+	alerts := [][]string{{"alert_id", "cause", "effect", "starts_at", "ends_at", "header", "description", "url", "route_ids", "stop_ids"}}
+	for i := range r.Alerts {
+		alert := &r.Alerts[i]
+		var routeIDs, stopIDs []string
+		for _, informedEntity := range alert.InformedEntities {
+			if informedEntity.RouteID != nil {
+				routeIDs = append(routeIDs, *informedEntity.RouteID)
+			}
+			if informedEntity.StopID != nil {
+				stopIDs = append(stopIDs, *informedEntity.StopID)
+			}
+		}
+		activePeriods := alert.ActivePeriods
+		if len(activePeriods) == 0 {
+			activePeriods = []AlertActivePeriod{{}}
+		}
+		for _, activePeriod := range activePeriods {
+			alerts = append(alerts, []string{
+				alert.ID,
+				alert.Cause.String(),
+				alert.Effect.String(),
+				formatUnix(activePeriod.StartsAt),
+				formatUnix(activePeriod.EndsAt),
+				firstText(alert.Header),
+				firstText(alert.Description),
+				firstText(alert.URL),
+				strings.Join(routeIDs, " "),
+				strings.Join(stopIDs, " "),
+			})
+		}
+	}
+	if result.AlertsCsv, err = writeCsv(alerts); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func formatStartDate(tripID TripID) string {
+	if !tripID.HasStartDate {
+		return ""
+	}
+	return tripID.StartDate.Format("2006-01-02")
+}
+
+func formatUnix(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+func formatSeconds(d *time.Duration) string {
+	if d == nil {
+		return ""
+	}
+	return strconv.FormatInt(int64(*d/time.Second), 10)
+}
+
+func formatInt32Ptr(i *int32) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.FormatInt(int64(*i), 10)
+}
+
+func formatFloat32Ptr(f *float32, precision int) string {
+	if f == nil {
+		return ""
+	}
+	return formatFloat(float64(*f), 32, precision)
+}
+
+func unPtr(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// firstText returns the text of the first translation, or the empty string if there are none.
+func firstText(texts []AlertText) string {
+	if len(texts) == 0 {
+		return ""
+	}
+	return texts[0].Text
+}
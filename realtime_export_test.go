@@ -0,0 +1,137 @@
+package gtfs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+)
+
+func TestRealtimeExportToCsv(t *testing.T) {
+	vehicle := gtfs.Vehicle{
+		ID: &gtfs.VehicleID{ID: vehicleID1, Label: "label, with comma", HasID: true, HasLabel: true},
+		Position: &gtfs.Position{
+			Latitude:  ptr(float32(40.5)),
+			Longitude: ptr(float32(-73.25)),
+		},
+		StopID:        ptr(stopID1),
+		CurrentStatus: ptr(gtfsrt.VehiclePosition_STOPPED_AT),
+		Timestamp:     ptr(time1),
+	}
+	trip := gtfs.Trip{
+		ID: gtfs.TripID{
+			ID:           tripID1,
+			RouteID:      "A",
+			DirectionID:  gtfs.DirectionID_True,
+			HasStartDate: true,
+			StartDate:    time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			HasStartTime: true,
+			StartTime:    time.Hour,
+		},
+		StopTimeUpdates: []gtfs.StopTimeUpdate{
+			{
+				StopSequence: ptr(uint32(3)),
+				StopID:       ptr(stopID1),
+				Track:        ptr("2"),
+				Arrival: &gtfs.StopTimeEvent{
+					Time:        ptr(time1),
+					Delay:       ptr(30 * time.Second),
+					Uncertainty: ptr(int32(60)),
+				},
+			},
+		},
+		Vehicle: &vehicle,
+	}
+	vehicle.Trip = &trip
+	realtime := &gtfs.Realtime{
+		Trips:    []gtfs.Trip{trip},
+		Vehicles: []gtfs.Vehicle{vehicle},
+		Alerts: []gtfs.Alert{
+			{
+				ID:     "alert",
+				Cause:  gtfsrt.Alert_CONSTRUCTION,
+				Effect: gtfsrt.Alert_DETOUR,
+				ActivePeriods: []gtfs.AlertActivePeriod{
+					{StartsAt: ptr(time1)},
+					{StartsAt: ptr(time2), EndsAt: ptr(time2)},
+				},
+				InformedEntities: []gtfs.AlertInformedEntity{
+					{RouteID: ptr("A")},
+					{RouteID: ptr("B"), StopID: ptr(stopID2)},
+				},
+				Header: []gtfs.AlertText{{Text: "Header"}, {Text: "Cabecera", Language: "es"}},
+			},
+		},
+	}
+
+	result, err := realtime.ExportToCsv()
+	if err != nil {
+		t.Fatalf("ExportToCsv() failed: %s", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{
+			name: "trips",
+			got:  result.TripsCsv,
+			want: "trip_id,route_id,direction_id,start_date,start_time,schedule_relationship,vehicle_id\n" +
+				"tripID1,A,1,2024-01-02,3600,SCHEDULED,vehicleID1\n",
+		},
+		{
+			name: "stop time updates",
+			got:  result.StopTimeUpdatesCsv,
+			want: "trip_id,start_date,stop_sequence,stop_id,track,arrival_time,arrival_delay,arrival_uncertainty,departure_time,departure_delay,departure_uncertainty,schedule_relationship\n" +
+				"tripID1,2024-01-02,3,stopID1,2,536871012,30,60,,,,SCHEDULED\n",
+		},
+		{
+			name: "vehicles",
+			got:  result.VehiclesCsv,
+			want: "vehicle_id,vehicle_label,license_plate,trip_id,start_date,latitude,longitude,bearing,speed,stop_id,current_stop_sequence,current_status,timestamp,occupancy_status\n" +
+				"vehicleID1,\"label, with comma\",,tripID1,2024-01-02,40.5,-73.25,,,stopID1,,STOPPED_AT,536871012,\n",
+		},
+		{
+			name: "alerts",
+			got:  result.AlertsCsv,
+			want: "alert_id,cause,effect,starts_at,ends_at,header,description,url,route_ids,stop_ids\n" +
+				"alert,CONSTRUCTION,DETOUR,536871012,,Header,,,A B,stopID2\n" +
+				"alert,CONSTRUCTION,DETOUR,536871112,536871112,Header,,,A B,stopID2\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if string(tc.got) != tc.want {
+				t.Errorf("got:\n%s\n!= want:\n%s", tc.got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRealtimeExportToCsv_Precision(t *testing.T) {
+	realtime := &gtfs.Realtime{
+		Vehicles: []gtfs.Vehicle{
+			{
+				ID: &gtfs.VehicleID{ID: vehicleID1},
+				Position: &gtfs.Position{
+					Latitude:  ptr(float32(40.7589123)),
+					Longitude: ptr(float32(-73.98512)),
+					Bearing:   ptr(float32(90.04)),
+					Speed:     ptr(float32(12.25)),
+				},
+			},
+		},
+	}
+
+	result, err := realtime.ExportToCsvWithOptions(gtfs.CsvExportOptions{CoordinatePrecision: 4, DecimalPrecision: 1})
+	if err != nil {
+		t.Fatalf("ExportToCsvWithOptions() failed: %s", err)
+	}
+
+	want := "vehicle_id,vehicle_label,license_plate,trip_id,start_date,latitude,longitude,bearing,speed,stop_id,current_stop_sequence,current_status,timestamp,occupancy_status\n" +
+		"vehicleID1,,,,,40.7589,-73.9851,90,12.2,,,,,\n"
+	if string(result.VehiclesCsv) != want {
+		t.Errorf("got:\n%s\n!= want:\n%s", result.VehiclesCsv, want)
+	}
+}
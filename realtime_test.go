@@ -1,13 +1,18 @@
 package gtfs_test
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/jamespfennell/gtfs"
+	"github.com/jamespfennell/gtfs/extensions"
 	"github.com/jamespfennell/gtfs/internal/testutil"
 	gtfsrt "github.com/jamespfennell/gtfs/proto"
+	"github.com/jamespfennell/gtfs/warnings"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -15,6 +20,7 @@ const (
 	tripID2    = "tripID2"
 	tripID3    = "tripID3"
 	vehicleID1 = "vehicleID1"
+	vehicleID2 = "vehicleID2"
 	stopID1    = "stopID1"
 	stopID2    = "stopID2"
 	stopID3    = "stopID3"
@@ -96,7 +102,8 @@ func TestRealtime(t *testing.T) {
 				}
 				vehicle := gtfs.Vehicle{
 					ID: &gtfs.VehicleID{
-						ID: vehicleID1,
+						ID:    vehicleID1,
+						HasID: true,
 					},
 					IsEntityInMessage: false,
 				}
@@ -193,7 +200,8 @@ func TestRealtime(t *testing.T) {
 				}
 				vehicle := gtfs.Vehicle{
 					ID: &gtfs.VehicleID{
-						ID: vehicleID1,
+						ID:    vehicleID1,
+						HasID: true,
 					},
 					Position:            &position,
 					CurrentStopSequence: ptr(uint32(6)),
@@ -376,7 +384,8 @@ func TestRealtime(t *testing.T) {
 				}
 				vehicle := gtfs.Vehicle{
 					ID: &gtfs.VehicleID{
-						ID: vehicleID1,
+						ID:    vehicleID1,
+						HasID: true,
 					},
 					IsEntityInMessage: true,
 				}
@@ -864,6 +873,304 @@ func TestRealtime(t *testing.T) {
 	}
 }
 
+func TestTripIDMatchesScheduled(t *testing.T) {
+	route := gtfs.Route{Id: "A"}
+	monday := time.Date(2022, 5, 2, 0, 0, 0, 0, time.UTC)
+	tuesday := time.Date(2022, 5, 3, 0, 0, 0, 0, time.UTC)
+	service := gtfs.Service{
+		Id:        "weekdays",
+		Monday:    true,
+		StartDate: monday,
+		EndDate:   monday.AddDate(0, 1, 0),
+	}
+	trip := gtfs.ScheduledTrip{
+		ID:          tripID1,
+		Route:       &route,
+		Service:     &service,
+		DirectionId: gtfs.DirectionID_True,
+		StopTimes: []gtfs.ScheduledStopTime{
+			{DepartureTime: 8 * time.Hour},
+			{DepartureTime: 9 * time.Hour},
+		},
+	}
+	for _, tc := range []struct {
+		name   string
+		tripID gtfs.TripID
+		date   time.Time
+		want   bool
+	}{
+		{
+			name:   "trip ID matches",
+			tripID: gtfs.TripID{ID: tripID1},
+			date:   monday,
+			want:   true,
+		},
+		{
+			name:   "trip ID does not match",
+			tripID: gtfs.TripID{ID: tripID2},
+			date:   monday,
+			want:   false,
+		},
+		{
+			name:   "service not running",
+			tripID: gtfs.TripID{ID: tripID1},
+			date:   tuesday,
+			want:   false,
+		},
+		{
+			name:   "start date overrides date",
+			tripID: gtfs.TripID{ID: tripID1, HasStartDate: true, StartDate: monday},
+			date:   tuesday,
+			want:   true,
+		},
+		{
+			name: "route, direction and start time match",
+			tripID: gtfs.TripID{
+				RouteID:      "A",
+				DirectionID:  gtfs.DirectionID_True,
+				HasStartTime: true,
+				StartTime:    8 * time.Hour,
+			},
+			date: monday,
+			want: true,
+		},
+		{
+			name:   "direction does not match",
+			tripID: gtfs.TripID{RouteID: "A", DirectionID: gtfs.DirectionID_False},
+			date:   monday,
+			want:   false,
+		},
+		{
+			name:   "start time does not match",
+			tripID: gtfs.TripID{RouteID: "A", HasStartTime: true, StartTime: 9 * time.Hour},
+			date:   monday,
+			want:   false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.tripID.MatchesScheduled(&trip, tc.date)
+			if got != tc.want {
+				t.Errorf("MatchesScheduled() got = %t, want = %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInferVehicleTrips(t *testing.T) {
+	entities := []*gtfsrt.FeedEntity{
+		{
+			Id: ptr("1"),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip: &gtfsrt.TripDescriptor{
+					TripId:  ptr(tripID1),
+					RouteId: ptr("A"),
+				},
+				StopTimeUpdate: []*gtfsrt.TripUpdate_StopTimeUpdate{
+					{StopId: ptr(stopID1)},
+					{StopId: ptr(stopID2)},
+				},
+			},
+		},
+		{
+			Id: ptr("2"),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip: &gtfsrt.TripDescriptor{
+					TripId:  ptr(tripID2),
+					RouteId: ptr("B"),
+				},
+				StopTimeUpdate: []*gtfsrt.TripUpdate_StopTimeUpdate{
+					{StopId: ptr(stopID2)},
+				},
+			},
+		},
+		{
+			Id: ptr("3"),
+			Vehicle: &gtfsrt.VehiclePosition{
+				Vehicle: &gtfsrt.VehicleDescriptor{
+					Id: ptr(vehicleID1),
+				},
+				StopId: ptr(stopID1),
+			},
+		},
+	}
+	for _, tc := range []struct {
+		name           string
+		infer          bool
+		wantLinkedTrip string
+		wantIsInferred bool
+	}{
+		{
+			name:  "disabled",
+			infer: false,
+		},
+		{
+			name:           "enabled",
+			infer:          true,
+			wantLinkedTrip: tripID1,
+			wantIsInferred: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := testutil.MustParse(t, nil, entities, &gtfs.ParseRealtimeOptions{
+				InferVehicleTrips: tc.infer,
+			})
+
+			if len(got.Trips) != 2 {
+				t.Errorf("got %d trips, want 2", len(got.Trips))
+			}
+			if len(got.Vehicles) != 1 {
+				t.Fatalf("got %d vehicles, want 1", len(got.Vehicles))
+			}
+			vehicle := got.Vehicles[0]
+			var gotLinkedTrip string
+			if vehicle.Trip != nil {
+				gotLinkedTrip = vehicle.Trip.ID.ID
+				if vehicle.Trip.Vehicle == nil || !vehicle.Trip.IsVehicleInferred {
+					t.Errorf("trip is not linked back to the vehicle")
+				}
+			}
+			if gotLinkedTrip != tc.wantLinkedTrip {
+				t.Errorf("vehicle linked to trip %q, want %q", gotLinkedTrip, tc.wantLinkedTrip)
+			}
+			if vehicle.IsTripInferred != tc.wantIsInferred {
+				t.Errorf("vehicle IsTripInferred = %t, want %t", vehicle.IsTripInferred, tc.wantIsInferred)
+			}
+		})
+	}
+}
+
+func TestDeduplicateAlerts(t *testing.T) {
+	newAlert := func(stopID string) *gtfsrt.Alert {
+		alert := buildBaseRtAlert()
+		alert.InformedEntity = []*gtfsrt.EntitySelector{
+			{StopId: ptr(stopID)},
+		}
+		return alert
+	}
+	otherAlert := newAlert(stopID3)
+	otherAlert.Effect = ptr(gtfsrt.Alert_NO_SERVICE)
+	entities := []*gtfsrt.FeedEntity{
+		{Id: ptr("1"), Alert: newAlert(stopID1)},
+		{Id: ptr("2"), Alert: newAlert(stopID2)},
+		{Id: ptr("3"), Alert: newAlert(stopID1)},
+		{Id: ptr("4"), Alert: otherAlert},
+	}
+	for _, tc := range []struct {
+		name string
+		opts gtfs.ParseRealtimeOptions
+		want map[string][]string
+	}{
+		{
+			name: "disabled",
+			opts: gtfs.ParseRealtimeOptions{},
+			want: map[string][]string{
+				"1": {stopID1},
+				"2": {stopID2},
+				"3": {stopID1},
+				"4": {stopID3},
+			},
+		},
+		{
+			name: "enabled",
+			opts: gtfs.ParseRealtimeOptions{DeduplicateAlerts: true},
+			want: map[string][]string{
+				"1": {stopID1, stopID2},
+				"4": {stopID3},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result := testutil.MustParse(t, nil, entities, &tc.opts)
+
+			got := map[string][]string{}
+			for _, alert := range result.Alerts {
+				for _, entity := range alert.InformedEntities {
+					got[alert.ID] = append(got[alert.ID], *entity.StopID)
+				}
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("got = %v, want = %v, diff: %s", got, tc.want, diff)
+			}
+		})
+	}
+}
+
+func TestAlertIsActiveAt(t *testing.T) {
+	time0 := createTime
+	time3 := time.Unix(2<<28+300, 0).UTC()
+	for _, tc := range []struct {
+		name          string
+		activePeriods []gtfs.AlertActivePeriod
+		t             time.Time
+		want          bool
+	}{
+		{
+			name: "no active periods",
+			t:    time1,
+			want: true,
+		},
+		{
+			name:          "within period",
+			activePeriods: []gtfs.AlertActivePeriod{{StartsAt: &time0, EndsAt: &time2}},
+			t:             time1,
+			want:          true,
+		},
+		{
+			name:          "at end of period",
+			activePeriods: []gtfs.AlertActivePeriod{{StartsAt: &time0, EndsAt: &time2}},
+			t:             time2,
+			want:          true,
+		},
+		{
+			name:          "before period",
+			activePeriods: []gtfs.AlertActivePeriod{{StartsAt: &time1, EndsAt: &time2}},
+			t:             time0,
+			want:          false,
+		},
+		{
+			name:          "open ended period",
+			activePeriods: []gtfs.AlertActivePeriod{{StartsAt: &time1}},
+			t:             time3,
+			want:          true,
+		},
+		{
+			name:          "open started period",
+			activePeriods: []gtfs.AlertActivePeriod{{EndsAt: &time1}},
+			t:             time0,
+			want:          true,
+		},
+		{
+			name: "between periods",
+			activePeriods: []gtfs.AlertActivePeriod{
+				{EndsAt: &time0},
+				{StartsAt: &time2},
+			},
+			t:    time1,
+			want: false,
+		},
+		{
+			name: "in second period",
+			activePeriods: []gtfs.AlertActivePeriod{
+				{EndsAt: &time0},
+				{StartsAt: &time2},
+			},
+			t:    time3,
+			want: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			alert := gtfs.Alert{ActivePeriods: tc.activePeriods}
+			if got := alert.IsActiveAt(tc.t); got != tc.want {
+				t.Errorf("IsActiveAt() got = %t, want = %t", got, tc.want)
+			}
+			realtime := gtfs.Realtime{Alerts: []gtfs.Alert{alert}}
+			if got := len(realtime.ActiveAlerts(tc.t)) == 1; got != tc.want {
+				t.Errorf("ActiveAlerts() got = %t, want = %t", got, tc.want)
+			}
+		})
+	}
+}
+
 func buildBaseRtAlert() *gtfsrt.Alert {
 	return &gtfsrt.Alert{
 		ActivePeriod: []*gtfsrt.TimeRange{
@@ -914,3 +1221,985 @@ func parseDirectionID_GTFSRealtimeRaw(d gtfs.DirectionID) *uint32 {
 func ptr[T any](t T) *T {
 	return &t
 }
+
+func TestTripUncertainty(t *testing.T) {
+	event := func(uncertainty *int32) *gtfs.StopTimeEvent {
+		return &gtfs.StopTimeEvent{Time: ptr(time.Unix(100, 0)), Uncertainty: uncertainty}
+	}
+	trip := gtfs.Trip{
+		StopTimeUpdates: []gtfs.StopTimeUpdate{
+			{
+				StopID:    ptr(stopID1),
+				Arrival:   event(ptr(int32(30))),
+				Departure: event(ptr(int32(60))),
+			},
+			{
+				StopID:    ptr(stopID2),
+				Arrival:   event(ptr(int32(120))),
+				Departure: event(nil),
+			},
+			{
+				StopID:  ptr(stopID3),
+				Arrival: event(ptr(int32(300))),
+			},
+			{
+				StopID:               ptr("stopID4"),
+				ScheduleRelationship: gtfsrt.TripUpdate_StopTimeUpdate_SKIPPED,
+			},
+		},
+	}
+
+	gotUncertainty := trip.Uncertainty()
+	wantUncertainty := gtfs.TripUncertainty{NumEvents: 4, Max: 300, Mean: 127.5}
+	if diff := cmp.Diff(gotUncertainty, wantUncertainty); diff != "" {
+		t.Errorf("Uncertainty() got = %v, want = %v, diff = %s", gotUncertainty, wantUncertainty, diff)
+	}
+
+	gotUpdates := trip.StopTimeUpdatesWithinUncertainty(100)
+	wantUpdates := []gtfs.StopTimeUpdate{
+		trip.StopTimeUpdates[0],
+		{
+			StopID:    ptr(stopID2),
+			Departure: event(nil),
+		},
+		trip.StopTimeUpdates[3],
+	}
+	if diff := cmp.Diff(gotUpdates, wantUpdates); diff != "" {
+		t.Errorf("StopTimeUpdatesWithinUncertainty() got = %v, want = %v, diff = %s", gotUpdates, wantUpdates, diff)
+	}
+	if trip.StopTimeUpdates[1].Arrival == nil {
+		t.Errorf("StopTimeUpdatesWithinUncertainty() modified the trip")
+	}
+
+	if got := (&gtfs.Trip{}).Uncertainty(); got != (gtfs.TripUncertainty{}) {
+		t.Errorf("Uncertainty() of empty trip = %v, want zero value", got)
+	}
+}
+
+func TestTrackFromAssignedStop(t *testing.T) {
+	entities := []*gtfsrt.FeedEntity{
+		{
+			Id: ptr("1"),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip: &gtfsrt.TripDescriptor{
+					TripId: ptr(tripID1),
+				},
+				StopTimeUpdate: []*gtfsrt.TripUpdate_StopTimeUpdate{
+					{
+						StopId: ptr(stopID1),
+						StopTimeProperties: &gtfsrt.TripUpdate_StopTimeUpdate_StopTimeProperties{
+							AssignedStopId: ptr("stopID1b"),
+						},
+					},
+					{
+						StopId: ptr(stopID2),
+					},
+				},
+			},
+		},
+	}
+	static := &gtfs.Static{
+		Stops: []gtfs.Stop{
+			{Id: stopID1, PlatformCode: "1"},
+			{Id: "stopID1b", PlatformCode: "2"},
+		},
+	}
+	for _, tc := range []struct {
+		name      string
+		static    *gtfs.Static
+		wantTrack *string
+	}{
+		{
+			name: "no static feed",
+		},
+		{
+			name:      "with static feed",
+			static:    static,
+			wantTrack: ptr("2"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := testutil.MustParse(t, nil, entities, &gtfs.ParseRealtimeOptions{
+				Static: tc.static,
+			})
+
+			want := []gtfs.StopTimeUpdate{
+				{
+					StopID:         ptr(stopID1),
+					AssignedStopID: ptr("stopID1b"),
+					Track:          tc.wantTrack,
+					NyctTrack:      tc.wantTrack,
+				},
+				{
+					StopID: ptr(stopID2),
+				},
+			}
+			if diff := cmp.Diff(got.Trips[0].StopTimeUpdates, want); diff != "" {
+				t.Errorf("got = %v, want = %v, diff = %s", got.Trips[0].StopTimeUpdates, want, diff)
+			}
+		})
+	}
+}
+
+func TestVehicleIDFieldPresence(t *testing.T) {
+	entities := []*gtfsrt.FeedEntity{
+		{
+			Id: ptr("1"),
+			Vehicle: &gtfsrt.VehiclePosition{
+				Vehicle: &gtfsrt.VehicleDescriptor{
+					Id:    ptr(vehicleID1),
+					Label: ptr(""),
+				},
+			},
+		},
+		{
+			Id: ptr("2"),
+			Vehicle: &gtfsrt.VehiclePosition{
+				Vehicle: &gtfsrt.VehicleDescriptor{
+					Id: ptr(vehicleID1),
+				},
+			},
+		},
+	}
+
+	got := testutil.MustParse(t, nil, entities, &gtfs.ParseRealtimeOptions{})
+
+	// The descriptors only differ in whether the empty label is present, so they are the same vehicle.
+	var gotIDs []gtfs.VehicleID
+	for _, vehicle := range got.Vehicles {
+		gotIDs = append(gotIDs, *vehicle.ID)
+	}
+	wantIDs := []gtfs.VehicleID{
+		{ID: vehicleID1, HasID: true},
+	}
+	if diff := cmp.Diff(gotIDs, wantIDs); diff != "" {
+		t.Errorf("got = %v, want = %v, diff = %s", gotIDs, wantIDs, diff)
+	}
+}
+
+func TestVehicleKey(t *testing.T) {
+	entities := []*gtfsrt.FeedEntity{
+		{
+			Id: ptr("1"),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip: &gtfsrt.TripDescriptor{
+					TripId: ptr(tripID1),
+				},
+				Vehicle: &gtfsrt.VehicleDescriptor{
+					Id:           ptr(vehicleID1),
+					Label:        ptr("label"),
+					LicensePlate: ptr("plate1"),
+				},
+			},
+		},
+		{
+			Id: ptr("2"),
+			Vehicle: &gtfsrt.VehiclePosition{
+				Vehicle: &gtfsrt.VehicleDescriptor{
+					Id:           ptr(vehicleID1),
+					Label:        ptr("label"),
+					LicensePlate: ptr("plate2"),
+				},
+			},
+		},
+	}
+	for _, tc := range []struct {
+		name         string
+		vehicleKey   func(gtfs.VehicleID) gtfs.VehicleID
+		wantVehicles int
+	}{
+		{
+			name:         "full vehicle ID",
+			wantVehicles: 2,
+		},
+		{
+			name:         "ID only",
+			vehicleKey:   gtfs.VehicleKeyByID,
+			wantVehicles: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := testutil.MustParse(t, nil, entities, &gtfs.ParseRealtimeOptions{
+				VehicleKey: tc.vehicleKey,
+			})
+
+			if len(got.Vehicles) != tc.wantVehicles {
+				t.Fatalf("got %d vehicles, want %d", len(got.Vehicles), tc.wantVehicles)
+			}
+			if len(got.Trips) != 1 || got.Trips[0].Vehicle == nil {
+				t.Fatalf("trip not linked to a vehicle")
+			}
+			if tc.wantVehicles == 1 && !got.Trips[0].Vehicle.IsEntityInMessage {
+				t.Errorf("trip linked to vehicle %v, want the vehicle entity in the message", got.Trips[0].Vehicle)
+			}
+		})
+	}
+}
+
+func TestVehicleWheelchairAccessible(t *testing.T) {
+	tripUpdate := func(tripID string, wheelchairAccessible *gtfsrt.VehicleDescriptor_WheelchairAccessible) *gtfsrt.FeedEntity {
+		return &gtfsrt.FeedEntity{
+			Id: ptr(tripID),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip: &gtfsrt.TripDescriptor{
+					TripId: ptr(tripID),
+				},
+				Vehicle: &gtfsrt.VehicleDescriptor{
+					Id:                   ptr(tripID + "_vehicle"),
+					WheelchairAccessible: wheelchairAccessible,
+				},
+			},
+		}
+	}
+	vehiclePosition := func(vehicleID string, wheelchairAccessible *gtfsrt.VehicleDescriptor_WheelchairAccessible) *gtfsrt.FeedEntity {
+		return &gtfsrt.FeedEntity{
+			Id: ptr(vehicleID),
+			Vehicle: &gtfsrt.VehiclePosition{
+				Vehicle: &gtfsrt.VehicleDescriptor{
+					Id:                   ptr(vehicleID),
+					WheelchairAccessible: wheelchairAccessible,
+				},
+			},
+		}
+	}
+	accessible := gtfsrt.VehicleDescriptor_WHEELCHAIR_ACCESSIBLE.Enum()
+	inaccessible := gtfsrt.VehicleDescriptor_WHEELCHAIR_INACCESSIBLE.Enum()
+	entities := []*gtfsrt.FeedEntity{
+		// Only in the trip update.
+		tripUpdate("trip_1", accessible),
+		// In the trip update, with a vehicle position that does not specify it.
+		tripUpdate("trip_2", inaccessible),
+		vehiclePosition("trip_2_vehicle", nil),
+		// Only in the vehicle position.
+		tripUpdate("trip_3", nil),
+		vehiclePosition("trip_3_vehicle", accessible),
+		// In neither.
+		tripUpdate("trip_4", nil),
+	}
+
+	got := testutil.MustParse(t, nil, entities, &gtfs.ParseRealtimeOptions{})
+
+	want := map[string]*gtfs.WheelchairAccessible{
+		"trip_1": accessible,
+		"trip_2": inaccessible,
+		"trip_3": accessible,
+		"trip_4": nil,
+	}
+	for _, trip := range got.Trips {
+		if trip.Vehicle == nil {
+			t.Errorf("trip %s not linked to a vehicle", trip.ID.ID)
+			continue
+		}
+		if diff := cmp.Diff(trip.Vehicle.WheelchairAccessible, want[trip.ID.ID]); diff != "" {
+			t.Errorf("trip %s: WheelchairAccessible diff: %s", trip.ID.ID, diff)
+		}
+	}
+}
+
+type fixHeaderTimestampExtension struct {
+	extensions.NoExtensionImpl
+	timestamp          uint64
+	tripFeedCreatedAts *[]uint64
+}
+
+func (e fixHeaderTimestampExtension) UpdateHeader(header *gtfsrt.FeedHeader) {
+	header.Timestamp = &e.timestamp
+}
+
+func (e fixHeaderTimestampExtension) UpdateTrip(trip *gtfsrt.TripUpdate, feedCreatedAt uint64) extensions.UpdateTripResult {
+	*e.tripFeedCreatedAts = append(*e.tripFeedCreatedAts, feedCreatedAt)
+	return extensions.UpdateTripResult{}
+}
+
+func TestExtensionUpdateHeader(t *testing.T) {
+	var tripFeedCreatedAts []uint64
+	extension := fixHeaderTimestampExtension{
+		timestamp:          uint64(time1.Unix()),
+		tripFeedCreatedAts: &tripFeedCreatedAts,
+	}
+	v := "2.0"
+	header := &gtfsrt.FeedHeader{
+		GtfsRealtimeVersion: &v,
+		Timestamp:           ptr(uint64(createTime.Unix())),
+	}
+	entities := []*gtfsrt.FeedEntity{
+		{
+			Id: ptr("1"),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip: &gtfsrt.TripDescriptor{TripId: ptr(tripID1)},
+			},
+		},
+	}
+
+	result := testutil.MustParse(t, header, entities, &gtfs.ParseRealtimeOptions{Extension: extension})
+
+	if !result.CreatedAt.Equal(time1) {
+		t.Errorf("CreatedAt got = %v, want = %v", result.CreatedAt, time1)
+	}
+	if want := []uint64{uint64(time1.Unix())}; !cmp.Equal(tripFeedCreatedAts, want) {
+		t.Errorf("UpdateTrip() feedCreatedAt got = %v, want = %v", tripFeedCreatedAts, want)
+	}
+}
+
+func TestDuplicateTripPolicy(t *testing.T) {
+	newEntity := func(id string, stopSequences ...uint32) *gtfsrt.FeedEntity {
+		var stopTimeUpdates []*gtfsrt.TripUpdate_StopTimeUpdate
+		for _, stopSequence := range stopSequences {
+			stopTimeUpdates = append(stopTimeUpdates, &gtfsrt.TripUpdate_StopTimeUpdate{
+				StopSequence: ptr(stopSequence),
+				StopId:       ptr(id),
+			})
+		}
+		return &gtfsrt.FeedEntity{
+			Id: ptr(id),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip:           &gtfsrt.TripDescriptor{TripId: ptr(tripID1)},
+				StopTimeUpdate: stopTimeUpdates,
+			},
+		}
+	}
+	entities := []*gtfsrt.FeedEntity{
+		newEntity("1", 1, 2),
+		newEntity("2", 2, 3),
+	}
+	for _, tc := range []struct {
+		policy       gtfs.DuplicateTripPolicy
+		wantStops    []string
+		wantWarnings []warnings.RealtimeWarning
+	}{
+		{
+			policy:    gtfs.DuplicateTripPolicy_KeepLast,
+			wantStops: []string{"2", "2"},
+		},
+		{
+			policy:    gtfs.DuplicateTripPolicy_KeepFirst,
+			wantStops: []string{"1", "1"},
+		},
+		{
+			policy:    gtfs.DuplicateTripPolicy_MergeStopTimeUpdates,
+			wantStops: []string{"1", "2", "2"},
+		},
+		{
+			policy:    gtfs.DuplicateTripPolicy_Warn,
+			wantStops: []string{"2", "2"},
+			wantWarnings: []warnings.RealtimeWarning{
+				{Kind: warnings.DuplicateTrip{TripID: tripID1}, EntityID: "2"},
+			},
+		},
+	} {
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			result := testutil.MustParse(t, nil, entities, &gtfs.ParseRealtimeOptions{
+				DuplicateTripPolicy: tc.policy,
+			})
+
+			if len(result.Trips) != 1 {
+				t.Fatalf("got %d trips, want 1", len(result.Trips))
+			}
+			var gotStops []string
+			for _, stopTimeUpdate := range result.Trips[0].StopTimeUpdates {
+				gotStops = append(gotStops, *stopTimeUpdate.StopID)
+			}
+			if diff := cmp.Diff(gotStops, tc.wantStops); diff != "" {
+				t.Errorf("stop IDs got = %v, want = %v", gotStops, tc.wantStops)
+			}
+			if diff := cmp.Diff(result.Warnings, tc.wantWarnings); diff != "" {
+				t.Errorf("warnings got = %v, want = %v", result.Warnings, tc.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestDuplicateTripPolicy_KeepFirstWithVehicles(t *testing.T) {
+	newEntity := func(id string, vehicleID string) *gtfsrt.FeedEntity {
+		return &gtfsrt.FeedEntity{
+			Id: ptr(id),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip:    &gtfsrt.TripDescriptor{TripId: ptr(tripID1)},
+				Vehicle: &gtfsrt.VehicleDescriptor{Id: ptr(vehicleID)},
+			},
+		}
+	}
+	entities := []*gtfsrt.FeedEntity{
+		newEntity("1", vehicleID1),
+		newEntity("2", vehicleID2),
+	}
+
+	result := testutil.MustParse(t, nil, entities, &gtfs.ParseRealtimeOptions{
+		DuplicateTripPolicy: gtfs.DuplicateTripPolicy_KeepFirst,
+	})
+
+	if len(result.Trips) != 1 {
+		t.Fatalf("got %d trips, want 1", len(result.Trips))
+	}
+	vehicle := result.Trips[0].Vehicle
+	if vehicle == nil || vehicle.GetID().ID != vehicleID1 {
+		t.Errorf("trip vehicle got = %v, want %s", vehicle, vehicleID1)
+	}
+	for _, vehicle := range result.Vehicles {
+		if vehicle.GetID().ID == vehicleID2 && vehicle.Trip != nil {
+			t.Errorf("vehicle %s of the discarded trip update is linked to trip %s", vehicleID2, vehicle.Trip.ID.ID)
+		}
+	}
+}
+
+func TestTripIDNormalizer(t *testing.T) {
+	entities := []*gtfsrt.FeedEntity{
+		{
+			Id: ptr("1"),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip: &gtfsrt.TripDescriptor{TripId: ptr("agency:" + tripID1)},
+			},
+		},
+		{
+			Id: ptr("2"),
+			Vehicle: &gtfsrt.VehiclePosition{
+				Trip:    &gtfsrt.TripDescriptor{TripId: ptr("agency:" + tripID1)},
+				Vehicle: &gtfsrt.VehicleDescriptor{Id: ptr(vehicleID1)},
+			},
+		},
+		{
+			Id: ptr("3"),
+			Alert: &gtfsrt.Alert{
+				InformedEntity: []*gtfsrt.EntitySelector{
+					{Trip: &gtfsrt.TripDescriptor{TripId: ptr("agency:" + tripID2)}},
+				},
+			},
+		},
+	}
+
+	result := testutil.MustParse(t, nil, entities, &gtfs.ParseRealtimeOptions{
+		TripIDNormalizer: gtfs.TrimTripIDPrefix("agency:"),
+	})
+
+	var gotTripIDs []string
+	for _, trip := range result.Trips {
+		gotTripIDs = append(gotTripIDs, trip.ID.ID)
+	}
+	if want := []string{tripID1, tripID2}; !cmp.Equal(gotTripIDs, want) {
+		t.Errorf("trip IDs got = %v, want = %v", gotTripIDs, want)
+	}
+	if len(result.Vehicles) != 1 || result.Vehicles[0].Trip == nil || result.Vehicles[0].Trip.ID.ID != tripID1 {
+		t.Errorf("vehicle not linked to normalized trip: %+v", result.Vehicles)
+	}
+	if got := result.Alerts[0].InformedEntities[0].TripID.ID; got != tripID2 {
+		t.Errorf("alert trip ID got = %q, want = %q", got, tripID2)
+	}
+}
+
+func TestStopIDNormalizer(t *testing.T) {
+	entities := []*gtfsrt.FeedEntity{
+		{
+			Id: ptr("1"),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip: &gtfsrt.TripDescriptor{TripId: ptr(tripID1)},
+				StopTimeUpdate: []*gtfsrt.TripUpdate_StopTimeUpdate{
+					{
+						StopId: ptr("00" + stopID1),
+						StopTimeProperties: &gtfsrt.TripUpdate_StopTimeUpdate_StopTimeProperties{
+							AssignedStopId: ptr("0" + stopID2),
+						},
+					},
+				},
+			},
+		},
+		{
+			Id: ptr("2"),
+			Vehicle: &gtfsrt.VehiclePosition{
+				Vehicle: &gtfsrt.VehicleDescriptor{Id: ptr(vehicleID1)},
+				StopId:  ptr("0" + stopID3),
+			},
+		},
+		{
+			Id: ptr("3"),
+			Alert: &gtfsrt.Alert{
+				InformedEntity: []*gtfsrt.EntitySelector{{StopId: ptr("0" + stopID1)}},
+			},
+		},
+	}
+
+	result := testutil.MustParse(t, nil, entities, &gtfs.ParseRealtimeOptions{
+		StopIDNormalizer: func(stopID string) string {
+			return strings.TrimLeft(stopID, "0")
+		},
+	})
+
+	stopTimeUpdate := result.Trips[0].StopTimeUpdates[0]
+	for _, tc := range []struct {
+		name string
+		got  *string
+		want string
+	}{
+		{"stop time update", stopTimeUpdate.StopID, stopID1},
+		{"assigned stop", stopTimeUpdate.AssignedStopID, stopID2},
+		{"vehicle", result.Vehicles[0].StopID, stopID3},
+		{"alert", result.Alerts[0].InformedEntities[0].StopID, stopID1},
+	} {
+		if tc.got == nil || *tc.got != tc.want {
+			t.Errorf("%s stop ID got = %v, want = %q", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+func TestSelectAlertText(t *testing.T) {
+	texts := []gtfs.AlertText{
+		{Text: "Delays", Language: "en"},
+		{Text: "Retrasos", Language: "es"},
+		{Text: "延误", Language: "zh-Hans"},
+		{Text: "延誤", Language: "zh-Hant"},
+	}
+	for _, tc := range []struct {
+		texts []gtfs.AlertText
+		lang  string
+		want  string
+	}{
+		{texts, "es", "Retrasos"},
+		{texts, "en-US", "Delays"},
+		{texts, "ES-mx", "Retrasos"},
+		{texts, "zh-TW", "延誤"},
+		{texts, "zh-CN", "延误"},
+		{texts, "fr", "Delays"},
+		{texts, "not a language tag", "Delays"},
+		{[]gtfs.AlertText{{Text: "Retards", Language: "fr"}, {Text: "Untranslated"}}, "de", "Untranslated"},
+		{nil, "en", ""},
+	} {
+		alert := gtfs.Alert{Header: tc.texts, Description: tc.texts}
+		if got := alert.HeaderIn(tc.lang); got != tc.want {
+			t.Errorf("HeaderIn(%q) = %q, want %q", tc.lang, got, tc.want)
+		}
+		if got := alert.DescriptionIn(tc.lang); got != tc.want {
+			t.Errorf("DescriptionIn(%q) = %q, want %q", tc.lang, got, tc.want)
+		}
+	}
+}
+
+func TestConvertAlertHTML(t *testing.T) {
+	alert := buildBaseRtAlert()
+	alert.DescriptionText.Translation[0].Text = ptr(`<p>Use the <a href="https://example.com">shuttle</a>.</p>`)
+	entities := []*gtfsrt.FeedEntity{{Id: ptr("1"), Alert: alert}}
+
+	result := testutil.MustParse(t, nil, entities, &gtfs.ParseRealtimeOptions{ConvertAlertHTML: true})
+
+	want := []gtfs.AlertText{
+		{
+			Text:     "Use the shuttle.",
+			Language: "DescriptionLanguage",
+			Links:    []gtfs.AlertLink{{Text: "shuttle", URL: "https://example.com"}},
+		},
+	}
+	if diff := cmp.Diff(result.Alerts[0].Description, want); diff != "" {
+		t.Errorf("Description diff: %s", diff)
+	}
+	if got := result.Alerts[0].Header[0].Text; got != "HeaderText" {
+		t.Errorf("Header text = %q, want %q", got, "HeaderText")
+	}
+}
+
+func TestAlertInformedEntityScope(t *testing.T) {
+	for _, tc := range []struct {
+		entity gtfs.AlertInformedEntity
+		want   gtfs.InformedEntityScope
+	}{
+		{gtfs.AlertInformedEntity{RouteType: gtfs.RouteType_Unknown}, gtfs.InformedEntityScope_Unknown},
+		{gtfs.AlertInformedEntity{AgencyID: ptr("agency"), RouteType: gtfs.RouteType_Unknown}, gtfs.InformedEntityScope_AgencyWide},
+		{gtfs.AlertInformedEntity{AgencyID: ptr("agency"), RouteType: gtfs.RouteType_Bus}, gtfs.InformedEntityScope_RouteTypeScoped},
+		{gtfs.AlertInformedEntity{AgencyID: ptr("agency"), RouteID: ptr("A")}, gtfs.InformedEntityScope_RouteScoped},
+		{gtfs.AlertInformedEntity{RouteID: ptr("A"), StopID: ptr(stopID1)}, gtfs.InformedEntityScope_StopScoped},
+		{gtfs.AlertInformedEntity{RouteID: ptr("A"), TripID: &gtfs.TripID{RouteID: "A"}}, gtfs.InformedEntityScope_RouteScoped},
+		{gtfs.AlertInformedEntity{StopID: ptr(stopID1), TripID: &gtfs.TripID{ID: tripID1}}, gtfs.InformedEntityScope_TripScoped},
+	} {
+		if got := tc.entity.Scope(); got != tc.want {
+			t.Errorf("Scope() of %+v = %s, want %s", tc.entity, got, tc.want)
+		}
+	}
+}
+
+func TestSystemWideAlerts(t *testing.T) {
+	realtime := &gtfs.Realtime{
+		Alerts: []gtfs.Alert{
+			{ID: "route", InformedEntities: []gtfs.AlertInformedEntity{{AgencyID: ptr("agency"), RouteID: ptr("A")}}},
+			{ID: "agency", InformedEntities: []gtfs.AlertInformedEntity{{StopID: ptr(stopID1)}, {AgencyID: ptr("agency"), RouteType: gtfs.RouteType_Unknown}}},
+		},
+	}
+
+	got := realtime.SystemWideAlerts()
+
+	if len(got) != 1 || got[0].ID != "agency" {
+		t.Errorf("SystemWideAlerts() = %+v, want the alert with ID agency", got)
+	}
+}
+
+func TestAlertsForRoute(t *testing.T) {
+	agency := &gtfs.Agency{Id: "agency"}
+	otherAgency := &gtfs.Agency{Id: "other"}
+	bus := &gtfs.Route{Id: "bus", Agency: agency, Type: gtfs.RouteType_Bus}
+	otherBus := &gtfs.Route{Id: "other_bus", Agency: otherAgency, Type: gtfs.RouteType_Bus}
+	subway := &gtfs.Route{Id: "subway", Agency: agency, Type: gtfs.RouteType_Subway}
+	realtime := &gtfs.Realtime{
+		Alerts: []gtfs.Alert{
+			{ID: "agency_buses", InformedEntities: []gtfs.AlertInformedEntity{{AgencyID: ptr("agency"), RouteType: gtfs.RouteType_Bus}}},
+			{ID: "all_buses", InformedEntities: []gtfs.AlertInformedEntity{{RouteType: gtfs.RouteType_Bus}}},
+			{ID: "agency", InformedEntities: []gtfs.AlertInformedEntity{{AgencyID: ptr("agency"), RouteType: gtfs.RouteType_Unknown}}},
+			{ID: "subway_stop", InformedEntities: []gtfs.AlertInformedEntity{{RouteID: ptr("subway"), RouteType: gtfs.RouteType_Unknown, StopID: ptr(stopID1)}}},
+			{ID: "stop", InformedEntities: []gtfs.AlertInformedEntity{{StopID: ptr(stopID1), RouteType: gtfs.RouteType_Unknown}}},
+		},
+	}
+
+	for _, tc := range []struct {
+		route *gtfs.Route
+		want  []string
+	}{
+		{bus, []string{"agency_buses", "all_buses", "agency"}},
+		{otherBus, []string{"all_buses"}},
+		{subway, []string{"agency", "subway_stop"}},
+	} {
+		var got []string
+		for _, alert := range realtime.AlertsForRoute(tc.route) {
+			got = append(got, alert.ID)
+		}
+		if diff := cmp.Diff(got, tc.want); diff != "" {
+			t.Errorf("AlertsForRoute(%s) diff: %s", tc.route.Id, diff)
+		}
+	}
+}
+
+func TestTripAndVehicleTimestamps(t *testing.T) {
+	entities := []*gtfsrt.FeedEntity{
+		{
+			Id: ptr("1"),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip:      &gtfsrt.TripDescriptor{TripId: ptr(tripID1)},
+				Vehicle:   &gtfsrt.VehicleDescriptor{Id: ptr(vehicleID1)},
+				Timestamp: ptr(uint64(200)),
+			},
+		},
+		{
+			Id: ptr("2"),
+			Vehicle: &gtfsrt.VehiclePosition{
+				Trip:      &gtfsrt.TripDescriptor{TripId: ptr(tripID1)},
+				Vehicle:   &gtfsrt.VehicleDescriptor{Id: ptr(vehicleID1)},
+				Timestamp: ptr(uint64(100)),
+			},
+		},
+		{
+			Id: ptr("3"),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip: &gtfsrt.TripDescriptor{TripId: ptr(tripID2)},
+			},
+		},
+	}
+
+	result := testutil.MustParse(t, nil, entities, &gtfs.ParseRealtimeOptions{})
+
+	trip := &result.Trips[0]
+	if trip.Timestamp == nil || !trip.Timestamp.Equal(time.Unix(200, 0)) {
+		t.Errorf("trip Timestamp = %v, want %v", trip.Timestamp, time.Unix(200, 0))
+	}
+	if trip.Vehicle.Timestamp == nil || !trip.Vehicle.Timestamp.Equal(time.Unix(100, 0)) {
+		t.Errorf("vehicle Timestamp = %v, want %v", trip.Vehicle.Timestamp, time.Unix(100, 0))
+	}
+	if got, ok := trip.LatestTimestamp(); !ok || !got.Equal(time.Unix(200, 0)) {
+		t.Errorf("LatestTimestamp() = %v, %t, want %v, true", got, ok, time.Unix(200, 0))
+	}
+	if _, ok := result.Trips[1].LatestTimestamp(); ok {
+		t.Errorf("LatestTimestamp() of trip without timestamps ok = true, want false")
+	}
+}
+
+func TestResolveAlerts(t *testing.T) {
+	static := &gtfs.Static{
+		Agencies: []gtfs.Agency{{Id: "agency"}},
+		Routes:   []gtfs.Route{{Id: "A"}},
+		Stops:    []gtfs.Stop{{Id: stopID1}},
+		Trips:    []gtfs.ScheduledTrip{{ID: tripID1}},
+	}
+	realtime := &gtfs.Realtime{
+		Alerts: []gtfs.Alert{
+			{
+				ID: "alert",
+				InformedEntities: []gtfs.AlertInformedEntity{
+					{AgencyID: ptr("agency"), RouteID: ptr("A")},
+					{StopID: ptr(stopID1), TripID: &gtfs.TripID{ID: tripID1}},
+					{RouteID: ptr("B"), StopID: ptr(stopID2)},
+				},
+			},
+		},
+	}
+
+	gotWarnings := realtime.ResolveAlerts(static)
+
+	informedEntities := realtime.Alerts[0].InformedEntities
+	if informedEntities[0].Agency != &static.Agencies[0] || informedEntities[0].Route != &static.Routes[0] {
+		t.Errorf("agency or route not resolved: %+v", informedEntities[0])
+	}
+	if informedEntities[1].Stop != &static.Stops[0] || informedEntities[1].Trip != &static.Trips[0] {
+		t.Errorf("stop or trip not resolved: %+v", informedEntities[1])
+	}
+	if informedEntities[2].Route != nil || informedEntities[2].Stop != nil {
+		t.Errorf("unknown route or stop resolved: %+v", informedEntities[2])
+	}
+	wantWarnings := []warnings.RealtimeWarning{
+		{Kind: warnings.UnresolvedInformedEntity{EntityType: "route", ID: "B"}, EntityID: "alert"},
+		{Kind: warnings.UnresolvedInformedEntity{EntityType: "stop", ID: stopID2}, EntityID: "alert"},
+	}
+	if diff := cmp.Diff(gotWarnings, wantWarnings); diff != "" {
+		t.Errorf("ResolveAlerts() warnings diff: %s", diff)
+	}
+}
+
+func TestStrictness(t *testing.T) {
+	entities := []*gtfsrt.FeedEntity{
+		{
+			Id:         ptr("1"),
+			TripUpdate: &gtfsrt.TripUpdate{Trip: &gtfsrt.TripDescriptor{TripId: ptr(tripID1)}},
+		},
+		{
+			Id:         ptr("2"),
+			TripUpdate: &gtfsrt.TripUpdate{},
+		},
+		{
+			Id:         ptr("3"),
+			TripUpdate: &gtfsrt.TripUpdate{Trip: &gtfsrt.TripDescriptor{TripId: ptr(tripID2), StartDate: ptr("2022-05-04")}},
+		},
+		{
+			Id: ptr("4"),
+			Vehicle: &gtfsrt.VehiclePosition{
+				Vehicle: &gtfsrt.VehicleDescriptor{Id: ptr(vehicleID1)},
+				Trip:    &gtfsrt.TripDescriptor{TripId: ptr(tripID3), StartTime: ptr("8:00:00")},
+			},
+		},
+	}
+	marshal := func(entities []*gtfsrt.FeedEntity) []byte {
+		b, err := proto.MarshalOptions{AllowPartial: true}.Marshal(&gtfsrt.FeedMessage{
+			Header: &gtfsrt.FeedHeader{GtfsRealtimeVersion: ptr("2.0")},
+			Entity: entities,
+		})
+		if err != nil {
+			t.Fatalf("failed to marshal GTFS-RT message: %s", err)
+		}
+		return b
+	}
+	// The trip update without a trip descriptor is missing a required field, so only the first
+	// message is partial.
+	partial := marshal(entities)
+	complete := marshal(append([]*gtfsrt.FeedEntity{entities[0]}, entities[2:]...))
+	for _, tc := range []struct {
+		name         string
+		strictness   gtfs.Strictness
+		content      []byte
+		wantErr      bool
+		wantTrips    []string
+		wantWarnings []warnings.RealtimeWarning
+	}{
+		{
+			name:       "lenient",
+			strictness: gtfs.Strictness_Lenient,
+			content:    partial,
+			wantTrips:  []string{tripID1},
+			wantWarnings: []warnings.RealtimeWarning{
+				{Kind: warnings.MissingRequiredFields{}},
+			},
+		},
+		{
+			name:       "standard",
+			strictness: gtfs.Strictness_Standard,
+			content:    complete,
+			wantTrips:  []string{tripID1},
+			wantWarnings: []warnings.RealtimeWarning{
+				{Kind: warnings.InvalidStartDate{StartDate: "2022-05-04"}, EntityID: "3"},
+				{Kind: warnings.InvalidStartTime{StartTime: "8:00:00"}, EntityID: "4"},
+			},
+		},
+		{
+			name:       "standard partial",
+			strictness: gtfs.Strictness_Standard,
+			content:    partial,
+			wantErr:    true,
+		},
+		{
+			// The zero value of the options rejects messages with missing required fields, as
+			// proto.Unmarshal does.
+			name:    "default partial",
+			content: partial,
+			wantErr: true,
+		},
+		{
+			name:       "strict",
+			strictness: gtfs.Strictness_Strict,
+			content:    complete,
+			wantErr:    true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := gtfs.ParseRealtime(tc.content, &gtfs.ParseRealtimeOptions{Strictness: tc.strictness})
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("ParseRealtime() err = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRealtime() err = %s", err)
+			}
+			var gotTrips []string
+			for _, trip := range result.Trips {
+				gotTrips = append(gotTrips, trip.ID.ID)
+			}
+			if diff := cmp.Diff(gotTrips, tc.wantTrips); diff != "" {
+				t.Errorf("trips got = %v, want = %v", gotTrips, tc.wantTrips)
+			}
+			if diff := cmp.Diff(result.Warnings, tc.wantWarnings, cmpopts.IgnoreFields(warnings.MissingRequiredFields{}, "Err")); diff != "" {
+				t.Errorf("warnings diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestStartDateAndTimeBounds(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		startDate     string
+		startTime     string
+		opts          gtfs.ParseRealtimeOptions
+		wantStartDate bool
+		wantStartTime bool
+	}{
+		{
+			name:          "valid",
+			startDate:     "20220504",
+			startTime:     "25:30:00",
+			wantStartDate: true,
+			wantStartTime: true,
+		},
+		{
+			name:      "year zero",
+			startDate: "00000000",
+			startTime: "08:00:00",
+			// The start time is still valid.
+			wantStartTime: true,
+		},
+		{
+			name:      "invalid day",
+			startDate: "20220230",
+			startTime: "08:60:00",
+		},
+		{
+			name:          "start time after default maximum",
+			startDate:     "20220504",
+			startTime:     "48:00:01",
+			wantStartDate: true,
+		},
+		{
+			name:          "start time within custom maximum",
+			startDate:     "20220504",
+			startTime:     "60:00:00",
+			opts:          gtfs.ParseRealtimeOptions{MaxStartTime: 72 * time.Hour},
+			wantStartDate: true,
+			wantStartTime: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			entities := []*gtfsrt.FeedEntity{
+				{
+					Id: ptr("1"),
+					TripUpdate: &gtfsrt.TripUpdate{
+						Trip: &gtfsrt.TripDescriptor{TripId: ptr(tripID1), StartDate: ptr(tc.startDate), StartTime: ptr(tc.startTime)},
+					},
+				},
+			}
+
+			result := testutil.MustParse(t, nil, entities, &tc.opts)
+			var wantWarnings []warnings.RealtimeWarning
+			switch {
+			case !tc.wantStartDate:
+				wantWarnings = append(wantWarnings, warnings.RealtimeWarning{Kind: warnings.InvalidStartDate{StartDate: tc.startDate}, EntityID: "1"})
+			case !tc.wantStartTime:
+				wantWarnings = append(wantWarnings, warnings.RealtimeWarning{Kind: warnings.InvalidStartTime{StartTime: tc.startTime}, EntityID: "1"})
+			}
+			if diff := cmp.Diff(result.Warnings, wantWarnings); diff != "" {
+				t.Errorf("warnings diff: %s", diff)
+			}
+			opts := tc.opts
+			opts.Strictness = gtfs.Strictness_Lenient
+			if lenient := testutil.MustParse(t, nil, entities, &opts); len(lenient.Trips) != len(result.Trips) || len(lenient.Warnings) != 0 {
+				t.Errorf("lenient got %d trips and warnings %v, want %d trips and no warnings", len(lenient.Trips), lenient.Warnings, len(result.Trips))
+			}
+			if len(wantWarnings) > 0 {
+				if len(result.Trips) != 0 {
+					t.Errorf("got %d trips, want the malformed trip to be dropped", len(result.Trips))
+				}
+				return
+			}
+			tripID := result.Trips[0].ID
+			if !tripID.HasStartDate || !tripID.HasStartTime {
+				t.Errorf("HasStartDate = %t, HasStartTime = %t, want both true", tripID.HasStartDate, tripID.HasStartTime)
+			}
+		})
+	}
+}
+
+func TestStopTimeUpdateHorizon(t *testing.T) {
+	createdAt := uint64(1_700_000_000)
+	stopTimeUpdate := func(stopID string, arrival *int64, departure *int64) *gtfsrt.TripUpdate_StopTimeUpdate {
+		u := &gtfsrt.TripUpdate_StopTimeUpdate{StopId: ptr(stopID)}
+		if arrival != nil {
+			u.Arrival = &gtfsrt.TripUpdate_StopTimeEvent{Time: arrival}
+		}
+		if departure != nil {
+			u.Departure = &gtfsrt.TripUpdate_StopTimeEvent{Time: departure}
+		}
+		return u
+	}
+	at := func(minutes int64) *int64 {
+		return ptr(int64(createdAt) + minutes*60)
+	}
+	entities := []*gtfsrt.FeedEntity{
+		{
+			Id: ptr("1"),
+			TripUpdate: &gtfsrt.TripUpdate{
+				Trip: &gtfsrt.TripDescriptor{TripId: ptr(tripID1)},
+				StopTimeUpdate: []*gtfsrt.TripUpdate_StopTimeUpdate{
+					stopTimeUpdate("stop_1", at(10), at(11)),
+					stopTimeUpdate("stop_2", nil, nil),
+					stopTimeUpdate("stop_3", nil, at(60)),
+					stopTimeUpdate("stop_4", at(61), at(62)),
+					stopTimeUpdate("stop_5", at(30), nil),
+				},
+			},
+		},
+	}
+	for _, tc := range []struct {
+		name    string
+		header  *gtfsrt.FeedHeader
+		horizon time.Duration
+		want    []string
+	}{
+		{
+			name:   "no horizon",
+			header: &gtfsrt.FeedHeader{GtfsRealtimeVersion: ptr("2.0"), Timestamp: ptr(createdAt)},
+			want:   []string{"stop_1", "stop_2", "stop_3", "stop_4", "stop_5"},
+		},
+		{
+			name:    "horizon",
+			header:  &gtfsrt.FeedHeader{GtfsRealtimeVersion: ptr("2.0"), Timestamp: ptr(createdAt)},
+			horizon: time.Hour,
+			want:    []string{"stop_1", "stop_2", "stop_3"},
+		},
+		{
+			name:    "no header timestamp",
+			header:  &gtfsrt.FeedHeader{GtfsRealtimeVersion: ptr("2.0")},
+			horizon: time.Hour,
+			want:    []string{"stop_1", "stop_2", "stop_3", "stop_4", "stop_5"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result := testutil.MustParse(t, tc.header, entities, &gtfs.ParseRealtimeOptions{StopTimeUpdateHorizon: tc.horizon})
+			var got []string
+			for _, stopTimeUpdate := range result.Trips[0].StopTimeUpdates {
+				got = append(got, *stopTimeUpdate.StopID)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("stop IDs diff: %s", diff)
+			}
+		})
+	}
+}
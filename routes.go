@@ -0,0 +1,140 @@
+package gtfs
+
+import (
+	"sort"
+)
+
+// RoutesSorted returns pointers to all routes in the order in which they should be presented to riders.
+//
+// Routes are ordered by route_sort_order, with routes that have a sort order before routes that do not.
+// Ties are broken using a natural sort of the short names, falling back to the long names, so that
+// route "10" comes after route "9". Remaining ties are broken using the route IDs.
+func (s *Static) RoutesSorted() []*Route {
+	routes := make([]*Route, len(s.Routes))
+	for i := range s.Routes {
+		routes[i] = &s.Routes[i]
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routeLess(routes[i], routes[j])
+	})
+	return routes
+}
+
+func routeLess(r1, r2 *Route) bool {
+	if (r1.SortOrder == nil) != (r2.SortOrder == nil) {
+		return r1.SortOrder != nil
+	}
+	if r1.SortOrder != nil && *r1.SortOrder != *r2.SortOrder {
+		return *r1.SortOrder < *r2.SortOrder
+	}
+	if c := naturalCompare(r1.displayName(), r2.displayName()); c != 0 {
+		return c < 0
+	}
+	return r1.Id < r2.Id
+}
+
+func (route *Route) displayName() string {
+	if route.ShortName != "" {
+		return route.ShortName
+	}
+	return route.LongName
+}
+
+// naturalCompare compares two strings, treating runs of digits as numbers.
+//
+// Numbers are compared by value, so "4" sorts before "05". If two strings are otherwise equal, the
+// first number with more leading zeros sorts first, so "007" sorts before "7".
+//
+// It returns a negative number if a sorts before b, a positive number if a sorts after b, and zero
+// if they are equal.
+func naturalCompare(a, b string) int {
+	leadingZerosTiebreak := 0
+	for a != "" && b != "" {
+		aChunk, aIsNumber := nextChunk(a)
+		bChunk, bIsNumber := nextChunk(b)
+		a, b = a[len(aChunk):], b[len(bChunk):]
+		if aIsNumber && bIsNumber {
+			aTrimmed, bTrimmed := trimLeadingZeros(aChunk), trimLeadingZeros(bChunk)
+			if len(aTrimmed) != len(bTrimmed) {
+				return len(aTrimmed) - len(bTrimmed)
+			}
+			if leadingZerosTiebreak == 0 {
+				leadingZerosTiebreak = len(bChunk) - len(aChunk)
+			}
+			aChunk, bChunk = aTrimmed, bTrimmed
+		}
+		if aChunk != bChunk {
+			if aChunk < bChunk {
+				return -1
+			}
+			return 1
+		}
+	}
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return leadingZerosTiebreak
+}
+
+// nextChunk returns the leading run of digits or non-digits of the string.
+func nextChunk(s string) (string, bool) {
+	isDigit := func(c byte) bool { return '0' <= c && c <= '9' }
+	isNumber := isDigit(s[0])
+	i := 1
+	for i < len(s) && isDigit(s[i]) == isNumber {
+		i++
+	}
+	return s[:i], isNumber
+}
+
+func trimLeadingZeros(s string) string {
+	for len(s) > 1 && s[0] == '0' {
+		s = s[1:]
+	}
+	return s
+}
+
+// AgencyRoutes is a group of routes operated by the same agency, returned by [Static.RoutesByAgency].
+type AgencyRoutes struct {
+	// The agency, or nil for the group of routes that do not reference an agency.
+	Agency *Agency
+	Routes []*Route
+}
+
+// RoutesByAgency groups the routes by the agency that operates them.
+//
+// Groups are returned in the order of the agencies in the Agencies field, and agencies without
+// routes are included with no routes. If some routes do not reference an agency, they are returned
+// in a final group with a nil agency. Within each group routes are ordered as in [Static.RoutesSorted].
+func (s *Static) RoutesByAgency() []AgencyRoutes {
+	groups := make([]AgencyRoutes, len(s.Agencies))
+	agencyToGroup := map[*Agency]*AgencyRoutes{}
+	for i := range s.Agencies {
+		groups[i].Agency = &s.Agencies[i]
+		agencyToGroup[&s.Agencies[i]] = &groups[i]
+	}
+	var noAgency []*Route
+	for _, route := range s.RoutesSorted() {
+		group, ok := agencyToGroup[route.Agency]
+		if !ok {
+			noAgency = append(noAgency, route)
+			continue
+		}
+		group.Routes = append(group.Routes, route)
+	}
+	if len(noAgency) > 0 {
+		groups = append(groups, AgencyRoutes{Routes: noAgency})
+	}
+	return groups
+}
+
+// Routes returns the routes operated by the agency, ordered as in [Static.RoutesSorted].
+func (agency *Agency) Routes(static *Static) []*Route {
+	var routes []*Route
+	for _, route := range static.RoutesSorted() {
+		if route.Agency == agency {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
@@ -0,0 +1,101 @@
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRoutesSorted(t *testing.T) {
+	static := &Static{
+		Routes: []Route{
+			{Id: "10", ShortName: "10"},
+			{Id: "express", ShortName: "X", SortOrder: ptr(int32(2))},
+			{Id: "9", ShortName: "9"},
+			{Id: "m15", ShortName: "M15"},
+			{Id: "m2", ShortName: "M2"},
+			{Id: "shuttle", LongName: "Airport shuttle"},
+			{Id: "a", ShortName: "A", SortOrder: ptr(int32(1))},
+			{Id: "b", ShortName: "9"},
+		},
+	}
+
+	var got []string
+	for _, route := range static.RoutesSorted() {
+		got = append(got, route.Id)
+	}
+
+	want := []string{"a", "express", "9", "b", "10", "shuttle", "m2", "m15"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("RoutesSorted() got = %v, want = %v, diff: %s", got, want, diff)
+	}
+}
+
+func TestNaturalCompare(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"9", "10", -1},
+		{"M2", "M15", -1},
+		{"B", "A", 1},
+		{"7", "007", 1},
+		{"05", "4", 1},
+		{"4", "05", -1},
+		{"05A", "5B", -1},
+		{"M05", "M5", -1},
+		{"Q1", "Q1", 0},
+		{"Q", "Q1", -1},
+	} {
+		got := naturalCompare(tc.a, tc.b)
+		if (got < 0) != (tc.want < 0) || (got > 0) != (tc.want > 0) {
+			t.Errorf("naturalCompare(%q, %q) = %d, want sign of %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestRoutesByAgency(t *testing.T) {
+	static := &Static{
+		Agencies: []Agency{{Id: "bus"}, {Id: "ferry"}, {Id: "subway"}},
+		Routes: []Route{
+			{Id: "m2", ShortName: "M2"},
+			{Id: "1", ShortName: "1"},
+			{Id: "m1", ShortName: "M1"},
+			{Id: "unknown"},
+		},
+	}
+	static.Routes[0].Agency = &static.Agencies[0]
+	static.Routes[1].Agency = &static.Agencies[2]
+	static.Routes[2].Agency = &static.Agencies[0]
+
+	type group struct {
+		AgencyID string
+		RouteIDs []string
+	}
+	var got []group
+	for _, agencyRoutes := range static.RoutesByAgency() {
+		g := group{}
+		if agencyRoutes.Agency != nil {
+			g.AgencyID = agencyRoutes.Agency.Id
+		}
+		for _, route := range agencyRoutes.Routes {
+			g.RouteIDs = append(g.RouteIDs, route.Id)
+		}
+		got = append(got, g)
+	}
+
+	want := []group{
+		{AgencyID: "bus", RouteIDs: []string{"m1", "m2"}},
+		{AgencyID: "ferry"},
+		{AgencyID: "subway", RouteIDs: []string{"1"}},
+		{RouteIDs: []string{"unknown"}},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("RoutesByAgency() got = %v, want = %v, diff: %s", got, want, diff)
+	}
+
+	routes := static.Agencies[0].Routes(static)
+	if len(routes) != 2 || routes[0] != &static.Routes[2] || routes[1] != &static.Routes[0] {
+		t.Errorf("Agency.Routes() got = %v, want routes m1 and m2", routes)
+	}
+}
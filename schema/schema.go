@@ -0,0 +1,363 @@
+// Package schema describes the GTFS static columns read by the parser in the gtfs package.
+//
+// The schema can be used to generate documentation or to drive downstream validation tools
+// without having to inspect the parser source.
+package schema
+
+import (
+	"github.com/jamespfennell/gtfs/constants"
+)
+
+// Requirement describes whether a column must be present in a file.
+type Requirement int32
+
+const (
+	// The parser rejects the file if the column is missing.
+	Requirement_Required Requirement = 0
+	// The column may be omitted.
+	Requirement_Optional Requirement = 1
+	// The column is required by the GTFS specification in some circumstances.
+	// The parser does not enforce this and treats the column as optional.
+	Requirement_ConditionallyRequired Requirement = 2
+)
+
+func (r Requirement) String() string {
+	switch r {
+	case Requirement_Required:
+		return "REQUIRED"
+	case Requirement_ConditionallyRequired:
+		return "CONDITIONALLY_REQUIRED"
+	default:
+		return "OPTIONAL"
+	}
+}
+
+// Column describes a single column read by the parser.
+type Column struct {
+	Name        string
+	Requirement Requirement
+
+	// Go fields the column is mapped to, in the form Type.Field.
+	//
+	// Some columns populate more than one field; for example, the trip_id column in
+	// stop_times.txt populates both ScheduledStopTime.Trip and ScheduledTrip.StopTimes.
+	Fields []string
+}
+
+// File describes a single GTFS static file read by the parser.
+type File struct {
+	File    constants.StaticFile
+	Columns []Column
+}
+
+// ColumnNames returns the names of the columns in the file, in schema order.
+func (f *File) ColumnNames() []string {
+	var names []string
+	for _, column := range f.Columns {
+		names = append(names, column.Name)
+	}
+	return names
+}
+
+// Files returns the schema of every file read by the parser.
+//
+// Files in the GTFS static specification that are not read by the parser are omitted.
+func Files() []File {
+	return []File{
+		{
+			File: constants.AgencyFile,
+			Columns: []Column{
+				{"agency_id", Requirement_ConditionallyRequired, []string{"Agency.Id"}},
+				{"agency_name", Requirement_Required, []string{"Agency.Name"}},
+				{"agency_url", Requirement_Required, []string{"Agency.Url"}},
+				{"agency_timezone", Requirement_Required, []string{"Agency.Timezone"}},
+				{"agency_lang", Requirement_Optional, []string{"Agency.Language"}},
+				{"agency_phone", Requirement_Optional, []string{"Agency.Phone"}},
+				{"agency_fare_url", Requirement_Optional, []string{"Agency.FareUrl"}},
+				{"agency_email", Requirement_Optional, []string{"Agency.Email"}},
+			},
+		},
+		{
+			File: constants.StopsFile,
+			Columns: []Column{
+				{"stop_id", Requirement_Required, []string{"Stop.Id"}},
+				{"stop_code", Requirement_Optional, []string{"Stop.Code"}},
+				{"stop_name", Requirement_ConditionallyRequired, []string{"Stop.Name"}},
+				{"stop_desc", Requirement_Optional, []string{"Stop.Description"}},
+				{"zone_id", Requirement_Optional, []string{"Stop.ZoneId"}},
+				{"stop_lon", Requirement_ConditionallyRequired, []string{"Stop.Longitude"}},
+				{"stop_lat", Requirement_ConditionallyRequired, []string{"Stop.Latitude"}},
+				{"stop_url", Requirement_Optional, []string{"Stop.Url"}},
+				{"location_type", Requirement_Optional, []string{"Stop.Type"}},
+				{"stop_timezone", Requirement_Optional, []string{"Stop.Timezone"}},
+				{"wheelchair_boarding", Requirement_Optional, []string{"Stop.WheelchairBoarding"}},
+				{"platform_code", Requirement_Optional, []string{"Stop.PlatformCode"}},
+				{"parent_station", Requirement_ConditionallyRequired, []string{"Stop.Parent"}},
+			},
+		},
+		{
+			File: constants.RoutesFile,
+			Columns: []Column{
+				{"route_id", Requirement_Required, []string{"Route.Id"}},
+				{"agency_id", Requirement_ConditionallyRequired, []string{"Route.Agency"}},
+				{"route_color", Requirement_Optional, []string{"Route.Color"}},
+				{"route_text_color", Requirement_Optional, []string{"Route.TextColor"}},
+				{"route_short_name", Requirement_ConditionallyRequired, []string{"Route.ShortName"}},
+				{"route_long_name", Requirement_ConditionallyRequired, []string{"Route.LongName"}},
+				{"route_desc", Requirement_Optional, []string{"Route.Description"}},
+				{"route_type", Requirement_Required, []string{"Route.Type"}},
+				{"route_url", Requirement_Optional, []string{"Route.Url"}},
+				{"route_sort_order", Requirement_Optional, []string{"Route.SortOrder"}},
+				{"continuous_pickup", Requirement_Optional, []string{"Route.ContinuousPickup"}},
+				{"continuous_drop_off", Requirement_Optional, []string{"Route.ContinuousDropOff"}},
+				{"network_id", Requirement_Optional, []string{"Route.Network"}},
+			},
+		},
+		{
+			File: constants.TripsFile,
+			Columns: []Column{
+				{"route_id", Requirement_Required, []string{"ScheduledTrip.Route"}},
+				{"service_id", Requirement_Required, []string{"ScheduledTrip.Service"}},
+				{"trip_id", Requirement_Required, []string{"ScheduledTrip.ID"}},
+				{"trip_headsign", Requirement_Optional, []string{"ScheduledTrip.Headsign"}},
+				{"trip_short_name", Requirement_Optional, []string{"ScheduledTrip.ShortName"}},
+				{"direction_id", Requirement_Optional, []string{"ScheduledTrip.DirectionId"}},
+				{"block_id", Requirement_Optional, []string{"ScheduledTrip.BlockID"}},
+				{"wheelchair_accessible", Requirement_Optional, []string{"ScheduledTrip.WheelchairAccessible"}},
+				{"bikes_allowed", Requirement_Optional, []string{"ScheduledTrip.BikesAllowed"}},
+				{"shape_id", Requirement_ConditionallyRequired, []string{"ScheduledTrip.Shape"}},
+			},
+		},
+		{
+			File: constants.StopTimesFile,
+			Columns: []Column{
+				{"stop_id", Requirement_Required, []string{"ScheduledStopTime.Stop"}},
+				{"stop_sequence", Requirement_Required, []string{"ScheduledStopTime.StopSequence"}},
+				{"trip_id", Requirement_Required, []string{"ScheduledStopTime.Trip", "ScheduledTrip.StopTimes"}},
+				{"arrival_time", Requirement_ConditionallyRequired, []string{"ScheduledStopTime.ArrivalTime"}},
+				{"departure_time", Requirement_ConditionallyRequired, []string{"ScheduledStopTime.DepartureTime"}},
+				{"stop_headsign", Requirement_Optional, []string{"ScheduledStopTime.Headsign"}},
+				{"pickup_type", Requirement_Optional, []string{"ScheduledStopTime.PickupType"}},
+				{"drop_off_type", Requirement_Optional, []string{"ScheduledStopTime.DropOffType"}},
+				{"continuous_pickup", Requirement_Optional, []string{"ScheduledStopTime.ContinuousPickup"}},
+				{"continuous_drop_off", Requirement_Optional, []string{"ScheduledStopTime.ContinuousDropOff"}},
+				{"shape_dist_traveled", Requirement_Optional, []string{"ScheduledStopTime.ShapeDistanceTraveled"}},
+				{"timepoint", Requirement_Optional, []string{"ScheduledStopTime.ExactTimes"}},
+				{"start_pickup_drop_off_window", Requirement_ConditionallyRequired, []string{"ScheduledStopTime.StartPickupDropOffWindow", "ScheduledStopTime.HasPickupDropOffWindow"}},
+				{"end_pickup_drop_off_window", Requirement_ConditionallyRequired, []string{"ScheduledStopTime.EndPickupDropOffWindow", "ScheduledStopTime.HasPickupDropOffWindow"}},
+				{"location_group_id", Requirement_ConditionallyRequired, []string{"ScheduledStopTime.LocationGroup"}},
+				{"location_id", Requirement_ConditionallyRequired, []string{"ScheduledStopTime.FlexLocation"}},
+				{"pickup_booking_rule_id", Requirement_Optional, []string{"ScheduledStopTime.PickupBookingRule"}},
+				{"drop_off_booking_rule_id", Requirement_Optional, []string{"ScheduledStopTime.DropOffBookingRule"}},
+			},
+		},
+		{
+			File: constants.CalendarFile,
+			Columns: []Column{
+				{"service_id", Requirement_Required, []string{"Service.Id"}},
+				{"monday", Requirement_Required, []string{"Service.Monday"}},
+				{"tuesday", Requirement_Required, []string{"Service.Tuesday"}},
+				{"wednesday", Requirement_Required, []string{"Service.Wednesday"}},
+				{"thursday", Requirement_Required, []string{"Service.Thursday"}},
+				{"friday", Requirement_Required, []string{"Service.Friday"}},
+				{"saturday", Requirement_Required, []string{"Service.Saturday"}},
+				{"sunday", Requirement_Required, []string{"Service.Sunday"}},
+				{"start_date", Requirement_Required, []string{"Service.StartDate"}},
+				{"end_date", Requirement_Required, []string{"Service.EndDate"}},
+			},
+		},
+		{
+			File: constants.CalendarDatesFile,
+			Columns: []Column{
+				{"service_id", Requirement_Required, []string{"Service.Id"}},
+				{"date", Requirement_Required, []string{"Service.AddedDates", "Service.RemovedDates"}},
+				{"exception_type", Requirement_Required, []string{"Service.AddedDates", "Service.RemovedDates"}},
+			},
+		},
+		{
+			File: constants.ShapesFile,
+			Columns: []Column{
+				{"shape_id", Requirement_Required, []string{"Shape.ID"}},
+				{"shape_pt_lat", Requirement_Required, []string{"ShapePoint.Latitude"}},
+				{"shape_pt_lon", Requirement_Required, []string{"ShapePoint.Longitude"}},
+				{"shape_pt_sequence", Requirement_Required, []string{"Shape.Points"}},
+				{"shape_dist_traveled", Requirement_Optional, []string{"ShapePoint.Distance"}},
+			},
+		},
+		{
+			File: constants.FeedInfoFile,
+			Columns: []Column{
+				{"feed_publisher_name", Requirement_Required, []string{"FeedInfo.PublisherName"}},
+				{"feed_publisher_url", Requirement_Required, []string{"FeedInfo.PublisherUrl"}},
+				{"feed_lang", Requirement_Required, []string{"FeedInfo.Language"}},
+				{"default_lang", Requirement_Optional, []string{"FeedInfo.DefaultLanguage"}},
+				{"feed_start_date", Requirement_Optional, []string{"FeedInfo.StartDate"}},
+				{"feed_end_date", Requirement_Optional, []string{"FeedInfo.EndDate"}},
+				{"feed_version", Requirement_Optional, []string{"FeedInfo.Version"}},
+				{"feed_contact_email", Requirement_Optional, []string{"FeedInfo.ContactEmail"}},
+				{"feed_contact_url", Requirement_Optional, []string{"FeedInfo.ContactUrl"}},
+			},
+		},
+		{
+			File: constants.FareRulesFile,
+			Columns: []Column{
+				{"fare_id", Requirement_Required, []string{"FareRule.FareID"}},
+				{"route_id", Requirement_Optional, []string{"FareRule.Route"}},
+				{"origin_id", Requirement_Optional, []string{"FareRule.OriginID"}},
+				{"destination_id", Requirement_Optional, []string{"FareRule.DestinationID"}},
+				{"contains_id", Requirement_Optional, []string{"FareRule.ContainsID"}},
+			},
+		},
+		{
+			File: constants.FareMediaFile,
+			Columns: []Column{
+				{"fare_media_id", Requirement_Required, []string{"FareMedium.ID"}},
+				{"fare_media_name", Requirement_Optional, []string{"FareMedium.Name"}},
+				{"fare_media_type", Requirement_Required, []string{"FareMedium.Type"}},
+			},
+		},
+		{
+			File: constants.FareProductsFile,
+			Columns: []Column{
+				{"fare_product_id", Requirement_Required, []string{"FareProduct.ID"}},
+				{"fare_product_name", Requirement_Optional, []string{"FareProduct.Name"}},
+				{"fare_media_id", Requirement_Optional, []string{"FareProduct.FareMedium"}},
+				{"amount", Requirement_Required, []string{"FareProduct.Amount"}},
+				{"currency", Requirement_Required, []string{"FareProduct.Currency"}},
+			},
+		},
+		{
+			File: constants.FareLegRulesFile,
+			Columns: []Column{
+				{"leg_group_id", Requirement_Optional, []string{"FareLegRule.LegGroupID"}},
+				{"network_id", Requirement_Optional, []string{"FareLegRule.Network"}},
+				{"from_area_id", Requirement_Optional, []string{"FareLegRule.FromArea"}},
+				{"to_area_id", Requirement_Optional, []string{"FareLegRule.ToArea"}},
+				{"from_timeframe_group_id", Requirement_Optional, []string{"FareLegRule.FromTimeframeGroupID"}},
+				{"to_timeframe_group_id", Requirement_Optional, []string{"FareLegRule.ToTimeframeGroupID"}},
+				{"fare_product_id", Requirement_Required, []string{"FareLegRule.FareProductID"}},
+				{"rule_priority", Requirement_Optional, []string{"FareLegRule.RulePriority"}},
+			},
+		},
+		{
+			File: constants.FareTransferRulesFile,
+			Columns: []Column{
+				{"from_leg_group_id", Requirement_Optional, []string{"FareTransferRule.FromLegGroupID"}},
+				{"to_leg_group_id", Requirement_Optional, []string{"FareTransferRule.ToLegGroupID"}},
+				{"transfer_count", Requirement_ConditionallyRequired, []string{"FareTransferRule.TransferCount"}},
+				{"duration_limit", Requirement_Optional, []string{"FareTransferRule.DurationLimit"}},
+				{"duration_limit_type", Requirement_ConditionallyRequired, []string{"FareTransferRule.DurationLimitType"}},
+				{"fare_transfer_type", Requirement_Required, []string{"FareTransferRule.FareTransferType"}},
+				{"fare_product_id", Requirement_Optional, []string{"FareTransferRule.FareProductID"}},
+			},
+		},
+		{
+			File: constants.AreasFile,
+			Columns: []Column{
+				{"area_id", Requirement_Required, []string{"Area.ID"}},
+				{"area_name", Requirement_Optional, []string{"Area.Name"}},
+			},
+		},
+		{
+			File: constants.StopAreasFile,
+			Columns: []Column{
+				{"area_id", Requirement_Required, []string{"Area.Stops"}},
+				{"stop_id", Requirement_Required, []string{"Area.Stops"}},
+			},
+		},
+		{
+			File: constants.NetworksFile,
+			Columns: []Column{
+				{"network_id", Requirement_Required, []string{"Network.ID"}},
+				{"network_name", Requirement_Optional, []string{"Network.Name"}},
+			},
+		},
+		{
+			File: constants.RouteNetworksFile,
+			Columns: []Column{
+				{"network_id", Requirement_Required, []string{"Network.Routes", "Route.Network"}},
+				{"route_id", Requirement_Required, []string{"Network.Routes", "Route.Network"}},
+			},
+		},
+		{
+			File: constants.FrequenciesFile,
+			Columns: []Column{
+				{"trip_id", Requirement_Required, []string{"ScheduledTrip.Frequencies"}},
+				{"start_time", Requirement_Required, []string{"Frequency.StartTime"}},
+				{"end_time", Requirement_Required, []string{"Frequency.EndTime"}},
+				{"headway_secs", Requirement_Required, []string{"Frequency.Headway"}},
+				{"exact_times", Requirement_Optional, []string{"Frequency.ExactTimes"}},
+			},
+		},
+		{
+			File: constants.TransfersFile,
+			Columns: []Column{
+				{"from_stop_id", Requirement_Required, []string{"Transfer.From"}},
+				{"to_stop_id", Requirement_Required, []string{"Transfer.To"}},
+				{"transfer_type", Requirement_Optional, []string{"Transfer.Type"}},
+				{"min_transfer_time", Requirement_Optional, []string{"Transfer.MinTransferTime"}},
+			},
+		},
+		{
+			File: constants.PathwaysFile,
+			Columns: []Column{
+				{"pathway_id", Requirement_Required, []string{"Pathway.ID"}},
+				{"from_stop_id", Requirement_Required, []string{"Pathway.From"}},
+				{"to_stop_id", Requirement_Required, []string{"Pathway.To"}},
+				{"pathway_mode", Requirement_Required, []string{"Pathway.Mode"}},
+				{"is_bidirectional", Requirement_Required, []string{"Pathway.IsBidirectional"}},
+				{"length", Requirement_Optional, []string{"Pathway.Length"}},
+				{"traversal_time", Requirement_Optional, []string{"Pathway.TraversalTime"}},
+				{"stair_count", Requirement_Optional, []string{"Pathway.StairCount"}},
+				{"max_slope", Requirement_Optional, []string{"Pathway.MaxSlope"}},
+				{"min_width", Requirement_Optional, []string{"Pathway.MinWidth"}},
+				{"signposted_as", Requirement_Optional, []string{"Pathway.SignpostedAs"}},
+				{"reversed_signposted_as", Requirement_Optional, []string{"Pathway.ReversedSignpostedAs"}},
+			},
+		},
+		{
+			File: constants.LocationGroupsFile,
+			Columns: []Column{
+				{"location_group_id", Requirement_Required, []string{"LocationGroup.ID"}},
+				{"location_group_name", Requirement_Optional, []string{"LocationGroup.Name"}},
+			},
+		},
+		{
+			File: constants.LocationGroupStopsFile,
+			Columns: []Column{
+				{"location_group_id", Requirement_Required, []string{"LocationGroup.Stops"}},
+				{"stop_id", Requirement_Required, []string{"LocationGroup.Stops"}},
+			},
+		},
+		{
+			File: constants.BookingRulesFile,
+			Columns: []Column{
+				{"booking_rule_id", Requirement_Required, []string{"BookingRule.ID"}},
+				{"booking_type", Requirement_Required, []string{"BookingRule.Type"}},
+				{"prior_notice_duration_min", Requirement_ConditionallyRequired, []string{"BookingRule.PriorNoticeDurationMin"}},
+				{"prior_notice_duration_max", Requirement_Optional, []string{"BookingRule.PriorNoticeDurationMax"}},
+				{"prior_notice_last_day", Requirement_ConditionallyRequired, []string{"BookingRule.PriorNoticeLastDay"}},
+				{"prior_notice_last_time", Requirement_ConditionallyRequired, []string{"BookingRule.PriorNoticeLastTime"}},
+				{"prior_notice_start_day", Requirement_Optional, []string{"BookingRule.PriorNoticeStartDay"}},
+				{"prior_notice_start_time", Requirement_ConditionallyRequired, []string{"BookingRule.PriorNoticeStartTime"}},
+				{"prior_notice_service_id", Requirement_Optional, []string{"BookingRule.PriorNoticeService"}},
+				{"message", Requirement_Optional, []string{"BookingRule.Message"}},
+				{"pickup_message", Requirement_Optional, []string{"BookingRule.PickupMessage"}},
+				{"drop_off_message", Requirement_Optional, []string{"BookingRule.DropOffMessage"}},
+				{"phone_number", Requirement_Optional, []string{"BookingRule.PhoneNumber"}},
+				{"info_url", Requirement_Optional, []string{"BookingRule.InfoURL"}},
+				{"booking_url", Requirement_Optional, []string{"BookingRule.BookingURL"}},
+			},
+		},
+	}
+}
+
+// Lookup returns the schema of the provided file, or false if the file is not read by the parser.
+func Lookup(file constants.StaticFile) (File, bool) {
+	for _, f := range Files() {
+		if f.File == file {
+			return f, true
+		}
+	}
+	return File{}, false
+}
@@ -0,0 +1,42 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/jamespfennell/gtfs/constants"
+)
+
+func TestFiles(t *testing.T) {
+	knownFiles := map[constants.StaticFile]bool{}
+	for _, file := range constants.StaticFiles() {
+		knownFiles[file] = true
+	}
+	for _, file := range Files() {
+		if !knownFiles[file.File] {
+			t.Errorf("schema contains unknown file %s", file.File)
+		}
+		seen := map[string]bool{}
+		for _, column := range file.Columns {
+			if seen[column.Name] {
+				t.Errorf("%s: duplicate column %s", file.File, column.Name)
+			}
+			seen[column.Name] = true
+			if len(column.Fields) == 0 {
+				t.Errorf("%s: column %s is not mapped to any field", file.File, column.Name)
+			}
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	file, ok := Lookup(constants.RoutesFile)
+	if !ok {
+		t.Fatalf("Lookup(%s) not found", constants.RoutesFile)
+	}
+	if file.Columns[0].Name != "route_id" || file.Columns[0].Requirement != Requirement_Required {
+		t.Errorf("Lookup(%s) first column = %+v, want required route_id", constants.RoutesFile, file.Columns[0])
+	}
+	if _, ok := Lookup(constants.LevelsFile); ok {
+		t.Errorf("Lookup(%s) found, want not found", constants.LevelsFile)
+	}
+}
@@ -0,0 +1,304 @@
+package gtfs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TripSampling determines which trips are used to build service maps.
+type TripSampling int32
+
+const (
+	// All trips are used.
+	TripSampling_AllTrips TripSampling = 0
+	// Only trips whose service runs on at least one weekday (Monday to Friday) are used.
+	TripSampling_WeekdayOnly TripSampling = 1
+)
+
+func (t TripSampling) String() string {
+	switch t {
+	case TripSampling_AllTrips:
+		return "ALL_TRIPS"
+	case TripSampling_WeekdayOnly:
+		return "WEEKDAY_ONLY"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (t TripSampling) includes(trip *ScheduledTrip) bool {
+	switch t {
+	case TripSampling_WeekdayOnly:
+		return trip.Service != nil && trip.Service.runsOnWeekday()
+	default:
+		return true
+	}
+}
+
+// runsOnWeekday returns true if the service runs on at least one weekday, either as part of its
+// weekly pattern or as an added date.
+func (service *Service) runsOnWeekday() bool {
+	if service.Monday || service.Tuesday || service.Wednesday || service.Thursday || service.Friday {
+		return true
+	}
+	for _, date := range service.AddedDates {
+		if weekday := date.Weekday(); weekday != time.Saturday && weekday != time.Sunday {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Static) routeByID(routeID string) *Route {
+	for i := range s.Routes {
+		if s.Routes[i].Id == routeID {
+			return &s.Routes[i]
+		}
+	}
+	return nil
+}
+
+// StopsServedByRoute returns the stops served by trips of the route in the direction, in the order
+// in which the trips visit them.
+//
+// The stop patterns of the trips selected by the sampling are merged into a single order in which
+// every trip visits its stops. If no such order exists, for example because some trips visit stops
+// in opposite orders, stops are ordered by their first appearance in the patterns, longest pattern first.
+func (s *Static) StopsServedByRoute(routeID string, direction DirectionID, sampling TripSampling) ([]*Stop, error) {
+	route := s.routeByID(routeID)
+	if route == nil {
+		return nil, fmt.Errorf("no route with ID %q", routeID)
+	}
+	patterns, err := s.stopPatterns(func(trip *ScheduledTrip) bool {
+		return trip.Route == route && trip.DirectionId == direction && sampling.includes(trip)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var distinct [][]*Stop
+	for _, pattern := range patterns {
+		distinct = append(distinct, pattern.stops)
+	}
+	return mergeStopPatterns(distinct), nil
+}
+
+// stopPattern is a distinct sequence of stops visited by one or more trips.
+type stopPattern struct {
+	stops []*Stop
+	// The trips with this pattern, in the order of the Trips field.
+	trips []*ScheduledTrip
+}
+
+// stopPatterns returns the distinct stop patterns of the trips that pass the filter, ordered by
+// decreasing number of stops and then by first appearance in the Trips field.
+func (s *Static) stopPatterns(filter func(trip *ScheduledTrip) bool) ([]stopPattern, error) {
+	type stopAtSequence struct {
+		stop     *Stop
+		sequence int
+	}
+	tripIDToTrip := map[string]*ScheduledTrip{}
+	for i := range s.Trips {
+		if filter(&s.Trips[i]) {
+			tripIDToTrip[s.Trips[i].ID] = &s.Trips[i]
+		}
+	}
+	tripToStops := map[*ScheduledTrip][]stopAtSequence{}
+	err := s.ForEachStopTime(func(trip ScheduledTrip, stopTime ScheduledStopTime) bool {
+		if tripPtr, ok := tripIDToTrip[trip.ID]; ok && stopTime.Stop != nil {
+			tripToStops[tripPtr] = append(tripToStops[tripPtr], stopAtSequence{stopTime.Stop, stopTime.StopSequence})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	var patterns []stopPattern
+	keyToIndex := map[string]int{}
+	for i := range s.Trips {
+		trip := &s.Trips[i]
+		stops, ok := tripToStops[trip]
+		if !ok {
+			continue
+		}
+		sort.SliceStable(stops, func(i, j int) bool { return stops[i].sequence < stops[j].sequence })
+		var key strings.Builder
+		pattern := make([]*Stop, len(stops))
+		for j, stop := range stops {
+			pattern[j] = stop.stop
+			key.WriteString(stop.stop.Id)
+			key.WriteByte(0)
+		}
+		j, ok := keyToIndex[key.String()]
+		if !ok {
+			j = len(patterns)
+			keyToIndex[key.String()] = j
+			patterns = append(patterns, stopPattern{stops: pattern})
+		}
+		patterns[j].trips = append(patterns[j].trips, trip)
+	}
+	sort.SliceStable(patterns, func(i, j int) bool { return len(patterns[i].stops) > len(patterns[j].stops) })
+	return patterns, nil
+}
+
+// mergeStopPatterns merges the stop patterns into a single sequence of stops that is consistent
+// with all of the patterns, if possible. The patterns should be ordered by decreasing length.
+func mergeStopPatterns(patterns [][]*Stop) []*Stop {
+	// Stops in order of first appearance, which is used both to break ties and as the fallback order.
+	var stops []*Stop
+	stopToIndex := map[*Stop]int{}
+	for _, pattern := range patterns {
+		for _, stop := range pattern {
+			if _, ok := stopToIndex[stop]; !ok {
+				stopToIndex[stop] = len(stops)
+				stops = append(stops, stop)
+			}
+		}
+	}
+	successors := make([]map[int]bool, len(stops))
+	inDegree := make([]int, len(stops))
+	for _, pattern := range patterns {
+		for k := 1; k < len(pattern); k++ {
+			from, to := stopToIndex[pattern[k-1]], stopToIndex[pattern[k]]
+			if from == to {
+				continue
+			}
+			if successors[from] == nil {
+				successors[from] = map[int]bool{}
+			}
+			if !successors[from][to] {
+				successors[from][to] = true
+				inDegree[to]++
+			}
+		}
+	}
+	// Kahn's algorithm, always choosing the available stop that appeared first.
+	var result []*Stop
+	visited := make([]bool, len(stops))
+	for len(result) < len(stops) {
+		next := -1
+		for i := range stops {
+			if !visited[i] && inDegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return stops
+		}
+		visited[next] = true
+		result = append(result, stops[next])
+		for to := range successors[next] {
+			inDegree[to]--
+		}
+	}
+	return result
+}
+
+// RoutesServingStop returns the routes whose trips call at the stop or, if the stop is a station,
+// at any of the stops within it. Routes are ordered as in [Static.RoutesSorted].
+//
+// The map from stops to routes is built from the stop times on first use, so subsequent calls do not
+// read the stop times again.
+func (s *Static) RoutesServingStop(stopID string) ([]*Route, error) {
+	stop := s.StopByID(stopID)
+	if stop == nil {
+		return nil, fmt.Errorf("no stop with ID %q", stopID)
+	}
+	idx := s.index()
+	idx.routesByStopOnce.Do(func() {
+		idx.routesByStop, idx.routesByStopErr = s.buildRoutesByStop()
+	})
+	if idx.routesByStopErr != nil {
+		return nil, idx.routesByStopErr
+	}
+	stops := []*Stop{stop}
+	for _, descendant := range idx.stopsByRoot[stop.Root()] {
+		for ancestor := descendant.Parent; ancestor != nil; ancestor = ancestor.Parent {
+			if ancestor == stop {
+				stops = append(stops, descendant)
+				break
+			}
+		}
+	}
+	servingRoutes := map[*Route]bool{}
+	for _, stop := range stops {
+		for _, route := range idx.routesByStop[stop] {
+			servingRoutes[route] = true
+		}
+	}
+	var routes []*Route
+	for _, route := range s.RoutesSorted() {
+		if servingRoutes[route] {
+			routes = append(routes, route)
+		}
+	}
+	return routes, nil
+}
+
+func (s *Static) buildRoutesByStop() (map[*Stop][]*Route, error) {
+	tripIDToRoute := map[string]*Route{}
+	for i := range s.Trips {
+		if s.Trips[i].Route != nil {
+			tripIDToRoute[s.Trips[i].ID] = s.Trips[i].Route
+		}
+	}
+	type stopAndRoute struct {
+		stop  *Stop
+		route *Route
+	}
+	seen := map[stopAndRoute]bool{}
+	routesByStop := map[*Stop][]*Route{}
+	err := s.ForEachStopTime(func(trip ScheduledTrip, stopTime ScheduledStopTime) bool {
+		route := tripIDToRoute[trip.ID]
+		if route == nil || stopTime.Stop == nil {
+			return true
+		}
+		key := stopAndRoute{stopTime.Stop, route}
+		if !seen[key] {
+			seen[key] = true
+			routesByStop[stopTime.Stop] = append(routesByStop[stopTime.Stop], route)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return routesByStop, nil
+}
+
+// CanonicalTrip returns a representative trip of the route in the direction, which is useful for
+// drawing the route or listing all of its stops.
+//
+// The representative trip is a trip with the longest stop pattern among the trips that run on
+// weekdays. If several patterns are equally long the one used by the most trips is chosen, and
+// the first trip in the Trips field with that pattern is returned. If the route has no weekday
+// trips in the direction, all of its trips in the direction are considered. If the route has no
+// trips in the direction, nil is returned.
+func (s *Static) CanonicalTrip(routeID string, direction DirectionID) (*ScheduledTrip, error) {
+	route := s.routeByID(routeID)
+	if route == nil {
+		return nil, fmt.Errorf("no route with ID %q", routeID)
+	}
+	for _, sampling := range []TripSampling{TripSampling_WeekdayOnly, TripSampling_AllTrips} {
+		patterns, err := s.stopPatterns(func(trip *ScheduledTrip) bool {
+			return trip.Route == route && trip.DirectionId == direction && sampling.includes(trip)
+		})
+		if err != nil {
+			return nil, err
+		}
+		var best *stopPattern
+		for i := range patterns {
+			pattern := &patterns[i]
+			if best == nil || len(pattern.stops) > len(best.stops) ||
+				(len(pattern.stops) == len(best.stops) && len(pattern.trips) > len(best.trips)) {
+				best = pattern
+			}
+		}
+		if best != nil {
+			return best.trips[0], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,168 @@
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newServiceMapsTestFeed() []byte {
+	return newZipBuilder().add(
+		"agency.txt",
+		"agency_name,agency_url,agency_timezone\na,b,UTC",
+	).add(
+		"routes.txt",
+		"route_id,route_type\nroute,3\nother,3",
+	).add(
+		"stops.txt",
+		"stop_id,parent_station,location_type\n"+
+			"station,,1\n"+
+			"a,,0\nb,,0\nc,,0\nd,station,0\ne,station,0\nx,,0",
+	).add(
+		"calendar.txt",
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n"+
+			"weekday,1,1,1,1,1,0,0,20220502,20220508\n"+
+			"weekend,0,0,0,0,0,1,1,20220502,20220508",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id,direction_id\n"+
+			"route,weekday,express,0\n"+
+			"route,weekday,local_1,0\n"+
+			"route,weekday,local_2,0\n"+
+			"route,weekend,weekend,0\n"+
+			"route,weekday,reverse,1\n"+
+			"other,weekday,other,0",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,arrival_time,departure_time,stop_sequence\n"+
+			"express,a,08:00:00,08:00:00,1\n"+
+			"express,b,08:05:00,08:05:00,2\n"+
+			"express,d,08:10:00,08:10:00,3\n"+
+			"local_1,b,09:05:00,09:05:00,2\n"+
+			"local_1,a,09:00:00,09:00:00,1\n"+
+			"local_1,c,09:07:00,09:07:00,3\n"+
+			"local_1,d,09:10:00,09:10:00,4\n"+
+			"local_2,a,10:00:00,10:00:00,1\n"+
+			"local_2,b,10:05:00,10:05:00,2\n"+
+			"local_2,c,10:07:00,10:07:00,3\n"+
+			"local_2,d,10:10:00,10:10:00,4\n"+
+			"weekend,a,08:00:00,08:00:00,1\n"+
+			"weekend,x,08:05:00,08:05:00,2\n"+
+			"weekend,d,08:10:00,08:10:00,3\n"+
+			"reverse,d,08:00:00,08:00:00,1\n"+
+			"reverse,a,08:10:00,08:10:00,2\n"+
+			"other,e,08:00:00,08:00:00,1",
+	).build()
+}
+
+func stopIDs(stops []*Stop) []string {
+	var ids []string
+	for _, stop := range stops {
+		ids = append(ids, stop.Id)
+	}
+	return ids
+}
+
+func TestStopsServedByRoute(t *testing.T) {
+	for _, streamStopTimes := range []bool{false, true} {
+		static, err := ParseStatic(newServiceMapsTestFeed(), ParseStaticOptions{StreamStopTimes: streamStopTimes})
+		if err != nil {
+			t.Fatalf("ParseStatic() err = %v", err)
+		}
+		for _, tc := range []struct {
+			direction DirectionID
+			sampling  TripSampling
+			want      []string
+		}{
+			{DirectionID_False, TripSampling_AllTrips, []string{"a", "b", "c", "x", "d"}},
+			{DirectionID_False, TripSampling_WeekdayOnly, []string{"a", "b", "c", "d"}},
+			{DirectionID_True, TripSampling_AllTrips, []string{"d", "a"}},
+		} {
+			got, err := static.StopsServedByRoute("route", tc.direction, tc.sampling)
+			if err != nil {
+				t.Fatalf("StopsServedByRoute() err = %v", err)
+			}
+			if diff := cmp.Diff(stopIDs(got), tc.want); diff != "" {
+				t.Errorf("StopsServedByRoute(%s, %s) got = %v, want = %v", tc.direction, tc.sampling, stopIDs(got), tc.want)
+			}
+		}
+	}
+	static, _ := ParseStatic(newServiceMapsTestFeed(), ParseStaticOptions{})
+	if _, err := static.StopsServedByRoute("unknown", DirectionID_False, TripSampling_AllTrips); err == nil {
+		t.Errorf("StopsServedByRoute() for unknown route err = nil, want error")
+	}
+}
+
+func TestMergeStopPatterns_Cycle(t *testing.T) {
+	a, b, c := &Stop{Id: "a"}, &Stop{Id: "b"}, &Stop{Id: "c"}
+
+	got := mergeStopPatterns([][]*Stop{{a, b, c}, {c, a}})
+
+	if diff := cmp.Diff(stopIDs(got), []string{"a", "b", "c"}); diff != "" {
+		t.Errorf("mergeStopPatterns() diff: %s", diff)
+	}
+}
+
+func TestRoutesServingStop(t *testing.T) {
+	for _, streamStopTimes := range []bool{false, true} {
+		static, err := ParseStatic(newServiceMapsTestFeed(), ParseStaticOptions{StreamStopTimes: streamStopTimes})
+		if err != nil {
+			t.Fatalf("ParseStatic() err = %v", err)
+		}
+		for _, tc := range []struct {
+			stopID string
+			want   []string
+		}{
+			{"a", []string{"route"}},
+			{"e", []string{"other"}},
+			{"station", []string{"other", "route"}},
+			{"x", []string{"route"}},
+		} {
+			routes, err := static.RoutesServingStop(tc.stopID)
+			if err != nil {
+				t.Fatalf("RoutesServingStop() err = %v", err)
+			}
+			var got []string
+			for _, route := range routes {
+				got = append(got, route.Id)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("RoutesServingStop(%s) got = %v, want = %v", tc.stopID, got, tc.want)
+			}
+		}
+		if _, err := static.RoutesServingStop("unknown"); err == nil {
+			t.Errorf("RoutesServingStop() for unknown stop err = nil, want error")
+		}
+	}
+}
+
+func TestCanonicalTrip(t *testing.T) {
+	for _, streamStopTimes := range []bool{false, true} {
+		static, err := ParseStatic(newServiceMapsTestFeed(), ParseStaticOptions{StreamStopTimes: streamStopTimes})
+		if err != nil {
+			t.Fatalf("ParseStatic() err = %v", err)
+		}
+		for _, tc := range []struct {
+			routeID   string
+			direction DirectionID
+			want      string
+		}{
+			{"route", DirectionID_False, "local_1"},
+			{"route", DirectionID_True, "reverse"},
+			{"other", DirectionID_False, "other"},
+			{"other", DirectionID_True, ""},
+		} {
+			trip, err := static.CanonicalTrip(tc.routeID, tc.direction)
+			if err != nil {
+				t.Fatalf("CanonicalTrip() err = %v", err)
+			}
+			var got string
+			if trip != nil {
+				got = trip.ID
+			}
+			if got != tc.want {
+				t.Errorf("CanonicalTrip(%s, %s) = %q, want %q", tc.routeID, tc.direction, got, tc.want)
+			}
+		}
+	}
+}
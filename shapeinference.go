@@ -0,0 +1,89 @@
+package gtfs
+
+import (
+	"sort"
+	"strings"
+)
+
+// inferShapes synthesizes a straight-line shape through the stops of each trip without a shape.
+func inferShapes(s *Static) error {
+	var tripIDs []string
+	tripIDToStopTimes := map[string][]ScheduledStopTime{}
+	for i := range s.Trips {
+		if s.Trips[i].Shape == nil {
+			tripIDs = append(tripIDs, s.Trips[i].ID)
+			tripIDToStopTimes[s.Trips[i].ID] = nil
+		}
+	}
+	if len(tripIDs) == 0 {
+		return nil
+	}
+	err := s.ForEachStopTime(func(trip ScheduledTrip, stopTime ScheduledStopTime) bool {
+		if stopTimes, ok := tripIDToStopTimes[trip.ID]; ok {
+			tripIDToStopTimes[trip.ID] = append(stopTimes, stopTime)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	var shapes []Shape
+	tripIDToShape := map[string]int{}
+	patternToShape := map[string]int{}
+	for _, tripID := range tripIDs {
+		stopTimes := tripIDToStopTimes[tripID]
+		sort.SliceStable(stopTimes, func(i, j int) bool {
+			return stopTimes[i].StopSequence < stopTimes[j].StopSequence
+		})
+		var pattern []string
+		var points []ShapePoint
+		for _, stopTime := range stopTimes {
+			lat, lon, ok := stopTime.Stop.position()
+			if !ok {
+				continue
+			}
+			pattern = append(pattern, stopTime.Stop.Id)
+			points = append(points, ShapePoint{Latitude: lat, Longitude: lon})
+		}
+		if len(points) < 2 {
+			continue
+		}
+		key := strings.Join(pattern, "\x00")
+		i, ok := patternToShape[key]
+		if !ok {
+			i = len(shapes)
+			patternToShape[key] = i
+			shapes = append(shapes, Shape{
+				ID:        "synthetic_" + tripID,
+				Points:    points,
+				Synthetic: true,
+			})
+		}
+		tripIDToShape[tripID] = i
+	}
+	if len(shapes) == 0 {
+		return nil
+	}
+
+	// Appending to the Shapes field can move the existing shapes, so the shapes of all trips are
+	// re-linked.
+	n := len(s.Shapes)
+	moved := make(map[*Shape]*Shape, n)
+	allShapes := make([]Shape, 0, n+len(shapes))
+	allShapes = append(allShapes, s.Shapes...)
+	allShapes = append(allShapes, shapes...)
+	for i := range s.Shapes {
+		moved[&s.Shapes[i]] = &allShapes[i]
+	}
+	s.Shapes = allShapes
+	for i := range s.Trips {
+		trip := &s.Trips[i]
+		if j, ok := tripIDToShape[trip.ID]; ok {
+			trip.Shape = &s.Shapes[n+j]
+		} else if shape, ok := moved[trip.Shape]; ok {
+			trip.Shape = shape
+		}
+	}
+	return nil
+}
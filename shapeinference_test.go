@@ -0,0 +1,77 @@
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse_InferShapes(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id,stop_lat,stop_lon\nstop_1,40.0,-74.0\nstop_2,40.1,-74.0\nstop_3,40.1,-73.9\nstop_4,,",
+	).add(
+		"shapes.txt",
+		"shape_id,shape_pt_lat,shape_pt_lon,shape_pt_sequence\nshape_1,40.0,-74.0,1\nshape_1,40.1,-73.9,2",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id,shape_id\n"+
+			"route_id,service_id,trip_1,\n"+
+			"route_id,service_id,trip_2,\n"+
+			"route_id,service_id,trip_3,shape_1\n"+
+			"route_id,service_id,trip_4,",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,arrival_time,departure_time,stop_sequence\n"+
+			"trip_1,stop_2,08:10:00,08:10:00,2\n"+
+			"trip_1,stop_1,08:00:00,08:00:00,1\n"+
+			"trip_1,stop_4,08:15:00,08:15:00,3\n"+
+			"trip_1,stop_3,08:20:00,08:20:00,4\n"+
+			"trip_2,stop_1,09:00:00,09:00:00,1\n"+
+			"trip_2,stop_2,09:10:00,09:10:00,2\n"+
+			"trip_2,stop_3,09:20:00,09:20:00,3\n"+
+			"trip_3,stop_1,10:00:00,10:00:00,1\n"+
+			"trip_3,stop_3,10:20:00,10:20:00,2\n"+
+			"trip_4,stop_4,11:00:00,11:00:00,1",
+	).build()
+
+	for _, streamStopTimes := range []bool{false, true} {
+		static, err := ParseStatic(content, ParseStaticOptions{InferShapes: true, StreamStopTimes: streamStopTimes})
+		if err != nil {
+			t.Fatalf("error when parsing: %s", err)
+		}
+		wantShapes := []Shape{
+			{
+				ID: "shape_1",
+				Points: []ShapePoint{
+					{Latitude: 40.0, Longitude: -74.0},
+					{Latitude: 40.1, Longitude: -73.9},
+				},
+			},
+			{
+				ID: "synthetic_trip_1",
+				Points: []ShapePoint{
+					{Latitude: 40.0, Longitude: -74.0},
+					{Latitude: 40.1, Longitude: -74.0},
+					{Latitude: 40.1, Longitude: -73.9},
+				},
+				Synthetic: true,
+			},
+		}
+		if diff := cmp.Diff(static.Shapes, wantShapes); diff != "" {
+			t.Errorf("StreamStopTimes=%t: shapes diff: %s", streamStopTimes, diff)
+		}
+		wantTripShapes := map[string]*Shape{
+			"trip_1": &static.Shapes[1],
+			"trip_2": &static.Shapes[1],
+			"trip_3": &static.Shapes[0],
+			"trip_4": nil,
+		}
+		for i := range static.Trips {
+			trip := &static.Trips[i]
+			if trip.Shape != wantTripShapes[trip.ID] {
+				t.Errorf("StreamStopTimes=%t: trip %s has shape %v, want %v", streamStopTimes, trip.ID, trip.Shape, wantTripShapes[trip.ID])
+			}
+		}
+	}
+}
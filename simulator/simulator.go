@@ -0,0 +1,346 @@
+// Package simulator contains a simulator that generates synthetic vehicle positions from a static
+// feed, for load testing consumers of GTFS realtime feeds and demoing user interfaces without live data.
+package simulator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// Options configures the simulator.
+type Options struct {
+	// Timezone in which the scheduled times of the feed are interpreted.
+	//
+	// If nil, the timezone the static message was parsed with, [gtfs.Static.Timezone], is used. If
+	// that is also nil, the timezone of the first agency in the feed is used, or UTC if it cannot be
+	// loaded.
+	Timezone *time.Location
+}
+
+// Simulator generates the positions of vehicles that run every trip of a static feed exactly on
+// schedule.
+//
+// Vehicles move along the shape of their trip, or in straight lines between stops if the trip has no
+// shape. A vehicle appears at the first stop of its trip at the scheduled arrival time and disappears
+// after arriving at the last stop. Trips generated by frequencies.txt are expanded.
+type Simulator struct {
+	clock    func() time.Time
+	timezone *time.Location
+	trips    []simulatedTrip
+}
+
+type simulatedTrip struct {
+	trip      *gtfs.ScheduledTrip
+	stopTimes []gtfs.ScheduledStopTime
+	// Distance along the path of each stop time, in meters.
+	distances []float64
+	path      path
+	// Offsets by which the schedule of the trip is shifted for each vehicle that runs it. This is
+	// a single zero offset unless the trip is generated by frequencies.txt.
+	offsets []time.Duration
+}
+
+// New returns a simulator for the static message that uses the clock to determine the current time.
+//
+// Trips without a service or with fewer than two stop times with locations are skipped.
+func New(static *gtfs.Static, clock func() time.Time, opts Options) (*Simulator, error) {
+	timezone := opts.Timezone
+	if timezone == nil {
+		timezone = static.Timezone
+	}
+	if timezone == nil {
+		timezone = time.UTC
+		if len(static.Agencies) > 0 {
+			if location, err := static.Agencies[0].Location(); err == nil {
+				timezone = location
+			}
+		}
+	}
+	tripIDToStopTimes := map[string][]gtfs.ScheduledStopTime{}
+	err := static.ForEachStopTime(func(trip gtfs.ScheduledTrip, stopTime gtfs.ScheduledStopTime) bool {
+		if _, ok := stopPosition(stopTime.Stop); ok {
+			tripIDToStopTimes[trip.ID] = append(tripIDToStopTimes[trip.ID], stopTime)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stop times: %w", err)
+	}
+	s := &Simulator{clock: clock, timezone: timezone}
+	for i := range static.Trips {
+		trip := &static.Trips[i]
+		stopTimes := tripIDToStopTimes[trip.ID]
+		if trip.Service == nil || len(stopTimes) < 2 {
+			continue
+		}
+		sort.SliceStable(stopTimes, func(i, j int) bool { return stopTimes[i].StopSequence < stopTimes[j].StopSequence })
+		simulated := simulatedTrip{
+			trip:      trip,
+			stopTimes: stopTimes,
+			offsets:   []time.Duration{0},
+		}
+		simulated.path, simulated.distances = buildPath(trip.Shape, stopTimes)
+		if len(trip.Frequencies) > 0 {
+			simulated.offsets = nil
+			for _, instance := range trip.Instances() {
+				simulated.offsets = append(simulated.offsets, instance.StartTime-stopTimes[0].DepartureTime)
+			}
+		}
+		s.trips = append(s.trips, simulated)
+	}
+	return s, nil
+}
+
+// Vehicles returns the vehicles that are running at the current time of the clock.
+//
+// Each vehicle has a trip whose ID contains the start date and, for trips generated by
+// frequencies.txt, the start time. The ID of the vehicle is the trip ID, followed by the start time
+// for trips generated by frequencies.txt. Vehicles are ordered by the position of their trip in the
+// static message and then by start time.
+func (s *Simulator) Vehicles() []gtfs.Vehicle {
+	now := s.clock()
+	t := now.In(s.timezone)
+	y, m, d := t.Date()
+	var vehicles []gtfs.Vehicle
+	for i := range s.trips {
+		trip := &s.trips[i]
+		for dayOffset := -1; dayOffset <= 0; dayOffset++ {
+			serviceDate := time.Date(y, m, d+dayOffset, 0, 0, 0, 0, s.timezone)
+			if !trip.trip.Service.RunsOn(serviceDate) {
+				continue
+			}
+			serviceDay := gtfs.ServiceDayStart(serviceDate)
+			for _, offset := range trip.offsets {
+				vehicle, ok := trip.vehicleAt(t.Sub(serviceDay)-offset, serviceDate, offset)
+				if !ok {
+					continue
+				}
+				timestamp := now
+				vehicle.Timestamp = &timestamp
+				vehicles = append(vehicles, vehicle)
+			}
+		}
+	}
+	for i := range vehicles {
+		vehicles[i].Trip.Vehicle = &vehicles[i]
+	}
+	return vehicles
+}
+
+// FeedMessage returns a full dataset GTFS realtime message containing the vehicle positions at the
+// current time of the clock.
+func (s *Simulator) FeedMessage() *gtfsrt.FeedMessage {
+	now := s.clock()
+	feedMessage := &gtfsrt.FeedMessage{
+		Header: &gtfsrt.FeedHeader{
+			GtfsRealtimeVersion: proto.String("2.0"),
+			Incrementality:      gtfsrt.FeedHeader_FULL_DATASET.Enum(),
+			Timestamp:           proto.Uint64(uint64(now.Unix())),
+		},
+	}
+	for _, vehicle := range s.Vehicles() {
+		tripID := vehicle.Trip.ID
+		tripDescriptor := &gtfsrt.TripDescriptor{
+			TripId:    proto.String(tripID.ID),
+			StartDate: proto.String(tripID.StartDate.Format("20060102")),
+		}
+		if tripID.RouteID != "" {
+			tripDescriptor.RouteId = proto.String(tripID.RouteID)
+		}
+		if tripID.HasStartTime {
+			tripDescriptor.StartTime = proto.String(gtfs.FormatGTFSTime(tripID.StartTime))
+		}
+		switch tripID.DirectionID {
+		case gtfs.DirectionID_False:
+			tripDescriptor.DirectionId = proto.Uint32(0)
+		case gtfs.DirectionID_True:
+			tripDescriptor.DirectionId = proto.Uint32(1)
+		}
+		feedMessage.Entity = append(feedMessage.Entity, &gtfsrt.FeedEntity{
+			Id: proto.String(vehicle.ID.ID),
+			Vehicle: &gtfsrt.VehiclePosition{
+				Trip:    tripDescriptor,
+				Vehicle: &gtfsrt.VehicleDescriptor{Id: proto.String(vehicle.ID.ID)},
+				Position: &gtfsrt.Position{
+					Latitude:  vehicle.Position.Latitude,
+					Longitude: vehicle.Position.Longitude,
+					Bearing:   vehicle.Position.Bearing,
+				},
+				CurrentStopSequence: vehicle.CurrentStopSequence,
+				StopId:              vehicle.StopID,
+				CurrentStatus:       vehicle.CurrentStatus,
+				Timestamp:           proto.Uint64(uint64(vehicle.Timestamp.Unix())),
+			},
+		})
+	}
+	return feedMessage
+}
+
+// vehicleAt returns the vehicle running the trip when the given time has elapsed since the start of
+// the service day, according to the schedule of the trip.
+func (trip *simulatedTrip) vehicleAt(elapsed time.Duration, serviceDate time.Time, offset time.Duration) (gtfs.Vehicle, bool) {
+	stopTimes := trip.stopTimes
+	last := len(stopTimes) - 1
+	if elapsed < stopTimes[0].ArrivalTime || elapsed > stopTimes[last].ArrivalTime {
+		return gtfs.Vehicle{}, false
+	}
+	// i is the last stop time whose arrival is not after the elapsed time.
+	i := sort.Search(len(stopTimes), func(j int) bool { return stopTimes[j].ArrivalTime > elapsed }) - 1
+	currentStatus := gtfsrt.VehiclePosition_STOPPED_AT
+	current := i
+	d := trip.distances[i]
+	if elapsed > stopTimes[i].DepartureTime && i < last {
+		currentStatus = gtfsrt.VehiclePosition_IN_TRANSIT_TO
+		current = i + 1
+		if travelTime := stopTimes[i+1].ArrivalTime - stopTimes[i].DepartureTime; travelTime > 0 {
+			f := float64(elapsed-stopTimes[i].DepartureTime) / float64(travelTime)
+			d += f * (trip.distances[i+1] - trip.distances[i])
+		}
+	}
+	latitude, longitude, bearing := trip.path.at(d)
+
+	tripID := gtfs.TripID{
+		ID:           trip.trip.ID,
+		DirectionID:  trip.trip.DirectionId,
+		HasStartDate: true,
+		StartDate:    time.Date(serviceDate.Year(), serviceDate.Month(), serviceDate.Day(), 0, 0, 0, 0, time.UTC),
+	}
+	if trip.trip.Route != nil {
+		tripID.RouteID = trip.trip.Route.Id
+	}
+	vehicleID := trip.trip.ID
+	if len(trip.trip.Frequencies) > 0 {
+		tripID.HasStartTime = true
+		tripID.StartTime = stopTimes[0].DepartureTime + offset
+		vehicleID = fmt.Sprintf("%s_%s", vehicleID, gtfs.FormatGTFSTime(tripID.StartTime))
+	}
+	stopSequence := uint32(stopTimes[current].StopSequence)
+	stopID := stopTimes[current].Stop.Id
+	vehicle := gtfs.Vehicle{
+		ID:   &gtfs.VehicleID{ID: vehicleID, HasID: true},
+		Trip: &gtfs.Trip{ID: tripID},
+		Position: &gtfs.Position{
+			Latitude:  proto.Float32(float32(latitude)),
+			Longitude: proto.Float32(float32(longitude)),
+			Bearing:   proto.Float32(float32(bearing)),
+		},
+		CurrentStopSequence: &stopSequence,
+		StopID:              &stopID,
+		CurrentStatus:       &currentStatus,
+	}
+	return vehicle, true
+}
+
+type point struct {
+	latitude, longitude float64
+}
+
+// path is a polyline along which vehicles move.
+type path struct {
+	points []point
+	// Distance along the path of each point, in meters.
+	distances []float64
+}
+
+func newPath(points []point) path {
+	p := path{points: points, distances: make([]float64, len(points))}
+	for i := 1; i < len(points); i++ {
+		p.distances[i] = p.distances[i-1] + distance(points[i-1], points[i])
+	}
+	return p
+}
+
+// at returns the location at the distance along the path, and the bearing of the path there.
+func (p path) at(d float64) (float64, float64, float64) {
+	if len(p.points) == 1 {
+		return p.points[0].latitude, p.points[0].longitude, 0
+	}
+	// i is the start of the segment containing the distance.
+	i := sort.SearchFloat64s(p.distances, d) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i > len(p.points)-2 {
+		i = len(p.points) - 2
+	}
+	from, to := p.points[i], p.points[i+1]
+	f := 0.0
+	if length := p.distances[i+1] - p.distances[i]; length > 0 {
+		f = math.Max(0, math.Min(1, (d-p.distances[i])/length))
+	}
+	return from.latitude + f*(to.latitude-from.latitude), from.longitude + f*(to.longitude-from.longitude), bearing(from, to)
+}
+
+// buildPath returns the path of a trip and the distance along it of each stop time.
+//
+// If the trip has a shape, each stop is placed at the closest point of the shape, searching forward
+// from the previous stop so that the distances are non-decreasing. Otherwise the path consists of
+// straight lines between the stops.
+func buildPath(shape *gtfs.Shape, stopTimes []gtfs.ScheduledStopTime) (path, []float64) {
+	stopPoints := make([]point, len(stopTimes))
+	for i, stopTime := range stopTimes {
+		stopPoints[i], _ = stopPosition(stopTime.Stop)
+	}
+	distances := make([]float64, len(stopTimes))
+	if shape == nil || len(shape.Points) < 2 {
+		p := newPath(stopPoints)
+		copy(distances, p.distances)
+		return p, distances
+	}
+	shapePoints := make([]point, len(shape.Points))
+	for i, shapePoint := range shape.Points {
+		shapePoints[i] = point{latitude: shapePoint.Latitude, longitude: shapePoint.Longitude}
+	}
+	p := newPath(shapePoints)
+	j := 0
+	for i, stopPoint := range stopPoints {
+		closest := j
+		for k := j + 1; k < len(shapePoints); k++ {
+			if distance(shapePoints[k], stopPoint) < distance(shapePoints[closest], stopPoint) {
+				closest = k
+			}
+		}
+		j = closest
+		distances[i] = p.distances[j]
+	}
+	return p, distances
+}
+
+// stopPosition returns the location of the stop, or of its closest ancestor with a location.
+func stopPosition(stop *gtfs.Stop) (point, bool) {
+	for ; stop != nil; stop = stop.Parent {
+		if stop.Latitude != nil && stop.Longitude != nil {
+			return point{latitude: *stop.Latitude, longitude: *stop.Longitude}, true
+		}
+	}
+	return point{}, false
+}
+
+// distance returns the great-circle distance in meters between two locations.
+func distance(p1, p2 point) float64 {
+	const earthRadiusMeters = 6371000.0
+	dLat := toRadians(p2.latitude - p1.latitude)
+	dLon := toRadians(p2.longitude - p1.longitude)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(p1.latitude))*math.Cos(toRadians(p2.latitude))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// bearing returns the initial bearing in degrees, clockwise from North, of the great circle from p1 to p2.
+func bearing(p1, p2 point) float64 {
+	lat1, lat2 := toRadians(p1.latitude), toRadians(p2.latitude)
+	dLon := toRadians(p2.longitude - p1.longitude)
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+func toRadians(d float64) float64 {
+	return d * math.Pi / 180
+}
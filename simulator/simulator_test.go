@@ -0,0 +1,217 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jamespfennell/gtfs"
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+var date = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+func newStatic() *gtfs.Static {
+	static := &gtfs.Static{
+		Agencies: []gtfs.Agency{{Id: "agency", Timezone: "UTC"}},
+		Routes:   []gtfs.Route{{Id: "route"}},
+		Services: []gtfs.Service{{Id: "service", AddedDates: []time.Time{date}}},
+		Stops: []gtfs.Stop{
+			{Id: "stop_1", Latitude: ptr(40.0), Longitude: ptr(-74.0)},
+			{Id: "stop_2", Latitude: ptr(40.1), Longitude: ptr(-74.0)},
+			{Id: "stop_3", Latitude: ptr(40.1), Longitude: ptr(-73.9)},
+		},
+		Shapes: []gtfs.Shape{
+			{
+				ID: "shape",
+				Points: []gtfs.ShapePoint{
+					{Latitude: 40.0, Longitude: -74.0},
+					{Latitude: 40.0, Longitude: -73.9},
+					{Latitude: 40.1, Longitude: -73.9},
+				},
+			},
+		},
+	}
+	static.Trips = []gtfs.ScheduledTrip{
+		{
+			ID:          "trip",
+			Route:       &static.Routes[0],
+			Service:     &static.Services[0],
+			DirectionId: gtfs.DirectionID_True,
+			StopTimes: []gtfs.ScheduledStopTime{
+				{Stop: &static.Stops[0], StopSequence: 1, ArrivalTime: 8 * time.Hour, DepartureTime: 8*time.Hour + time.Minute},
+				{Stop: &static.Stops[1], StopSequence: 2, ArrivalTime: 8*time.Hour + 11*time.Minute, DepartureTime: 8*time.Hour + 11*time.Minute},
+			},
+		},
+		{
+			ID:      "shape_trip",
+			Route:   &static.Routes[0],
+			Service: &static.Services[0],
+			Shape:   &static.Shapes[0],
+			StopTimes: []gtfs.ScheduledStopTime{
+				{Stop: &static.Stops[0], StopSequence: 1, ArrivalTime: 9 * time.Hour, DepartureTime: 9 * time.Hour},
+				{Stop: &static.Stops[2], StopSequence: 2, ArrivalTime: 9*time.Hour + 20*time.Minute, DepartureTime: 9*time.Hour + 20*time.Minute},
+			},
+			Frequencies: []gtfs.Frequency{
+				{StartTime: 9 * time.Hour, EndTime: 10 * time.Hour, Headway: 30 * time.Minute},
+			},
+		},
+	}
+	return static
+}
+
+func TestVehicles(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		time time.Duration
+		want []gtfs.Vehicle
+	}{
+		{
+			name: "before service",
+			time: 7 * time.Hour,
+		},
+		{
+			name: "stopped at first stop",
+			time: 8*time.Hour + 30*time.Second,
+			want: []gtfs.Vehicle{
+				vehicle("trip", "trip", gtfs.DirectionID_True, nil, 40.0, -74.0, 0, "stop_1", 1, gtfsrt.VehiclePosition_STOPPED_AT),
+			},
+		},
+		{
+			name: "halfway between stops",
+			time: 8*time.Hour + 6*time.Minute,
+			want: []gtfs.Vehicle{
+				vehicle("trip", "trip", gtfs.DirectionID_True, nil, 40.05, -74.0, 0, "stop_2", 2, gtfsrt.VehiclePosition_IN_TRANSIT_TO),
+			},
+		},
+		{
+			name: "along shape",
+			time: 9*time.Hour + 35*time.Minute,
+			want: []gtfs.Vehicle{
+				// The first leg of the shape, heading East, is about 8.5km long and the second, heading
+				// North, is about 11.1km long.
+				vehicle("shape_trip_09:30:00", "shape_trip", gtfs.DirectionID_Unspecified, ptr(9*time.Hour+30*time.Minute), 40.0, -73.9424, 89.9679, "stop_3", 2, gtfsrt.VehiclePosition_IN_TRANSIT_TO),
+			},
+		},
+		{
+			name: "second leg of shape",
+			time: 9*time.Hour + 45*time.Minute,
+			want: []gtfs.Vehicle{
+				vehicle("shape_trip_09:30:00", "shape_trip", gtfs.DirectionID_Unspecified, ptr(9*time.Hour+30*time.Minute), 40.0558, -73.9, 0, "stop_3", 2, gtfsrt.VehiclePosition_IN_TRANSIT_TO),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			now := date.Add(tc.time)
+			s, err := New(newStatic(), func() time.Time { return now }, Options{})
+			if err != nil {
+				t.Fatalf("New() err = %v", err)
+			}
+			got := s.Vehicles()
+			for i := range tc.want {
+				tc.want[i].Timestamp = &now
+			}
+			if diff := cmp.Diff(got, tc.want, cmpopts.EquateApprox(0, 0.0005), cmpopts.IgnoreFields(gtfs.Trip{}, "Vehicle")); diff != "" {
+				t.Errorf("Vehicles() diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestVehicles_DaylightSavingTime(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("failed to load timezone: %s", err)
+	}
+	// Clocks go forward at 2am on 13 March 2022, so the trip's first arrival at 08:00:00 is 8 hours
+	// after noon minus 12h but only 7 hours after midnight.
+	static := newStatic()
+	static.Agencies[0].Timezone = "America/New_York"
+	static.Services[0].AddedDates = []time.Time{time.Date(2022, 3, 13, 0, 0, 0, 0, newYork)}
+	now := time.Date(2022, 3, 13, 8, 0, 30, 0, newYork)
+	s, err := New(static, func() time.Time { return now }, Options{})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	got := s.Vehicles()
+	if len(got) != 1 {
+		t.Fatalf("Vehicles() returned %d vehicles, want 1", len(got))
+	}
+	if got[0].StopID == nil || *got[0].StopID != "stop_1" || got[0].CurrentStatus == nil || *got[0].CurrentStatus != gtfsrt.VehiclePosition_STOPPED_AT {
+		t.Errorf("Vehicles() got stop %v and status %v, want stopped at stop_1", got[0].StopID, got[0].CurrentStatus)
+	}
+	if want := time.Date(2022, 3, 13, 0, 0, 0, 0, time.UTC); !got[0].Trip.ID.StartDate.Equal(want) {
+		t.Errorf("Vehicles() got start date %s, want %s", got[0].Trip.ID.StartDate, want)
+	}
+}
+
+func TestFeedMessage(t *testing.T) {
+	now := date.Add(9*time.Hour + 5*time.Minute)
+	s, err := New(newStatic(), func() time.Time { return now }, Options{})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	b, err := proto.Marshal(s.FeedMessage())
+	if err != nil {
+		t.Fatalf("failed to marshal feed message: %s", err)
+	}
+	realtime, err := gtfs.ParseRealtime(b, &gtfs.ParseRealtimeOptions{Strictness: gtfs.Strictness_Strict})
+	if err != nil {
+		t.Fatalf("failed to parse feed message: %s", err)
+	}
+
+	if len(realtime.Vehicles) != 1 {
+		t.Fatalf("len(Vehicles) = %d, want 1", len(realtime.Vehicles))
+	}
+	gotTripID := realtime.Vehicles[0].GetTrip().ID
+	wantTripID := gtfs.TripID{
+		ID:           "shape_trip",
+		RouteID:      "route",
+		HasStartDate: true,
+		StartDate:    date,
+		HasStartTime: true,
+		StartTime:    9 * time.Hour,
+	}
+	if diff := cmp.Diff(gotTripID, wantTripID); diff != "" {
+		t.Errorf("trip ID diff: %s", diff)
+	}
+	if got := realtime.Vehicles[0].GetID().ID; got != "shape_trip_09:00:00" {
+		t.Errorf("vehicle ID = %q, want %q", got, "shape_trip_09:00:00")
+	}
+}
+
+func vehicle(vehicleID, tripID string, directionID gtfs.DirectionID, startTime *time.Duration, latitude, longitude, bearing float32, stopID string, stopSequence uint32, currentStatus gtfs.CurrentStatus) gtfs.Vehicle {
+	trip := gtfs.Trip{
+		ID: gtfs.TripID{
+			ID:           tripID,
+			RouteID:      "route",
+			DirectionID:  directionID,
+			HasStartDate: true,
+			StartDate:    date,
+		},
+	}
+	if startTime != nil {
+		trip.ID.HasStartTime = true
+		trip.ID.StartTime = *startTime
+	}
+	return gtfs.Vehicle{
+		ID:   &gtfs.VehicleID{ID: vehicleID, HasID: true},
+		Trip: &trip,
+		Position: &gtfs.Position{
+			Latitude:  ptr(latitude),
+			Longitude: ptr(longitude),
+			Bearing:   ptr(bearing),
+		},
+		CurrentStopSequence: ptr(stopSequence),
+		StopID:              ptr(stopID),
+		CurrentStatus:       ptr(currentStatus),
+	}
+}
+
+func ptr[T any](t T) *T {
+	return &t
+}
@@ -0,0 +1,204 @@
+// Package snapshot contains tools for archiving raw GTFS realtime messages and replaying them.
+package snapshot
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+)
+
+// Snapshot is a raw GTFS realtime message archived at a point in time.
+type Snapshot struct {
+	// The time the message was fetched.
+	Time time.Time
+	// The raw bytes of the message.
+	Content []byte
+	// Hash of the parsed message, as calculated by [HashRealtime], or nil if the hash was not calculated.
+	Hash []byte
+}
+
+// ErrNotFound is returned by [Store.Get] if there is no snapshot at the requested time.
+var ErrNotFound = errors.New("snapshot not found")
+
+// Store archives snapshots by time.
+type Store interface {
+	// Put stores the snapshot, replacing any snapshot with the same time.
+	Put(snapshot *Snapshot) error
+	// Get returns the snapshot at the time, or an error wrapping [ErrNotFound] if there is none.
+	Get(t time.Time) (*Snapshot, error)
+	// List returns the times of all snapshots in the time range, inclusive, in increasing order.
+	List(start, end time.Time) ([]time.Time, error)
+}
+
+// HashRealtime calculates a hash of the trips and vehicles in the parsed message.
+//
+// Two messages with the same hash contain the same trip and vehicle data, even if their raw bytes
+// differ, for example because only the header timestamp changed. The hash uses [gtfs.Trip.Hash]
+// and [gtfs.Vehicle.Hash], and so ignores the same fields they do. The hash does not depend on the
+// order of the trips and vehicles, which for vehicles is not deterministic.
+func HashRealtime(realtime *gtfs.Realtime) []byte {
+	tripHashes := make([][]byte, len(realtime.Trips))
+	for i := range realtime.Trips {
+		h := sha256.New()
+		realtime.Trips[i].Hash(h)
+		tripHashes[i] = h.Sum(nil)
+	}
+	vehicleHashes := make([][]byte, len(realtime.Vehicles))
+	for i := range realtime.Vehicles {
+		h := sha256.New()
+		realtime.Vehicles[i].Hash(h)
+		vehicleHashes[i] = h.Sum(nil)
+	}
+	h := sha256.New()
+	for _, hashes := range [][][]byte{tripHashes, vehicleHashes} {
+		sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i], hashes[j]) < 0 })
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(len(hashes)))
+		h.Write(b[:])
+		for _, hash := range hashes {
+			h.Write(hash)
+		}
+	}
+	return h.Sum(nil)
+}
+
+const (
+	fileTimeFormat    = "20060102T150405.000000000Z"
+	contentFileSuffix = ".pb"
+	hashFileSuffix    = ".sha256"
+)
+
+// FilesystemStore is a [Store] that keeps each snapshot in a file in a directory.
+//
+// The content of each snapshot is stored in a file named after its time in UTC, for example
+// 20220504T081500.000000000Z.pb, so that files sort in time order. If the snapshot has a hash,
+// it is stored hex-encoded in a file with the same name and the .sha256 extension.
+type FilesystemStore struct {
+	dir string
+}
+
+var _ Store = (*FilesystemStore)(nil)
+
+// NewFilesystemStore returns a store that keeps snapshots in the directory, creating it if needed.
+func NewFilesystemStore(dir string) (*FilesystemStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FilesystemStore{dir: dir}, nil
+}
+
+func (s *FilesystemStore) path(t time.Time, suffix string) string {
+	return filepath.Join(s.dir, t.UTC().Format(fileTimeFormat)+suffix)
+}
+
+func (s *FilesystemStore) Put(snapshot *Snapshot) error {
+	hashPath := s.path(snapshot.Time, hashFileSuffix)
+	if snapshot.Hash != nil {
+		if err := os.WriteFile(hashPath, []byte(hex.EncodeToString(snapshot.Hash)), 0644); err != nil {
+			return err
+		}
+	} else if err := os.Remove(hashPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return os.WriteFile(s.path(snapshot.Time, contentFileSuffix), snapshot.Content, 0644)
+}
+
+func (s *FilesystemStore) Get(t time.Time) (*Snapshot, error) {
+	content, err := os.ReadFile(s.path(t, contentFileSuffix))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w at %s", ErrNotFound, t)
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{
+		Time:    t,
+		Content: content,
+	}
+	rawHash, err := os.ReadFile(s.path(t, hashFileSuffix))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if snapshot.Hash, err = hex.DecodeString(strings.TrimSpace(string(rawHash))); err != nil {
+			return nil, fmt.Errorf("invalid hash for snapshot at %s: %w", t, err)
+		}
+	}
+	return snapshot, nil
+}
+
+func (s *FilesystemStore) List(start, end time.Time) ([]time.Time, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var times []time.Time
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasSuffix(name, contentFileSuffix) {
+			continue
+		}
+		t, err := time.Parse(fileTimeFormat, strings.TrimSuffix(name, contentFileSuffix))
+		if err != nil {
+			continue
+		}
+		if t.Before(start) || t.After(end) {
+			continue
+		}
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times, nil
+}
+
+// Source replays the snapshots in a store as parsed GTFS realtime messages.
+//
+// Source implements the journal.GtfsrtSource interface, so archived snapshots can be used to build
+// journals.
+type Source struct {
+	store Store
+	times []time.Time
+	opts  *gtfs.ParseRealtimeOptions
+}
+
+// NewSource returns a source that replays the snapshots in the time range, inclusive, in time order.
+func NewSource(store Store, start, end time.Time, opts *gtfs.ParseRealtimeOptions) (*Source, error) {
+	times, err := store.List(start, end)
+	if err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &gtfs.ParseRealtimeOptions{}
+	}
+	return &Source{store: store, times: times, opts: opts}, nil
+}
+
+// Next returns the next snapshot that can be parsed, or nil if there are no more snapshots.
+//
+// Snapshots that cannot be read or parsed are skipped.
+func (s *Source) Next() *gtfs.Realtime {
+	for len(s.times) > 0 {
+		t := s.times[0]
+		s.times = s.times[1:]
+		snapshot, err := s.store.Get(t)
+		if err != nil {
+			continue
+		}
+		result, err := gtfs.ParseRealtime(snapshot.Content, s.opts)
+		if err != nil {
+			continue
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs"
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestFilesystemStore(t *testing.T) {
+	store, err := NewFilesystemStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFilesystemStore() err = %s", err)
+	}
+	t1 := time.Date(2022, 5, 4, 8, 15, 0, 0, time.UTC)
+	t2 := t1.Add(30 * time.Second)
+	t3 := t1.Add(time.Minute)
+	b1, b2, b3 := marshal(t, 100, "trip1"), marshal(t, 130, "trip1"), marshal(t, 160, "trip2")
+	snapshots := []*Snapshot{
+		{Time: t3, Content: b3},
+		{Time: t1, Content: b1, Hash: HashRealtime(parse(t, b1))},
+		{Time: t2, Content: b2, Hash: HashRealtime(parse(t, b2))},
+	}
+	for _, snapshot := range snapshots {
+		if err := store.Put(snapshot); err != nil {
+			t.Fatalf("Put() err = %s", err)
+		}
+	}
+
+	got, err := store.Get(t1)
+	if err != nil {
+		t.Fatalf("Get() err = %s", err)
+	}
+	if diff := cmp.Diff(got, snapshots[1]); diff != "" {
+		t.Errorf("Get() diff: %s", diff)
+	}
+	if _, err := store.Get(t1.Add(time.Second)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() for missing snapshot err = %v, want ErrNotFound", err)
+	}
+
+	times, err := store.List(t1, t2)
+	if err != nil {
+		t.Fatalf("List() err = %s", err)
+	}
+	if diff := cmp.Diff(times, []time.Time{t1, t2}); diff != "" {
+		t.Errorf("List() diff: %s", diff)
+	}
+
+	// Only the header timestamp differs between the first two snapshots.
+	if !bytes.Equal(snapshots[1].Hash, snapshots[2].Hash) {
+		t.Errorf("HashRealtime() differs for messages with the same trips")
+	}
+	if bytes.Equal(snapshots[1].Hash, HashRealtime(parse(t, b3))) {
+		t.Errorf("HashRealtime() is the same for messages with different trips")
+	}
+
+	source, err := NewSource(store, t1, t3, nil)
+	if err != nil {
+		t.Fatalf("NewSource() err = %s", err)
+	}
+	var createdAts []time.Time
+	for realtime := source.Next(); realtime != nil; realtime = source.Next() {
+		createdAts = append(createdAts, realtime.CreatedAt)
+	}
+	want := []time.Time{time.Unix(100, 0).UTC(), time.Unix(130, 0).UTC(), time.Unix(160, 0).UTC()}
+	if diff := cmp.Diff(createdAts, want); diff != "" {
+		t.Errorf("Source.Next() diff: %s", diff)
+	}
+}
+
+func TestHashRealtime_MultipleVehicles(t *testing.T) {
+	message := &gtfsrt.FeedMessage{
+		Header: &gtfsrt.FeedHeader{GtfsRealtimeVersion: proto.String("2.0")},
+	}
+	for _, vehicleID := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		message.Entity = append(message.Entity, &gtfsrt.FeedEntity{
+			Id: proto.String(vehicleID),
+			Vehicle: &gtfsrt.VehiclePosition{
+				Vehicle: &gtfsrt.VehicleDescriptor{Id: proto.String(vehicleID)},
+			},
+		})
+	}
+	b, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatalf("failed to marshal GTFS-RT message: %s", err)
+	}
+	want := HashRealtime(parse(t, b))
+	for i := 0; i < 20; i++ {
+		if got := HashRealtime(parse(t, b)); !bytes.Equal(got, want) {
+			t.Fatalf("HashRealtime() = %x, want %x", got, want)
+		}
+	}
+}
+
+func marshal(t *testing.T, timestamp uint64, tripID string) []byte {
+	message := &gtfsrt.FeedMessage{
+		Header: &gtfsrt.FeedHeader{
+			GtfsRealtimeVersion: proto.String("2.0"),
+			Timestamp:           proto.Uint64(timestamp),
+		},
+		Entity: []*gtfsrt.FeedEntity{
+			{
+				Id: proto.String("1"),
+				TripUpdate: &gtfsrt.TripUpdate{
+					Trip: &gtfsrt.TripDescriptor{TripId: proto.String(tripID)},
+				},
+			},
+		},
+	}
+	b, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatalf("failed to marshal GTFS-RT message: %s", err)
+	}
+	return b
+}
+
+func parse(t *testing.T, b []byte) *gtfs.Realtime {
+	realtime, err := gtfs.ParseRealtime(b, &gtfs.ParseRealtimeOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse GTFS-RT message: %s", err)
+	}
+	return realtime
+}
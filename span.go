@@ -0,0 +1,91 @@
+package gtfs
+
+import (
+	"time"
+)
+
+// ServiceSpan is the period of a service day during which a route has departures.
+//
+// Like the times of a [ScheduledStopTime], the times are measured from the start of the service day
+// and may exceed 24 hours for service that runs past midnight.
+type ServiceSpan struct {
+	FirstDeparture time.Duration
+	LastDeparture  time.Duration
+}
+
+// ServiceSpan returns the first and last departure times of the route on the provided date.
+//
+// All trips of the route whose service runs on the date are considered, including the trips
+// generated by frequencies.txt. If the route has no departures on the date, nil is returned.
+//
+// This scans all stop times in the feed, so callers that need the span of many routes should
+// cache the results.
+func (route *Route) ServiceSpan(static *Static, date time.Time) (*ServiceSpan, error) {
+	type tripSpan struct {
+		trip        *ScheduledTrip
+		first, last time.Duration
+	}
+	tripIDToSpan := map[string]*tripSpan{}
+	for i := range static.Trips {
+		trip := &static.Trips[i]
+		if trip.Route != route || trip.Service == nil || !trip.Service.RunsOn(date) {
+			continue
+		}
+		tripIDToSpan[trip.ID] = &tripSpan{trip: trip, first: -1, last: -1}
+	}
+	if len(tripIDToSpan) == 0 {
+		return nil, nil
+	}
+	err := static.ForEachStopTime(func(trip ScheduledTrip, stopTime ScheduledStopTime) bool {
+		span, ok := tripIDToSpan[trip.ID]
+		if !ok {
+			return true
+		}
+		if span.first < 0 || stopTime.DepartureTime < span.first {
+			span.first = stopTime.DepartureTime
+		}
+		if span.last < 0 || stopTime.DepartureTime > span.last {
+			span.last = stopTime.DepartureTime
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result *ServiceSpan
+	include := func(first, last time.Duration) {
+		if result == nil {
+			result = &ServiceSpan{FirstDeparture: first, LastDeparture: last}
+			return
+		}
+		if first < result.FirstDeparture {
+			result.FirstDeparture = first
+		}
+		if last > result.LastDeparture {
+			result.LastDeparture = last
+		}
+	}
+	for _, span := range tripIDToSpan {
+		if span.first < 0 {
+			continue
+		}
+		if len(span.trip.Frequencies) == 0 {
+			include(span.first, span.last)
+			continue
+		}
+		// For frequency-based trips the stop times only define the travel times between stops;
+		// the trip starts at each headway between the start time (inclusive) and end time (exclusive).
+		for _, frequency := range span.trip.Frequencies {
+			if frequency.EndTime <= frequency.StartTime {
+				continue
+			}
+			lastStart := frequency.StartTime
+			if frequency.Headway > 0 {
+				lastStart += (frequency.EndTime - frequency.StartTime - 1) / frequency.Headway * frequency.Headway
+			}
+			include(frequency.StartTime, lastStart+span.last-span.first)
+		}
+	}
+	return result, nil
+}
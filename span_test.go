@@ -0,0 +1,108 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRouteServiceSpan(t *testing.T) {
+	content := newZipBuilder().add(
+		"agency.txt",
+		"agency_name,agency_url,agency_timezone\na,b,UTC",
+	).add(
+		"routes.txt",
+		"route_id,route_type\nroute1,3\nroute2,3\nroute3,3",
+	).add(
+		"stops.txt",
+		"stop_id\nstop1\nstop2",
+	).add(
+		"calendar.txt",
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n"+
+			"weekday,1,1,1,1,1,0,0,20220502,20220508\n"+
+			"weekend,0,0,0,0,0,1,1,20220502,20220508",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id\n"+
+			"route1,weekday,early\n"+
+			"route1,weekday,late\n"+
+			"route1,weekend,weekend\n"+
+			"route2,weekday,frequency",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,arrival_time,departure_time,stop_sequence\n"+
+			"early,stop1,05:00:00,05:00:00,1\n"+
+			"early,stop2,05:10:00,05:10:00,2\n"+
+			"late,stop1,24:40:00,24:40:00,1\n"+
+			"late,stop2,25:00:00,25:00:00,2\n"+
+			"weekend,stop1,08:00:00,08:00:00,1\n"+
+			"frequency,stop1,00:00:00,00:00:00,1\n"+
+			"frequency,stop2,00:15:00,00:15:00,2",
+	).add(
+		"frequencies.txt",
+		"trip_id,start_time,end_time,headway_secs\n"+
+			"frequency,06:00:00,10:00:00,1800",
+	).build()
+	wednesday := time.Date(2022, 5, 4, 0, 0, 0, 0, time.UTC)
+	saturday := time.Date(2022, 5, 7, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		desc  string
+		route string
+		date  time.Time
+		want  *ServiceSpan
+	}{
+		{
+			desc:  "weekday",
+			route: "route1",
+			date:  wednesday,
+			want:  &ServiceSpan{FirstDeparture: 5 * time.Hour, LastDeparture: 25 * time.Hour},
+		},
+		{
+			desc:  "weekend",
+			route: "route1",
+			date:  saturday,
+			want:  &ServiceSpan{FirstDeparture: 8 * time.Hour, LastDeparture: 8 * time.Hour},
+		},
+		{
+			desc:  "frequencies",
+			route: "route2",
+			date:  wednesday,
+			want:  &ServiceSpan{FirstDeparture: 6 * time.Hour, LastDeparture: 9*time.Hour + 45*time.Minute},
+		},
+		{
+			desc:  "no service",
+			route: "route2",
+			date:  saturday,
+		},
+		{
+			desc:  "no trips",
+			route: "route3",
+			date:  wednesday,
+		},
+	} {
+		for _, streamStopTimes := range []bool{false, true} {
+			t.Run(tc.desc, func(t *testing.T) {
+				static, err := ParseStatic(content, ParseStaticOptions{StreamStopTimes: streamStopTimes})
+				if err != nil {
+					t.Fatalf("ParseStatic() err = %v", err)
+				}
+				var route *Route
+				for i := range static.Routes {
+					if static.Routes[i].Id == tc.route {
+						route = &static.Routes[i]
+					}
+				}
+
+				got, err := route.ServiceSpan(static, tc.date)
+				if err != nil {
+					t.Fatalf("ServiceSpan() err = %v", err)
+				}
+				if diff := cmp.Diff(got, tc.want); diff != "" {
+					t.Errorf("ServiceSpan() got = %v, want = %v, diff: %s", got, tc.want, diff)
+				}
+			})
+		}
+	}
+}
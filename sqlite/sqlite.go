@@ -0,0 +1,276 @@
+// Package sqlite exports GTFS static data to a normalized SQLite database.
+//
+// This module does not depend on a SQLite driver. The data can either be written to a database
+// opened by the caller with the driver of their choice, using [Export], or written as a SQL script
+// that can be loaded with the sqlite3 command line tool, using [WriteScript]:
+//
+//	sqlite3 gtfs.db < gtfs.sql
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+)
+
+var schema = []string{
+	`CREATE TABLE agencies (
+	agency_id TEXT PRIMARY KEY,
+	agency_name TEXT NOT NULL,
+	agency_url TEXT NOT NULL,
+	agency_timezone TEXT NOT NULL
+)`,
+	`CREATE TABLE routes (
+	route_id TEXT PRIMARY KEY,
+	agency_id TEXT REFERENCES agencies(agency_id),
+	route_short_name TEXT,
+	route_long_name TEXT,
+	route_type INTEGER NOT NULL,
+	route_color TEXT
+)`,
+	`CREATE TABLE stops (
+	stop_id TEXT PRIMARY KEY,
+	stop_code TEXT,
+	stop_name TEXT,
+	stop_lat REAL,
+	stop_lon REAL,
+	stop_type TEXT NOT NULL,
+	parent_station TEXT REFERENCES stops(stop_id),
+	platform_code TEXT
+)`,
+	`CREATE TABLE services (
+	service_id TEXT PRIMARY KEY,
+	monday INTEGER NOT NULL,
+	tuesday INTEGER NOT NULL,
+	wednesday INTEGER NOT NULL,
+	thursday INTEGER NOT NULL,
+	friday INTEGER NOT NULL,
+	saturday INTEGER NOT NULL,
+	sunday INTEGER NOT NULL,
+	start_date TEXT NOT NULL,
+	end_date TEXT NOT NULL
+)`,
+	`CREATE TABLE service_exceptions (
+	service_id TEXT NOT NULL REFERENCES services(service_id),
+	date TEXT NOT NULL,
+	added INTEGER NOT NULL,
+	PRIMARY KEY (service_id, date)
+)`,
+	`CREATE TABLE trips (
+	trip_id TEXT PRIMARY KEY,
+	route_id TEXT NOT NULL REFERENCES routes(route_id),
+	service_id TEXT NOT NULL REFERENCES services(service_id),
+	trip_headsign TEXT,
+	direction_id INTEGER
+)`,
+	`CREATE TABLE stop_times (
+	trip_id TEXT NOT NULL REFERENCES trips(trip_id),
+	stop_sequence INTEGER NOT NULL,
+	stop_id TEXT NOT NULL REFERENCES stops(stop_id),
+	arrival_time INTEGER NOT NULL,
+	departure_time INTEGER NOT NULL,
+	PRIMARY KEY (trip_id, stop_sequence)
+)`,
+	`CREATE INDEX routes_agency_id ON routes (agency_id)`,
+	`CREATE INDEX stops_parent_station ON stops (parent_station)`,
+	`CREATE INDEX trips_route_id ON trips (route_id)`,
+	`CREATE INDEX trips_service_id ON trips (service_id)`,
+	`CREATE INDEX stop_times_stop_id ON stop_times (stop_id)`,
+}
+
+// Export writes the static data to the database, creating the tables first.
+//
+// The database must be empty and opened with a SQLite driver. All data is written in a
+// single transaction. Arrival and departure times in the stop_times table are stored as the
+// number of seconds since the start of the service day, and dates are stored as YYYY-MM-DD.
+func Export(static *gtfs.Static, db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	err = forEachStatement(static, func(query string, args []any) error {
+		if _, err := tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("failed to execute %q: %w", query, err)
+		}
+		return nil
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// WriteScript writes a SQL script that creates the same database as [Export].
+func WriteScript(static *gtfs.Static, w io.Writer) error {
+	if _, err := io.WriteString(w, "BEGIN TRANSACTION;\n"); err != nil {
+		return err
+	}
+	err := forEachStatement(static, func(query string, args []any) error {
+		parts := strings.Split(query, "?")
+		var b strings.Builder
+		for i, part := range parts {
+			b.WriteString(part)
+			if i < len(args) {
+				b.WriteString(literal(args[i]))
+			}
+		}
+		b.WriteString(";\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "COMMIT;\n")
+	return err
+}
+
+func forEachStatement(static *gtfs.Static, f func(query string, args []any) error) error {
+	for _, query := range schema {
+		if err := f(query, nil); err != nil {
+			return err
+		}
+	}
+	for _, agency := range static.Agencies {
+		if err := f(
+			"INSERT INTO agencies VALUES (?, ?, ?, ?)",
+			[]any{agency.Id, agency.Name, agency.Url, agency.Timezone},
+		); err != nil {
+			return err
+		}
+	}
+	for _, route := range static.Routes {
+		var agencyID any
+		if route.Agency != nil {
+			agencyID = route.Agency.Id
+		}
+		if err := f(
+			"INSERT INTO routes VALUES (?, ?, ?, ?, ?, ?)",
+			[]any{route.Id, agencyID, route.ShortName, route.LongName, int64(route.Type), route.Color},
+		); err != nil {
+			return err
+		}
+	}
+	for _, stop := range static.Stops {
+		var parentID any
+		if stop.Parent != nil {
+			parentID = stop.Parent.Id
+		}
+		if err := f(
+			"INSERT INTO stops VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			[]any{stop.Id, stop.Code, stop.Name, floatPtr(stop.Latitude), floatPtr(stop.Longitude), stop.Type.String(), parentID, stop.PlatformCode},
+		); err != nil {
+			return err
+		}
+	}
+	for _, service := range static.Services {
+		if err := f(
+			"INSERT INTO services VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			[]any{
+				service.Id,
+				service.Monday, service.Tuesday, service.Wednesday, service.Thursday,
+				service.Friday, service.Saturday, service.Sunday,
+				formatDate(service.StartDate), formatDate(service.EndDate),
+			},
+		); err != nil {
+			return err
+		}
+		for _, dates := range []struct {
+			dates []time.Time
+			added bool
+		}{
+			{service.AddedDates, true},
+			{service.RemovedDates, false},
+		} {
+			for _, date := range dates.dates {
+				if err := f(
+					"INSERT INTO service_exceptions VALUES (?, ?, ?)",
+					[]any{service.Id, formatDate(date), dates.added},
+				); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, trip := range static.Trips {
+		var routeID, serviceID any
+		if trip.Route != nil {
+			routeID = trip.Route.Id
+		}
+		if trip.Service != nil {
+			serviceID = trip.Service.Id
+		}
+		var directionID any
+		switch trip.DirectionId {
+		case gtfs.DirectionID_False:
+			directionID = int64(0)
+		case gtfs.DirectionID_True:
+			directionID = int64(1)
+		}
+		if err := f(
+			"INSERT INTO trips VALUES (?, ?, ?, ?, ?)",
+			[]any{trip.ID, routeID, serviceID, trip.Headsign, directionID},
+		); err != nil {
+			return err
+		}
+	}
+	// Stop times are read using ForEachStopTime so that streamed stop times are also exported.
+	var insertErr error
+	err := static.ForEachStopTime(func(trip gtfs.ScheduledTrip, stopTime gtfs.ScheduledStopTime) bool {
+		var stopID any
+		if stopTime.Stop != nil {
+			stopID = stopTime.Stop.Id
+		}
+		insertErr = f(
+			"INSERT INTO stop_times VALUES (?, ?, ?, ?, ?)",
+			[]any{trip.ID, int64(stopTime.StopSequence), stopID, int64(stopTime.ArrivalTime / time.Second), int64(stopTime.DepartureTime / time.Second)},
+		)
+		return insertErr == nil
+	})
+	if err != nil {
+		return err
+	}
+	return insertErr
+}
+
+func floatPtr(f *float64) any {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
+
+func formatDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// literal formats the argument as a SQLite literal.
+func literal(arg any) string {
+	switch v := arg.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "NULL"
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		panic(fmt.Sprintf("unsupported SQL argument type %T", arg))
+	}
+}
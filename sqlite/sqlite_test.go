@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+)
+
+func TestWriteScript(t *testing.T) {
+	agency := gtfs.Agency{Id: "agency", Name: "O'Brien Transit", Url: "https://example.com", Timezone: "America/New_York"}
+	route := gtfs.Route{Id: "A", Agency: &agency, ShortName: "A?", Type: gtfs.RouteType_Subway}
+	station := gtfs.Stop{Id: "station", Name: "Station", Type: gtfs.StopType_Station}
+	platform := gtfs.Stop{Id: "platform", Parent: &station, Latitude: ptr(40.5), Longitude: ptr(-73.25), Type: gtfs.StopType_Platform}
+	service := gtfs.Service{
+		Id:           "weekday",
+		Monday:       true,
+		StartDate:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:      time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+		RemovedDates: []time.Time{time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+	}
+	static := &gtfs.Static{
+		Agencies: []gtfs.Agency{agency},
+		Routes:   []gtfs.Route{route},
+		Stops:    []gtfs.Stop{station, platform},
+		Services: []gtfs.Service{service},
+		Trips: []gtfs.ScheduledTrip{
+			{
+				ID:          "trip",
+				Route:       &route,
+				Service:     &service,
+				DirectionId: gtfs.DirectionID_False,
+				StopTimes: []gtfs.ScheduledStopTime{
+					{Stop: &platform, StopSequence: 1, ArrivalTime: 25 * time.Hour, DepartureTime: 25*time.Hour + time.Minute},
+				},
+			},
+		},
+	}
+
+	var b bytes.Buffer
+	if err := WriteScript(static, &b); err != nil {
+		t.Fatalf("WriteScript() failed: %s", err)
+	}
+	got := b.String()
+
+	for _, want := range []string{
+		"BEGIN TRANSACTION;\n",
+		"CREATE INDEX stop_times_stop_id ON stop_times (stop_id);\n",
+		"INSERT INTO agencies VALUES ('agency', 'O''Brien Transit', 'https://example.com', 'America/New_York');\n",
+		"INSERT INTO routes VALUES ('A', 'agency', 'A?', '', 1, '');\n",
+		"INSERT INTO stops VALUES ('station', '', 'Station', NULL, NULL, 'STATION', NULL, '');\n",
+		"INSERT INTO stops VALUES ('platform', '', '', 40.5, -73.25, 'PLATFORM', 'station', '');\n",
+		"INSERT INTO services VALUES ('weekday', 1, 0, 0, 0, 0, 0, 0, '2024-01-01', '2024-01-31');\n",
+		"INSERT INTO service_exceptions VALUES ('weekday', '2024-01-15', 0);\n",
+		"INSERT INTO trips VALUES ('trip', 'A', 'weekday', '', 0);\n",
+		"INSERT INTO stop_times VALUES ('trip', 1, 'platform', 90000, 90060);\n",
+		"COMMIT;\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("script does not contain %q; script:\n%s", want, got)
+		}
+	}
+}
+
+func ptr[T any](t T) *T {
+	return &t
+}
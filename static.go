@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 	"unicode"
 
@@ -18,17 +19,118 @@ import (
 )
 
 // Static contains the parsed content for a single GTFS static message.
+//
+// A message returned by [ParseStatic] is owned by the caller and may be modified. Lookup methods
+// like [Static.StopByID] build indices on first use, so if the message is modified after they have
+// been called [Static.InvalidateIndex] must be called.
+//
+// A message that is shared, for example between goroutines, must not be modified: entities reference
+// each other using pointers, so a change made by one owner is seen by all of them. To modify a shared
+// message, first take a private copy using [Static.DeepClone].
 type Static struct {
-	Agencies  []Agency
-	Routes    []Route
+	Agencies []Agency
+	Routes   []Route
+	// Stops are referenced by pointer from other entities, like [Stop.Parent], [Transfer.From] and
+	// [ScheduledStopTime.Stop]. Appending to this field directly can move the stops to a new backing
+	// array and leave these pointers referencing stale copies, so stops must be added using
+	// [Static.AppendStops].
 	Stops     []Stop
 	Transfers []Transfer
+	Pathways  []Pathway
 	Services  []Service
 	Trips     []ScheduledTrip
 	Shapes    []Shape
+	FareRules []FareRule
+
+	// Fares V2 entities, from the areas.txt, stop_areas.txt, networks.txt, route_networks.txt,
+	// fare_media.txt, fare_products.txt, fare_leg_rules.txt and fare_transfer_rules.txt files.
+	Areas             []Area
+	Networks          []Network
+	FareMedia         []FareMedium
+	FareProducts      []FareProduct
+	FareLegRules      []FareLegRule
+	FareTransferRules []FareTransferRule
+
+	// GTFS-Flex entities, from the location_groups.txt, location_group_stops.txt, locations.geojson
+	// and booking_rules.txt files. The locations.geojson file is only read if [ParseStaticOptions.Flex]
+	// is true.
+	LocationGroups []LocationGroup
+	FlexLocations  []FlexLocation
+	BookingRules   []BookingRule
+
+	// Information about the feed itself, from feed_info.txt.
+	//
+	// This is nil if the feed does not contain a valid feed_info.txt file.
+	FeedInfo *FeedInfo
+
+	// The timezone used to interpret the dates in the feed: [ParseStaticOptions.Timezone] if set,
+	// and otherwise the timezone of the first agency in agency.txt, or UTC if it cannot be loaded.
+	//
+	// This is nil if the message was not returned by [ParseStatic].
+	Timezone *time.Location
 
 	// Warnings raised during GTFS static parsing.
 	Warnings []warnings.StaticWarning
+
+	// Number of warnings raised during parsing that are not in the Warnings field because of
+	// [ParseStaticOptions.DeduplicateWarnings] or [ParseStaticOptions.MaxWarnings].
+	SuppressedWarnings int
+
+	// Number of warnings of each kind raised during parsing, keyed by [warnings.StaticWarningKind.Code].
+	// Unlike the Warnings field, this includes suppressed warnings.
+	//
+	// This is only populated if [ParseStaticOptions.DeduplicateWarnings] is true or
+	// [ParseStaticOptions.MaxWarnings] is positive.
+	WarningCounts map[string]int
+
+	// Source of the stop times if they are being streamed rather than materialized.
+	stopTimesSource *stopTimesSource
+
+	// Lookup indices, stored as a *staticIndex. They are held behind a pointer so that the message
+	// can be copied; a copy shares the indices of the original until [Static.InvalidateIndex] is called.
+	idx atomic.Value
+}
+
+type stopTimesSource struct {
+	zipFile *zip.File
+	opts    stopTimesOptions
+}
+
+type stopTimesOptions struct {
+	recordSourceRows bool
+	preserveRawTimes bool
+	flex             bool
+}
+
+// ForEachStopTime invokes f for each scheduled stop time in the feed, along with the trip it belongs to.
+// Iteration stops as soon as f returns false.
+//
+// If the feed was parsed with [ParseStaticOptions.StreamStopTimes] set to true, the stop_times.txt
+// file is re-read on each invocation and stop times are visited in the order they appear in the file.
+// Otherwise stop times are visited trip by trip, in the order of the trips in the Trips field.
+// In both cases the order is the same across invocations.
+func (s *Static) ForEachStopTime(f func(ScheduledTrip, ScheduledStopTime) bool) error {
+	if s.stopTimesSource == nil {
+		for i := range s.Trips {
+			for j := range s.Trips[i].StopTimes {
+				if !f(s.Trips[i], s.Trips[i].StopTimes[j]) {
+					return nil
+				}
+			}
+		}
+		return nil
+	}
+	file, err := openCsvFile(constants.StopTimesFile, s.stopTimesSource.zipFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %q: %w", constants.StopTimesFile, err)
+	}
+	readScheduledStopTimes(file, nil, s, s.stopTimesSource.opts, func(trip *ScheduledTrip, stopTime ScheduledStopTime) bool {
+		return f(*trip, stopTime)
+	})
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to read %q: %w", constants.StopTimesFile, err)
+	}
+	return nil
 }
 
 // Agency corresponds to a single row in the agency.txt file.
@@ -41,6 +143,33 @@ type Agency struct {
 	Phone    string
 	FareUrl  string
 	Email    string
+
+	// Row number of the agency in agency.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
+}
+
+// FeedInfo corresponds to the single row in the feed_info.txt file.
+type FeedInfo struct {
+	PublisherName string
+	PublisherUrl  string
+	Language      string
+	// Language used when the data consumer doesn't know the language of the rider.
+	DefaultLanguage string
+	ContactEmail    string
+	ContactUrl      string
+	// Version of the feed, which can be used to detect that the feed has been updated.
+	Version string
+	// First and last days of service covered by the feed, in the timezone of the feed. Each is the
+	// zero time if it is not specified.
+	StartDate time.Time
+	EndDate   time.Time
+
+	// Row number of the feed info in feed_info.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
 }
 
 // Route corresponds to a single row in the routes.txt file.
@@ -57,6 +186,21 @@ type Route struct {
 	SortOrder         *int32
 	ContinuousPickup  PickupDropOffPolicy
 	ContinuousDropOff PickupDropOffPolicy
+	// Network the route belongs to, or nil if the route is not in a network. The network is taken
+	// from route_networks.txt or, for older feeds, from the network_id column of routes.txt.
+	Network *Network
+
+	// Row number of the route in routes.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
+}
+
+func (route *Route) GetAgency() Agency {
+	if route != nil && route.Agency != nil {
+		return *route.Agency
+	}
+	return Agency{}
 }
 
 type Stop struct {
@@ -73,6 +217,11 @@ type Stop struct {
 	Timezone           string
 	WheelchairBoarding WheelchairBoarding
 	PlatformCode       string
+
+	// Row number of the stop in stops.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
 }
 
 // Root returns the root stop.
@@ -85,11 +234,123 @@ func (stop *Stop) Root() *Stop {
 	}
 }
 
+// AppendStops appends the stops to the message and returns pointers to the appended stops.
+//
+// Appending to the Stops field directly can move the existing stops to a new backing array, in
+// which case pointers to them, like [Stop.Parent], [Transfer.From] and [ScheduledStopTime.Stop], still
+// reference the stale copies in the old array. AppendStops re-links these pointers to the moved stops
+// and resets the lookup indices so that they include the appended stops. The parents of the appended
+// stops may be stops that are already in the message or other stops in the provided slice.
+func (s *Static) AppendStops(stops ...Stop) []*Stop {
+	n := len(s.Stops)
+	old := s.Stops
+	s.Stops = append(s.Stops, stops...)
+	moved := map[*Stop]*Stop{}
+	if n > 0 && &old[0] != &s.Stops[0] {
+		for i := range old {
+			moved[&old[i]] = &s.Stops[i]
+		}
+	}
+	for i := range stops {
+		moved[&stops[i]] = &s.Stops[n+i]
+	}
+	if len(moved) > 0 {
+		relink := func(stop **Stop) {
+			if newStop, ok := moved[*stop]; ok {
+				*stop = newStop
+			}
+		}
+		for i := range s.Stops {
+			relink(&s.Stops[i].Parent)
+		}
+		for i := range s.Transfers {
+			relink(&s.Transfers[i].From)
+			relink(&s.Transfers[i].To)
+		}
+		for i := range s.Pathways {
+			relink(&s.Pathways[i].From)
+			relink(&s.Pathways[i].To)
+		}
+		for i := range s.Areas {
+			for j := range s.Areas[i].Stops {
+				relink(&s.Areas[i].Stops[j])
+			}
+		}
+		for i := range s.LocationGroups {
+			for j := range s.LocationGroups[i].Stops {
+				relink(&s.LocationGroups[i].Stops[j])
+			}
+		}
+		for i := range s.Trips {
+			for j := range s.Trips[i].StopTimes {
+				relink(&s.Trips[i].StopTimes[j].Stop)
+			}
+		}
+	}
+	s.InvalidateIndex()
+	result := make([]*Stop, len(stops))
+	for i := range stops {
+		result[i] = &s.Stops[n+i]
+	}
+	return result
+}
+
 type Transfer struct {
 	From            *Stop
 	To              *Stop
 	Type            TransferType
 	MinTransferTime *int32
+
+	// Row number of the transfer in transfers.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
+}
+
+// Pathway corresponds to a single row in the pathways.txt file.
+//
+// A pathway links two locations of a station, like an entrance and a platform.
+type Pathway struct {
+	ID   string
+	From *Stop
+	To   *Stop
+	Mode PathwayMode
+	// If false, the pathway can only be used from the From stop to the To stop.
+	IsBidirectional bool
+	// Horizontal length of the pathway in meters.
+	Length *float64
+	// Average time in seconds needed to walk through the pathway.
+	TraversalTime *int32
+	// Number of stairs of the pathway. Positive if the stairs go up from the From stop to the To stop.
+	StairCount *int32
+	// Slope ratio of the pathway. Positive if the pathway goes up from the From stop to the To stop.
+	MaxSlope *float64
+	// Minimum width of the pathway in meters.
+	MinWidth             *float64
+	SignpostedAs         string
+	ReversedSignpostedAs string
+
+	// Row number of the pathway in pathways.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
+}
+
+// FareRule corresponds to a single row in the fare_rules.txt file.
+type FareRule struct {
+	FareID string
+	// Route the fare applies to, or nil if the fare is not restricted to a route.
+	Route *Route
+	// Fare zones, as in [Stop.ZoneId], of the origin and destination of the journey and of a zone the
+	// journey passes through. Each is empty if the fare is not restricted by it.
+	OriginID      string
+	DestinationID string
+	ContainsID    string
+
+	// Row number of the fare rule in fare_rules.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
 }
 
 type Service struct {
@@ -105,6 +366,65 @@ type Service struct {
 	EndDate      time.Time
 	AddedDates   []time.Time
 	RemovedDates []time.Time
+
+	// Row number of the service in calendar.txt or, if the service is only in calendar_dates.txt,
+	// of its first row in calendar_dates.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
+}
+
+// RunsOn returns true if the service runs on the provided date.
+//
+// Only the calendar date (year, month and day) of each time is considered, and each time is read
+// in its own location; no time is converted to another location. The date is in the service's
+// added or removed dates if one of them has the same calendar date, and is in the service's range
+// if its calendar date is between those of StartDate and EndDate inclusive. The day of the week is
+// that of the provided date in its location.
+//
+// The dates parsed by [ParseStatic] are midnight in the agency's timezone, so the provided date
+// should be in that timezone too: the same instant can fall on a different calendar date elsewhere.
+func (service *Service) RunsOn(date time.Time) bool {
+	sameDay := func(t time.Time) bool {
+		y1, m1, d1 := t.Date()
+		y2, m2, d2 := date.Date()
+		return y1 == y2 && m1 == m2 && d1 == d2
+	}
+	for _, removedDate := range service.RemovedDates {
+		if sameDay(removedDate) {
+			return false
+		}
+	}
+	for _, addedDate := range service.AddedDates {
+		if sameDay(addedDate) {
+			return true
+		}
+	}
+	y, m, d := date.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	toDay := func(t time.Time) time.Time {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	if day.Before(toDay(service.StartDate)) || day.After(toDay(service.EndDate)) {
+		return false
+	}
+	switch date.Weekday() {
+	case time.Monday:
+		return service.Monday
+	case time.Tuesday:
+		return service.Tuesday
+	case time.Wednesday:
+		return service.Wednesday
+	case time.Thursday:
+		return service.Thursday
+	case time.Friday:
+		return service.Friday
+	case time.Saturday:
+		return service.Saturday
+	default:
+		return service.Sunday
+	}
 }
 
 type ScheduledTrip struct {
@@ -120,9 +440,36 @@ type ScheduledTrip struct {
 	StopTimes            []ScheduledStopTime
 	Shape                *Shape
 	Frequencies          []Frequency
+
+	// Row number of the trip in trips.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
+}
+
+func (trip *ScheduledTrip) GetRoute() Route {
+	if trip != nil && trip.Route != nil {
+		return *trip.Route
+	}
+	return Route{}
+}
+
+func (trip *ScheduledTrip) GetService() Service {
+	if trip != nil && trip.Service != nil {
+		return *trip.Service
+	}
+	return Service{}
+}
+
+func (trip *ScheduledTrip) GetShape() Shape {
+	if trip != nil && trip.Shape != nil {
+		return *trip.Shape
+	}
+	return Shape{}
 }
 
 type ScheduledStopTime struct {
+	// Trip the stop time belongs to.
 	Trip                  *ScheduledTrip
 	Stop                  *Stop
 	ArrivalTime           time.Duration
@@ -135,6 +482,38 @@ type ScheduledStopTime struct {
 	ContinuousDropOff     PickupDropOffPolicy
 	ShapeDistanceTraveled *float64
 	ExactTimes            bool
+
+	// The arrival and departure times exactly as they appear in stop_times.txt, or empty if the
+	// column is not set for this stop time. Unlike ArrivalTime and DepartureTime, these are not
+	// filled in from each other.
+	//
+	// These are only populated if [ParseStaticOptions.PreserveRawStopTimes] is true.
+	RawArrival   string
+	RawDeparture string
+
+	// Time window during which an on-demand vehicle can pick up or drop off riders, from the GTFS-Flex
+	// start_pickup_drop_off_window and end_pickup_drop_off_window columns. Stop times with a window
+	// usually do not have arrival and departure times.
+	//
+	// These are only populated if HasPickupDropOffWindow is true.
+	HasPickupDropOffWindow   bool
+	StartPickupDropOffWindow time.Duration
+	EndPickupDropOffWindow   time.Duration
+
+	// GTFS-Flex location group or zone the vehicle serves, if the stop time references one instead
+	// of a stop.
+	//
+	// These are only populated if [ParseStaticOptions.Flex] is true.
+	LocationGroup *LocationGroup
+	FlexLocation  *FlexLocation
+	// Booking rules for picking up and dropping off riders, or nil if no booking is needed.
+	PickupBookingRule  *BookingRule
+	DropOffBookingRule *BookingRule
+
+	// Row number of the stop time in stop_times.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
 }
 
 type ShapePoint struct {
@@ -146,6 +525,15 @@ type ShapePoint struct {
 type Shape struct {
 	ID     string
 	Points []ShapePoint
+
+	// If true, the shape is not in shapes.txt and was instead inferred from the stops of a trip.
+	// See [ParseStaticOptions.InferShapes].
+	Synthetic bool
+
+	// Row number of the first row of the shape in shapes.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
 }
 
 type Frequency struct {
@@ -153,12 +541,62 @@ type Frequency struct {
 	EndTime    time.Duration
 	Headway    time.Duration
 	ExactTimes ExactTimes
+
+	// Row number of the frequency in frequencies.txt.
+	//
+	// This is only populated if [ParseStaticOptions.RecordSourceRows] is true.
+	SourceRow int
 }
 
 type ParseStaticOptions struct {
 	// If true, wheelchair boarding information is inherited from parent station
 	// when unspecified for a child stop/platform, entrance, or exit.
 	InheritWheelchairBoarding bool
+
+	// If true, the row number of each parsed entity in its source CSV file is recorded
+	// in the entity's SourceRow field. Row numbers are counted in the same way as
+	// in [warnings.StaticWarning].
+	RecordSourceRows bool
+
+	// If true, the stop times in stop_times.txt are not stored in the StopTimes field of each
+	// scheduled trip. Instead they can be streamed using [Static.ForEachStopTime].
+	// This substantially reduces memory usage for large feeds when only aggregate
+	// information about stop times is needed.
+	StreamStopTimes bool
+
+	// If true, the arrival and departure times in stop_times.txt are additionally stored as the
+	// original strings in the RawArrival and RawDeparture fields of each scheduled stop time.
+	// This is useful for tools that write the times back out unchanged.
+	PreserveRawStopTimes bool
+
+	// The timezone used to interpret dates in the feed.
+	//
+	// If nil, the timezone of the first agency in agency.txt is used. Setting this avoids
+	// depending on the host's tzdata to resolve the agency timezone, and is useful for feeds whose
+	// agency_timezone values are blank or wrong. The timezone used is recorded in [Static.Timezone].
+	Timezone *time.Location
+
+	// If true, only the first warning of each kind in each file is kept in [Static.Warnings].
+	// Huge broken feeds can otherwise generate millions of identical warnings.
+	DeduplicateWarnings bool
+
+	// If positive, at most this many warnings are kept in [Static.Warnings].
+	MaxWarnings int
+
+	// If true, stop times in stop_times.txt that reference a GTFS-Flex location group or zone in
+	// the location_group_id or location_id column instead of a stop are kept, with a nil Stop field
+	// and the LocationGroup or FlexLocation field set. Otherwise they are skipped, as most code that
+	// reads stop times expects each to have a stop.
+	//
+	// The locations.geojson file is also only read if this is true. If the file is not valid GeoJSON,
+	// a warning is added and no flex locations are parsed.
+	Flex bool
+
+	// If true, a straight-line shape through the stops of each trip is synthesized for trips that
+	// do not reference a shape in shapes.txt, so that every trip has geometry. Trips that call at
+	// the same stops in the same order share a shape. Synthesized shapes are appended to the
+	// Shapes field and have the Synthetic field set.
+	InferShapes bool
 }
 
 // ParseStatic parses the content as a GTFS static feed.
@@ -172,65 +610,154 @@ func ParseStatic(content []byte, opts ParseStaticOptions) (*Static, error) {
 	for _, file := range reader.File {
 		fileNameToFile[constants.StaticFile(file.Name)] = file
 	}
+	warningCollector := newWarningCollector(result, opts)
 	serviceIdToService := map[string]Service{}
 	shapeIdToShape := map[string]*Shape{}
 	tripIdToScheduledTrip := map[string]*ScheduledTrip{}
 	timezone := time.UTC
+	// Values of the legacy network_id column of routes.txt, indexed like result.Routes.
+	var routeNetworkIDs []string
+	// The locations.geojson file is not a CSV file so it is parsed separately.
+	if zipFile := fileNameToFile[constants.LocationsFile]; zipFile != nil && opts.Flex {
+		var err error
+		if result.FlexLocations, err = parseFlexLocations(zipFile); err != nil {
+			warningCollector.add(warnings.StaticWarning{
+				Kind: warnings.InvalidGeoJSON{Err: err.Error()},
+				File: constants.LocationsFile,
+			})
+		}
+	}
 	for _, table := range []struct {
 		File        constants.StaticFile
-		Action      func(file *csv.File) []warnings.StaticWarning
+		Action      func(file *csv.File)
 		PostProcess func()
 		Optional    bool
 	}{
 		{
 			File: constants.AgencyFile,
-			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				result.Agencies, w = parseAgencies(file)
-				if len(result.Agencies) > 0 {
+			Action: func(file *csv.File) {
+				result.Agencies = parseAgencies(file, warningCollector, opts.RecordSourceRows)
+				if opts.Timezone != nil {
+					timezone = opts.Timezone
+				} else if len(result.Agencies) > 0 {
 					var err error
-					timezone, err = time.LoadLocation(result.Agencies[0].Timezone)
+					timezone, err = loadLocation(result.Agencies[0].Timezone)
 					if err != nil {
 						timezone = time.UTC
 					}
 				}
-				return
 			},
 		},
 		{
-			File: "routes.txt",
-			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				result.Routes = parseRoutes(file, result.Agencies)
-				return
+			File: constants.FeedInfoFile,
+			Action: func(file *csv.File) {
+				result.FeedInfo = parseFeedInfo(file, timezone, opts.RecordSourceRows)
 			},
+			Optional: true,
+		},
+		{
+			File: constants.RoutesFile,
+			Action: func(file *csv.File) {
+				result.Routes, routeNetworkIDs = parseRoutes(file, result.Agencies, opts.RecordSourceRows)
+			},
+		},
+		{
+			File: constants.StopsFile,
+			Action: func(file *csv.File) {
+				result.AppendStops(parseStops(file, opts.InheritWheelchairBoarding, opts.RecordSourceRows)...)
+			},
+		},
+		{
+			File: constants.TransfersFile,
+			Action: func(file *csv.File) {
+				result.Transfers = parseTransfers(file, warningCollector, result.Stops, opts.RecordSourceRows)
+			},
+			Optional: true,
+		},
+		{
+			File: constants.PathwaysFile,
+			Action: func(file *csv.File) {
+				result.Pathways = parsePathways(file, warningCollector, result.Stops, opts.RecordSourceRows)
+			},
+			Optional: true,
 		},
 		{
-			File: "stops.txt",
-			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				result.Stops = parseStops(file, opts.InheritWheelchairBoarding)
-				return
+			File: constants.FareRulesFile,
+			Action: func(file *csv.File) {
+				result.FareRules = parseFareRules(file, warningCollector, result.Routes, result.Stops, opts.RecordSourceRows)
 			},
+			Optional: true,
+		},
+		{
+			File: constants.AreasFile,
+			Action: func(file *csv.File) {
+				result.Areas = parseAreas(file, warningCollector, opts.RecordSourceRows)
+			},
+			Optional: true,
 		},
 		{
-			File: "transfers.txt",
-			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				result.Transfers = parseTransfers(file, result.Stops)
-				return
+			File: constants.StopAreasFile,
+			Action: func(file *csv.File) {
+				parseStopAreas(file, warningCollector, result.Areas, result.Stops)
 			},
 			Optional: true,
 		},
 		{
-			File: "calendar.txt",
-			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				parseCalendar(file, serviceIdToService, timezone)
-				return
+			File: constants.NetworksFile,
+			Action: func(file *csv.File) {
+				result.Networks = parseNetworks(file, warningCollector, opts.RecordSourceRows)
 			},
 			Optional: true,
 		},
 		{
-			File: "calendar_dates.txt",
-			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				parseCalendarDates(file, serviceIdToService, timezone)
-				return
+			File: constants.RouteNetworksFile,
+			Action: func(file *csv.File) {
+				parseRouteNetworks(file, warningCollector, result.Networks, result.Routes)
+			},
+			PostProcess: func() {
+				result.Networks = linkRouteNetworks(result.Networks, result.Routes, routeNetworkIDs)
+			},
+			Optional: true,
+		},
+		{
+			File: constants.FareMediaFile,
+			Action: func(file *csv.File) {
+				result.FareMedia = parseFareMedia(file, warningCollector, opts.RecordSourceRows)
+			},
+			Optional: true,
+		},
+		{
+			File: constants.FareProductsFile,
+			Action: func(file *csv.File) {
+				result.FareProducts = parseFareProducts(file, warningCollector, result.FareMedia, opts.RecordSourceRows)
+			},
+			Optional: true,
+		},
+		{
+			File: constants.FareLegRulesFile,
+			Action: func(file *csv.File) {
+				result.FareLegRules = parseFareLegRules(file, warningCollector, result.Networks, result.Areas, result.FareProducts, opts.RecordSourceRows)
+			},
+			Optional: true,
+		},
+		{
+			File: constants.FareTransferRulesFile,
+			Action: func(file *csv.File) {
+				result.FareTransferRules = parseFareTransferRules(file, warningCollector, result.FareLegRules, result.FareProducts, opts.RecordSourceRows)
+			},
+			Optional: true,
+		},
+		{
+			File: constants.CalendarFile,
+			Action: func(file *csv.File) {
+				parseCalendar(file, serviceIdToService, timezone, opts.RecordSourceRows)
+			},
+			Optional: true,
+		},
+		{
+			File: constants.CalendarDatesFile,
+			Action: func(file *csv.File) {
+				parseCalendarDates(file, serviceIdToService, timezone, opts.RecordSourceRows)
 			},
 			PostProcess: func() {
 				for _, service := range serviceIdToService {
@@ -240,39 +767,71 @@ func ParseStatic(content []byte, opts ParseStaticOptions) (*Static, error) {
 			Optional: true,
 		},
 		{
-			File: "shapes.txt",
-			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				result.Shapes = parseShapes(file)
+			File: constants.LocationGroupsFile,
+			Action: func(file *csv.File) {
+				result.LocationGroups = parseLocationGroups(file, warningCollector, opts.RecordSourceRows)
+			},
+			Optional: true,
+		},
+		{
+			File: constants.LocationGroupStopsFile,
+			Action: func(file *csv.File) {
+				parseLocationGroupStops(file, warningCollector, result.LocationGroups, result.Stops)
+			},
+			Optional: true,
+		},
+		{
+			File: constants.BookingRulesFile,
+			Action: func(file *csv.File) {
+				result.BookingRules = parseBookingRules(file, warningCollector, result.Services, opts.RecordSourceRows)
+			},
+			Optional: true,
+		},
+		{
+			File: constants.ShapesFile,
+			Action: func(file *csv.File) {
+				result.Shapes = parseShapes(file, opts.RecordSourceRows)
 				for idx, shape := range result.Shapes {
 					shapeIdToShape[shape.ID] = &result.Shapes[idx]
 				}
-				return
 			},
 			Optional: true,
 		},
 		{
-			File: "trips.txt",
-			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				result.Trips = parseScheduledTrips(file, result.Routes, result.Services, shapeIdToShape)
+			File: constants.TripsFile,
+			Action: func(file *csv.File) {
+				result.Trips = parseScheduledTrips(file, warningCollector, result.Routes, result.Services, shapeIdToShape, opts.RecordSourceRows)
+				if opts.Timezone == nil && agenciesHaveDifferentTimezones(result.Agencies) {
+					localizeServices(result.Trips, timezone)
+				}
 				for idx, trip := range result.Trips {
 					tripIdToScheduledTrip[trip.ID] = &result.Trips[idx]
 				}
-				return
 			},
 		},
 		{
-			File: "frequencies.txt",
-			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				parseFrequencies(file, tripIdToScheduledTrip)
-				return
+			File: constants.FrequenciesFile,
+			Action: func(file *csv.File) {
+				parseFrequencies(file, tripIdToScheduledTrip, opts.RecordSourceRows)
 			},
 			Optional: true,
 		},
 		{
-			File: "stop_times.txt",
-			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				parseScheduledStopTimes(file, result.Stops, result.Trips)
-				return
+			File: constants.StopTimesFile,
+			Action: func(file *csv.File) {
+				stopTimesOpts := stopTimesOptions{
+					recordSourceRows: opts.RecordSourceRows,
+					preserveRawTimes: opts.PreserveRawStopTimes,
+					flex:             opts.Flex,
+				}
+				if opts.StreamStopTimes {
+					result.stopTimesSource = &stopTimesSource{
+						zipFile: fileNameToFile[constants.StopTimesFile],
+						opts:    stopTimesOpts,
+					}
+					return
+				}
+				parseScheduledStopTimes(file, warningCollector, result, stopTimesOpts)
 			},
 		},
 	} {
@@ -291,16 +850,70 @@ func ParseStatic(content []byte, opts ParseStaticOptions) (*Static, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to read %q: %w", table.File, err)
 		}
-		w := table.Action(file)
+		table.Action(file)
 		table.PostProcess()
-		result.Warnings = append(result.Warnings, w...)
 		if err := file.Close(); err != nil {
 			return nil, fmt.Errorf("failed to read %q: %w", table.File, err)
 		}
 	}
+	result.Timezone = timezone
+	if opts.InferShapes {
+		if err := inferShapes(result); err != nil {
+			return nil, err
+		}
+	}
 	return result, nil
 }
 
+// warningCollector adds warnings to a static message, applying the deduplication and limit options.
+//
+// The collector is passed to the parsers so that warnings beyond the limit are counted but not stored.
+// A nil collector discards all warnings.
+type warningCollector struct {
+	result      *Static
+	deduplicate bool
+	max         int
+	seen        map[warningCollectorKey]bool
+}
+
+type warningCollectorKey struct {
+	file constants.StaticFile
+	code string
+}
+
+func newWarningCollector(result *Static, opts ParseStaticOptions) *warningCollector {
+	c := &warningCollector{
+		result:      result,
+		deduplicate: opts.DeduplicateWarnings,
+		max:         opts.MaxWarnings,
+	}
+	if c.deduplicate || c.max > 0 {
+		c.seen = map[warningCollectorKey]bool{}
+		result.WarningCounts = map[string]int{}
+	}
+	return c
+}
+
+func (c *warningCollector) add(ws ...warnings.StaticWarning) {
+	if c == nil {
+		return
+	}
+	if c.seen == nil {
+		c.result.Warnings = append(c.result.Warnings, ws...)
+		return
+	}
+	for _, w := range ws {
+		key := warningCollectorKey{file: w.File, code: w.Kind.Code()}
+		c.result.WarningCounts[key.code]++
+		if (c.deduplicate && c.seen[key]) || (c.max > 0 && len(c.result.Warnings) >= c.max) {
+			c.result.SuppressedWarnings++
+			continue
+		}
+		c.seen[key] = true
+		c.result.Warnings = append(c.result.Warnings, w)
+	}
+}
+
 func openCsvFile(file constants.StaticFile, zipFile *zip.File) (*csv.File, error) {
 	content, err := zipFile.Open()
 	if err != nil {
@@ -313,8 +926,7 @@ func openCsvFile(file constants.StaticFile, zipFile *zip.File) (*csv.File, error
 	return f, nil
 }
 
-func parseAgencies(csv *csv.File) ([]Agency, []warnings.StaticWarning) {
-	var w []warnings.StaticWarning
+func parseAgencies(csv *csv.File, w *warningCollector, recordSourceRows bool) []Agency {
 	idColumn := csv.OptionalColumn("agency_id")
 	nameColumn := csv.RequiredColumn("agency_name")
 	urlColumn := csv.RequiredColumn("agency_url")
@@ -324,8 +936,8 @@ func parseAgencies(csv *csv.File) ([]Agency, []warnings.StaticWarning) {
 	fareUrlColumn := csv.OptionalColumn("agency_fare_url")
 	emailColumn := csv.OptionalColumn("agency_email")
 
-	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
-		return nil, warnings
+	if checkForMissingColumns(csv, w) {
+		return nil
 	}
 
 	var agencies []Agency
@@ -342,19 +954,135 @@ func parseAgencies(csv *csv.File) ([]Agency, []warnings.StaticWarning) {
 			FareUrl:  fareUrlColumn.Read(),
 			Email:    emailColumn.Read(),
 		}
+		agency.SourceRow = sourceRow(csv, recordSourceRows)
 		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
-			w = append(w, warnings.NewStaticWarning(csv, warnings.AgencyMissingValues{
+			w.add(warnings.NewStaticWarning(csv, warnings.AgencyMissingValues{
 				AgencyID: agency.Id,
 				Columns:  missingKeys,
 			}))
 			continue
 		}
+		// Per the spec all agencies must have the same timezone, but some feeds violate this.
+		if len(agencies) > 0 && agency.Timezone != agencies[0].Timezone {
+			w.add(warnings.NewStaticWarning(csv, warnings.AgencyTimezoneDiffers{
+				AgencyID:         agency.Id,
+				Timezone:         agency.Timezone,
+				ExpectedTimezone: agencies[0].Timezone,
+			}))
+		}
 		agencies = append(agencies, agency)
 	}
-	return agencies, w
+	return agencies
+}
+
+func agenciesHaveDifferentTimezones(agencies []Agency) bool {
+	for i := range agencies {
+		if agencies[i].Timezone != agencies[0].Timezone {
+			return true
+		}
+	}
+	return false
+}
+
+// localizeServices reinterprets the dates of each service in the timezone of the agency whose
+// trips use the service.
+//
+// Services are initially parsed in the timezone of the first agency. This only makes a difference
+// for feeds in which agencies have different timezones.
+func localizeServices(trips []ScheduledTrip, defaultTimezone *time.Location) {
+	serviceToTimezone := map[*Service]*time.Location{}
+	for i := range trips {
+		trip := &trips[i]
+		if _, ok := serviceToTimezone[trip.Service]; ok {
+			continue
+		}
+		timezone := defaultTimezone
+		if trip.Route.Agency != nil {
+			if t, err := loadLocation(trip.Route.Agency.Timezone); err == nil {
+				timezone = t
+			}
+		}
+		serviceToTimezone[trip.Service] = timezone
+	}
+	inTimezone := func(t time.Time, timezone *time.Location) time.Time {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, timezone)
+	}
+	for service, timezone := range serviceToTimezone {
+		if timezone.String() == defaultTimezone.String() {
+			continue
+		}
+		service.StartDate = inTimezone(service.StartDate, timezone)
+		service.EndDate = inTimezone(service.EndDate, timezone)
+		for i := range service.AddedDates {
+			service.AddedDates[i] = inTimezone(service.AddedDates[i], timezone)
+		}
+		for i := range service.RemovedDates {
+			service.RemovedDates[i] = inTimezone(service.RemovedDates[i], timezone)
+		}
+	}
 }
 
-func parseRoutes(csv *csv.File, agencies []Agency) []Route {
+func parseFeedInfo(csv *csv.File, timezone *time.Location, recordSourceRows bool) *FeedInfo {
+	publisherNameColumn := csv.RequiredColumn("feed_publisher_name")
+	publisherUrlColumn := csv.RequiredColumn("feed_publisher_url")
+	languageColumn := csv.RequiredColumn("feed_lang")
+	defaultLanguageColumn := csv.OptionalColumn("default_lang")
+	contactEmailColumn := csv.OptionalColumn("feed_contact_email")
+	contactUrlColumn := csv.OptionalColumn("feed_contact_url")
+	versionColumn := csv.OptionalColumn("feed_version")
+	startDateColumn := csv.OptionalColumn("feed_start_date")
+	endDateColumn := csv.OptionalColumn("feed_end_date")
+
+	if err := csv.MissingRequiredColumns(); err != nil {
+		log.Printf("Skipping feed info because of missing columns %s", err)
+		return nil
+	}
+
+	if !csv.NextRow() {
+		return nil
+	}
+	feedInfo := &FeedInfo{
+		PublisherName:   publisherNameColumn.Read(),
+		PublisherUrl:    publisherUrlColumn.Read(),
+		Language:        languageColumn.Read(),
+		DefaultLanguage: defaultLanguageColumn.Read(),
+		ContactEmail:    contactEmailColumn.Read(),
+		ContactUrl:      contactUrlColumn.Read(),
+		Version:         versionColumn.Read(),
+		SourceRow:       sourceRow(csv, recordSourceRows),
+	}
+	if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+		log.Printf("Skipping feed info because of missing keys %s", missingKeys)
+		return nil
+	}
+	for _, date := range []struct {
+		column string
+		value  string
+		dst    *time.Time
+	}{
+		{"feed_start_date", startDateColumn.Read(), &feedInfo.StartDate},
+		{"feed_end_date", endDateColumn.Read(), &feedInfo.EndDate},
+	} {
+		if date.value == "" {
+			continue
+		}
+		t, err := parseTime(date.value, timezone)
+		if err != nil {
+			log.Printf("Ignoring invalid %s %q", date.column, date.value)
+			continue
+		}
+		*date.dst = t
+	}
+	if csv.NextRow() {
+		log.Printf("Ignoring additional rows in %s", csv.Name())
+	}
+	return feedInfo
+}
+
+// parseRoutes parses routes.txt. Alongside the routes it returns the value of the legacy network_id
+// column for each route.
+func parseRoutes(csv *csv.File, agencies []Agency, recordSourceRows bool) ([]Route, []string) {
 	idColumn := csv.RequiredColumn("route_id")
 	agencyIDColumn := csv.OptionalColumn("agency_id")
 	colorColumn := csv.OptionalColumn("route_color")
@@ -367,13 +1095,15 @@ func parseRoutes(csv *csv.File, agencies []Agency) []Route {
 	sortOrderColumn := csv.OptionalColumn("route_sort_order")
 	continuousPickupColumn := csv.OptionalColumn("continuous_pickup")
 	continuousDropOffColumn := csv.OptionalColumn("continuous_drop_off")
+	networkIDColumn := csv.OptionalColumn("network_id")
 
 	if err := csv.MissingRequiredColumns(); err != nil {
 		fmt.Println(err)
-		return nil
+		return nil, nil
 	}
 
 	var routes []Route
+	var networkIDs []string
 	for csv.NextRow() {
 		routeID := idColumn.Read()
 		agencyID := agencyIDColumn.Read()
@@ -410,14 +1140,17 @@ func parseRoutes(csv *csv.File, agencies []Agency) []Route {
 			SortOrder:         parseRouteSortOrder(sortOrderColumn.Read()),
 			ContinuousPickup:  parsePickupDropOffPolicy(continuousPickupColumn.ReadOr("")),
 			ContinuousDropOff: parsePickupDropOffPolicy(continuousDropOffColumn.ReadOr("")),
+			SourceRow:         sourceRow(csv, recordSourceRows),
 		}
+		networkID := networkIDColumn.Read()
 		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
 			log.Printf("Skipping route %+v because of missing keys %s", route, missingKeys)
 			continue
 		}
 		routes = append(routes, route)
+		networkIDs = append(networkIDs, networkID)
 	}
-	return routes
+	return routes, networkIDs
 }
 
 func parseRouteSortOrder(raw string) *int32 {
@@ -432,7 +1165,7 @@ func parseRouteSortOrder(raw string) *int32 {
 	return &i32
 }
 
-func parseStops(csv *csv.File, inheritWheelchairBoarding bool) []Stop {
+func parseStops(csv *csv.File, inheritWheelchairBoarding bool, recordSourceRows bool) []Stop {
 	idColumn := csv.RequiredColumn("stop_id")
 	codeColumn := csv.OptionalColumn("stop_code")
 	nameColumn := csv.OptionalColumn("stop_name")
@@ -475,6 +1208,7 @@ func parseStops(csv *csv.File, inheritWheelchairBoarding bool) []Stop {
 			Timezone:           timezoneColumn.Read(),
 			WheelchairBoarding: parseWheelchairBoarding(wheelchairBoardingColumn.Read()),
 			PlatformCode:       platformCodeColumn.Read(),
+			SourceRow:          sourceRow(csv, recordSourceRows),
 		}
 		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
 			log.Printf("Skipping stop %+v because of missing keys %s", stop, missingKeys)
@@ -490,6 +1224,7 @@ func parseStops(csv *csv.File, inheritWheelchairBoarding bool) []Stop {
 		}
 		stops[stopIdToIndex[stopId]].Parent = &stops[parentStopIndex]
 	}
+	breakParentCycles(stops)
 
 	// Inherit wheelchair boarding from parent stops if specified.
 	if inheritWheelchairBoarding {
@@ -504,6 +1239,30 @@ func parseStops(csv *csv.File, inheritWheelchairBoarding bool) []Stop {
 	return stops
 }
 
+// breakParentCycles removes parent links that would make a stop its own ancestor.
+//
+// Without this, walking up the stop hierarchy (for example in [Stop.Root]) would never terminate.
+func breakParentCycles(stops []Stop) {
+	// 0 = unvisited, 1 = on the current path, 2 = known to terminate
+	state := make(map[*Stop]int, len(stops))
+	for i := range stops {
+		var path []*Stop
+		stop := &stops[i]
+		for stop != nil && state[stop] == 0 {
+			state[stop] = 1
+			path = append(path, stop)
+			if stop.Parent != nil && state[stop.Parent] == 1 {
+				log.Printf("Removing parent station %s of stop %s because it creates a cycle", stop.Parent.Id, stop.Id)
+				stop.Parent = nil
+			}
+			stop = stop.Parent
+		}
+		for _, s := range path {
+			state[s] = 2
+		}
+	}
+}
+
 func parseFloat64(s string) *float64 {
 	if s == "" {
 		return nil
@@ -515,7 +1274,9 @@ func parseFloat64(s string) *float64 {
 	return &f
 }
 
-func parseTransfers(csv *csv.File, stops []Stop) []Transfer {
+// parseTransfers parses the transfers. Transfers that reference a stop that does not exist are
+// skipped with a warning.
+func parseTransfers(csv *csv.File, w *warningCollector, stops []Stop, recordSourceRows bool) []Transfer {
 	fromStopIDColumn := csv.RequiredColumn("from_stop_id")
 	toStopIDColumn := csv.RequiredColumn("to_stop_id")
 	typeColumn := csv.OptionalColumn("transfer_type")
@@ -541,11 +1302,12 @@ func parseTransfers(csv *csv.File, stops []Stop) []Transfer {
 		fromStop, fromStopOk := stopIdToStop[fromStopID]
 		toStop, toStopOk := stopIdToStop[toStopID]
 		if !fromStopOk {
-			log.Printf("Skipping transfer because from_stop_id %q is invalid", fromStopID)
-			continue
+			w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "from_stop_id", ID: fromStopID}))
 		}
 		if !toStopOk {
-			log.Printf("Skipping transfer because to_stop_id %q is invalid", toStopID)
+			w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "to_stop_id", ID: toStopID}))
+		}
+		if !fromStopOk || !toStopOk {
 			continue
 		}
 		if fromStop.Id == toStop.Id {
@@ -557,11 +1319,136 @@ func parseTransfers(csv *csv.File, stops []Stop) []Transfer {
 			To:              toStop,
 			Type:            parseTransferType(typeColumn.Read()),
 			MinTransferTime: parseInt32(transferTimeColumn.Read()),
+			SourceRow:       sourceRow(csv, recordSourceRows),
 		})
 	}
 	return transfers
 }
 
+// parsePathways parses the pathways. Pathways that reference a stop that does not exist are
+// skipped with a warning.
+func parsePathways(csv *csv.File, w *warningCollector, stops []Stop, recordSourceRows bool) []Pathway {
+	idColumn := csv.RequiredColumn("pathway_id")
+	fromStopIDColumn := csv.RequiredColumn("from_stop_id")
+	toStopIDColumn := csv.RequiredColumn("to_stop_id")
+	modeColumn := csv.RequiredColumn("pathway_mode")
+	isBidirectionalColumn := csv.RequiredColumn("is_bidirectional")
+	lengthColumn := csv.OptionalColumn("length")
+	traversalTimeColumn := csv.OptionalColumn("traversal_time")
+	stairCountColumn := csv.OptionalColumn("stair_count")
+	maxSlopeColumn := csv.OptionalColumn("max_slope")
+	minWidthColumn := csv.OptionalColumn("min_width")
+	signpostedAsColumn := csv.OptionalColumn("signposted_as")
+	reversedSignpostedAsColumn := csv.OptionalColumn("reversed_signposted_as")
+
+	if checkForMissingColumns(csv, w) {
+		return nil
+	}
+
+	stopIDToStop := map[string]*Stop{}
+	for i := range stops {
+		stopIDToStop[stops[i].Id] = &stops[i]
+	}
+	var pathways []Pathway
+	for csv.NextRow() {
+		pathway := Pathway{
+			ID:                   idColumn.Read(),
+			Mode:                 parsePathwayMode(modeColumn.Read()),
+			IsBidirectional:      isBidirectionalColumn.Read() == "1",
+			Length:               parseFloat64(lengthColumn.Read()),
+			TraversalTime:        parseInt32(traversalTimeColumn.Read()),
+			StairCount:           parseInt32(stairCountColumn.Read()),
+			MaxSlope:             parseFloat64(maxSlopeColumn.Read()),
+			MinWidth:             parseFloat64(minWidthColumn.Read()),
+			SignpostedAs:         signpostedAsColumn.Read(),
+			ReversedSignpostedAs: reversedSignpostedAsColumn.Read(),
+			SourceRow:            sourceRow(csv, recordSourceRows),
+		}
+		fromStopID := fromStopIDColumn.Read()
+		toStopID := toStopIDColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			log.Printf("Skipping pathway because of missing keys %s", missingKeys)
+			continue
+		}
+		var fromStopOk, toStopOk bool
+		pathway.From, fromStopOk = stopIDToStop[fromStopID]
+		pathway.To, toStopOk = stopIDToStop[toStopID]
+		if !fromStopOk {
+			w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "from_stop_id", ID: fromStopID}))
+		}
+		if !toStopOk {
+			w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "to_stop_id", ID: toStopID}))
+		}
+		if !fromStopOk || !toStopOk {
+			continue
+		}
+		pathways = append(pathways, pathway)
+	}
+	return pathways
+}
+
+// parseFareRules parses the fare rules. Rules that reference a route or fare zone that does not
+// exist are skipped with a warning.
+func parseFareRules(csv *csv.File, w *warningCollector, routes []Route, stops []Stop, recordSourceRows bool) []FareRule {
+	fareIDColumn := csv.RequiredColumn("fare_id")
+	routeIDColumn := csv.OptionalColumn("route_id")
+	originIDColumn := csv.OptionalColumn("origin_id")
+	destinationIDColumn := csv.OptionalColumn("destination_id")
+	containsIDColumn := csv.OptionalColumn("contains_id")
+
+	if err := csv.MissingRequiredColumns(); err != nil {
+		fmt.Println(err)
+		return nil
+	}
+
+	routeIDToRoute := map[string]*Route{}
+	for i := range routes {
+		routeIDToRoute[routes[i].Id] = &routes[i]
+	}
+	zoneIDs := map[string]bool{}
+	for i := range stops {
+		zoneIDs[stops[i].ZoneId] = true
+	}
+	var fareRules []FareRule
+	for csv.NextRow() {
+		fareRule := FareRule{
+			FareID:        fareIDColumn.Read(),
+			OriginID:      originIDColumn.Read(),
+			DestinationID: destinationIDColumn.Read(),
+			ContainsID:    containsIDColumn.Read(),
+			SourceRow:     sourceRow(csv, recordSourceRows),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			log.Printf("Skipping fare rule because of missing keys %s", missingKeys)
+			continue
+		}
+		valid := true
+		if routeID := routeIDColumn.Read(); routeID != "" {
+			if fareRule.Route = routeIDToRoute[routeID]; fareRule.Route == nil {
+				w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "route_id", ID: routeID}))
+				valid = false
+			}
+		}
+		for _, zone := range []struct {
+			column string
+			id     string
+		}{
+			{"origin_id", fareRule.OriginID},
+			{"destination_id", fareRule.DestinationID},
+			{"contains_id", fareRule.ContainsID},
+		} {
+			if zone.id != "" && !zoneIDs[zone.id] {
+				w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: zone.column, ID: zone.id}))
+				valid = false
+			}
+		}
+		if valid {
+			fareRules = append(fareRules, fareRule)
+		}
+	}
+	return fareRules
+}
+
 func parseInt32(s string) *int32 {
 	if s == "" {
 		return nil
@@ -574,7 +1461,7 @@ func parseInt32(s string) *int32 {
 	return &i32
 }
 
-func parseCalendar(f *csv.File, m map[string]Service, timezone *time.Location) {
+func parseCalendar(f *csv.File, m map[string]Service, timezone *time.Location, recordSourceRows bool) {
 	startDateColumn := f.RequiredColumn("start_date")
 	endDateColumn := f.RequiredColumn("end_date")
 	serviceIDColumn := f.RequiredColumn("service_id")
@@ -619,6 +1506,7 @@ func parseCalendar(f *csv.File, m map[string]Service, timezone *time.Location) {
 			Sunday:    parseBool(dayColumns[6].Read()),
 			StartDate: startDate,
 			EndDate:   endDate,
+			SourceRow: sourceRow(f, recordSourceRows),
 		}
 		if missingKeys := f.MissingRowKeys(); len(missingKeys) > 0 {
 			log.Printf("Skipping calendar because of missing keys %s", missingKeys)
@@ -628,7 +1516,7 @@ func parseCalendar(f *csv.File, m map[string]Service, timezone *time.Location) {
 	}
 }
 
-func parseCalendarDates(csv *csv.File, m map[string]Service, timezone *time.Location) {
+func parseCalendarDates(csv *csv.File, m map[string]Service, timezone *time.Location, recordSourceRows bool) {
 	serviceIDColumn := csv.RequiredColumn("service_id")
 	dateColumn := csv.RequiredColumn("date")
 	exceptionTypeColumn := csv.RequiredColumn("exception_type")
@@ -654,6 +1542,7 @@ func parseCalendarDates(csv *csv.File, m map[string]Service, timezone *time.Loca
 		if !ok {
 			service.StartDate = date
 			service.EndDate = date
+			service.SourceRow = sourceRow(csv, recordSourceRows)
 		} else {
 			if date.Before(service.StartDate) {
 				service.StartDate = date
@@ -678,7 +1567,10 @@ func parseTime(s string, timezone *time.Location) (time.Time, error) {
 	return time.ParseInLocation("20060102", s, timezone)
 }
 
-func parseScheduledTrips(csv *csv.File, routes []Route, services []Service, shapeIDToShape map[string]*Shape) []ScheduledTrip {
+// parseScheduledTrips parses the trips. Trips that reference a route or service that does not exist
+// are skipped with a warning. If the shape of a trip does not exist, the trip is kept without a
+// shape and a warning is added.
+func parseScheduledTrips(csv *csv.File, w *warningCollector, routes []Route, services []Service, shapeIDToShape map[string]*Shape, recordSourceRows bool) []ScheduledTrip {
 	routeIDColumn := csv.RequiredColumn("route_id")
 	serviceIDColumn := csv.RequiredColumn("service_id")
 	tripIDColumn := csv.RequiredColumn("trip_id")
@@ -705,9 +1597,10 @@ func parseScheduledTrips(csv *csv.File, routes []Route, services []Service, shap
 	}
 	var trips []ScheduledTrip
 	for csv.NextRow() {
+		routeID, serviceID := routeIDColumn.Read(), serviceIDColumn.Read()
 		trip := ScheduledTrip{
-			Route:                idToRoute[routeIDColumn.Read()],
-			Service:              idToService[serviceIDColumn.Read()],
+			Route:                idToRoute[routeID],
+			Service:              idToService[serviceID],
 			ID:                   tripIDColumn.Read(),
 			Headsign:             tripHeadsignColumn.Read(),
 			ShortName:            tripShortNameColumn.Read(),
@@ -715,15 +1608,7 @@ func parseScheduledTrips(csv *csv.File, routes []Route, services []Service, shap
 			BlockID:              blockIDColumn.Read(),
 			WheelchairAccessible: parseWheelchairBoarding(wheelchairAccessibleColumn.Read()),
 			BikesAllowed:         parseBikesAllowed(bikesAllowedColumn.ReadOr("")),
-		}
-
-		shapeIDOrNil := shapeIDColumn.Read()
-		if shapeIDOrNil != "" {
-			if shape, ok := shapeIDToShape[shapeIDOrNil]; ok {
-				trip.Shape = shape
-			} else {
-				log.Printf("Shape %s not found for trip %s", shapeIDOrNil, trip.ID)
-			}
+			SourceRow:            sourceRow(csv, recordSourceRows),
 		}
 
 		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
@@ -731,20 +1616,94 @@ func parseScheduledTrips(csv *csv.File, routes []Route, services []Service, shap
 			continue
 		}
 		if trip.Route == nil {
-			log.Print("Skipping trip because of missing route")
-			continue
+			w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "route_id", ID: routeID}))
 		}
 		if trip.Service == nil {
-			log.Print("Skipping trip because of missing service")
+			w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "service_id", ID: serviceID}))
+		}
+		if trip.Route == nil || trip.Service == nil {
 			continue
 		}
+		if shapeID := shapeIDColumn.Read(); shapeID != "" {
+			if shape, ok := shapeIDToShape[shapeID]; ok {
+				trip.Shape = shape
+			} else {
+				w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "shape_id", ID: shapeID}))
+			}
+		}
 		trips = append(trips, trip)
 	}
 	return trips
 }
 
-func parseScheduledStopTimes(csv *csv.File, stops []Stop, trips []ScheduledTrip) {
-	stopIDColumn := csv.RequiredColumn("stop_id")
+// parseScheduledStopTimes reads the stop times and appends them to their trips.
+//
+// Stop times are expected to be grouped by trip, in which case the stop times of each trip are
+// appended together. If they are not, a warning is added and the stop times after the first
+// out-of-place row are bucketed by trip first so that each trip's slice is only grown once.
+func parseScheduledStopTimes(csv *csv.File, w *warningCollector, s *Static, opts stopTimesOptions) {
+	type pendingStopTime struct {
+		trip     *ScheduledTrip
+		stopTime ScheduledStopTime
+	}
+	var pending []pendingStopTime
+	var previousTrip *ScheduledTrip
+	grouped := true
+	readScheduledStopTimes(csv, w, s, opts, func(trip *ScheduledTrip, stopTime ScheduledStopTime) bool {
+		if !grouped {
+			pending = append(pending, pendingStopTime{trip: trip, stopTime: stopTime})
+			return true
+		}
+		if trip != previousTrip {
+			if len(trip.StopTimes) > 0 {
+				grouped = false
+				w.add(warnings.NewStaticWarning(csv, warnings.StopTimesNotGroupedByTrip{TripID: trip.ID}))
+				pending = append(pending, pendingStopTime{trip: trip, stopTime: stopTime})
+				return true
+			}
+			if previousTrip != nil && cap(trip.StopTimes) == 0 {
+				trip.StopTimes = make([]ScheduledStopTime, 0, len(previousTrip.StopTimes))
+			}
+			previousTrip = trip
+		}
+		trip.StopTimes = append(trip.StopTimes, stopTime)
+		return true
+	})
+	if len(pending) > 0 {
+		tripToNumPending := map[*ScheduledTrip]int{}
+		for _, p := range pending {
+			tripToNumPending[p.trip]++
+		}
+		for trip, n := range tripToNumPending {
+			stopTimes := make([]ScheduledStopTime, len(trip.StopTimes), len(trip.StopTimes)+n)
+			copy(stopTimes, trip.StopTimes)
+			trip.StopTimes = stopTimes
+		}
+		for _, p := range pending {
+			p.trip.StopTimes = append(p.trip.StopTimes, p.stopTime)
+		}
+	}
+	for i := range s.Trips {
+		trip := &s.Trips[i]
+		sort.Slice(trip.StopTimes, func(i, j int) bool {
+			return trip.StopTimes[i].StopSequence < trip.StopTimes[j].StopSequence
+		})
+	}
+}
+
+// readScheduledStopTimes reads the rows of the stop_times.txt file and invokes f for each valid stop time.
+// A warning is added for each row that references a stop, trip, location group or booking rule that
+// does not exist.
+//
+// Reading stops as soon as f returns false.
+func readScheduledStopTimes(csv *csv.File, w *warningCollector, s *Static, opts stopTimesOptions, f func(trip *ScheduledTrip, stopTime ScheduledStopTime) bool) {
+	// In GTFS-Flex feeds the stop ID is empty for stop times that reference a location group.
+	var stopIDColumn interface{ Read() string }
+	if opts.flex {
+		stopIDColumn = csv.OptionalColumn("stop_id")
+	} else {
+		stopIDColumn = csv.RequiredColumn("stop_id")
+	}
 	stopSequenceKey := csv.RequiredColumn("stop_sequence")
 	tripIDColumn := csv.RequiredColumn("trip_id")
 	arrivalTimeColumn := csv.OptionalColumn("arrival_time")
@@ -756,25 +1715,46 @@ func parseScheduledStopTimes(csv *csv.File, stops []Stop, trips []ScheduledTrip)
 	continuousDropOffColumn := csv.OptionalColumn("continuous_drop_off")
 	shapeDistanceTraveledColumn := csv.OptionalColumn("shape_dist_traveled")
 	timepointColumn := csv.OptionalColumn("timepoint")
+	startWindowColumn := csv.OptionalColumn("start_pickup_drop_off_window")
+	endWindowColumn := csv.OptionalColumn("end_pickup_drop_off_window")
+	locationGroupIDColumn := csv.OptionalColumn("location_group_id")
+	locationIDColumn := csv.OptionalColumn("location_id")
+	pickupBookingRuleIDColumn := csv.OptionalColumn("pickup_booking_rule_id")
+	dropOffBookingRuleIDColumn := csv.OptionalColumn("drop_off_booking_rule_id")
 	if err := csv.MissingRequiredColumns(); err != nil {
 		fmt.Println(err)
 		return
 	}
 
 	idToStop := map[string]*Stop{}
-	for i := range stops {
-		idToStop[stops[i].Id] = &stops[i]
+	for i := range s.Stops {
+		idToStop[s.Stops[i].Id] = &s.Stops[i]
 	}
 	idToTrip := map[string]*ScheduledTrip{}
-	for i := range trips {
-		idToTrip[trips[i].ID] = &trips[i]
+	for i := range s.Trips {
+		idToTrip[s.Trips[i].ID] = &s.Trips[i]
+	}
+	idToLocationGroup := map[string]*LocationGroup{}
+	for i := range s.LocationGroups {
+		idToLocationGroup[s.LocationGroups[i].ID] = &s.LocationGroups[i]
+	}
+	idToFlexLocation := map[string]*FlexLocation{}
+	for i := range s.FlexLocations {
+		idToFlexLocation[s.FlexLocations[i].ID] = &s.FlexLocations[i]
+	}
+	idToBookingRule := map[string]*BookingRule{}
+	for i := range s.BookingRules {
+		idToBookingRule[s.BookingRules[i].ID] = &s.BookingRules[i]
 	}
 	var currentTrip *ScheduledTrip
 	var currentTripID string
 	for csv.NextRow() {
 		arrival, arrivalOk := parseGtfsTimeToDuration(arrivalTimeColumn.Read())
 		departure, departureOk := parseGtfsTimeToDuration(departureTimeColumn.Read())
-		if !arrivalOk && !departureOk {
+		startWindow, startWindowOk := parseGtfsTimeToDuration(startWindowColumn.Read())
+		endWindow, endWindowOk := parseGtfsTimeToDuration(endWindowColumn.Read())
+		hasWindow := startWindowOk && endWindowOk
+		if !arrivalOk && !departureOk && !hasWindow {
 			continue
 		}
 		if !departureOk {
@@ -788,8 +1768,9 @@ func parseScheduledStopTimes(csv *csv.File, stops []Stop, trips []ScheduledTrip)
 			// TODO: log a warning
 			continue
 		}
+		stopID := stopIDColumn.Read()
 		stopTime := ScheduledStopTime{
-			Stop:                  idToStop[stopIDColumn.Read()],
+			Stop:                  idToStop[stopID],
 			Headsign:              stopHeadsignColumn.Read(),
 			ArrivalTime:           arrival,
 			StopSequence:          stopSequence,
@@ -800,33 +1781,76 @@ func parseScheduledStopTimes(csv *csv.File, stops []Stop, trips []ScheduledTrip)
 			ContinuousDropOff:     parsePickupDropOffPolicy(continuousDropOffColumn.ReadOr("")),
 			ShapeDistanceTraveled: parseFloat64(shapeDistanceTraveledColumn.Read()),
 			ExactTimes:            timepointColumn.ReadOr("1") == "1",
+			SourceRow:             sourceRow(csv, opts.recordSourceRows),
+		}
+		if hasWindow {
+			stopTime.HasPickupDropOffWindow = true
+			stopTime.StartPickupDropOffWindow = startWindow
+			stopTime.EndPickupDropOffWindow = endWindow
+		}
+		if opts.preserveRawTimes {
+			stopTime.RawArrival = arrivalTimeColumn.Read()
+			stopTime.RawDeparture = departureTimeColumn.Read()
 		}
 		tripID := tripIDColumn.Read()
 		if currentTrip == nil || currentTripID != tripID {
-			thisTrip := idToTrip[tripID]
-			if currentTrip != nil && cap(thisTrip.StopTimes) == 0 {
-				thisTrip.StopTimes = make([]ScheduledStopTime, 0, len(currentTrip.StopTimes))
-			}
-			currentTrip = thisTrip
+			currentTrip = idToTrip[tripID]
 			currentTripID = tripID
 		}
 		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
 			log.Printf("Skipping stop time because of missing keys %s", missingKeys)
 			continue
 		}
-		if stopTime.Stop == nil {
+		valid := true
+		locationGroupID := locationGroupIDColumn.Read()
+		locationID := locationIDColumn.Read()
+		switch {
+		case stopID != "" || !opts.flex:
+			if stopTime.Stop == nil {
+				w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "stop_id", ID: stopID}))
+				valid = false
+			}
+		case locationGroupID != "":
+			if stopTime.LocationGroup = idToLocationGroup[locationGroupID]; stopTime.LocationGroup == nil {
+				w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "location_group_id", ID: locationGroupID}))
+				valid = false
+			}
+		case locationID != "":
+			if stopTime.FlexLocation = idToFlexLocation[locationID]; stopTime.FlexLocation == nil {
+				w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "location_id", ID: locationID}))
+				valid = false
+			}
+		default:
 			continue
 		}
 		if currentTrip == nil {
+			w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: "trip_id", ID: tripID}))
+			valid = false
+		}
+		for _, bookingRule := range []struct {
+			column string
+			id     string
+			dst    **BookingRule
+		}{
+			{"pickup_booking_rule_id", pickupBookingRuleIDColumn.Read(), &stopTime.PickupBookingRule},
+			{"drop_off_booking_rule_id", dropOffBookingRuleIDColumn.Read(), &stopTime.DropOffBookingRule},
+		} {
+			if bookingRule.id == "" {
+				continue
+			}
+			if *bookingRule.dst = idToBookingRule[bookingRule.id]; *bookingRule.dst == nil {
+				w.add(warnings.NewStaticWarning(csv, warnings.InvalidForeignID{Column: bookingRule.column, ID: bookingRule.id}))
+			}
+		}
+		if !valid {
 			continue
 		}
-		currentTrip.StopTimes = append(currentTrip.StopTimes, stopTime)
-	}
-	for _, trip := range idToTrip {
-		sort.Slice(trip.StopTimes, func(i, j int) bool {
-			return trip.StopTimes[i].StopSequence < trip.StopTimes[j].StopSequence
-		})
+		stopTime.Trip = currentTrip
+		if !f(currentTrip, stopTime) {
+			return
+		}
 	}
+	return
 }
 
 func parseGtfsTimeToDuration(s string) (time.Duration, bool) {
@@ -862,7 +1886,7 @@ type ShapeRow struct {
 	ShapeDistTraveled *float64
 }
 
-func parseShapes(csv *csv.File) []Shape {
+func parseShapes(csv *csv.File, recordSourceRows bool) []Shape {
 	shapeIDColumn := csv.RequiredColumn("shape_id")
 	shapePtLatColumn := csv.RequiredColumn("shape_pt_lat")
 	shapePtLonColumn := csv.RequiredColumn("shape_pt_lon")
@@ -875,6 +1899,7 @@ func parseShapes(csv *csv.File) []Shape {
 	}
 
 	shapeIDToRowData := map[string][]ShapeRow{}
+	shapeIDToSourceRow := map[string]int{}
 	for csv.NextRow() {
 		shapeID := shapeIDColumn.Read()
 		shapePtLat := parseFloat64(shapePtLatColumn.Read())
@@ -886,7 +1911,14 @@ func parseShapes(csv *csv.File) []Shape {
 			log.Printf("Skipping shape because of missing keys %s", missingKeys)
 			continue
 		}
+		if shapePtLat == nil || shapePtLon == nil || shapePtSequence == nil {
+			log.Printf("Skipping shape %s because of invalid point", shapeID)
+			continue
+		}
 
+		if _, ok := shapeIDToRowData[shapeID]; !ok {
+			shapeIDToSourceRow[shapeID] = sourceRow(csv, recordSourceRows)
+		}
 		shapeIDToRowData[shapeID] = append(shapeIDToRowData[shapeID], ShapeRow{
 			ShapePtLat:        *shapePtLat,
 			ShapePtLon:        *shapePtLon,
@@ -912,8 +1944,9 @@ func parseShapes(csv *csv.File) []Shape {
 		}
 
 		shapes = append(shapes, Shape{
-			ID:     shapeID,
-			Points: points,
+			ID:        shapeID,
+			Points:    points,
+			SourceRow: shapeIDToSourceRow[shapeID],
 		})
 	}
 
@@ -925,7 +1958,7 @@ func parseShapes(csv *csv.File) []Shape {
 	return shapes
 }
 
-func parseFrequencies(csv *csv.File, tripIDToScheduledTrip map[string]*ScheduledTrip) {
+func parseFrequencies(csv *csv.File, tripIDToScheduledTrip map[string]*ScheduledTrip, recordSourceRows bool) {
 	tripIDColumn := csv.RequiredColumn("trip_id")
 	startTimeColumn := csv.RequiredColumn("start_time")
 	endTimeColumn := csv.RequiredColumn("end_time")
@@ -973,18 +2006,27 @@ func parseFrequencies(csv *csv.File, tripIDToScheduledTrip map[string]*Scheduled
 			EndTime:    endTimeDuration,
 			Headway:    time.Duration(*headwaySecsOrNil) * time.Second,
 			ExactTimes: parseExactTimes(exactTimesColumn.Read()),
+			SourceRow:  sourceRow(csv, recordSourceRows),
 		}
 
 		scheduledTripOrNil.Frequencies = append(scheduledTripOrNil.Frequencies, frequency)
 	}
 }
 
-func checkForMissingColumns(csv *csv.File) []warnings.StaticWarning {
+// sourceRow returns the current row number of the file if source rows are being recorded, and 0 otherwise.
+func sourceRow(f *csv.File, recordSourceRows bool) int {
+	if !recordSourceRows {
+		return 0
+	}
+	return f.RowNumber()
+}
+
+// checkForMissingColumns adds a warning and returns true if required columns are missing.
+func checkForMissingColumns(csv *csv.File, w *warningCollector) bool {
 	missing := csv.MissingRequiredColumns()
 	if len(missing) == 0 {
-		return nil
-	}
-	return []warnings.StaticWarning{
-		warnings.NewStaticWarning(csv, warnings.MissingColumns{Columns: missing}),
+		return false
 	}
+	w.add(warnings.NewStaticWarning(csv, warnings.MissingColumns{Columns: missing}))
+	return true
 }
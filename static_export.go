@@ -0,0 +1,236 @@
+package gtfs
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CsvExportOptions configures the CSV exports of static and realtime messages.
+//
+// By default decimal values are written with the shortest representation that round trips, which
+// can make files with many coordinates large.
+type CsvExportOptions struct {
+	// If positive, latitudes and longitudes are rounded to this number of decimal places.
+	// 5 decimal places corresponds to roughly one meter.
+	CoordinatePrecision int
+
+	// If positive, other decimal values, such as shape distances, bearings and speeds, are rounded
+	// to this number of decimal places.
+	DecimalPrecision int
+
+	// If non-zero, only service dates on or after this date are exported.
+	ServiceDatesStart time.Time
+
+	// If non-zero, only service dates on or before this date are exported.
+	//
+	// Some feeds use far future end dates like 20991231 for services that run indefinitely, in
+	// which case the service is otherwise expanded to every date until then.
+	ServiceDatesEnd time.Time
+}
+
+// StaticCsvExport contains flat CSV exports of a static message.
+type StaticCsvExport struct {
+	AgenciesCsv     []byte
+	RoutesCsv       []byte
+	StopsCsv        []byte
+	TripsCsv        []byte
+	StopTimesCsv    []byte
+	ServiceDatesCsv []byte
+	ShapesCsv       []byte
+}
+
+// ExportToCsv exports the static message to flat CSV files suitable for loading into a data warehouse.
+//
+// Unlike the GTFS static files, the exported files are normalized: every route has its agency
+// resolved, every stop has its root stop resolved, enums are written using their names, and
+// services are expanded into the absolute dates on which they run. Arrival and departure times
+// are written as the number of seconds since the start of the service day.
+func (s *Static) ExportToCsv() (*StaticCsvExport, error) {
+	return s.ExportToCsvWithOptions(CsvExportOptions{})
+}
+
+// ExportToCsvWithOptions is the same as ExportToCsv, but with options.
+func (s *Static) ExportToCsvWithOptions(opts CsvExportOptions) (*StaticCsvExport, error) {
+	var err error
+	result := &StaticCsvExport{}
+
+	agencies := [][]string{{"agency_id", "agency_name", "agency_url", "agency_timezone", "agency_lang", "agency_phone"}}
+	for _, agency := range s.Agencies {
+		agencies = append(agencies, []string{agency.Id, agency.Name, agency.Url, agency.Timezone, agency.Language, agency.Phone})
+	}
+	if result.AgenciesCsv, err = writeCsv(agencies); err != nil {
+		return nil, err
+	}
+
+	routes := [][]string{{"route_id", "agency_id", "agency_name", "route_short_name", "route_long_name", "route_type", "route_color", "route_text_color"}}
+	for _, route := range s.Routes {
+		var agencyID, agencyName string
+		if route.Agency != nil {
+			agencyID, agencyName = route.Agency.Id, route.Agency.Name
+		}
+		routes = append(routes, []string{route.Id, agencyID, agencyName, route.ShortName, route.LongName, route.Type.String(), route.Color, route.TextColor})
+	}
+	if result.RoutesCsv, err = writeCsv(routes); err != nil {
+		return nil, err
+	}
+
+	stops := [][]string{{"stop_id", "stop_code", "stop_name", "stop_lat", "stop_lon", "stop_type", "parent_stop_id", "root_stop_id", "platform_code"}}
+	for i := range s.Stops {
+		stop := &s.Stops[i]
+		var parentID string
+		if stop.Parent != nil {
+			parentID = stop.Parent.Id
+		}
+		stops = append(stops, []string{
+			stop.Id, stop.Code, stop.Name, formatFloat64Ptr(stop.Latitude, opts.CoordinatePrecision), formatFloat64Ptr(stop.Longitude, opts.CoordinatePrecision),
+			stop.Type.String(), parentID, stop.Root().Id, stop.PlatformCode,
+		})
+	}
+	if result.StopsCsv, err = writeCsv(stops); err != nil {
+		return nil, err
+	}
+
+	trips := [][]string{{"trip_id", "route_id", "service_id", "trip_headsign", "direction_id", "shape_id"}}
+	for _, trip := range s.Trips {
+		var routeID, serviceID, shapeID string
+		if trip.Route != nil {
+			routeID = trip.Route.Id
+		}
+		if trip.Service != nil {
+			serviceID = trip.Service.Id
+		}
+		if trip.Shape != nil {
+			shapeID = trip.Shape.ID
+		}
+		trips = append(trips, []string{trip.ID, routeID, serviceID, trip.Headsign, formatDirectionID(trip.DirectionId), shapeID})
+	}
+	if result.TripsCsv, err = writeCsv(trips); err != nil {
+		return nil, err
+	}
+
+	stopTimes := [][]string{{"trip_id", "stop_id", "stop_sequence", "arrival_time", "departure_time"}}
+	err = s.ForEachStopTime(func(trip ScheduledTrip, stopTime ScheduledStopTime) bool {
+		var stopID string
+		if stopTime.Stop != nil {
+			stopID = stopTime.Stop.Id
+		}
+		stopTimes = append(stopTimes, []string{
+			trip.ID,
+			stopID,
+			strconv.Itoa(stopTime.StopSequence),
+			strconv.FormatInt(int64(stopTime.ArrivalTime/time.Second), 10),
+			strconv.FormatInt(int64(stopTime.DepartureTime/time.Second), 10),
+		})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.StopTimesCsv, err = writeCsv(stopTimes); err != nil {
+		return nil, err
+	}
+
+	serviceDates := [][]string{{"service_id", "date"}}
+	for i := range s.Services {
+		for _, date := range s.Services[i].dates(opts.ServiceDatesStart, opts.ServiceDatesEnd) {
+			serviceDates = append(serviceDates, []string{s.Services[i].Id, date.Format("2006-01-02")})
+		}
+	}
+	if result.ServiceDatesCsv, err = writeCsv(serviceDates); err != nil {
+		return nil, err
+	}
+
+	shapes := [][]string{{"shape_id", "shape_pt_sequence", "shape_pt_lat", "shape_pt_lon", "shape_dist_traveled"}}
+	for _, shape := range s.Shapes {
+		for i, point := range shape.Points {
+			shapes = append(shapes, []string{
+				shape.ID,
+				strconv.Itoa(i),
+				formatFloat(point.Latitude, 64, opts.CoordinatePrecision),
+				formatFloat(point.Longitude, 64, opts.CoordinatePrecision),
+				formatFloat64Ptr(point.Distance, opts.DecimalPrecision),
+			})
+		}
+	}
+	if result.ShapesCsv, err = writeCsv(shapes); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// dates returns the dates on which the service runs, in increasing order.
+//
+// If start or end is non-zero, the dates are clamped to the window they define.
+func (service *Service) dates(start, end time.Time) []time.Time {
+	first, last := service.StartDate, service.EndDate
+	for _, added := range service.AddedDates {
+		if first.IsZero() || added.Before(first) {
+			first = added
+		}
+		if last.IsZero() || added.After(last) {
+			last = added
+		}
+	}
+	if first.IsZero() || last.IsZero() {
+		return nil
+	}
+	if !start.IsZero() && start.After(first) {
+		first = start
+	}
+	if !end.IsZero() && end.Before(last) {
+		last = end
+	}
+	var dates []time.Time
+	y, m, d := first.Date()
+	for date := time.Date(y, m, d, 0, 0, 0, 0, first.Location()); !date.After(last); date = date.AddDate(0, 0, 1) {
+		if service.RunsOn(date) {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+func writeCsv(rows [][]string) ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func formatFloat64Ptr(f *float64, precision int) string {
+	if f == nil {
+		return ""
+	}
+	return formatFloat(*f, 64, precision)
+}
+
+// formatFloat formats the value without an exponent. If the precision is positive the value is
+// rounded to that many decimal places and trailing zeros are trimmed, so that, for example, 40.5
+// is written as 40.5 rather than 40.50000.
+func formatFloat(f float64, bitSize int, precision int) string {
+	if precision <= 0 {
+		return strconv.FormatFloat(f, 'f', -1, bitSize)
+	}
+	s := strconv.FormatFloat(f, 'f', precision, bitSize)
+	s = strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+func formatDirectionID(d DirectionID) string {
+	switch d {
+	case DirectionID_False:
+		return "0"
+	case DirectionID_True:
+		return "1"
+	default:
+		return ""
+	}
+}
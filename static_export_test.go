@@ -0,0 +1,184 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStaticExportToCsv(t *testing.T) {
+	static, err := ParseStatic(newZipBuilder().add(
+		"agency.txt",
+		"agency_id,agency_name,agency_url,agency_timezone\nagency,\"Transit, Inc.\",url,America/New_York",
+	).add(
+		"routes.txt",
+		"route_id,route_type,route_short_name\nA,1,A",
+	).add(
+		"stops.txt",
+		"stop_id,stop_name,location_type,parent_station,stop_lat,stop_lon\nstation,Station,1,,40.5,-73.25\nplatform,Platform,,station,,",
+	).add(
+		"calendar.txt",
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n"+
+			"weekend,0,0,0,0,0,1,1,20220504,20220510",
+	).add(
+		"calendar_dates.txt",
+		"service_id,date,exception_type\nweekend,20220512,1\nweekend,20220508,2",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id,direction_id\nA,weekend,trip,1",
+	).add(
+		"stop_times.txt",
+		"stop_id,trip_id,arrival_time,departure_time,stop_sequence\nplatform,trip,25:00:00,25:01:00,1",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse static feed: %s", err)
+	}
+
+	result, err := static.ExportToCsv()
+	if err != nil {
+		t.Fatalf("ExportToCsv() failed: %s", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{
+			name: "agencies",
+			got:  result.AgenciesCsv,
+			want: "agency_id,agency_name,agency_url,agency_timezone,agency_lang,agency_phone\n" +
+				"agency,\"Transit, Inc.\",url,America/New_York,,\n",
+		},
+		{
+			name: "routes",
+			got:  result.RoutesCsv,
+			want: "route_id,agency_id,agency_name,route_short_name,route_long_name,route_type,route_color,route_text_color\n" +
+				"A,agency,\"Transit, Inc.\",A,,SUBWAY,FFFFFF,000000\n",
+		},
+		{
+			name: "stops",
+			got:  result.StopsCsv,
+			want: "stop_id,stop_code,stop_name,stop_lat,stop_lon,stop_type,parent_stop_id,root_stop_id,platform_code\n" +
+				"station,,Station,40.5,-73.25,STATION,,station,\n" +
+				"platform,,Platform,,,PLATFORM,station,station,\n",
+		},
+		{
+			name: "trips",
+			got:  result.TripsCsv,
+			want: "trip_id,route_id,service_id,trip_headsign,direction_id,shape_id\n" +
+				"trip,A,weekend,,1,\n",
+		},
+		{
+			name: "stop times",
+			got:  result.StopTimesCsv,
+			want: "trip_id,stop_id,stop_sequence,arrival_time,departure_time\n" +
+				"trip,platform,1,90000,90060\n",
+		},
+		{
+			name: "service dates",
+			got:  result.ServiceDatesCsv,
+			want: "service_id,date\n" +
+				"weekend,2022-05-07\n" +
+				"weekend,2022-05-12\n",
+		},
+		{
+			name: "shapes",
+			got:  result.ShapesCsv,
+			want: "shape_id,shape_pt_sequence,shape_pt_lat,shape_pt_lon,shape_dist_traveled\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if string(tc.got) != tc.want {
+				t.Errorf("got:\n%s\n!= want:\n%s", tc.got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStaticExportToCsv_Precision(t *testing.T) {
+	static, err := ParseStatic(newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id,stop_lat,stop_lon\nstop,40.7589123456,-73.9851",
+	).add(
+		"shapes.txt",
+		"shape_id,shape_pt_sequence,shape_pt_lat,shape_pt_lon,shape_dist_traveled\n"+
+			"shape,1,40.700004,-74.0000049,0\n"+
+			"shape,2,40.71,-74.00001,1234.5678",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse static feed: %s", err)
+	}
+
+	result, err := static.ExportToCsvWithOptions(CsvExportOptions{CoordinatePrecision: 5, DecimalPrecision: 1})
+	if err != nil {
+		t.Fatalf("ExportToCsvWithOptions() failed: %s", err)
+	}
+
+	wantStops := "stop_id,stop_code,stop_name,stop_lat,stop_lon,stop_type,parent_stop_id,root_stop_id,platform_code\n" +
+		"stop,,,40.75891,-73.9851,STOP,,stop,\n"
+	if string(result.StopsCsv) != wantStops {
+		t.Errorf("stops got:\n%s\n!= want:\n%s", result.StopsCsv, wantStops)
+	}
+	wantShapes := "shape_id,shape_pt_sequence,shape_pt_lat,shape_pt_lon,shape_dist_traveled\n" +
+		"shape,0,40.7,-74,0\n" +
+		"shape,1,40.71,-74.00001,1234.6\n"
+	if string(result.ShapesCsv) != wantShapes {
+		t.Errorf("shapes got:\n%s\n!= want:\n%s", result.ShapesCsv, wantShapes)
+	}
+}
+
+func TestStaticExportToCsv_ServiceDatesWindow(t *testing.T) {
+	static := &Static{
+		Services: []Service{
+			{
+				Id:        "indefinite",
+				Saturday:  true,
+				StartDate: time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:   time.Date(2099, 12, 31, 0, 0, 0, 0, time.UTC),
+				AddedDates: []time.Time{
+					time.Date(2022, 5, 2, 0, 0, 0, 0, time.UTC),
+					time.Date(2022, 5, 12, 0, 0, 0, 0, time.UTC),
+					time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+	}
+
+	result, err := static.ExportToCsvWithOptions(CsvExportOptions{
+		ServiceDatesStart: time.Date(2022, 5, 5, 0, 0, 0, 0, time.UTC),
+		ServiceDatesEnd:   time.Date(2022, 5, 14, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("ExportToCsvWithOptions() failed: %s", err)
+	}
+
+	want := "service_id,date\n" +
+		"indefinite,2022-05-07\n" +
+		"indefinite,2022-05-12\n" +
+		"indefinite,2022-05-14\n"
+	if string(result.ServiceDatesCsv) != want {
+		t.Errorf("service dates got:\n%s\n!= want:\n%s", result.ServiceDatesCsv, want)
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	for _, tc := range []struct {
+		f         float64
+		bitSize   int
+		precision int
+		want      string
+	}{
+		{40.5, 64, 0, "40.5"},
+		{1e21, 64, 0, "1000000000000000000000"},
+		{40.5, 64, 5, "40.5"},
+		{40.123456, 64, 5, "40.12346"},
+		{100, 64, 2, "100"},
+		{-0.000001, 64, 5, "0"},
+		{float64(float32(40.1)), 32, 0, "40.1"},
+		{float64(float32(40.1)), 32, 3, "40.1"},
+	} {
+		if got := formatFloat(tc.f, tc.bitSize, tc.precision); got != tc.want {
+			t.Errorf("formatFloat(%v, %d, %d) got = %q, want = %q", tc.f, tc.bitSize, tc.precision, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package gtfs
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamespfennell/gtfs/constants"
+)
+
+// ReportFormat is the format of a report generated by [Static.Report].
+type ReportFormat int32
+
+const (
+	ReportFormat_Markdown ReportFormat = 0
+	ReportFormat_HTML     ReportFormat = 1
+)
+
+func (f ReportFormat) String() string {
+	switch f {
+	case ReportFormat_Markdown:
+		return "MARKDOWN"
+	case ReportFormat_HTML:
+		return "HTML"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Report returns a human-readable summary of the static message in the provided format.
+//
+// The summary contains the feed info, the number of entities of each kind, a table of the agencies,
+// a table of the routes with their colors, a matrix of the days of the week each service runs on,
+// and the number of warnings of each kind in each file, with an example message.
+// The HTML report is a fragment that can be embedded in a larger page.
+func (s *Static) Report(format ReportFormat) string {
+	var r reportWriter
+	if format == ReportFormat_HTML {
+		r = &htmlReportWriter{}
+	} else {
+		r = &markdownReportWriter{}
+	}
+
+	r.heading("GTFS static feed summary")
+	if feedInfo := s.FeedInfo; feedInfo != nil {
+		r.subheading("Feed info")
+		var dates string
+		if !feedInfo.StartDate.IsZero() || !feedInfo.EndDate.IsZero() {
+			dates = fmt.Sprintf("%s to %s", formatReportDate(feedInfo.StartDate), formatReportDate(feedInfo.EndDate))
+		}
+		r.table(
+			[]string{"Publisher", "Version", "Language", "Dates"},
+			[][]reportCell{{
+				{text: feedInfo.PublisherName, url: feedInfo.PublisherUrl},
+				{text: feedInfo.Version},
+				{text: feedInfo.Language},
+				{text: dates},
+			}},
+		)
+	}
+
+	r.subheading("Entities")
+	var numStopTimes int
+	_ = s.ForEachStopTime(func(ScheduledTrip, ScheduledStopTime) bool {
+		numStopTimes++
+		return true
+	})
+	var counts [][]reportCell
+	for _, count := range []struct {
+		name string
+		n    int
+	}{
+		{"Agencies", len(s.Agencies)},
+		{"Routes", len(s.Routes)},
+		{"Stops", len(s.Stops)},
+		{"Services", len(s.Services)},
+		{"Trips", len(s.Trips)},
+		{"Stop times", numStopTimes},
+		{"Shapes", len(s.Shapes)},
+		{"Transfers", len(s.Transfers)},
+		{"Pathways", len(s.Pathways)},
+	} {
+		counts = append(counts, []reportCell{{text: count.name}, {text: fmt.Sprint(count.n)}})
+	}
+	r.table([]string{"Entity", "Count"}, counts)
+
+	r.subheading("Agencies")
+	var agencies [][]reportCell
+	for _, agency := range s.Agencies {
+		agencies = append(agencies, []reportCell{
+			{text: agency.Id},
+			{text: agency.Name, url: agency.Url},
+			{text: agency.Timezone},
+		})
+	}
+	r.table([]string{"ID", "Name", "Timezone"}, agencies)
+
+	r.subheading("Routes")
+	var routes [][]reportCell
+	for _, route := range s.Routes {
+		routes = append(routes, []reportCell{
+			{text: route.Id},
+			{text: route.ShortName},
+			{text: route.LongName},
+			{text: route.Type.String()},
+			{text: route.Color, color: route.Color},
+			{text: route.TextColor, color: route.TextColor},
+		})
+	}
+	r.table([]string{"ID", "Short name", "Long name", "Type", "Color", "Text color"}, routes)
+
+	r.subheading("Services")
+	services := make([]*Service, len(s.Services))
+	for i := range s.Services {
+		services[i] = &s.Services[i]
+	}
+	sort.SliceStable(services, func(i, j int) bool { return services[i].Id < services[j].Id })
+	var calendar [][]reportCell
+	for _, service := range services {
+		row := []reportCell{{text: service.Id}}
+		for _, runs := range []bool{service.Monday, service.Tuesday, service.Wednesday, service.Thursday, service.Friday, service.Saturday, service.Sunday} {
+			var cell reportCell
+			if runs {
+				cell.text = "✓"
+			}
+			row = append(row, cell)
+		}
+		row = append(row,
+			reportCell{text: formatReportDate(service.StartDate)},
+			reportCell{text: formatReportDate(service.EndDate)},
+			reportCell{text: fmt.Sprint(len(service.AddedDates))},
+			reportCell{text: fmt.Sprint(len(service.RemovedDates))},
+		)
+		calendar = append(calendar, row)
+	}
+	r.table([]string{"ID", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun", "Start", "End", "Added dates", "Removed dates"}, calendar)
+
+	r.subheading("Warnings")
+	type warningKey struct {
+		file constants.StaticFile
+		code string
+	}
+	var keys []warningKey
+	keyToCount := map[warningKey]int{}
+	keyToExample := map[warningKey]string{}
+	for _, w := range s.Warnings {
+		key := warningKey{file: w.File, code: w.Kind.Code()}
+		if _, ok := keyToCount[key]; !ok {
+			keys = append(keys, key)
+			keyToExample[key] = w.Kind.Error()
+		}
+		keyToCount[key]++
+	}
+	var warningRows [][]reportCell
+	for _, key := range keys {
+		warningRows = append(warningRows, []reportCell{
+			{text: string(key.file)},
+			{text: key.code},
+			{text: fmt.Sprint(keyToCount[key])},
+			{text: keyToExample[key]},
+		})
+	}
+	r.table([]string{"File", "Code", "Count", "Example"}, warningRows)
+	if s.SuppressedWarnings > 0 {
+		r.paragraph(fmt.Sprintf("%d additional warnings were suppressed.", s.SuppressedWarnings))
+	}
+	return r.String()
+}
+
+// formatReportDate formats the date, or returns "?" if it is the zero time.
+func formatReportDate(t time.Time) string {
+	if t.IsZero() {
+		return "?"
+	}
+	return t.Format("2006-01-02")
+}
+
+type reportCell struct {
+	text string
+	// URL the text links to, if not empty.
+	url string
+	// Hex color shown next to the text, if not empty.
+	color string
+}
+
+type reportWriter interface {
+	heading(s string)
+	subheading(s string)
+	paragraph(s string)
+	table(header []string, rows [][]reportCell)
+	String() string
+}
+
+type markdownReportWriter struct {
+	strings.Builder
+}
+
+func (w *markdownReportWriter) heading(s string) {
+	fmt.Fprintf(w, "# %s\n\n", s)
+}
+
+func (w *markdownReportWriter) subheading(s string) {
+	fmt.Fprintf(w, "## %s\n\n", s)
+}
+
+func (w *markdownReportWriter) paragraph(s string) {
+	fmt.Fprintf(w, "%s\n\n", s)
+}
+
+func (w *markdownReportWriter) table(header []string, rows [][]reportCell) {
+	if len(rows) == 0 {
+		w.paragraph("None.")
+		return
+	}
+	w.row(header)
+	separators := make([]string, len(header))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	w.row(separators)
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			text := strings.NewReplacer("|", `\|`, "\n", " ").Replace(cell.text)
+			switch {
+			case cell.url != "" && text != "":
+				cells[i] = fmt.Sprintf("[%s](%s)", text, cell.url)
+			case cell.color != "":
+				cells[i] = "#" + text
+			default:
+				cells[i] = text
+			}
+		}
+		w.row(cells)
+	}
+	w.WriteString("\n")
+}
+
+func (w *markdownReportWriter) row(cells []string) {
+	fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+}
+
+type htmlReportWriter struct {
+	strings.Builder
+}
+
+func (w *htmlReportWriter) heading(s string) {
+	fmt.Fprintf(w, "<h1>%s</h1>\n", html.EscapeString(s))
+}
+
+func (w *htmlReportWriter) subheading(s string) {
+	fmt.Fprintf(w, "<h2>%s</h2>\n", html.EscapeString(s))
+}
+
+func (w *htmlReportWriter) paragraph(s string) {
+	fmt.Fprintf(w, "<p>%s</p>\n", html.EscapeString(s))
+}
+
+func (w *htmlReportWriter) table(header []string, rows [][]reportCell) {
+	if len(rows) == 0 {
+		w.paragraph("None.")
+		return
+	}
+	w.WriteString("<table>\n<tr>")
+	for _, h := range header {
+		fmt.Fprintf(w, "<th>%s</th>", html.EscapeString(h))
+	}
+	w.WriteString("</tr>\n")
+	for _, row := range rows {
+		w.WriteString("<tr>")
+		for _, cell := range row {
+			text := html.EscapeString(cell.text)
+			switch {
+			case cell.url != "" && text != "":
+				fmt.Fprintf(w, `<td><a href="%s">%s</a></td>`, html.EscapeString(cell.url), text)
+			case cell.color != "" && isHexColor(cell.color):
+				fmt.Fprintf(w, `<td><span style="display:inline-block;width:1em;height:1em;background-color:#%s"></span> #%s</td>`, cell.color, text)
+			default:
+				fmt.Fprintf(w, "<td>%s</td>", text)
+			}
+		}
+		w.WriteString("</tr>\n")
+	}
+	w.WriteString("</table>\n")
+}
+
+func isHexColor(s string) bool {
+	if len(s) != 6 {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9') && !('a' <= c && c <= 'f') && !('A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
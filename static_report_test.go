@@ -0,0 +1,68 @@
+package gtfs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"routes.txt",
+		"route_id,route_short_name,route_long_name,route_type,route_color",
+		"route_1,A,8 Av <Express>,1,0039A6",
+		"route_2,B|D,,3,",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"route_1,service_id,trip_1",
+		"route_3,service_id,trip_2",
+	).build()
+
+	static, err := ParseStatic(content, ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	if len(static.Warnings) == 0 {
+		t.Fatalf("expected parsing to produce warnings")
+	}
+	warningCode := static.Warnings[0].Kind.Code()
+
+	for _, tc := range []struct {
+		format ReportFormat
+		want   []string
+	}{
+		{
+			format: ReportFormat_Markdown,
+			want: []string{
+				"# GTFS static feed summary\n",
+				"| Agencies | 1 |\n",
+				"| Trips | 1 |\n",
+				"| a | [b](c) | d |\n",
+				"| route_1 | A | 8 Av <Express> | SUBWAY | #0039A6 | #000000 |\n",
+				`| route_2 | B\|D |  | BUS |  | #000000 |` + "\n",
+				"| service_id |  |  |  |  |  |  |  | 2022-05-04 | 2022-05-07 | 0 | 0 |\n",
+				"| trips.txt | " + warningCode + " | 1 |",
+			},
+		},
+		{
+			format: ReportFormat_HTML,
+			want: []string{
+				"<h1>GTFS static feed summary</h1>\n",
+				`<td>a</td><td><a href="c">b</a></td><td>d</td>`,
+				"<td>8 Av &lt;Express&gt;</td>",
+				`<span style="display:inline-block;width:1em;height:1em;background-color:#0039A6"></span> #0039A6`,
+				"<td>B|D</td>",
+				"<td>trips.txt</td><td>" + warningCode + "</td><td>1</td>",
+			},
+		},
+	} {
+		t.Run(tc.format.String(), func(t *testing.T) {
+			report := static.Report(tc.format)
+			for _, want := range tc.want {
+				if !strings.Contains(report, want) {
+					t.Errorf("report does not contain %q; full report:\n%s", want, report)
+				}
+			}
+		})
+	}
+}
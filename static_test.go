@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/jamespfennell/gtfs/constants"
 	"github.com/jamespfennell/gtfs/warnings"
 )
@@ -223,6 +224,19 @@ func TestParse(t *testing.T) {
 						ContinuousDropOff: PickupDropOffPolicy_No,
 					},
 				},
+				Warnings: []warnings.StaticWarning{
+					{
+						Kind: warnings.AgencyTimezoneDiffers{
+							AgencyID:         "e",
+							Timezone:         "h",
+							ExpectedTimezone: "d",
+						},
+						File:          constants.AgencyFile,
+						RowNumber:     2,
+						RowContent:    []string{"e", "f", "g", "h"},
+						HeaderContent: []string{"agency_id", "agency_name", "agency_url", "agency_timezone"},
+					},
+				},
 			},
 		},
 		{
@@ -286,6 +300,73 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "feed info",
+			content: newZipBuilder().add(
+				"feed_info.txt",
+				"feed_publisher_name,feed_publisher_url,feed_lang,default_lang,feed_contact_email,feed_contact_url\n"+
+					"a,b,mul,en,c,d",
+			).build(),
+			expected: &Static{
+				FeedInfo: &FeedInfo{
+					PublisherName:   "a",
+					PublisherUrl:    "b",
+					Language:        "mul",
+					DefaultLanguage: "en",
+					ContactEmail:    "c",
+					ContactUrl:      "d",
+				},
+			},
+		},
+		{
+			desc: "feed info with version and dates",
+			content: newZipBuilder().add(
+				"feed_info.txt",
+				"feed_publisher_name,feed_publisher_url,feed_lang,feed_version,feed_start_date,feed_end_date\n"+
+					"a,b,en,2024-03-01,20240301,not_a_date",
+			).build(),
+			expected: &Static{
+				FeedInfo: &FeedInfo{
+					PublisherName: "a",
+					PublisherUrl:  "b",
+					Language:      "en",
+					Version:       "2024-03-01",
+					StartDate:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			desc: "feed info missing publisher",
+			content: newZipBuilder().add(
+				"feed_info.txt",
+				"feed_publisher_name,feed_publisher_url,feed_lang\n,b,en",
+			).build(),
+			expected: &Static{},
+		},
+		{
+			desc: "stop parent cycle",
+			content: newZipBuilder().add(
+				"stops.txt",
+				"stop_id,parent_station\na,b\nb,a\nc,c",
+			).build(),
+			expected: &Static{
+				Stops: []Stop{
+					{
+						Id:     "a",
+						Parent: &Stop{Id: "b", Type: StopType_Platform},
+						Type:   StopType_Platform,
+					},
+					{
+						Id:   "b",
+						Type: StopType_Platform,
+					},
+					{
+						Id:   "c",
+						Type: StopType_Platform,
+					},
+				},
+			},
+		},
 		{
 			desc: "transfer",
 			content: newZipBuilder().add(
@@ -334,6 +415,15 @@ func TestParse(t *testing.T) {
 			).build(),
 			expected: &Static{
 				Stops: []Stop{{Id: "a"}},
+				Warnings: []warnings.StaticWarning{
+					{
+						Kind:          warnings.InvalidForeignID{Column: "to_stop_id", ID: "b"},
+						File:          constants.TransfersFile,
+						RowNumber:     1,
+						RowContent:    []string{"a", "b"},
+						HeaderContent: []string{"from_stop_id", "to_stop_id"},
+					},
+				},
 			},
 		},
 		{
@@ -347,6 +437,65 @@ func TestParse(t *testing.T) {
 			).build(),
 			expected: &Static{
 				Stops: []Stop{{Id: "b"}},
+				Warnings: []warnings.StaticWarning{
+					{
+						Kind:          warnings.InvalidForeignID{Column: "from_stop_id", ID: "a"},
+						File:          constants.TransfersFile,
+						RowNumber:     1,
+						RowContent:    []string{"a", "b"},
+						HeaderContent: []string{"from_stop_id", "to_stop_id"},
+					},
+				},
+			},
+		},
+		{
+			desc: "pathways.txt",
+			content: newZipBuilder().add(
+				"stops.txt",
+				"stop_id\na\nb",
+			).add(
+				"pathways.txt",
+				"pathway_id,from_stop_id,to_stop_id,pathway_mode,is_bidirectional,length,traversal_time,stair_count,max_slope,min_width,signposted_as,reversed_signposted_as\n"+
+					"p1,a,b,2,1,10.5,60,-20,,1.2,Platforms,Exit\n"+
+					"p2,b,a,5,0,,,,0.1,,,\n"+
+					"p3,a,c,1,1,,,,,,,",
+			).build(),
+			expected: &Static{
+				Stops: []Stop{
+					{Id: "a"},
+					{Id: "b"},
+				},
+				Pathways: []Pathway{
+					{
+						ID:                   "p1",
+						From:                 &Stop{Id: "a"},
+						To:                   &Stop{Id: "b"},
+						Mode:                 PathwayMode_Stairs,
+						IsBidirectional:      true,
+						Length:               ptr(10.5),
+						TraversalTime:        ptr(int32(60)),
+						StairCount:           ptr(int32(-20)),
+						MinWidth:             ptr(1.2),
+						SignpostedAs:         "Platforms",
+						ReversedSignpostedAs: "Exit",
+					},
+					{
+						ID:       "p2",
+						From:     &Stop{Id: "b"},
+						To:       &Stop{Id: "a"},
+						Mode:     PathwayMode_Elevator,
+						MaxSlope: ptr(0.1),
+					},
+				},
+				Warnings: []warnings.StaticWarning{
+					{
+						Kind:          warnings.InvalidForeignID{Column: "to_stop_id", ID: "c"},
+						File:          constants.PathwaysFile,
+						RowNumber:     3,
+						RowContent:    []string{"p3", "a", "c", "1", "1", "", "", "", "", "", "", ""},
+						HeaderContent: []string{"pathway_id", "from_stop_id", "to_stop_id", "pathway_mode", "is_bidirectional", "length", "traversal_time", "stair_count", "max_slope", "min_width", "signposted_as", "reversed_signposted_as"},
+					},
+				},
 			},
 		},
 		{
@@ -527,6 +676,51 @@ func TestParse(t *testing.T) {
 					},
 				},
 				Shapes: []Shape{},
+				Warnings: []warnings.StaticWarning{
+					{
+						Kind:          warnings.InvalidForeignID{Column: "shape_id", ID: "shape_id"},
+						File:          constants.TripsFile,
+						RowNumber:     1,
+						RowContent:    []string{"route_id", "service_id", "trip_id", "shape_id"},
+						HeaderContent: []string{"route_id", "service_id", "trip_id", "shape_id"},
+					},
+					defaultStopTimesWarning,
+				},
+			},
+		},
+		{
+			desc: "shape with invalid point",
+			content: newZipBuilderWithDefaults().add(
+				"shapes.txt",
+				"shape_id,shape_pt_lat,shape_pt_lon,shape_pt_sequence\nshape_id,north,2,0",
+			).add(
+				"trips.txt",
+				"route_id,service_id,trip_id,shape_id",
+				"route_id,service_id,trip_id,shape_id",
+			).build(),
+			expected: &Static{
+				Agencies: []Agency{defaultAgency},
+				Routes:   []Route{defaultRoute},
+				Services: []Service{defaultService},
+				Stops:    []Stop{defaultStop},
+				Trips: []ScheduledTrip{
+					{
+						Route:   &defaultRoute,
+						Service: &defaultService,
+						ID:      "trip_id",
+					},
+				},
+				Shapes: []Shape{},
+				Warnings: []warnings.StaticWarning{
+					{
+						Kind:          warnings.InvalidForeignID{Column: "shape_id", ID: "shape_id"},
+						File:          constants.TripsFile,
+						RowNumber:     1,
+						RowContent:    []string{"route_id", "service_id", "trip_id", "shape_id"},
+						HeaderContent: []string{"route_id", "service_id", "trip_id", "shape_id"},
+					},
+					defaultStopTimesWarning,
+				},
 			},
 		},
 		{
@@ -572,6 +766,7 @@ func TestParse(t *testing.T) {
 						},
 					},
 				},
+				Warnings: []warnings.StaticWarning{defaultStopTimesWarning},
 			},
 		},
 		{
@@ -635,6 +830,7 @@ func TestParse(t *testing.T) {
 						},
 					},
 				},
+				Warnings: []warnings.StaticWarning{defaultStopTimesWarning},
 			},
 		},
 		{
@@ -698,6 +894,7 @@ func TestParse(t *testing.T) {
 						},
 					},
 				},
+				Warnings: []warnings.StaticWarning{defaultStopTimesWarning},
 			},
 		},
 		{
@@ -818,6 +1015,7 @@ func TestParse(t *testing.T) {
 						},
 					},
 				},
+				Warnings: []warnings.StaticWarning{defaultStopTimesWarning},
 			},
 		},
 		{
@@ -885,6 +1083,7 @@ func TestParse(t *testing.T) {
 						},
 					},
 				},
+				Warnings: []warnings.StaticWarning{defaultStopTimesWarning},
 			},
 		},
 		{
@@ -899,6 +1098,7 @@ func TestParse(t *testing.T) {
 				Services: []Service{defaultService},
 				Stops:    []Stop{defaultStop},
 				Trips:    []ScheduledTrip{defaultTrip},
+				Warnings: []warnings.StaticWarning{defaultStopTimesWarning},
 			},
 		},
 		{
@@ -947,6 +1147,7 @@ func TestParse(t *testing.T) {
 						},
 					},
 				},
+				Warnings: []warnings.StaticWarning{defaultStopTimesWarning},
 			},
 		},
 		{
@@ -980,6 +1181,7 @@ func TestParse(t *testing.T) {
 						},
 					},
 				},
+				Warnings: []warnings.StaticWarning{defaultStopTimesWarning},
 			},
 		},
 		{
@@ -1009,6 +1211,7 @@ func TestParse(t *testing.T) {
 						},
 					},
 				},
+				Warnings: []warnings.StaticWarning{defaultStopTimesWarning},
 			},
 		},
 		{
@@ -1024,6 +1227,7 @@ func TestParse(t *testing.T) {
 				Services: []Service{defaultService},
 				Stops:    []Stop{defaultStop},
 				Trips:    []ScheduledTrip{defaultTrip},
+				Warnings: []warnings.StaticWarning{defaultStopTimesWarning},
 			},
 		},
 		{
@@ -1060,6 +1264,7 @@ func TestParse(t *testing.T) {
 						},
 					},
 				},
+				Warnings: []warnings.StaticWarning{defaultStopTimesWarning},
 			},
 		},
 		{
@@ -1167,19 +1372,461 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc:    "timezone override",
+			content: newZipBuilderWithDefaults().build(),
+			opts: ParseStaticOptions{
+				Timezone: time.FixedZone("UTC-5", -5*60*60),
+			},
+			expected: &Static{
+				Agencies: []Agency{defaultAgency},
+				Routes:   []Route{defaultRoute},
+				Stops:    []Stop{defaultStop},
+				Services: []Service{
+					{
+						Id:        "service_id",
+						StartDate: time.Date(2022, 5, 4, 0, 0, 0, 0, time.FixedZone("UTC-5", -5*60*60)),
+						EndDate:   time.Date(2022, 5, 7, 0, 0, 0, 0, time.FixedZone("UTC-5", -5*60*60)),
+					},
+				},
+				Trips: []ScheduledTrip{
+					{
+						ID:    "trip_id",
+						Route: &defaultRoute,
+						Service: &Service{
+							Id:        "service_id",
+							StartDate: time.Date(2022, 5, 4, 0, 0, 0, 0, time.FixedZone("UTC-5", -5*60*60)),
+							EndDate:   time.Date(2022, 5, 7, 0, 0, 0, 0, time.FixedZone("UTC-5", -5*60*60)),
+						},
+					},
+				},
+				Warnings: []warnings.StaticWarning{defaultStopTimesWarning},
+			},
+		},
+		{
+			desc: "source rows recorded",
+			content: newZipBuilder().add(
+				"stops.txt",
+				"stop_id",
+				"a",
+				"b",
+			).build(),
+			opts: ParseStaticOptions{
+				RecordSourceRows: true,
+			},
+			expected: &Static{
+				Stops: []Stop{
+					{
+						Id:        "a",
+						SourceRow: 1,
+					},
+					{
+						Id:        "b",
+						SourceRow: 2,
+					},
+				},
+			},
+		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			actual, err := ParseStatic(tc.content, tc.opts)
 			if err != nil {
 				t.Errorf("error when parsing: %s", err)
 			}
-			if diff := cmp.Diff(actual, tc.expected); diff != "" {
+			for i := range tc.expected.Trips {
+				trip := &tc.expected.Trips[i]
+				for j := range trip.StopTimes {
+					trip.StopTimes[j].Trip = trip
+				}
+			}
+			// The timezone is tested in TestParse_Timezone.
+			if diff := cmp.Diff(actual, tc.expected, cmpopts.IgnoreUnexported(Static{}), cmpopts.IgnoreFields(Static{}, "Timezone")); diff != "" {
 				t.Errorf("not the same: \ngot: %+v != \nwant:%+v\ndiff:%s", actual, tc.expected, diff)
 			}
 		})
 	}
 }
 
+func TestParse_SourceRows_ServicesAndShapes(t *testing.T) {
+	static, err := ParseStatic(newZipBuilder().add(
+		"calendar.txt",
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n"+
+			"weekday,1,1,1,1,1,0,0,20220504,20220510",
+	).add(
+		"calendar_dates.txt",
+		"service_id,date,exception_type\nweekday,20220512,1\nholiday,20220530,1\nholiday,20220704,1",
+	).add(
+		"shapes.txt",
+		"shape_id,shape_pt_sequence,shape_pt_lat,shape_pt_lon\n"+
+			"a,2,1,1\n"+
+			"b,1,1,1\n"+
+			"a,1,1,1",
+	).build(), ParseStaticOptions{RecordSourceRows: true})
+	if err != nil {
+		t.Fatalf("failed to parse static feed: %s", err)
+	}
+
+	gotServices := map[string]int{}
+	for _, service := range static.Services {
+		gotServices[service.Id] = service.SourceRow
+	}
+	wantServices := map[string]int{"weekday": 1, "holiday": 2}
+	if diff := cmp.Diff(gotServices, wantServices); diff != "" {
+		t.Errorf("service source rows diff: %s", diff)
+	}
+
+	gotShapes := map[string]int{}
+	for _, shape := range static.Shapes {
+		gotShapes[shape.ID] = shape.SourceRow
+	}
+	wantShapes := map[string]int{"a": 1, "b": 2}
+	if diff := cmp.Diff(gotShapes, wantShapes); diff != "" {
+		t.Errorf("shape source rows diff: %s", diff)
+	}
+}
+
+func TestParse_Timezone(t *testing.T) {
+	override := time.FixedZone("UTC-5", -5*60*60)
+	for _, tc := range []struct {
+		desc     string
+		timezone string
+		opts     ParseStaticOptions
+		want     string
+	}{
+		{"agency timezone", "America/New_York", ParseStaticOptions{}, "America/New_York"},
+		{"invalid agency timezone", "Not/A_Timezone", ParseStaticOptions{}, "UTC"},
+		{"override", "Not/A_Timezone", ParseStaticOptions{Timezone: override}, "UTC-5"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			content := newZipBuilderWithDefaults().add(
+				"agency.txt",
+				"agency_id,agency_name,agency_url,agency_timezone\na,b,c,"+tc.timezone,
+			).build()
+			static, err := ParseStatic(content, tc.opts)
+			if err != nil {
+				t.Fatalf("error when parsing: %s", err)
+			}
+			if static.Timezone == nil || static.Timezone.String() != tc.want {
+				t.Errorf("Timezone = %v, want %s", static.Timezone, tc.want)
+			}
+		})
+	}
+}
+
+func TestForEachStopTime(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"route_id,service_id,a",
+		"route_id,service_id,b",
+	).add(
+		"stop_times.txt",
+		"stop_id,trip_id,arrival_time,stop_sequence",
+		"stop_id,b,00:00:02,2",
+		"stop_id,b,00:00:01,1",
+		"stop_id,a,00:00:03,3",
+	).build()
+	type visit struct {
+		TripID       string
+		StopSequence int
+	}
+	for _, tc := range []struct {
+		desc            string
+		streamStopTimes bool
+		want            []visit
+	}{
+		{
+			desc:            "materialized",
+			streamStopTimes: false,
+			want:            []visit{{"a", 3}, {"b", 1}, {"b", 2}},
+		},
+		{
+			desc:            "streamed",
+			streamStopTimes: true,
+			want:            []visit{{"b", 2}, {"b", 1}, {"a", 3}},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			static, err := ParseStatic(content, ParseStaticOptions{StreamStopTimes: tc.streamStopTimes})
+			if err != nil {
+				t.Fatalf("error when parsing: %s", err)
+			}
+			if tc.streamStopTimes {
+				for _, trip := range static.Trips {
+					if len(trip.StopTimes) != 0 {
+						t.Errorf("trip %s has %d materialized stop times, want 0", trip.ID, len(trip.StopTimes))
+					}
+				}
+			}
+			for i := 0; i < 2; i++ {
+				var got []visit
+				err := static.ForEachStopTime(func(trip ScheduledTrip, stopTime ScheduledStopTime) bool {
+					got = append(got, visit{trip.ID, stopTime.StopSequence})
+					return true
+				})
+				if err != nil {
+					t.Fatalf("ForEachStopTime() err = %s", err)
+				}
+				if diff := cmp.Diff(got, tc.want); diff != "" {
+					t.Errorf("ForEachStopTime() got = %v, want = %v, diff: %s", got, tc.want, diff)
+				}
+			}
+
+			var n int
+			static.ForEachStopTime(func(trip ScheduledTrip, stopTime ScheduledStopTime) bool {
+				n++
+				return false
+			})
+			if n != 1 {
+				t.Errorf("ForEachStopTime() visited %d stop times after returning false, want 1", n)
+			}
+		})
+	}
+}
+
+func TestPreserveRawStopTimes(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"route_id,service_id,a",
+	).add(
+		"stop_times.txt",
+		"stop_id,trip_id,arrival_time,departure_time,stop_sequence",
+		"stop_id,a,,8:00:00,1",
+		"stop_id,a,25:01:00,25:02:00,2",
+	).build()
+	type rawTimes struct {
+		Arrival, Departure string
+	}
+	want := []rawTimes{{"", "8:00:00"}, {"25:01:00", "25:02:00"}}
+	for _, streamStopTimes := range []bool{false, true} {
+		static, err := ParseStatic(content, ParseStaticOptions{PreserveRawStopTimes: true, StreamStopTimes: streamStopTimes})
+		if err != nil {
+			t.Fatalf("error when parsing: %s", err)
+		}
+		var got []rawTimes
+		err = static.ForEachStopTime(func(trip ScheduledTrip, stopTime ScheduledStopTime) bool {
+			got = append(got, rawTimes{stopTime.RawArrival, stopTime.RawDeparture})
+			return true
+		})
+		if err != nil {
+			t.Fatalf("ForEachStopTime() err = %s", err)
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("raw stop times got = %v, want = %v, diff: %s", got, want, diff)
+		}
+	}
+}
+
+func TestParse_StopTimesNotGroupedByTrip(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"route_id,service_id,a",
+		"route_id,service_id,b",
+	).add(
+		"stop_times.txt",
+		"stop_id,trip_id,arrival_time,departure_time,stop_sequence",
+		"stop_id,a,8:00:00,8:00:00,1",
+		"stop_id,b,9:00:00,9:00:00,1",
+		"stop_id,a,8:10:00,8:10:00,3",
+		"stop_id,b,9:10:00,9:10:00,2",
+		"stop_id,a,8:05:00,8:05:00,2",
+	).build()
+
+	static, err := ParseStatic(content, ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+
+	wantStopSequences := map[string][]int{"a": {1, 2, 3}, "b": {1, 2}}
+	for _, trip := range static.Trips {
+		var got []int
+		for _, stopTime := range trip.StopTimes {
+			got = append(got, stopTime.StopSequence)
+		}
+		if diff := cmp.Diff(got, wantStopSequences[trip.ID]); diff != "" {
+			t.Errorf("stop sequences of trip %s got = %v, want = %v", trip.ID, got, wantStopSequences[trip.ID])
+		}
+	}
+	wantWarnings := []warnings.StaticWarning{
+		{
+			Kind:          warnings.StopTimesNotGroupedByTrip{TripID: "a"},
+			File:          constants.StopTimesFile,
+			RowNumber:     3,
+			RowContent:    []string{"stop_id", "a", "8:10:00", "8:10:00", "3"},
+			HeaderContent: []string{"stop_id", "trip_id", "arrival_time", "departure_time", "stop_sequence"},
+		},
+	}
+	if diff := cmp.Diff(static.Warnings, wantWarnings); diff != "" {
+		t.Errorf("warnings not the same: %s", diff)
+	}
+}
+
+func TestParse_PickupDropOffWindow(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"route_id,service_id,a",
+	).add(
+		"stop_times.txt",
+		"stop_id,trip_id,arrival_time,departure_time,stop_sequence,start_pickup_drop_off_window,end_pickup_drop_off_window",
+		"stop_id,a,8:00:00,8:00:00,1,,",
+		"stop_id,a,,,2,8:30:00,25:00:00",
+		"stop_id,a,,,3,9:00:00,",
+	).build()
+	type window struct {
+		Has        bool
+		Start, End time.Duration
+	}
+	want := []window{{}, {true, 8*time.Hour + 30*time.Minute, 25 * time.Hour}}
+	for _, streamStopTimes := range []bool{false, true} {
+		static, err := ParseStatic(content, ParseStaticOptions{StreamStopTimes: streamStopTimes})
+		if err != nil {
+			t.Fatalf("error when parsing: %s", err)
+		}
+		var got []window
+		err = static.ForEachStopTime(func(trip ScheduledTrip, stopTime ScheduledStopTime) bool {
+			got = append(got, window{stopTime.HasPickupDropOffWindow, stopTime.StartPickupDropOffWindow, stopTime.EndPickupDropOffWindow})
+			return true
+		})
+		if err != nil {
+			t.Fatalf("ForEachStopTime() err = %s", err)
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("pickup/drop off windows got = %v, want = %v, diff: %s", got, want, diff)
+		}
+	}
+}
+
+func TestParse_AgenciesWithDifferentTimezones(t *testing.T) {
+	content := newZipBuilder().add(
+		"agency.txt",
+		"agency_id,agency_name,agency_url,agency_timezone",
+		"a,b,c,America/New_York",
+		"d,e,f,America/Los_Angeles",
+	).add(
+		"routes.txt",
+		"route_id,agency_id,route_type",
+		"r1,a,3",
+		"r2,d,3",
+	).add(
+		"calendar.txt",
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date",
+		"s1,0,0,0,0,0,0,0,20220504,20220507",
+		"s2,0,0,0,0,0,0,0,20220504,20220507",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"r1,s1,t1",
+		"r2,s2,t2",
+	).build()
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tzdata not available: %s", err)
+	}
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("tzdata not available: %s", err)
+	}
+
+	static, err := ParseStatic(content, ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+
+	wantStartDates := map[string]time.Time{
+		"t1": time.Date(2022, 5, 4, 0, 0, 0, 0, newYork),
+		"t2": time.Date(2022, 5, 4, 0, 0, 0, 0, losAngeles),
+	}
+	for _, trip := range static.Trips {
+		if !trip.Service.StartDate.Equal(wantStartDates[trip.ID]) {
+			t.Errorf("trip %s service start date got = %s, want = %s", trip.ID, trip.Service.StartDate, wantStartDates[trip.ID])
+		}
+	}
+	wantWarnings := []warnings.StaticWarning{
+		{
+			Kind: warnings.AgencyTimezoneDiffers{
+				AgencyID:         "d",
+				Timezone:         "America/Los_Angeles",
+				ExpectedTimezone: "America/New_York",
+			},
+			File:          constants.AgencyFile,
+			RowNumber:     2,
+			RowContent:    []string{"d", "e", "f", "America/Los_Angeles"},
+			HeaderContent: []string{"agency_id", "agency_name", "agency_url", "agency_timezone"},
+		},
+	}
+	if diff := cmp.Diff(static.Warnings, wantWarnings); diff != "" {
+		t.Errorf("warnings not the same: %s", diff)
+	}
+}
+
+func TestParse_DeduplicateAndCapWarnings(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id,zone_id",
+		"stop_1,zone_1",
+	).add(
+		// The default stop time references stops and trips that are not in this feed.
+		"stop_times.txt",
+		"stop_id,trip_id,arrival_time,departure_time,stop_sequence",
+	).add(
+		"fare_rules.txt",
+		"fare_id,route_id,origin_id",
+		"fare_1,route_2,",
+		"fare_2,route_3,",
+		"fare_3,,zone_2",
+	).build()
+
+	for _, tc := range []struct {
+		name           string
+		opts           ParseStaticOptions
+		wantRows       []int
+		wantSuppressed int
+		wantCounts     map[string]int
+	}{
+		{
+			name:     "default",
+			wantRows: []int{1, 2, 3},
+		},
+		{
+			name:           "deduplicate",
+			opts:           ParseStaticOptions{DeduplicateWarnings: true},
+			wantRows:       []int{1},
+			wantSuppressed: 2,
+			wantCounts:     map[string]int{"invalid_foreign_id": 3},
+		},
+		{
+			name:           "max warnings",
+			opts:           ParseStaticOptions{MaxWarnings: 2},
+			wantRows:       []int{1, 2},
+			wantSuppressed: 1,
+			wantCounts:     map[string]int{"invalid_foreign_id": 3},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			static, err := ParseStatic(content, tc.opts)
+			if err != nil {
+				t.Fatalf("error when parsing: %s", err)
+			}
+			var gotRows []int
+			for _, w := range static.Warnings {
+				gotRows = append(gotRows, w.RowNumber)
+			}
+			if diff := cmp.Diff(gotRows, tc.wantRows); diff != "" {
+				t.Errorf("warning rows diff: %s", diff)
+			}
+			if static.SuppressedWarnings != tc.wantSuppressed {
+				t.Errorf("SuppressedWarnings = %d, want %d", static.SuppressedWarnings, tc.wantSuppressed)
+			}
+			if diff := cmp.Diff(static.WarningCounts, tc.wantCounts); diff != "" {
+				t.Errorf("WarningCounts diff: %s", diff)
+			}
+		})
+	}
+}
+
 type zipBuilder struct {
 	m map[string]string
 }
@@ -1200,6 +1847,16 @@ func newZipBuilder() *zipBuilder {
 	)
 }
 
+// defaultStopTimesWarning is the warning raised for the stop time added by newZipBuilderWithDefaults,
+// which references a trip that does not exist.
+var defaultStopTimesWarning = warnings.StaticWarning{
+	Kind:          warnings.InvalidForeignID{Column: "trip_id", ID: "a"},
+	File:          constants.StopTimesFile,
+	RowNumber:     1,
+	RowContent:    []string{"stop_id", "a", "04:05:06", "13:14:15", "50", "b"},
+	HeaderContent: []string{"stop_id", "trip_id", "arrival_time", "departure_time", "stop_sequence", "stop_headsign"},
+}
+
 func newZipBuilderWithDefaults() *zipBuilder {
 	return newZipBuilder().add(
 		"agency.txt",
@@ -1249,3 +1906,90 @@ func (z *zipBuilder) build() []byte {
 func ptr[T any](t T) *T {
 	return &t
 }
+
+func TestNilSafeGetters(t *testing.T) {
+	var nilTrip *ScheduledTrip
+	orphan := &ScheduledTrip{ID: "orphan"}
+	for _, trip := range []*ScheduledTrip{nilTrip, orphan} {
+		if got := trip.GetRoute(); got.Id != "" {
+			t.Errorf("GetRoute() = %+v, want zero route", got)
+		}
+		if got := trip.GetService(); got.Id != "" {
+			t.Errorf("GetService() = %+v, want zero service", got)
+		}
+		if got := trip.GetShape(); got.ID != "" {
+			t.Errorf("GetShape() = %+v, want zero shape", got)
+		}
+		if got := trip.GetRoute(); got.GetAgency().Id != "" {
+			t.Errorf("GetRoute().GetAgency() = %+v, want zero agency", got.GetAgency())
+		}
+	}
+
+	agency := &Agency{Id: "agency"}
+	trip := &ScheduledTrip{
+		Route:   &Route{Id: "route", Agency: agency},
+		Service: &Service{Id: "service"},
+		Shape:   &Shape{ID: "shape"},
+	}
+	if got := trip.GetRoute(); got.Id != "route" {
+		t.Errorf("GetRoute() = %+v, want route", got)
+	}
+	if got := trip.GetService(); got.Id != "service" {
+		t.Errorf("GetService() = %+v, want service", got)
+	}
+	if got := trip.GetShape(); got.ID != "shape" {
+		t.Errorf("GetShape() = %+v, want shape", got)
+	}
+	if got := trip.Route.GetAgency(); got.Id != "agency" {
+		t.Errorf("GetAgency() = %+v, want agency", got)
+	}
+}
+
+func TestAppendStops(t *testing.T) {
+	static := &Static{
+		Stops: make([]Stop, 2),
+		Trips: []ScheduledTrip{{ID: "trip"}},
+	}
+	static.Stops[0] = Stop{Id: "station"}
+	static.Stops[1] = Stop{Id: "platform", Parent: &static.Stops[0]}
+	static.Transfers = []Transfer{{From: &static.Stops[1], To: &static.Stops[1]}}
+	static.Trips[0].StopTimes = []ScheduledStopTime{{Stop: &static.Stops[1]}}
+	if static.StopByID("new") != nil {
+		t.Fatalf("StopByID(new) != nil before the stop was appended")
+	}
+
+	got := static.AppendStops(Stop{Id: "new", Parent: &static.Stops[0]})
+
+	if got[0] != &static.Stops[2] {
+		t.Errorf("AppendStops() did not return a pointer to the appended stop")
+	}
+	station, platform := &static.Stops[0], &static.Stops[1]
+	if platform.Parent != station || static.Stops[2].Parent != station {
+		t.Errorf("stop parents not re-linked")
+	}
+	if static.Transfers[0].From != platform || static.Transfers[0].To != platform {
+		t.Errorf("transfer stops not re-linked")
+	}
+	if static.Trips[0].StopTimes[0].Stop != platform {
+		t.Errorf("stop time stop not re-linked")
+	}
+	if static.StopByID("new") != got[0] || static.StopByID("station") != station {
+		t.Errorf("StopByID() does not reflect the appended stops")
+	}
+}
+
+func TestAppendStops_ParentInAppendedStops(t *testing.T) {
+	stops := make([]Stop, 2)
+	stops[0] = Stop{Id: "station"}
+	stops[1] = Stop{Id: "platform", Parent: &stops[0]}
+	static := &Static{}
+
+	got := static.AppendStops(stops...)
+
+	if got[0] != &static.Stops[0] || got[1] != &static.Stops[1] {
+		t.Fatalf("AppendStops() did not return pointers to the appended stops")
+	}
+	if static.Stops[1].Parent != &static.Stops[0] {
+		t.Errorf("parent of appended stop references the provided slice, not the message")
+	}
+}
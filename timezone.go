@@ -0,0 +1,35 @@
+package gtfs
+
+import (
+	"sync"
+	"time"
+)
+
+// timezoneCache maps timezone names to successfully loaded locations.
+//
+// Only valid names are cached, so the size of the cache is bounded by the number of timezones in the
+// tzdata and arbitrary invalid names in feeds do not grow it.
+var timezoneCache sync.Map
+
+// loadLocation is a cached version of time.LoadLocation.
+//
+// Loading a location involves reading and parsing the host's tzdata which is comparatively slow,
+// and the same handful of timezones are loaded every time a feed is parsed.
+func loadLocation(name string) (*time.Location, error) {
+	if location, ok := timezoneCache.Load(name); ok {
+		return location.(*time.Location), nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	timezoneCache.Store(name, location)
+	return location, nil
+}
+
+// Location returns the timezone of the agency.
+//
+// Locations are cached, so this is cheaper than calling time.LoadLocation with the agency's timezone.
+func (agency *Agency) Location() (*time.Location, error) {
+	return loadLocation(agency.Timezone)
+}
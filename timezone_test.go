@@ -0,0 +1,33 @@
+package gtfs
+
+import "testing"
+
+func TestLoadLocation_InvalidNameNotCached(t *testing.T) {
+	if _, err := loadLocation("Not/A_Timezone"); err == nil {
+		t.Fatalf("loadLocation() err = nil, want error")
+	}
+	if _, ok := timezoneCache.Load("Not/A_Timezone"); ok {
+		t.Errorf("invalid timezone was cached")
+	}
+	location, err := loadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("loadLocation() err = %v", err)
+	}
+	if cached, ok := timezoneCache.Load("America/New_York"); !ok || cached != location {
+		t.Errorf("valid timezone was not cached")
+	}
+}
+
+func TestAgencyLocation(t *testing.T) {
+	agency := Agency{Timezone: "America/New_York"}
+	location, err := agency.Location()
+	if err != nil {
+		t.Fatalf("Location() err = %v", err)
+	}
+	if location.String() != "America/New_York" {
+		t.Errorf("Location() = %s, want America/New_York", location)
+	}
+	if _, err := (&Agency{Timezone: "Not/A_Timezone"}).Location(); err == nil {
+		t.Errorf("Location() err = nil, want error")
+	}
+}
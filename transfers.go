@@ -0,0 +1,162 @@
+package gtfs
+
+// TransferSource describes where a transfer returned by [Static.TransfersFrom] comes from.
+type TransferSource int32
+
+const (
+	// The transfer is listed in transfers.txt.
+	TransferSource_TransfersFile TransferSource = 0
+	// The transfer is implied by the two stops having the same parent station.
+	TransferSource_ParentStation TransferSource = 1
+	// The transfer is implied by the two stops being connected by pathways in pathways.txt.
+	TransferSource_Pathway TransferSource = 2
+)
+
+func (s TransferSource) String() string {
+	switch s {
+	case TransferSource_TransfersFile:
+		return "TRANSFERS_FILE"
+	case TransferSource_ParentStation:
+		return "PARENT_STATION"
+	case TransferSource_Pathway:
+		return "PATHWAY"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ReachableStop is a stop that can be reached from another stop by transferring.
+type ReachableStop struct {
+	Stop            *Stop
+	Type            TransferType
+	MinTransferTime *int32
+	Source          TransferSource
+}
+
+// TransfersFrom returns the stops that can be reached from the provided stop by transferring.
+//
+// The result merges transfers listed in transfers.txt with implicit transfers to stops connected
+// to the provided stop by pathways, and to stops that have the same parent station. Transfers in
+// transfers.txt whose from stop is the provided stop or one of its ancestors are used, and these
+// take precedence over implicit transfers to the same stop. Transfers of type
+// [TransferType_NotPossible] are not returned, and suppress implicit transfers to the same stop.
+//
+// Pathway transfers take precedence over parent station transfers. The minimum transfer time of a
+// pathway transfer is the total traversal time of the fastest sequence of pathways to the stop, and
+// is nil if a pathway in the sequence does not have a traversal time.
+func (s *Static) TransfersFrom(stop *Stop) []ReachableStop {
+	if stop == nil {
+		return nil
+	}
+	idx := s.index()
+	var result []ReachableStop
+	seen := map[*Stop]bool{stop: true}
+	for from := stop; from != nil; from = from.Parent {
+		for _, transfer := range idx.transfersByFromStop[from] {
+			if seen[transfer.To] {
+				continue
+			}
+			seen[transfer.To] = true
+			if transfer.Type == TransferType_NotPossible {
+				continue
+			}
+			result = append(result, ReachableStop{
+				Stop:            transfer.To,
+				Type:            transfer.Type,
+				MinTransferTime: transfer.MinTransferTime,
+				Source:          TransferSource_TransfersFile,
+			})
+		}
+	}
+	for _, reachable := range idx.stopsByPathway(stop) {
+		if seen[reachable.stop] {
+			continue
+		}
+		seen[reachable.stop] = true
+		if reachable.stop.Type != StopType_Stop && reachable.stop.Type != StopType_Platform {
+			continue
+		}
+		transfer := ReachableStop{
+			Stop:   reachable.stop,
+			Type:   TransferType_Recommended,
+			Source: TransferSource_Pathway,
+		}
+		if reachable.timeKnown {
+			transfer.Type = TransferType_RequiresTime
+			transfer.MinTransferTime = &reachable.time
+		}
+		result = append(result, transfer)
+	}
+	if stop.Parent != nil {
+		for _, other := range idx.stopsByRoot[stop.Root()] {
+			if seen[other] {
+				continue
+			}
+			seen[other] = true
+			if other.Type != StopType_Stop && other.Type != StopType_Platform {
+				continue
+			}
+			result = append(result, ReachableStop{
+				Stop:   other,
+				Type:   TransferType_Recommended,
+				Source: TransferSource_ParentStation,
+			})
+		}
+	}
+	return result
+}
+
+type pathwayReachableStop struct {
+	stop      *Stop
+	time      int32
+	timeKnown bool
+}
+
+// stopsByPathway returns the stops reachable from the stop through pathways, ordered by the total
+// traversal time of the fastest sequence of pathways to each. Pathways without a traversal time are
+// treated as taking no time.
+func (idx *staticIndex) stopsByPathway(stop *Stop) []pathwayReachableStop {
+	if len(idx.pathwaysByFromStop) == 0 {
+		return nil
+	}
+	best := map[*Stop]*pathwayReachableStop{stop: {stop: stop, timeKnown: true}}
+	done := map[*Stop]bool{}
+	var result []pathwayReachableStop
+	for {
+		// Station pathway graphs are small, so the next stop is found with a linear scan.
+		var next *pathwayReachableStop
+		for _, candidate := range best {
+			if done[candidate.stop] {
+				continue
+			}
+			if next == nil || candidate.time < next.time || (candidate.time == next.time && candidate.stop.Id < next.stop.Id) {
+				next = candidate
+			}
+		}
+		if next == nil {
+			return result
+		}
+		done[next.stop] = true
+		if next.stop != stop {
+			result = append(result, *next)
+		}
+		for _, pathway := range idx.pathwaysByFromStop[next.stop] {
+			to := pathway.To
+			if to == next.stop {
+				to = pathway.From
+			}
+			if to == nil || done[to] {
+				continue
+			}
+			candidate := pathwayReachableStop{stop: to, time: next.time, timeKnown: next.timeKnown}
+			if pathway.TraversalTime != nil {
+				candidate.time += *pathway.TraversalTime
+			} else {
+				candidate.timeKnown = false
+			}
+			if current, ok := best[to]; !ok || candidate.time < current.time {
+				best[to] = &candidate
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTransfersFrom(t *testing.T) {
+	static := &Static{
+		Stops: []Stop{
+			{Id: "station", Type: StopType_Station},
+			{Id: "platform1", Type: StopType_Platform},
+			{Id: "platform2", Type: StopType_Platform},
+			{Id: "platform3", Type: StopType_Platform},
+			{Id: "entrance", Type: StopType_EntranceOrExit},
+			{Id: "other"},
+			{Id: "blocked"},
+		},
+	}
+	station := &static.Stops[0]
+	for i := 1; i <= 4; i++ {
+		static.Stops[i].Parent = station
+	}
+	platform1, platform2, platform3 := &static.Stops[1], &static.Stops[2], &static.Stops[3]
+	other, blocked := &static.Stops[5], &static.Stops[6]
+	static.Transfers = []Transfer{
+		{From: platform1, To: platform2, Type: TransferType_RequiresTime, MinTransferTime: ptr(int32(120))},
+		{From: station, To: other, Type: TransferType_Recommended},
+		{From: platform1, To: blocked, Type: TransferType_NotPossible},
+		{From: platform1, To: platform3, Type: TransferType_NotPossible},
+	}
+
+	got := static.TransfersFrom(platform1)
+
+	want := []ReachableStop{
+		{Stop: platform2, Type: TransferType_RequiresTime, MinTransferTime: ptr(int32(120)), Source: TransferSource_TransfersFile},
+		{Stop: other, Type: TransferType_Recommended, Source: TransferSource_TransfersFile},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("TransfersFrom() got = %v, want = %v, diff: %s", got, want, diff)
+	}
+
+	got = static.TransfersFrom(platform2)
+
+	want = []ReachableStop{
+		{Stop: other, Type: TransferType_Recommended, Source: TransferSource_TransfersFile},
+		{Stop: platform1, Type: TransferType_Recommended, Source: TransferSource_ParentStation},
+		{Stop: platform3, Type: TransferType_Recommended, Source: TransferSource_ParentStation},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("TransfersFrom() got = %v, want = %v, diff: %s", got, want, diff)
+	}
+}
+
+func TestTransfersFrom_Pathways(t *testing.T) {
+	static := &Static{
+		Stops: []Stop{
+			{Id: "station", Type: StopType_Station},
+			{Id: "platform1", Type: StopType_Platform},
+			{Id: "platform2", Type: StopType_Platform},
+			{Id: "platform3", Type: StopType_Platform},
+			{Id: "node", Type: StopType_GenericNode},
+			{Id: "platform4", Type: StopType_Platform},
+		},
+	}
+	station := &static.Stops[0]
+	for i := 1; i < len(static.Stops); i++ {
+		static.Stops[i].Parent = station
+	}
+	platform1, platform2, platform3 := &static.Stops[1], &static.Stops[2], &static.Stops[3]
+	node, platform4 := &static.Stops[4], &static.Stops[5]
+	static.Pathways = []Pathway{
+		{ID: "1", From: platform1, To: node, IsBidirectional: true, TraversalTime: ptr(int32(30))},
+		{ID: "2", From: node, To: platform2, IsBidirectional: true, TraversalTime: ptr(int32(60))},
+		{ID: "3", From: platform3, To: node, IsBidirectional: true, TraversalTime: ptr(int32(20))},
+		{ID: "4", From: platform1, To: platform2, IsBidirectional: true, TraversalTime: ptr(int32(200))},
+		// One way, and so not usable from platform1.
+		{ID: "5", From: platform4, To: platform1, TraversalTime: ptr(int32(10))},
+		{ID: "6", From: platform2, To: platform4, IsBidirectional: true},
+	}
+	static.Transfers = []Transfer{
+		{From: platform1, To: platform3, Type: TransferType_RequiresTime, MinTransferTime: ptr(int32(180))},
+	}
+
+	got := static.TransfersFrom(platform1)
+
+	want := []ReachableStop{
+		{Stop: platform3, Type: TransferType_RequiresTime, MinTransferTime: ptr(int32(180)), Source: TransferSource_TransfersFile},
+		{Stop: platform2, Type: TransferType_RequiresTime, MinTransferTime: ptr(int32(90)), Source: TransferSource_Pathway},
+		{Stop: platform4, Type: TransferType_Recommended, Source: TransferSource_Pathway},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("TransfersFrom() got = %v, want = %v, diff: %s", got, want, diff)
+	}
+}
@@ -0,0 +1,172 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+)
+
+// CompressServices rewrites each service as a weekly pattern plus exceptions, using the fewest exceptions.
+//
+// Feeds that only use calendar_dates.txt list every date on which each service runs. This function
+// finds the days of the week on which each service usually runs and replaces the added dates by
+// a weekly pattern between the first and last active dates, adding and removing dates where the service
+// deviates from the pattern. The rewritten services run on exactly the same dates as before, as
+// determined by [gtfs.Service.RunsOn]. Services are only rewritten if this reduces the number of
+// exceptions. The message is modified in place.
+func CompressServices(static *gtfs.Static) {
+	for i := range static.Services {
+		service := &static.Services[i]
+		compressed, ok := compressService(service)
+		if !ok {
+			continue
+		}
+		if len(compressed.AddedDates)+len(compressed.RemovedDates) < len(service.AddedDates)+len(service.RemovedDates) {
+			*service = compressed
+		}
+	}
+}
+
+func compressService(service *gtfs.Service) (gtfs.Service, bool) {
+	dates := activeDates(service)
+	if len(dates) == 0 {
+		return gtfs.Service{}, false
+	}
+	first, last := dates[0], dates[len(dates)-1]
+	active := map[int64]bool{}
+	for _, date := range dates {
+		active[date.Unix()] = true
+	}
+	var numDays, numActive [7]int
+	forEachDay(first, last, func(day time.Time) {
+		numDays[day.Weekday()]++
+		if active[day.Unix()] {
+			numActive[day.Weekday()]++
+		}
+	})
+	// A weekday is part of the pattern if the service runs on most of its occurrences, as this
+	// minimizes the number of exceptions needed for that weekday.
+	var runs [7]bool
+	for weekday := range runs {
+		runs[weekday] = 2*numActive[weekday] > numDays[weekday]
+	}
+	compressed := gtfs.Service{
+		Id:        service.Id,
+		Sunday:    runs[time.Sunday],
+		Monday:    runs[time.Monday],
+		Tuesday:   runs[time.Tuesday],
+		Wednesday: runs[time.Wednesday],
+		Thursday:  runs[time.Thursday],
+		Friday:    runs[time.Friday],
+		Saturday:  runs[time.Saturday],
+		StartDate: first,
+		EndDate:   last,
+	}
+	forEachDay(first, last, func(day time.Time) {
+		switch {
+		case active[day.Unix()] && !runs[day.Weekday()]:
+			compressed.AddedDates = append(compressed.AddedDates, day)
+		case !active[day.Unix()] && runs[day.Weekday()]:
+			compressed.RemovedDates = append(compressed.RemovedDates, day)
+		}
+	})
+	return compressed, true
+}
+
+// activeDates returns the dates on which the service runs, in increasing order.
+//
+// Dates are midnight in the location of the service's dates.
+func activeDates(service *gtfs.Service) []time.Time {
+	var first, last time.Time
+	for _, date := range append([]time.Time{service.StartDate, service.EndDate}, service.AddedDates...) {
+		if date.IsZero() {
+			continue
+		}
+		if first.IsZero() || date.Before(first) {
+			first = date
+		}
+		if last.IsZero() || date.After(last) {
+			last = date
+		}
+	}
+	if first.IsZero() {
+		return nil
+	}
+	var dates []time.Time
+	forEachDay(first, last, func(day time.Time) {
+		if service.RunsOn(day) {
+			dates = append(dates, day)
+		}
+	})
+	return dates
+}
+
+// forEachDay invokes f for midnight of each day from first to last inclusive, in the location of first.
+func forEachDay(first, last time.Time, f func(day time.Time)) {
+	y, m, d := first.Date()
+	for day := time.Date(y, m, d, 0, 0, 0, 0, first.Location()); !day.After(last); day = day.AddDate(0, 0, 1) {
+		f(day)
+	}
+}
+
+// DeduplicateServices merges services that run on exactly the same dates.
+//
+// For each set of services with the same active dates, the service with the smallest ID is kept
+// and trips and booking rules using the other services are rewritten to use it. The other services
+// are removed.
+// The returned map is from the ID of each removed service to the ID of the service that replaced it.
+// The message is modified in place.
+func DeduplicateServices(static *gtfs.Static) map[string]string {
+	keys := make([]string, len(static.Services))
+	canonical := map[string]*gtfs.Service{}
+	for i := range static.Services {
+		service := &static.Services[i]
+		keys[i] = datesKey(activeDates(service))
+		if c, ok := canonical[keys[i]]; !ok || service.Id < c.Id {
+			canonical[keys[i]] = service
+		}
+	}
+	mapping := map[string]string{}
+	services := make([]gtfs.Service, 0, len(canonical))
+	canonicalToNew := map[*gtfs.Service]*gtfs.Service{}
+	for i := range static.Services {
+		service := &static.Services[i]
+		if c := canonical[keys[i]]; c != service {
+			mapping[service.Id] = c.Id
+			continue
+		}
+		services = append(services, *service)
+		canonicalToNew[service] = &services[len(services)-1]
+	}
+	if len(mapping) == 0 {
+		return mapping
+	}
+	oldToNew := map[*gtfs.Service]*gtfs.Service{}
+	for i := range static.Services {
+		oldToNew[&static.Services[i]] = canonicalToNew[canonical[keys[i]]]
+	}
+	for i := range static.Trips {
+		if service, ok := oldToNew[static.Trips[i].Service]; ok {
+			static.Trips[i].Service = service
+		}
+	}
+	for i := range static.BookingRules {
+		if service, ok := oldToNew[static.BookingRules[i].PriorNoticeService]; ok {
+			static.BookingRules[i].PriorNoticeService = service
+		}
+	}
+	static.Services = services
+	return mapping
+}
+
+// datesKey returns a string that uniquely identifies the set of dates.
+func datesKey(dates []time.Time) string {
+	var b strings.Builder
+	for _, date := range dates {
+		y, m, d := date.Date()
+		fmt.Fprintf(&b, "%04d%02d%02d,", y, m, d)
+	}
+	return b.String()
+}
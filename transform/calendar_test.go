@@ -0,0 +1,112 @@
+package transform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs"
+)
+
+func TestCompressServices(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2022, 5, d, 0, 0, 0, 0, time.UTC)
+	}
+	// Weekdays from Monday 2 May to Friday 20 May, except Wednesday 11 May, plus Saturday 14 May.
+	var weekdayDates []time.Time
+	for d := 2; d <= 20; d++ {
+		if weekday := day(d).Weekday(); weekday == time.Saturday || weekday == time.Sunday || d == 11 {
+			continue
+		}
+		weekdayDates = append(weekdayDates, day(d))
+	}
+	weekdayDates = append(weekdayDates, day(14))
+	original := gtfs.Service{
+		Id:         "weekday",
+		StartDate:  day(2),
+		EndDate:    day(20),
+		AddedDates: weekdayDates,
+	}
+	alreadyCompact := gtfs.Service{
+		Id:        "compact",
+		Monday:    true,
+		StartDate: day(2),
+		EndDate:   day(20),
+	}
+	static := &gtfs.Static{
+		Services: []gtfs.Service{original, alreadyCompact},
+	}
+
+	CompressServices(static)
+
+	want := []gtfs.Service{
+		{
+			Id:           "weekday",
+			Monday:       true,
+			Tuesday:      true,
+			Wednesday:    true,
+			Thursday:     true,
+			Friday:       true,
+			StartDate:    day(2),
+			EndDate:      day(20),
+			AddedDates:   []time.Time{day(14)},
+			RemovedDates: []time.Time{day(11)},
+		},
+		alreadyCompact,
+	}
+	if diff := cmp.Diff(static.Services, want); diff != "" {
+		t.Errorf("CompressServices() got = %v, want = %v, diff: %s", static.Services, want, diff)
+	}
+	for d := 1; d <= 31; d++ {
+		if got, want := static.Services[0].RunsOn(day(d)), original.RunsOn(day(d)); got != want {
+			t.Errorf("RunsOn(%s) got = %t, want = %t", day(d).Format("2006-01-02"), got, want)
+		}
+	}
+}
+
+func TestDeduplicateServices(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2022, 5, d, 0, 0, 0, 0, time.UTC)
+	}
+	static := &gtfs.Static{
+		Services: []gtfs.Service{
+			// Runs on Monday 2 May and Monday 9 May.
+			{Id: "c", AddedDates: []time.Time{day(2), day(9)}, StartDate: day(2), EndDate: day(9)},
+			{Id: "a", Monday: true, StartDate: day(1), EndDate: day(14)},
+			{Id: "b", AddedDates: []time.Time{day(3)}, StartDate: day(3), EndDate: day(3)},
+		},
+	}
+	static.Trips = []gtfs.ScheduledTrip{
+		{ID: "trip1", Service: &static.Services[0]},
+		{ID: "trip2", Service: &static.Services[1]},
+		{ID: "trip3", Service: &static.Services[2]},
+	}
+	static.BookingRules = []gtfs.BookingRule{
+		{ID: "booking_rule1", PriorNoticeService: &static.Services[0]},
+		{ID: "booking_rule2", PriorNoticeService: &static.Services[2]},
+		{ID: "booking_rule3"},
+	}
+
+	mapping := DeduplicateServices(static)
+
+	if want := map[string]string{"c": "a"}; !cmp.Equal(mapping, want) {
+		t.Errorf("DeduplicateServices() mapping got = %v, want = %v", mapping, want)
+	}
+	var gotServiceIDs []string
+	for _, service := range static.Services {
+		gotServiceIDs = append(gotServiceIDs, service.Id)
+	}
+	if want := []string{"a", "b"}; !cmp.Equal(gotServiceIDs, want) {
+		t.Errorf("DeduplicateServices() services got = %v, want = %v", gotServiceIDs, want)
+	}
+	for i, want := range []*gtfs.Service{&static.Services[0], &static.Services[0], &static.Services[1]} {
+		if got := static.Trips[i].Service; got != want {
+			t.Errorf("trip %s service got = %v, want = %v", static.Trips[i].ID, got, want)
+		}
+	}
+	for i, want := range []*gtfs.Service{&static.Services[0], &static.Services[1], nil} {
+		if got := static.BookingRules[i].PriorNoticeService; got != want {
+			t.Errorf("booking rule %s prior notice service got = %v, want = %v", static.BookingRules[i].ID, got, want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package transform
+
+import "github.com/jamespfennell/gtfs"
+
+// IDKind is the kind of entity an ID identifies.
+type IDKind int32
+
+const (
+	IDKind_Agency  IDKind = 0
+	IDKind_Route   IDKind = 1
+	IDKind_Stop    IDKind = 2
+	IDKind_Trip    IDKind = 3
+	IDKind_Service IDKind = 4
+	IDKind_Shape   IDKind = 5
+)
+
+func (k IDKind) String() string {
+	switch k {
+	case IDKind_Agency:
+		return "AGENCY"
+	case IDKind_Route:
+		return "ROUTE"
+	case IDKind_Stop:
+		return "STOP"
+	case IDKind_Trip:
+		return "TRIP"
+	case IDKind_Service:
+		return "SERVICE"
+	case IDKind_Shape:
+		return "SHAPE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// IDRewriter returns the new ID for an entity of the provided kind with the provided ID.
+type IDRewriter func(kind IDKind, id string) string
+
+// Prefix returns an ID rewriter that adds the prefix to every ID.
+func Prefix(prefix string) IDRewriter {
+	return func(_ IDKind, id string) string {
+		return prefix + id
+	}
+}
+
+// Mapping returns an ID rewriter that replaces IDs using the provided map.
+// IDs that are not in the map are left unchanged.
+func Mapping(m map[IDKind]map[string]string) IDRewriter {
+	return func(kind IDKind, id string) string {
+		if newID, ok := m[kind][id]; ok {
+			return newID
+		}
+		return id
+	}
+}
+
+// RewriteIDs rewrites the IDs of all agencies, routes, stops, trips, services and shapes in the static message.
+//
+// This is useful when ingesting multiple feeds whose IDs collide.
+// Because entities in a [gtfs.Static] reference each other using pointers, all references
+// are rewritten consistently. Empty IDs are left unchanged. The message is modified in place, and
+// its lookup indices are invalidated so that methods like [gtfs.Static.StopByID] use the new IDs.
+func RewriteIDs(static *gtfs.Static, rewrite IDRewriter) {
+	apply := func(kind IDKind, id *string) {
+		if *id == "" {
+			return
+		}
+		*id = rewrite(kind, *id)
+	}
+	for i := range static.Agencies {
+		apply(IDKind_Agency, &static.Agencies[i].Id)
+	}
+	for i := range static.Routes {
+		apply(IDKind_Route, &static.Routes[i].Id)
+	}
+	for i := range static.Stops {
+		apply(IDKind_Stop, &static.Stops[i].Id)
+	}
+	for i := range static.Services {
+		apply(IDKind_Service, &static.Services[i].Id)
+	}
+	for i := range static.Shapes {
+		apply(IDKind_Shape, &static.Shapes[i].ID)
+	}
+	for i := range static.Trips {
+		apply(IDKind_Trip, &static.Trips[i].ID)
+	}
+	static.InvalidateIndex()
+}
@@ -0,0 +1,82 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/jamespfennell/gtfs"
+)
+
+func TestRewriteIDs(t *testing.T) {
+	newStatic := func() *gtfs.Static {
+		static := &gtfs.Static{
+			Agencies: []gtfs.Agency{{Id: "agency"}},
+			Routes:   []gtfs.Route{{Id: "route"}},
+			Stops:    []gtfs.Stop{{Id: "station"}, {Id: "stop"}},
+			Services: []gtfs.Service{{Id: "service"}},
+			Shapes:   []gtfs.Shape{{ID: "shape"}},
+			Trips:    []gtfs.ScheduledTrip{{ID: "trip"}},
+		}
+		static.Routes[0].Agency = &static.Agencies[0]
+		static.Stops[1].Parent = &static.Stops[0]
+		static.Trips[0].Route = &static.Routes[0]
+		static.Trips[0].Service = &static.Services[0]
+		static.Trips[0].Shape = &static.Shapes[0]
+		static.Trips[0].StopTimes = []gtfs.ScheduledStopTime{{Stop: &static.Stops[1]}}
+		return static
+	}
+
+	t.Run("prefix", func(t *testing.T) {
+		static := newStatic()
+
+		RewriteIDs(static, Prefix("nyc:"))
+
+		trip := static.Trips[0]
+		for _, tc := range []struct {
+			got  string
+			want string
+		}{
+			{trip.ID, "nyc:trip"},
+			{trip.Route.Id, "nyc:route"},
+			{trip.Route.Agency.Id, "nyc:agency"},
+			{trip.Service.Id, "nyc:service"},
+			{trip.Shape.ID, "nyc:shape"},
+			{trip.StopTimes[0].Stop.Id, "nyc:stop"},
+			{trip.StopTimes[0].Stop.Parent.Id, "nyc:station"},
+		} {
+			if tc.got != tc.want {
+				t.Errorf("got ID %q, want %q", tc.got, tc.want)
+			}
+		}
+	})
+
+	t.Run("mapping", func(t *testing.T) {
+		static := newStatic()
+
+		RewriteIDs(static, Mapping(map[IDKind]map[string]string{
+			IDKind_Stop: {"stop": "new_stop"},
+		}))
+
+		if got := static.Trips[0].StopTimes[0].Stop.Id; got != "new_stop" {
+			t.Errorf("got stop ID %q, want %q", got, "new_stop")
+		}
+		if got := static.Stops[0].Id; got != "station" {
+			t.Errorf("got stop ID %q, want %q", got, "station")
+		}
+	})
+
+	t.Run("lookup after rewrite", func(t *testing.T) {
+		static := newStatic()
+		if static.StopByID("stop") == nil {
+			t.Fatalf("StopByID(%q) = nil before rewriting", "stop")
+		}
+
+		RewriteIDs(static, Prefix("a_"))
+
+		if static.StopByID("stop") != nil {
+			t.Errorf("StopByID(%q) != nil after rewriting", "stop")
+		}
+		if got := static.StopByID("a_stop"); got != &static.Stops[1] {
+			t.Errorf("StopByID(%q) = %v, want %v", "a_stop", got, &static.Stops[1])
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package transform
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+)
+
+// Normalize applies spec-recommended cleanups to the static message so that two messages with the same
+// content compare equal, which makes the result safe for diffing and hashing.
+//
+// The following cleanups are applied:
+//
+//   - Leading and trailing whitespace is trimmed from all string fields, including IDs.
+//   - Route colors are uppercased.
+//   - If a route has a short name but no long name, or vice versa, the missing name is filled from the other.
+//   - Duplicate transfers are removed, keeping the first.
+//   - Services are sorted by ID, and their added and removed dates are sorted.
+//
+// The message is modified in place, and its lookup indices are invalidated so that methods like
+// [gtfs.Static.StopByID] reflect the cleanups.
+func Normalize(static *gtfs.Static) {
+	for i := range static.Agencies {
+		agency := &static.Agencies[i]
+		trimSpace(&agency.Id, &agency.Name, &agency.Url, &agency.Timezone, &agency.Language, &agency.Phone, &agency.FareUrl, &agency.Email)
+	}
+	for i := range static.Routes {
+		route := &static.Routes[i]
+		trimSpace(&route.Id, &route.Color, &route.TextColor, &route.ShortName, &route.LongName, &route.Description, &route.Url)
+		route.Color = strings.ToUpper(route.Color)
+		route.TextColor = strings.ToUpper(route.TextColor)
+		if route.ShortName == "" {
+			route.ShortName = route.LongName
+		}
+		if route.LongName == "" {
+			route.LongName = route.ShortName
+		}
+	}
+	for i := range static.Stops {
+		stop := &static.Stops[i]
+		trimSpace(&stop.Id, &stop.Code, &stop.Name, &stop.Description, &stop.ZoneId, &stop.Url, &stop.Timezone, &stop.PlatformCode)
+	}
+	for i := range static.Shapes {
+		trimSpace(&static.Shapes[i].ID)
+	}
+	for i := range static.Trips {
+		trip := &static.Trips[i]
+		trimSpace(&trip.ID, &trip.Headsign, &trip.ShortName, &trip.BlockID)
+		for j := range trip.StopTimes {
+			trimSpace(&trip.StopTimes[j].Headsign)
+		}
+	}
+	if feedInfo := static.FeedInfo; feedInfo != nil {
+		trimSpace(&feedInfo.PublisherName, &feedInfo.PublisherUrl, &feedInfo.Language, &feedInfo.DefaultLanguage, &feedInfo.ContactEmail, &feedInfo.ContactUrl, &feedInfo.Version)
+	}
+	normalizeServices(static)
+	dedupTransfers(static)
+	static.InvalidateIndex()
+}
+
+func trimSpace(fields ...*string) {
+	for _, field := range fields {
+		*field = strings.TrimSpace(*field)
+	}
+}
+
+// normalizeServices sorts the services by ID, updating the trips that reference them.
+func normalizeServices(static *gtfs.Static) {
+	for i := range static.Services {
+		service := &static.Services[i]
+		trimSpace(&service.Id)
+		sortDates(service.AddedDates)
+		sortDates(service.RemovedDates)
+	}
+	order := make([]int, len(static.Services))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return static.Services[order[i]].Id < static.Services[order[j]].Id
+	})
+	services := make([]gtfs.Service, len(static.Services))
+	oldToNew := map[*gtfs.Service]*gtfs.Service{}
+	for i, j := range order {
+		services[i] = static.Services[j]
+		oldToNew[&static.Services[j]] = &services[i]
+	}
+	for i := range static.Trips {
+		if service, ok := oldToNew[static.Trips[i].Service]; ok {
+			static.Trips[i].Service = service
+		}
+	}
+	for i := range static.BookingRules {
+		if service, ok := oldToNew[static.BookingRules[i].PriorNoticeService]; ok {
+			static.BookingRules[i].PriorNoticeService = service
+		}
+	}
+	static.Services = services
+}
+
+func sortDates(dates []time.Time) {
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+}
+
+// dedupTransfers removes transfers that are identical to an earlier transfer.
+func dedupTransfers(static *gtfs.Static) {
+	type key struct {
+		from, to        *gtfs.Stop
+		transferType    gtfs.TransferType
+		hasMinTime      bool
+		minTransferTime int32
+	}
+	seen := map[key]bool{}
+	var transfers []gtfs.Transfer
+	for _, transfer := range static.Transfers {
+		k := key{from: transfer.From, to: transfer.To, transferType: transfer.Type}
+		if transfer.MinTransferTime != nil {
+			k.hasMinTime, k.minTransferTime = true, *transfer.MinTransferTime
+		}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		transfers = append(transfers, transfer)
+	}
+	static.Transfers = transfers
+}
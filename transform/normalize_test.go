@@ -0,0 +1,87 @@
+package transform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jamespfennell/gtfs"
+)
+
+func TestNormalize(t *testing.T) {
+	day1 := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2022, 5, 2, 0, 0, 0, 0, time.UTC)
+	static := &gtfs.Static{
+		Agencies: []gtfs.Agency{{Id: " agency ", Name: "Agency\t"}},
+		Routes: []gtfs.Route{
+			{Id: "route1", Color: "ff00aa", TextColor: " 000000", ShortName: "A"},
+			{Id: "route2", LongName: "Long name"},
+			{Id: "route3"},
+		},
+		Stops:    []gtfs.Stop{{Id: "stop1 "}, {Id: "stop2"}},
+		Services: []gtfs.Service{{Id: "weekend", AddedDates: []time.Time{day2, day1}}, {Id: "weekday"}},
+		Trips:    []gtfs.ScheduledTrip{{ID: "trip", Headsign: " Downtown "}},
+	}
+	static.Trips[0].Service = &static.Services[0]
+	static.Transfers = []gtfs.Transfer{
+		{From: &static.Stops[0], To: &static.Stops[1], Type: gtfs.TransferType_RequiresTime, MinTransferTime: ptr(int32(60))},
+		{From: &static.Stops[0], To: &static.Stops[1], Type: gtfs.TransferType_RequiresTime, MinTransferTime: ptr(int32(60))},
+		{From: &static.Stops[0], To: &static.Stops[1], Type: gtfs.TransferType_RequiresTime, MinTransferTime: ptr(int32(120))},
+		{From: &static.Stops[1], To: &static.Stops[0]},
+	}
+
+	Normalize(static)
+
+	want := &gtfs.Static{
+		Agencies: []gtfs.Agency{{Id: "agency", Name: "Agency"}},
+		Routes: []gtfs.Route{
+			{Id: "route1", Color: "FF00AA", TextColor: "000000", ShortName: "A", LongName: "A"},
+			{Id: "route2", ShortName: "Long name", LongName: "Long name"},
+			{Id: "route3"},
+		},
+		Stops:    []gtfs.Stop{{Id: "stop1"}, {Id: "stop2"}},
+		Services: []gtfs.Service{{Id: "weekday"}, {Id: "weekend", AddedDates: []time.Time{day1, day2}}},
+		Trips:    []gtfs.ScheduledTrip{{ID: "trip", Headsign: "Downtown", Service: &gtfs.Service{Id: "weekend", AddedDates: []time.Time{day1, day2}}}},
+		Transfers: []gtfs.Transfer{
+			{From: &gtfs.Stop{Id: "stop1"}, To: &gtfs.Stop{Id: "stop2"}, Type: gtfs.TransferType_RequiresTime, MinTransferTime: ptr(int32(60))},
+			{From: &gtfs.Stop{Id: "stop1"}, To: &gtfs.Stop{Id: "stop2"}, Type: gtfs.TransferType_RequiresTime, MinTransferTime: ptr(int32(120))},
+			{From: &gtfs.Stop{Id: "stop2"}, To: &gtfs.Stop{Id: "stop1"}},
+		},
+	}
+	if diff := cmp.Diff(static, want, cmpopts.IgnoreUnexported(gtfs.Static{})); diff != "" {
+		t.Errorf("Normalize() diff: %s", diff)
+	}
+	if static.Trips[0].Service != &static.Services[1] {
+		t.Errorf("Normalize() trip service does not point into the services slice")
+	}
+}
+
+func TestNormalize_BookingRules(t *testing.T) {
+	static := &gtfs.Static{
+		Services: []gtfs.Service{{Id: "weekend"}, {Id: "weekday"}},
+	}
+	static.BookingRules = []gtfs.BookingRule{{ID: "rule", PriorNoticeService: &static.Services[1]}}
+
+	Normalize(static)
+
+	if static.BookingRules[0].PriorNoticeService != &static.Services[0] {
+		t.Errorf("Normalize() prior notice service does not point into the services slice")
+	}
+}
+
+func TestNormalize_InvalidatesIndex(t *testing.T) {
+	static := &gtfs.Static{Stops: []gtfs.Stop{{Id: " stop ", Code: " 123 "}}}
+	if static.StopByID("stop") != nil {
+		t.Fatalf("StopByID(%q) != nil before normalizing", "stop")
+	}
+
+	Normalize(static)
+
+	if got := static.StopByID("stop"); got != &static.Stops[0] {
+		t.Errorf("StopByID(%q) = %v, want %v", "stop", got, &static.Stops[0])
+	}
+	if got := static.StopByCode("123"); got != &static.Stops[0] {
+		t.Errorf("StopByCode(%q) = %v, want %v", "123", got, &static.Stops[0])
+	}
+}
@@ -0,0 +1,112 @@
+package transform
+
+import "github.com/jamespfennell/gtfs"
+
+// RealtimeSubsetOptions contains the options for [ExtractRealtimeSubset].
+//
+// Each non-empty filter must match for an entity to be kept.
+type RealtimeSubsetOptions struct {
+	// If non-empty, only trips for these routes are kept.
+	RouteIDs []string
+
+	// If non-empty, only trips with a stop time update at one of these stops are kept.
+	StopIDs []string
+
+	// If non-empty, only trips with these trip IDs are kept.
+	TripIDs []string
+}
+
+// ExtractRealtimeSubset returns a new realtime message containing only the trips that match the options,
+// along with their vehicles and the alerts that inform them.
+//
+// A vehicle is kept if its trip is kept. A vehicle without a trip is kept only if the route and
+// trip filters are empty and its stop matches the stop filter.
+// An alert is kept if one of its informed entities references a kept route, stop or trip, or if it
+// informs an agency or route type without referencing a specific route, stop or trip.
+//
+// The Vehicle and Trip pointers of kept entities point at the copies in the result, so trip to vehicle
+// links are the same as in the provided message. A pointer is nil if the entity it referenced was not
+// kept. The provided message is not modified, and the result shares no memory with it except for
+// extension data, as in [gtfs.Realtime.DeepClone].
+func ExtractRealtimeSubset(realtime *gtfs.Realtime, opts RealtimeSubsetOptions) *gtfs.Realtime {
+	routeIDs := toSet(opts.RouteIDs)
+	stopIDs := toSet(opts.StopIDs)
+	tripIDs := toSet(opts.TripIDs)
+	keepTrip := func(trip *gtfs.Trip) bool {
+		if len(routeIDs) > 0 && !routeIDs[trip.ID.RouteID] {
+			return false
+		}
+		if len(tripIDs) > 0 && !tripIDs[trip.ID.ID] {
+			return false
+		}
+		if len(stopIDs) == 0 {
+			return true
+		}
+		for _, stopTimeUpdate := range trip.StopTimeUpdates {
+			if stopTimeUpdate.StopID != nil && stopIDs[*stopTimeUpdate.StopID] {
+				return true
+			}
+		}
+		return false
+	}
+
+	result := &gtfs.Realtime{
+		CreatedAt: realtime.CreatedAt,
+	}
+	keptRouteIDs := map[string]bool{}
+	keptTripIDs := map[string]bool{}
+	keepTrips := map[*gtfs.Trip]bool{}
+	for i := range realtime.Trips {
+		trip := &realtime.Trips[i]
+		if !keepTrip(trip) {
+			continue
+		}
+		keepTrips[trip] = true
+		keptRouteIDs[trip.ID.RouteID] = true
+		keptTripIDs[trip.ID.ID] = true
+	}
+	keepVehicles := map[*gtfs.Vehicle]bool{}
+	for i := range realtime.Vehicles {
+		vehicle := &realtime.Vehicles[i]
+		if vehicle.Trip != nil {
+			if !keepTrip(vehicle.Trip) {
+				continue
+			}
+		} else if len(routeIDs) > 0 || len(tripIDs) > 0 || (len(stopIDs) > 0 && (vehicle.StopID == nil || !stopIDs[*vehicle.StopID])) {
+			continue
+		}
+		keepVehicles[vehicle] = true
+	}
+	trips := map[*gtfs.Trip]*gtfs.Trip{}
+	result.Trips = filter(realtime.Trips, keepTrips, trips)
+	vehicles := map[*gtfs.Vehicle]*gtfs.Vehicle{}
+	result.Vehicles = filter(realtime.Vehicles, keepVehicles, vehicles)
+	for i := range result.Trips {
+		result.Trips[i].Vehicle = remap(result.Trips[i].Vehicle, vehicles)
+	}
+	for i := range result.Vehicles {
+		result.Vehicles[i].Trip = remap(result.Vehicles[i].Trip, trips)
+	}
+	for _, alert := range realtime.Alerts {
+		for _, entity := range alert.InformedEntities {
+			if (entity.RouteID != nil && keptRouteIDs[*entity.RouteID]) ||
+				(entity.StopID != nil && stopIDs[*entity.StopID]) ||
+				(entity.TripID != nil && keptTripIDs[entity.TripID.ID]) ||
+				(entity.RouteID == nil && entity.StopID == nil && entity.TripID == nil) {
+				result.Alerts = append(result.Alerts, alert)
+				break
+			}
+		}
+	}
+	// The kept entities are shallow copies that share stop time updates, informed entities and so on
+	// with the provided message.
+	return result.DeepClone()
+}
+
+func toSet(s []string) map[string]bool {
+	m := map[string]bool{}
+	for _, v := range s {
+		m[v] = true
+	}
+	return m
+}
@@ -0,0 +1,150 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs"
+)
+
+func TestExtractRealtimeSubset(t *testing.T) {
+	realtime := &gtfs.Realtime{
+		Trips: []gtfs.Trip{
+			{
+				ID:              gtfs.TripID{ID: "trip1", RouteID: "A"},
+				StopTimeUpdates: []gtfs.StopTimeUpdate{{StopID: ptr("stop1")}},
+			},
+			{
+				ID:              gtfs.TripID{ID: "trip2", RouteID: "B"},
+				StopTimeUpdates: []gtfs.StopTimeUpdate{{StopID: ptr("stop2")}},
+			},
+		},
+		Vehicles: []gtfs.Vehicle{
+			{ID: &gtfs.VehicleID{ID: "vehicle1"}},
+			{ID: &gtfs.VehicleID{ID: "vehicle2"}},
+		},
+		Alerts: []gtfs.Alert{
+			{ID: "alert1", InformedEntities: []gtfs.AlertInformedEntity{{RouteID: ptr("A")}}},
+			{ID: "alert2", InformedEntities: []gtfs.AlertInformedEntity{{RouteID: ptr("B")}}},
+			{ID: "alert3", InformedEntities: []gtfs.AlertInformedEntity{{AgencyID: ptr("agency")}}},
+		},
+	}
+	for i := range realtime.Trips {
+		realtime.Trips[i].Vehicle = &realtime.Vehicles[i]
+		realtime.Vehicles[i].Trip = &realtime.Trips[i]
+	}
+	for _, tc := range []struct {
+		desc           string
+		opts           RealtimeSubsetOptions
+		wantTripIDs    []string
+		wantVehicleIDs []string
+		wantAlertIDs   []string
+	}{
+		{
+			desc:           "by route",
+			opts:           RealtimeSubsetOptions{RouteIDs: []string{"A"}},
+			wantTripIDs:    []string{"trip1"},
+			wantVehicleIDs: []string{"vehicle1"},
+			wantAlertIDs:   []string{"alert1", "alert3"},
+		},
+		{
+			desc:           "by stop",
+			opts:           RealtimeSubsetOptions{StopIDs: []string{"stop2"}},
+			wantTripIDs:    []string{"trip2"},
+			wantVehicleIDs: []string{"vehicle2"},
+			wantAlertIDs:   []string{"alert2", "alert3"},
+		},
+		{
+			desc:         "no match",
+			opts:         RealtimeSubsetOptions{RouteIDs: []string{"A"}, TripIDs: []string{"trip2"}},
+			wantAlertIDs: []string{"alert3"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := ExtractRealtimeSubset(realtime, tc.opts)
+
+			var gotTripIDs, gotVehicleIDs, gotAlertIDs []string
+			for i := range got.Trips {
+				trip := &got.Trips[i]
+				gotTripIDs = append(gotTripIDs, trip.ID.ID)
+				if trip.Vehicle != &got.Vehicles[i] || trip.Vehicle.Trip != trip {
+					t.Errorf("trip %s is not linked to its vehicle in the subset", trip.ID.ID)
+				}
+			}
+			for _, vehicle := range got.Vehicles {
+				gotVehicleIDs = append(gotVehicleIDs, vehicle.GetID().ID)
+			}
+			for _, alert := range got.Alerts {
+				gotAlertIDs = append(gotAlertIDs, alert.ID)
+			}
+			if diff := cmp.Diff(gotTripIDs, tc.wantTripIDs); diff != "" {
+				t.Errorf("trip IDs got = %v, want = %v", gotTripIDs, tc.wantTripIDs)
+			}
+			if diff := cmp.Diff(gotVehicleIDs, tc.wantVehicleIDs); diff != "" {
+				t.Errorf("vehicle IDs got = %v, want = %v", gotVehicleIDs, tc.wantVehicleIDs)
+			}
+			if diff := cmp.Diff(gotAlertIDs, tc.wantAlertIDs); diff != "" {
+				t.Errorf("alert IDs got = %v, want = %v", gotAlertIDs, tc.wantAlertIDs)
+			}
+		})
+	}
+}
+
+func TestExtractRealtimeSubset_VehicleFilteredOut(t *testing.T) {
+	realtime := &gtfs.Realtime{
+		Trips: []gtfs.Trip{
+			{ID: gtfs.TripID{ID: "trip1", RouteID: "A"}},
+		},
+		Vehicles: []gtfs.Vehicle{
+			{ID: &gtfs.VehicleID{ID: "vehicle1"}},
+		},
+	}
+	// The vehicle's trip descriptor is not set, so the vehicle is not kept by the route filter.
+	realtime.Trips[0].Vehicle = &realtime.Vehicles[0]
+
+	got := ExtractRealtimeSubset(realtime, RealtimeSubsetOptions{RouteIDs: []string{"A"}})
+
+	if len(got.Trips) != 1 || len(got.Vehicles) != 0 {
+		t.Fatalf("got %d trips and %d vehicles, want 1 and 0", len(got.Trips), len(got.Vehicles))
+	}
+	if got.Trips[0].Vehicle != nil {
+		t.Errorf("trip vehicle got = %v, want nil", got.Trips[0].Vehicle)
+	}
+	if realtime.Trips[0].Vehicle != &realtime.Vehicles[0] {
+		t.Errorf("ExtractRealtimeSubset() modified the source message")
+	}
+}
+
+func TestExtractRealtimeSubset_DoesNotAlias(t *testing.T) {
+	realtime := &gtfs.Realtime{
+		Trips: []gtfs.Trip{
+			{
+				ID:              gtfs.TripID{ID: "trip1", RouteID: "A"},
+				StopTimeUpdates: []gtfs.StopTimeUpdate{{StopID: ptr("stop1")}},
+			},
+		},
+		Alerts: []gtfs.Alert{
+			{
+				ID:               "alert1",
+				InformedEntities: []gtfs.AlertInformedEntity{{RouteID: ptr("A")}},
+				Header:           []gtfs.AlertText{{Text: "Delays", Language: "en"}},
+			},
+		},
+	}
+
+	got := ExtractRealtimeSubset(realtime, RealtimeSubsetOptions{RouteIDs: []string{"A"}})
+	*got.Trips[0].StopTimeUpdates[0].StopID = "other_stop"
+	got.Trips[0].StopTimeUpdates[0] = gtfs.StopTimeUpdate{}
+	*got.Alerts[0].InformedEntities[0].RouteID = "B"
+	got.Alerts[0].Header[0].Text = "No delays"
+
+	if got := *realtime.Trips[0].StopTimeUpdates[0].StopID; got != "stop1" {
+		t.Errorf("source stop ID got = %q, want %q", got, "stop1")
+	}
+	if got := *realtime.Alerts[0].InformedEntities[0].RouteID; got != "A" {
+		t.Errorf("source informed route ID got = %q, want %q", got, "A")
+	}
+	if got := realtime.Alerts[0].Header[0].Text; got != "Delays" {
+		t.Errorf("source alert header got = %q, want %q", got, "Delays")
+	}
+}
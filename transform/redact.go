@@ -0,0 +1,107 @@
+// Package transform contains transformations that can be applied to parsed GTFS static and realtime data.
+package transform
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/jamespfennell/gtfs"
+)
+
+// RedactOptions contains the options for redacting potentially sensitive data.
+type RedactOptions struct {
+	// If true, sensitive values are replaced by a hash of the value rather than being removed.
+	//
+	// Hashing preserves the ability to correlate entities (e.g. the same vehicle across
+	// multiple realtime messages) without revealing the underlying values.
+	Hash bool
+
+	// Salt to add to values before hashing. This should be kept secret if the redacted
+	// values are guessable, like phone numbers.
+	Salt string
+
+	// If true, vehicle IDs are also redacted in addition to vehicle labels and license plates.
+	RedactVehicleIDs bool
+}
+
+// RedactStatic redacts potentially sensitive data in the static message.
+//
+// The agency phone numbers and emails, and the feed contact email and URL, are redacted.
+// The message is modified in place.
+func RedactStatic(static *gtfs.Static, opts RedactOptions) {
+	r := redactor{opts: opts}
+	for i := range static.Agencies {
+		r.redact(&static.Agencies[i].Phone)
+		r.redact(&static.Agencies[i].Email)
+	}
+	if static.FeedInfo != nil {
+		r.redact(&static.FeedInfo.ContactEmail)
+		r.redact(&static.FeedInfo.ContactUrl)
+	}
+}
+
+// RedactRealtime redacts potentially sensitive data in the realtime message.
+//
+// Vehicle labels and license plates are redacted, as well as vehicle IDs if
+// [RedactOptions.RedactVehicleIDs] is true. The message is modified in place.
+func RedactRealtime(realtime *gtfs.Realtime, opts RedactOptions) {
+	r := redactor{
+		opts: opts,
+		seen: map[*gtfs.VehicleID]bool{},
+	}
+	for i := range realtime.Vehicles {
+		r.vehicle(&realtime.Vehicles[i])
+	}
+	for i := range realtime.Trips {
+		r.vehicle(realtime.Trips[i].Vehicle)
+	}
+}
+
+type redactor struct {
+	opts RedactOptions
+	// Vehicle IDs are shared between trips and vehicles, so we track which have been
+	// redacted to avoid hashing a value twice.
+	seen map[*gtfs.VehicleID]bool
+}
+
+func (r *redactor) vehicle(vehicle *gtfs.Vehicle) {
+	if vehicle == nil {
+		return
+	}
+	r.vehicleID(vehicle.ID)
+	if vehicle.Trip != nil {
+		r.vehicleID(vehicle.Trip.GetVehicle().ID)
+	}
+}
+
+func (r *redactor) vehicleID(vehicleID *gtfs.VehicleID) {
+	if vehicleID == nil || r.seen[vehicleID] {
+		return
+	}
+	r.seen[vehicleID] = true
+	r.redactOptional(&vehicleID.Label, &vehicleID.HasLabel)
+	r.redactOptional(&vehicleID.LicensePlate, &vehicleID.HasLicensePlate)
+	if r.opts.RedactVehicleIDs {
+		r.redactOptional(&vehicleID.ID, &vehicleID.HasID)
+	}
+}
+
+// redactOptional redacts the value and, if the value is removed, marks it as absent.
+func (r *redactor) redactOptional(s *string, has *bool) {
+	if *s != "" && !r.opts.Hash {
+		*has = false
+	}
+	r.redact(s)
+}
+
+func (r *redactor) redact(s *string) {
+	if *s == "" {
+		return
+	}
+	if !r.opts.Hash {
+		*s = ""
+		return
+	}
+	sum := sha256.Sum256([]byte(r.opts.Salt + *s))
+	*s = hex.EncodeToString(sum[:8])
+}
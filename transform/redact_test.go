@@ -0,0 +1,123 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs"
+)
+
+func TestRedactRealtime(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		opts RedactOptions
+		want gtfs.VehicleID
+	}{
+		{
+			desc: "strip",
+			opts: RedactOptions{},
+			want: gtfs.VehicleID{ID: "id", HasID: true},
+		},
+		{
+			desc: "strip including IDs",
+			opts: RedactOptions{RedactVehicleIDs: true},
+			want: gtfs.VehicleID{},
+		},
+		{
+			desc: "hash",
+			opts: RedactOptions{Hash: true, Salt: "salt"},
+			want: gtfs.VehicleID{
+				ID:              "id",
+				Label:           hash("salt", "label"),
+				LicensePlate:    hash("salt", "plate"),
+				HasID:           true,
+				HasLabel:        true,
+				HasLicensePlate: true,
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			vehicleID := &gtfs.VehicleID{
+				ID:              "id",
+				Label:           "label",
+				LicensePlate:    "plate",
+				HasID:           true,
+				HasLabel:        true,
+				HasLicensePlate: true,
+			}
+			trip := gtfs.Trip{}
+			vehicle := gtfs.Vehicle{ID: vehicleID, Trip: &trip}
+			trip.Vehicle = &vehicle
+			realtime := &gtfs.Realtime{
+				Trips:    []gtfs.Trip{trip},
+				Vehicles: []gtfs.Vehicle{vehicle},
+			}
+
+			RedactRealtime(realtime, tc.opts)
+
+			if diff := cmp.Diff(*realtime.Vehicles[0].ID, tc.want); diff != "" {
+				t.Errorf("RedactRealtime() got = %v, want = %v, diff: %s", *realtime.Vehicles[0].ID, tc.want, diff)
+			}
+		})
+	}
+}
+
+func TestRedactStatic(t *testing.T) {
+	static := &gtfs.Static{
+		Agencies: []gtfs.Agency{
+			{Id: "a", Name: "b", Phone: "555-1234", Email: "c@d.com"},
+		},
+	}
+
+	RedactStatic(static, RedactOptions{})
+
+	want := gtfs.Agency{Id: "a", Name: "b"}
+	if diff := cmp.Diff(static.Agencies[0], want); diff != "" {
+		t.Errorf("RedactStatic() got = %v, want = %v, diff: %s", static.Agencies[0], want, diff)
+	}
+}
+
+func TestRedactStatic_FeedInfo(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		opts RedactOptions
+		want gtfs.FeedInfo
+	}{
+		{
+			desc: "strip",
+			opts: RedactOptions{},
+			want: gtfs.FeedInfo{PublisherName: "publisher"},
+		},
+		{
+			desc: "hash",
+			opts: RedactOptions{Hash: true, Salt: "salt"},
+			want: gtfs.FeedInfo{
+				PublisherName: "publisher",
+				ContactEmail:  hash("salt", "feeds@example.com"),
+				ContactUrl:    hash("salt", "https://example.com/contact"),
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			static := &gtfs.Static{
+				FeedInfo: &gtfs.FeedInfo{
+					PublisherName: "publisher",
+					ContactEmail:  "feeds@example.com",
+					ContactUrl:    "https://example.com/contact",
+				},
+			}
+
+			RedactStatic(static, tc.opts)
+
+			if diff := cmp.Diff(*static.FeedInfo, tc.want); diff != "" {
+				t.Errorf("RedactStatic() got = %v, want = %v, diff: %s", *static.FeedInfo, tc.want, diff)
+			}
+		})
+	}
+}
+
+func hash(salt, s string) string {
+	r := redactor{opts: RedactOptions{Hash: true, Salt: salt}}
+	r.redact(&s)
+	return s
+}
@@ -0,0 +1,369 @@
+package transform
+
+import "github.com/jamespfennell/gtfs"
+
+// BoundingBox is a geographic area bounded by lines of latitude and longitude.
+type BoundingBox struct {
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude float64
+	MaxLongitude float64
+}
+
+// Contains returns true if the stop has a location that lies within the bounding box.
+func (b *BoundingBox) Contains(stop *gtfs.Stop) bool {
+	if stop.Latitude == nil || stop.Longitude == nil {
+		return false
+	}
+	return b.MinLatitude <= *stop.Latitude && *stop.Latitude <= b.MaxLatitude &&
+		b.MinLongitude <= *stop.Longitude && *stop.Longitude <= b.MaxLongitude
+}
+
+// SubsetOptions contains the options for [ExtractSubset].
+type SubsetOptions struct {
+	// If non-empty, only trips for these routes are kept.
+	RouteIDs []string
+
+	// If non-nil, only trips that call at a stop within the bounding box are kept.
+	BoundingBox *BoundingBox
+}
+
+// ExtractSubset returns a new static message containing only the trips that match the options,
+// along with the routes, agencies, stops, services, shapes, transfers and pathways they reference,
+// and the GTFS-Flex location groups, flex locations and booking rules their stop times reference.
+//
+// Fares are kept where they apply to the subset: fare rules for kept routes or for no route, the
+// networks of kept routes, areas containing kept stops, fare leg rules whose network and areas are
+// kept, fare transfer rules between the leg groups of kept fare leg rules, and the fare products and
+// fare media these rules reference. Networks and areas only list their kept routes and stops.
+// The feed info and timezone are copied as-is. All other entities are pruned.
+//
+// Trips are kept in their entirety, so the subset can contain stops outside of the bounding box if a
+// trip calling within the bounding box also calls at them. Parent stations of kept stops, the stops of
+// kept location groups and the prior notice services of kept booking rules are also kept.
+// The provided message is not modified; the entities in the result are copies.
+//
+// Only stop times stored in the StopTimes field of trips are considered, so this function
+// should not be used with messages parsed using [gtfs.ParseStaticOptions.StreamStopTimes].
+func ExtractSubset(static *gtfs.Static, opts SubsetOptions) *gtfs.Static {
+	routeIDs := map[string]bool{}
+	for _, routeID := range opts.RouteIDs {
+		routeIDs[routeID] = true
+	}
+	keepTrip := func(trip *gtfs.ScheduledTrip) bool {
+		if len(routeIDs) > 0 && (trip.Route == nil || !routeIDs[trip.Route.Id]) {
+			return false
+		}
+		if opts.BoundingBox == nil {
+			return true
+		}
+		for i := range trip.StopTimes {
+			if stop := trip.StopTimes[i].Stop; stop != nil && opts.BoundingBox.Contains(stop) {
+				return true
+			}
+		}
+		return false
+	}
+
+	keep := newKeepSet()
+	for i := range static.Trips {
+		trip := &static.Trips[i]
+		if !keepTrip(trip) {
+			continue
+		}
+		keep.trips[trip] = true
+		if trip.Route != nil {
+			keep.routes[trip.Route] = true
+			if trip.Route.Agency != nil {
+				keep.agencies[trip.Route.Agency] = true
+			}
+		}
+		if trip.Service != nil {
+			keep.services[trip.Service] = true
+		}
+		if trip.Shape != nil {
+			keep.shapes[trip.Shape] = true
+		}
+		for j := range trip.StopTimes {
+			stopTime := &trip.StopTimes[j]
+			keep.addStop(stopTime.Stop)
+			if group := stopTime.LocationGroup; group != nil {
+				keep.locationGroups[group] = true
+				for _, stop := range group.Stops {
+					keep.addStop(stop)
+				}
+			}
+			if stopTime.FlexLocation != nil {
+				keep.flexLocations[stopTime.FlexLocation] = true
+			}
+			for _, bookingRule := range []*gtfs.BookingRule{stopTime.PickupBookingRule, stopTime.DropOffBookingRule} {
+				if bookingRule == nil {
+					continue
+				}
+				keep.bookingRules[bookingRule] = true
+				if bookingRule.PriorNoticeService != nil {
+					keep.services[bookingRule.PriorNoticeService] = true
+				}
+			}
+		}
+	}
+	return keep.build(static)
+}
+
+type keepSet struct {
+	agencies map[*gtfs.Agency]bool
+	routes   map[*gtfs.Route]bool
+	stops    map[*gtfs.Stop]bool
+	services map[*gtfs.Service]bool
+	shapes   map[*gtfs.Shape]bool
+	trips    map[*gtfs.ScheduledTrip]bool
+
+	locationGroups map[*gtfs.LocationGroup]bool
+	flexLocations  map[*gtfs.FlexLocation]bool
+	bookingRules   map[*gtfs.BookingRule]bool
+}
+
+func newKeepSet() *keepSet {
+	return &keepSet{
+		agencies: map[*gtfs.Agency]bool{},
+		routes:   map[*gtfs.Route]bool{},
+		stops:    map[*gtfs.Stop]bool{},
+		services: map[*gtfs.Service]bool{},
+		shapes:   map[*gtfs.Shape]bool{},
+		trips:    map[*gtfs.ScheduledTrip]bool{},
+
+		locationGroups: map[*gtfs.LocationGroup]bool{},
+		flexLocations:  map[*gtfs.FlexLocation]bool{},
+		bookingRules:   map[*gtfs.BookingRule]bool{},
+	}
+}
+
+// addStop keeps the stop and its parent stations.
+func (k *keepSet) addStop(stop *gtfs.Stop) {
+	for ; stop != nil; stop = stop.Parent {
+		k.stops[stop] = true
+	}
+}
+
+// build copies the kept entities into a new static message, rewiring pointers to point at the copies.
+func (k *keepSet) build(static *gtfs.Static) *gtfs.Static {
+	result := &gtfs.Static{Timezone: static.Timezone}
+	if static.FeedInfo != nil {
+		feedInfo := *static.FeedInfo
+		result.FeedInfo = &feedInfo
+	}
+
+	agencies := map[*gtfs.Agency]*gtfs.Agency{}
+	result.Agencies = filter(static.Agencies, k.agencies, agencies)
+	services := map[*gtfs.Service]*gtfs.Service{}
+	result.Services = filter(static.Services, k.services, services)
+	shapes := map[*gtfs.Shape]*gtfs.Shape{}
+	result.Shapes = filter(static.Shapes, k.shapes, shapes)
+
+	routes := map[*gtfs.Route]*gtfs.Route{}
+	result.Routes = filter(static.Routes, k.routes, routes)
+	networks := map[*gtfs.Network]*gtfs.Network{}
+	result.Networks = filter(static.Networks, k.networks(), networks)
+	for i := range result.Routes {
+		result.Routes[i].Agency = remap(result.Routes[i].Agency, agencies)
+		result.Routes[i].Network = remap(result.Routes[i].Network, networks)
+	}
+	for i := range result.Networks {
+		result.Networks[i].Routes = remapKept(result.Networks[i].Routes, routes)
+	}
+	for _, fareRule := range static.FareRules {
+		if fareRule.Route != nil && !k.routes[fareRule.Route] {
+			continue
+		}
+		fareRule.Route = remap(fareRule.Route, routes)
+		result.FareRules = append(result.FareRules, fareRule)
+	}
+
+	stops := map[*gtfs.Stop]*gtfs.Stop{}
+	result.Stops = filter(static.Stops, k.stops, stops)
+	for i := range result.Stops {
+		result.Stops[i].Parent = remap(result.Stops[i].Parent, stops)
+	}
+
+	areas := map[*gtfs.Area]*gtfs.Area{}
+	result.Areas = filter(static.Areas, k.areas(static.Areas), areas)
+	for i := range result.Areas {
+		result.Areas[i].Stops = remapKept(result.Areas[i].Stops, stops)
+	}
+	k.buildFares(result, static, networks, areas)
+
+	for _, transfer := range static.Transfers {
+		if !k.stops[transfer.From] || !k.stops[transfer.To] {
+			continue
+		}
+		transfer.From = stops[transfer.From]
+		transfer.To = stops[transfer.To]
+		result.Transfers = append(result.Transfers, transfer)
+	}
+	for _, pathway := range static.Pathways {
+		if !k.stops[pathway.From] || !k.stops[pathway.To] {
+			continue
+		}
+		pathway.From = stops[pathway.From]
+		pathway.To = stops[pathway.To]
+		result.Pathways = append(result.Pathways, pathway)
+	}
+
+	locationGroups := map[*gtfs.LocationGroup]*gtfs.LocationGroup{}
+	result.LocationGroups = filter(static.LocationGroups, k.locationGroups, locationGroups)
+	for i := range result.LocationGroups {
+		group := &result.LocationGroups[i]
+		groupStops := make([]*gtfs.Stop, len(group.Stops))
+		for j, stop := range group.Stops {
+			groupStops[j] = remap(stop, stops)
+		}
+		group.Stops = groupStops
+	}
+	flexLocations := map[*gtfs.FlexLocation]*gtfs.FlexLocation{}
+	result.FlexLocations = filter(static.FlexLocations, k.flexLocations, flexLocations)
+	bookingRules := map[*gtfs.BookingRule]*gtfs.BookingRule{}
+	result.BookingRules = filter(static.BookingRules, k.bookingRules, bookingRules)
+	for i := range result.BookingRules {
+		result.BookingRules[i].PriorNoticeService = remap(result.BookingRules[i].PriorNoticeService, services)
+	}
+
+	trips := map[*gtfs.ScheduledTrip]*gtfs.ScheduledTrip{}
+	result.Trips = filter(static.Trips, k.trips, trips)
+	for i := range result.Trips {
+		trip := &result.Trips[i]
+		trip.Route = remap(trip.Route, routes)
+		trip.Service = remap(trip.Service, services)
+		trip.Shape = remap(trip.Shape, shapes)
+		stopTimes := make([]gtfs.ScheduledStopTime, len(trip.StopTimes))
+		copy(stopTimes, trip.StopTimes)
+		for j := range stopTimes {
+			stopTimes[j].Stop = remap(stopTimes[j].Stop, stops)
+			stopTimes[j].Trip = remap(stopTimes[j].Trip, trips)
+			stopTimes[j].LocationGroup = remap(stopTimes[j].LocationGroup, locationGroups)
+			stopTimes[j].FlexLocation = remap(stopTimes[j].FlexLocation, flexLocations)
+			stopTimes[j].PickupBookingRule = remap(stopTimes[j].PickupBookingRule, bookingRules)
+			stopTimes[j].DropOffBookingRule = remap(stopTimes[j].DropOffBookingRule, bookingRules)
+		}
+		trip.StopTimes = stopTimes
+	}
+	return result
+}
+
+// networks returns the networks of the kept routes.
+func (k *keepSet) networks() map[*gtfs.Network]bool {
+	networks := map[*gtfs.Network]bool{}
+	for route := range k.routes {
+		if route.Network != nil {
+			networks[route.Network] = true
+		}
+	}
+	return networks
+}
+
+// areas returns the areas that contain a kept stop.
+func (k *keepSet) areas(areas []gtfs.Area) map[*gtfs.Area]bool {
+	keep := map[*gtfs.Area]bool{}
+	for i := range areas {
+		for _, stop := range areas[i].Stops {
+			if k.stops[stop] {
+				keep[&areas[i]] = true
+				break
+			}
+		}
+	}
+	return keep
+}
+
+// buildFares copies the Fares V2 rules that apply to the kept networks and areas, and the fare
+// products and fare media they reference, into the result.
+func (k *keepSet) buildFares(result, static *gtfs.Static, networks map[*gtfs.Network]*gtfs.Network, areas map[*gtfs.Area]*gtfs.Area) {
+	keptOrNil := func(area *gtfs.Area) bool {
+		return area == nil || areas[area] != nil
+	}
+	legGroupIDs := map[string]bool{}
+	fareProductIDs := map[string]bool{}
+	for _, rule := range static.FareLegRules {
+		if (rule.Network != nil && networks[rule.Network] == nil) || !keptOrNil(rule.FromArea) || !keptOrNil(rule.ToArea) {
+			continue
+		}
+		rule.Network = remap(rule.Network, networks)
+		rule.FromArea = remap(rule.FromArea, areas)
+		rule.ToArea = remap(rule.ToArea, areas)
+		result.FareLegRules = append(result.FareLegRules, rule)
+		legGroupIDs[rule.LegGroupID] = true
+		fareProductIDs[rule.FareProductID] = true
+	}
+	keptOrEmpty := func(legGroupID string) bool {
+		return legGroupID == "" || legGroupIDs[legGroupID]
+	}
+	for _, rule := range static.FareTransferRules {
+		if !keptOrEmpty(rule.FromLegGroupID) || !keptOrEmpty(rule.ToLegGroupID) {
+			continue
+		}
+		result.FareTransferRules = append(result.FareTransferRules, rule)
+		if rule.FareProductID != "" {
+			fareProductIDs[rule.FareProductID] = true
+		}
+	}
+
+	keepFareProducts := map[*gtfs.FareProduct]bool{}
+	keepFareMedia := map[*gtfs.FareMedium]bool{}
+	for i := range static.FareProducts {
+		fareProduct := &static.FareProducts[i]
+		if !fareProductIDs[fareProduct.ID] {
+			continue
+		}
+		keepFareProducts[fareProduct] = true
+		if fareProduct.FareMedium != nil {
+			keepFareMedia[fareProduct.FareMedium] = true
+		}
+	}
+	fareMedia := map[*gtfs.FareMedium]*gtfs.FareMedium{}
+	result.FareMedia = filter(static.FareMedia, keepFareMedia, fareMedia)
+	result.FareProducts = filter(static.FareProducts, keepFareProducts, map[*gtfs.FareProduct]*gtfs.FareProduct{})
+	for i := range result.FareProducts {
+		result.FareProducts[i].FareMedium = remap(result.FareProducts[i].FareMedium, fareMedia)
+	}
+}
+
+// filter returns copies of the kept elements of the slice, and populates the map from old to new pointers.
+//
+// The result is allocated up front so that the new pointers remain valid.
+func filter[T any](in []T, keep map[*T]bool, oldToNew map[*T]*T) []T {
+	var n int
+	for i := range in {
+		if keep[&in[i]] {
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	out := make([]T, 0, n)
+	for i := range in {
+		if !keep[&in[i]] {
+			continue
+		}
+		out = append(out, in[i])
+		oldToNew[&in[i]] = &out[len(out)-1]
+	}
+	return out
+}
+
+// remapKept returns the new pointers of the kept elements of the slice, in order.
+func remapKept[T any](in []*T, oldToNew map[*T]*T) []*T {
+	var out []*T
+	for _, p := range in {
+		if newP := remap(p, oldToNew); newP != nil {
+			out = append(out, newP)
+		}
+	}
+	return out
+}
+
+func remap[T any](p *T, oldToNew map[*T]*T) *T {
+	if p == nil {
+		return nil
+	}
+	return oldToNew[p]
+}
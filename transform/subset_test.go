@@ -0,0 +1,255 @@
+package transform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs"
+)
+
+func TestExtractSubset(t *testing.T) {
+	static := &gtfs.Static{
+		Agencies: []gtfs.Agency{{Id: "agency1"}, {Id: "agency2"}},
+		Routes:   []gtfs.Route{{Id: "route1"}, {Id: "route2"}},
+		Stops: []gtfs.Stop{
+			{Id: "station", Latitude: ptr(40.0), Longitude: ptr(-74.0)},
+			{Id: "stop1", Latitude: ptr(40.0), Longitude: ptr(-74.0)},
+			{Id: "stop2", Latitude: ptr(41.0), Longitude: ptr(-74.0)},
+			{Id: "stop3", Latitude: ptr(42.0), Longitude: ptr(-74.0)},
+		},
+		Services: []gtfs.Service{{Id: "service1"}, {Id: "service2"}},
+		Trips:    []gtfs.ScheduledTrip{{ID: "trip1"}, {ID: "trip2"}},
+	}
+	static.Routes[0].Agency = &static.Agencies[0]
+	static.Routes[1].Agency = &static.Agencies[1]
+	static.Stops[1].Parent = &static.Stops[0]
+	static.Transfers = []gtfs.Transfer{
+		{From: &static.Stops[1], To: &static.Stops[2]},
+		{From: &static.Stops[2], To: &static.Stops[3]},
+	}
+	static.Trips[0].Route = &static.Routes[0]
+	static.Trips[0].Service = &static.Services[0]
+	static.Trips[0].StopTimes = []gtfs.ScheduledStopTime{{Stop: &static.Stops[1]}, {Stop: &static.Stops[2]}}
+	static.Trips[1].Route = &static.Routes[1]
+	static.Trips[1].Service = &static.Services[1]
+	static.Trips[1].StopTimes = []gtfs.ScheduledStopTime{{Stop: &static.Stops[3]}}
+
+	for _, tc := range []struct {
+		desc          string
+		opts          SubsetOptions
+		wantTripIDs   []string
+		wantStopIDs   []string
+		wantTransfers int
+	}{
+		{
+			desc:          "by route",
+			opts:          SubsetOptions{RouteIDs: []string{"route1"}},
+			wantTripIDs:   []string{"trip1"},
+			wantStopIDs:   []string{"station", "stop1", "stop2"},
+			wantTransfers: 1,
+		},
+		{
+			desc: "by bounding box",
+			opts: SubsetOptions{BoundingBox: &BoundingBox{
+				MinLatitude:  41.5,
+				MaxLatitude:  42.5,
+				MinLongitude: -75,
+				MaxLongitude: -73,
+			}},
+			wantTripIDs:   []string{"trip2"},
+			wantStopIDs:   []string{"stop3"},
+			wantTransfers: 0,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := ExtractSubset(static, tc.opts)
+
+			var gotTripIDs []string
+			for _, trip := range got.Trips {
+				gotTripIDs = append(gotTripIDs, trip.ID)
+				if trip.Route != &got.Routes[0] || trip.Service != &got.Services[0] || trip.Route.Agency != &got.Agencies[0] {
+					t.Errorf("trip %s does not reference entities in the subset", trip.ID)
+				}
+			}
+			if diff := cmp.Diff(gotTripIDs, tc.wantTripIDs); diff != "" {
+				t.Errorf("trip IDs got = %v, want = %v", gotTripIDs, tc.wantTripIDs)
+			}
+			var gotStopIDs []string
+			for _, stop := range got.Stops {
+				gotStopIDs = append(gotStopIDs, stop.Id)
+			}
+			if diff := cmp.Diff(gotStopIDs, tc.wantStopIDs); diff != "" {
+				t.Errorf("stop IDs got = %v, want = %v", gotStopIDs, tc.wantStopIDs)
+			}
+			if len(got.Transfers) != tc.wantTransfers {
+				t.Errorf("got %d transfers, want %d", len(got.Transfers), tc.wantTransfers)
+			}
+		})
+	}
+}
+
+func TestExtractSubset_Flex(t *testing.T) {
+	static := &gtfs.Static{
+		Routes: []gtfs.Route{{Id: "route1"}, {Id: "route2"}},
+		Stops: []gtfs.Stop{
+			{Id: "stop1"},
+			{Id: "stop2"},
+			{Id: "stop3"},
+		},
+		Services:       []gtfs.Service{{Id: "service1"}, {Id: "service2"}, {Id: "booking_service"}},
+		LocationGroups: []gtfs.LocationGroup{{ID: "group1"}, {ID: "group2"}},
+		FlexLocations:  []gtfs.FlexLocation{{ID: "zone1"}, {ID: "zone2"}},
+		BookingRules:   []gtfs.BookingRule{{ID: "booking_rule1"}, {ID: "booking_rule2"}},
+		Trips:          []gtfs.ScheduledTrip{{ID: "trip1"}, {ID: "trip2"}},
+	}
+	static.LocationGroups[0].Stops = []*gtfs.Stop{&static.Stops[1]}
+	static.LocationGroups[1].Stops = []*gtfs.Stop{&static.Stops[2]}
+	static.BookingRules[0].PriorNoticeService = &static.Services[2]
+	static.Trips[0].Route = &static.Routes[0]
+	static.Trips[0].Service = &static.Services[0]
+	static.Trips[0].StopTimes = []gtfs.ScheduledStopTime{
+		{Stop: &static.Stops[0]},
+		{LocationGroup: &static.LocationGroups[0], PickupBookingRule: &static.BookingRules[0]},
+		{FlexLocation: &static.FlexLocations[0], DropOffBookingRule: &static.BookingRules[0]},
+	}
+	static.Trips[1].Route = &static.Routes[1]
+	static.Trips[1].Service = &static.Services[1]
+	static.Trips[1].StopTimes = []gtfs.ScheduledStopTime{
+		{LocationGroup: &static.LocationGroups[1], PickupBookingRule: &static.BookingRules[1]},
+		{FlexLocation: &static.FlexLocations[1]},
+	}
+
+	got := ExtractSubset(static, SubsetOptions{RouteIDs: []string{"route1"}})
+
+	if len(got.LocationGroups) != 1 || got.LocationGroups[0].ID != "group1" {
+		t.Fatalf("location groups got = %v, want [group1]", got.LocationGroups)
+	}
+	if len(got.FlexLocations) != 1 || got.FlexLocations[0].ID != "zone1" {
+		t.Fatalf("flex locations got = %v, want [zone1]", got.FlexLocations)
+	}
+	if len(got.BookingRules) != 1 || got.BookingRules[0].ID != "booking_rule1" {
+		t.Fatalf("booking rules got = %v, want [booking_rule1]", got.BookingRules)
+	}
+	var gotStopIDs []string
+	for _, stop := range got.Stops {
+		gotStopIDs = append(gotStopIDs, stop.Id)
+	}
+	if want := []string{"stop1", "stop2"}; !cmp.Equal(gotStopIDs, want) {
+		t.Errorf("stop IDs got = %v, want = %v", gotStopIDs, want)
+	}
+	var gotServiceIDs []string
+	for _, service := range got.Services {
+		gotServiceIDs = append(gotServiceIDs, service.Id)
+	}
+	if want := []string{"service1", "booking_service"}; !cmp.Equal(gotServiceIDs, want) {
+		t.Errorf("service IDs got = %v, want = %v", gotServiceIDs, want)
+	}
+	if got.LocationGroups[0].Stops[0] != &got.Stops[1] {
+		t.Errorf("location group stop does not reference a stop in the subset")
+	}
+	if got.BookingRules[0].PriorNoticeService != &got.Services[1] {
+		t.Errorf("booking rule prior notice service does not reference a service in the subset")
+	}
+	stopTimes := got.Trips[0].StopTimes
+	if stopTimes[1].LocationGroup != &got.LocationGroups[0] || stopTimes[1].PickupBookingRule != &got.BookingRules[0] {
+		t.Errorf("stop time does not reference the location group and booking rule in the subset")
+	}
+	if stopTimes[2].FlexLocation != &got.FlexLocations[0] || stopTimes[2].DropOffBookingRule != &got.BookingRules[0] {
+		t.Errorf("stop time does not reference the flex location and booking rule in the subset")
+	}
+	if static.Trips[0].StopTimes[1].LocationGroup != &static.LocationGroups[0] {
+		t.Errorf("ExtractSubset() modified the source message")
+	}
+}
+
+func TestExtractSubset_Fares(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("failed to load timezone: %s", err)
+	}
+	static := &gtfs.Static{
+		Routes:            []gtfs.Route{{Id: "route1"}, {Id: "route2"}},
+		Stops:             []gtfs.Stop{{Id: "stop1"}, {Id: "stop2"}},
+		Services:          []gtfs.Service{{Id: "service"}},
+		Trips:             []gtfs.ScheduledTrip{{ID: "trip1"}, {ID: "trip2"}},
+		Networks:          []gtfs.Network{{ID: "network1"}, {ID: "network2"}},
+		Areas:             []gtfs.Area{{ID: "area1"}, {ID: "area2"}},
+		FareMedia:         []gtfs.FareMedium{{ID: "card"}, {ID: "cash"}},
+		FareProducts:      []gtfs.FareProduct{{ID: "product1"}, {ID: "product2"}},
+		FareLegRules:      []gtfs.FareLegRule{{LegGroupID: "leg1", FareProductID: "product1"}, {LegGroupID: "leg2", FareProductID: "product2"}},
+		FareTransferRules: []gtfs.FareTransferRule{{FromLegGroupID: "leg1", ToLegGroupID: "leg1"}, {FromLegGroupID: "leg1", ToLegGroupID: "leg2"}},
+		FeedInfo:          &gtfs.FeedInfo{PublisherName: "publisher"},
+		Timezone:          newYork,
+	}
+	static.FareRules = []gtfs.FareRule{{FareID: "route1_fare", Route: &static.Routes[0]}, {FareID: "route2_fare", Route: &static.Routes[1]}, {FareID: "zone_fare"}}
+	static.Routes[0].Network = &static.Networks[0]
+	static.Routes[1].Network = &static.Networks[1]
+	static.Networks[0].Routes = []*gtfs.Route{&static.Routes[0]}
+	static.Networks[1].Routes = []*gtfs.Route{&static.Routes[1]}
+	static.Areas[0].Stops = []*gtfs.Stop{&static.Stops[0], &static.Stops[1]}
+	static.Areas[1].Stops = []*gtfs.Stop{&static.Stops[1]}
+	static.FareProducts[0].FareMedium = &static.FareMedia[0]
+	static.FareProducts[1].FareMedium = &static.FareMedia[1]
+	static.FareLegRules[0].Network = &static.Networks[0]
+	static.FareLegRules[0].FromArea = &static.Areas[0]
+	static.FareLegRules[1].Network = &static.Networks[1]
+	for i, stop := range []*gtfs.Stop{&static.Stops[0], &static.Stops[1]} {
+		static.Trips[i].Route = &static.Routes[i]
+		static.Trips[i].Service = &static.Services[0]
+		static.Trips[i].StopTimes = []gtfs.ScheduledStopTime{{Stop: stop}}
+	}
+
+	got := ExtractSubset(static, SubsetOptions{RouteIDs: []string{"route1"}})
+
+	if got.Timezone != newYork {
+		t.Errorf("timezone got = %v, want %v", got.Timezone, newYork)
+	}
+	if got.FeedInfo == nil || got.FeedInfo == static.FeedInfo || got.FeedInfo.PublisherName != "publisher" {
+		t.Errorf("feed info got = %v, want a copy of %v", got.FeedInfo, static.FeedInfo)
+	}
+	var gotFareIDs []string
+	for _, fareRule := range got.FareRules {
+		gotFareIDs = append(gotFareIDs, fareRule.FareID)
+	}
+	if want := []string{"route1_fare", "zone_fare"}; !cmp.Equal(gotFareIDs, want) {
+		t.Errorf("fare IDs got = %v, want = %v", gotFareIDs, want)
+	}
+	if got.FareRules[0].Route != &got.Routes[0] {
+		t.Errorf("fare rule route does not reference a route in the subset")
+	}
+	if len(got.Networks) != 1 || got.Networks[0].ID != "network1" {
+		t.Fatalf("networks got = %v, want [network1]", got.Networks)
+	}
+	if got.Routes[0].Network != &got.Networks[0] || len(got.Networks[0].Routes) != 1 || got.Networks[0].Routes[0] != &got.Routes[0] {
+		t.Errorf("route and network do not reference each other in the subset")
+	}
+	if len(got.Areas) != 1 || got.Areas[0].ID != "area1" {
+		t.Fatalf("areas got = %v, want [area1]", got.Areas)
+	}
+	if len(got.Areas[0].Stops) != 1 || got.Areas[0].Stops[0] != &got.Stops[0] {
+		t.Errorf("area stops got = %v, want the kept stop", got.Areas[0].Stops)
+	}
+	if len(got.FareLegRules) != 1 || got.FareLegRules[0].LegGroupID != "leg1" {
+		t.Fatalf("fare leg rules got = %v, want [leg1]", got.FareLegRules)
+	}
+	if got.FareLegRules[0].Network != &got.Networks[0] || got.FareLegRules[0].FromArea != &got.Areas[0] {
+		t.Errorf("fare leg rule does not reference the network and area in the subset")
+	}
+	if len(got.FareTransferRules) != 1 || got.FareTransferRules[0].ToLegGroupID != "leg1" {
+		t.Errorf("fare transfer rules got = %v, want the transfer within leg1", got.FareTransferRules)
+	}
+	if len(got.FareProducts) != 1 || got.FareProducts[0].ID != "product1" {
+		t.Fatalf("fare products got = %v, want [product1]", got.FareProducts)
+	}
+	if len(got.FareMedia) != 1 || got.FareProducts[0].FareMedium != &got.FareMedia[0] {
+		t.Errorf("fare product medium does not reference a fare medium in the subset")
+	}
+	if static.Routes[0].Network != &static.Networks[0] || len(static.Areas[0].Stops) != 2 {
+		t.Errorf("ExtractSubset() modified the source message")
+	}
+}
+
+func ptr[T any](t T) *T {
+	return &t
+}
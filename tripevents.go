@@ -0,0 +1,162 @@
+package gtfs
+
+import (
+	"time"
+
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+)
+
+// TripEvent is a change to a trip between two consecutive realtime messages, as returned by
+// [DiffTrips]. The event is one of [TripAdded], [TripCancelled], [PredictionChanged] and
+// [VehicleAssigned].
+type TripEvent interface {
+	// GetTrip returns the trip the event is about, in the newer message.
+	GetTrip() *Trip
+}
+
+// TripAdded is emitted for a trip that is in the newer message but not in the older message.
+type TripAdded struct {
+	Trip *Trip
+}
+
+// TripCancelled is emitted for a trip that is cancelled in the newer message but was not cancelled,
+// or was not present, in the older message.
+type TripCancelled struct {
+	Trip *Trip
+}
+
+// PredictionChanged is emitted for a stop time update whose predicted time changed by at least
+// [DiffTripsOptions.PredictionThreshold]. The departure time is compared if it is predicted in both
+// messages, and otherwise the arrival time.
+type PredictionChanged struct {
+	Trip           *Trip
+	StopTimeUpdate *StopTimeUpdate
+	// Whether the departure time, rather than the arrival time, was compared.
+	IsDeparture  bool
+	PreviousTime time.Time
+	Time         time.Time
+}
+
+// VehicleAssigned is emitted for a trip that has a vehicle with an ID in the newer message, and had
+// no vehicle or a vehicle with a different ID in the older message.
+type VehicleAssigned struct {
+	Trip    *Trip
+	Vehicle *Vehicle
+}
+
+func (e TripAdded) GetTrip() *Trip         { return e.Trip }
+func (e TripCancelled) GetTrip() *Trip     { return e.Trip }
+func (e PredictionChanged) GetTrip() *Trip { return e.Trip }
+func (e VehicleAssigned) GetTrip() *Trip   { return e.Trip }
+
+// DiffTripsOptions configures [DiffTrips].
+type DiffTripsOptions struct {
+	// Smallest change in a predicted time that is reported as a [PredictionChanged] event. If zero,
+	// every change is reported.
+	PredictionThreshold time.Duration
+}
+
+// DiffTrips returns the events in the lifecycle of the trips that happened between the previous and
+// current realtime messages.
+//
+// Trips are matched across the messages by trip ID, start date and start time, and stop time
+// updates are matched by stop sequence or, if either update does not have one, by stop ID. Events
+// are returned in the order of the trips in the current message. Trips that are in the previous
+// message but not the current message do not generate events, as feeds usually drop trips once
+// they are complete.
+func DiffTrips(previous, current *Realtime, opts DiffTripsOptions) []TripEvent {
+	keyToPreviousTrip := map[tripKey]*Trip{}
+	if previous != nil {
+		for i := range previous.Trips {
+			key := newTripKey(previous.Trips[i].ID)
+			if _, ok := keyToPreviousTrip[key]; !ok {
+				keyToPreviousTrip[key] = &previous.Trips[i]
+			}
+		}
+	}
+	var events []TripEvent
+	for i := range current.Trips {
+		trip := &current.Trips[i]
+		previousTrip := keyToPreviousTrip[newTripKey(trip.ID)]
+		if previousTrip == nil {
+			events = append(events, TripAdded{Trip: trip})
+		}
+		if isCancelled(trip) && (previousTrip == nil || !isCancelled(previousTrip)) {
+			events = append(events, TripCancelled{Trip: trip})
+		}
+		if vehicleID := trip.GetVehicle().ID; vehicleID != nil && vehicleID.ID != "" {
+			previousVehicleID := previousTrip.GetVehicle().ID
+			if previousVehicleID == nil || previousVehicleID.ID != vehicleID.ID {
+				events = append(events, VehicleAssigned{Trip: trip, Vehicle: trip.Vehicle})
+			}
+		}
+		if previousTrip == nil {
+			continue
+		}
+		for j := range trip.StopTimeUpdates {
+			stopTimeUpdate := &trip.StopTimeUpdates[j]
+			previousStopTimeUpdate := findStopTimeUpdate(previousTrip, stopTimeUpdate)
+			if previousStopTimeUpdate == nil {
+				continue
+			}
+			event := PredictionChanged{Trip: trip, StopTimeUpdate: stopTimeUpdate}
+			if t, previousT := stopTimeUpdate.GetDeparture().Time, previousStopTimeUpdate.GetDeparture().Time; t != nil && previousT != nil {
+				event.IsDeparture = true
+				event.PreviousTime, event.Time = *previousT, *t
+			} else if t, previousT := stopTimeUpdate.GetArrival().Time, previousStopTimeUpdate.GetArrival().Time; t != nil && previousT != nil {
+				event.PreviousTime, event.Time = *previousT, *t
+			} else {
+				continue
+			}
+			change := event.Time.Sub(event.PreviousTime)
+			if change < 0 {
+				change = -change
+			}
+			if change == 0 || change < opts.PredictionThreshold {
+				continue
+			}
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// tripKey identifies a trip across realtime messages. Unlike [TripID], it does not include the
+// schedule relationship, which changes when a trip is cancelled.
+type tripKey struct {
+	id           string
+	hasStartDate bool
+	startDate    int64
+	hasStartTime bool
+	startTime    time.Duration
+}
+
+func newTripKey(id TripID) tripKey {
+	return tripKey{
+		id:           id.ID,
+		hasStartDate: id.HasStartDate,
+		startDate:    id.StartDate.Unix(),
+		hasStartTime: id.HasStartTime,
+		startTime:    id.StartTime,
+	}
+}
+
+func isCancelled(trip *Trip) bool {
+	return trip.ID.ScheduleRelationship == gtfsrt.TripDescriptor_CANCELED
+}
+
+func findStopTimeUpdate(trip *Trip, target *StopTimeUpdate) *StopTimeUpdate {
+	for i := range trip.StopTimeUpdates {
+		stopTimeUpdate := &trip.StopTimeUpdates[i]
+		if stopTimeUpdate.StopSequence != nil && target.StopSequence != nil {
+			if *stopTimeUpdate.StopSequence == *target.StopSequence {
+				return stopTimeUpdate
+			}
+			continue
+		}
+		if stopTimeUpdate.StopID != nil && target.StopID != nil && *stopTimeUpdate.StopID == *target.StopID {
+			return stopTimeUpdate
+		}
+	}
+	return nil
+}
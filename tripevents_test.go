@@ -0,0 +1,96 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+)
+
+func TestDiffTrips(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	stopTimeUpdate := func(stopSequence uint32, arrival, departure *time.Time) StopTimeUpdate {
+		u := StopTimeUpdate{StopSequence: ptr(stopSequence)}
+		if arrival != nil {
+			u.Arrival = &StopTimeEvent{Time: arrival}
+		}
+		if departure != nil {
+			u.Departure = &StopTimeEvent{Time: departure}
+		}
+		return u
+	}
+	previous := &Realtime{
+		Trips: []Trip{
+			{
+				ID: TripID{ID: "unchanged"},
+				StopTimeUpdates: []StopTimeUpdate{
+					stopTimeUpdate(1, nil, ptr(t0)),
+				},
+			},
+			{
+				ID: TripID{ID: "delayed"},
+				StopTimeUpdates: []StopTimeUpdate{
+					stopTimeUpdate(1, ptr(t0), ptr(t0)),
+					stopTimeUpdate(2, ptr(t0.Add(5*time.Minute)), nil),
+					stopTimeUpdate(3, ptr(t0.Add(10*time.Minute)), nil),
+				},
+			},
+			{ID: TripID{ID: "cancelled"}},
+			{ID: TripID{ID: "assigned"}},
+			{ID: TripID{ID: "removed"}},
+		},
+	}
+	current := &Realtime{
+		Trips: []Trip{
+			{
+				ID: TripID{ID: "unchanged"},
+				StopTimeUpdates: []StopTimeUpdate{
+					stopTimeUpdate(1, nil, ptr(t0)),
+				},
+			},
+			{
+				ID: TripID{ID: "delayed"},
+				StopTimeUpdates: []StopTimeUpdate{
+					stopTimeUpdate(1, ptr(t0), ptr(t0.Add(2*time.Minute))),
+					stopTimeUpdate(2, ptr(t0.Add(5*time.Minute+30*time.Second)), nil),
+					stopTimeUpdate(3, ptr(t0.Add(8*time.Minute)), nil),
+					stopTimeUpdate(4, ptr(t0.Add(15*time.Minute)), nil),
+				},
+			},
+			{ID: TripID{ID: "cancelled", ScheduleRelationship: gtfsrt.TripDescriptor_CANCELED}},
+			{ID: TripID{ID: "assigned"}, Vehicle: &Vehicle{ID: &VehicleID{ID: "vehicle"}}},
+			{ID: TripID{ID: "added"}},
+		},
+	}
+
+	got := DiffTrips(previous, current, DiffTripsOptions{PredictionThreshold: time.Minute})
+
+	delayed := &current.Trips[1]
+	want := []TripEvent{
+		PredictionChanged{
+			Trip:           delayed,
+			StopTimeUpdate: &delayed.StopTimeUpdates[0],
+			IsDeparture:    true,
+			PreviousTime:   t0,
+			Time:           t0.Add(2 * time.Minute),
+		},
+		PredictionChanged{
+			Trip:           delayed,
+			StopTimeUpdate: &delayed.StopTimeUpdates[2],
+			PreviousTime:   t0.Add(10 * time.Minute),
+			Time:           t0.Add(8 * time.Minute),
+		},
+		TripCancelled{Trip: &current.Trips[2]},
+		VehicleAssigned{Trip: &current.Trips[3], Vehicle: current.Trips[3].Vehicle},
+		TripAdded{Trip: &current.Trips[4]},
+	}
+	// Pointers are compared by identity so that the diff shows which trip an event is about.
+	if diff := cmp.Diff(got, want, cmp.Comparer(func(a, b *Trip) bool { return a == b })); diff != "" {
+		t.Errorf("DiffTrips() diff: %s", diff)
+	}
+
+	if got := DiffTrips(current, current, DiffTripsOptions{}); len(got) != 0 {
+		t.Errorf("DiffTrips() of a message with itself = %v, want no events", got)
+	}
+}
@@ -0,0 +1,192 @@
+package gtfs
+
+import (
+	"fmt"
+	"time"
+
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// TripDeviation is a simple model of how a trip deviates from its schedule.
+type TripDeviation struct {
+	TripID string
+	// Start date of the trip. If zero, the start date is not set in the trip descriptor.
+	StartDate time.Time
+	// The trip runs this much later than scheduled from the stop with ID FromStopID onward.
+	// Negative delays mean the trip is early.
+	Delay time.Duration
+	// Stop from which the delay applies. If empty, the delay applies to the whole trip.
+	FromStopID string
+	// If true, the trip is canceled and the delay is ignored.
+	Canceled bool
+}
+
+// TripUpdateFeedBuilder converts trip deviations into a GTFS realtime feed of trip updates, which is
+// useful for simulating a feed when testing downstream consumers.
+type TripUpdateFeedBuilder struct {
+	static     *Static
+	deviations []TripDeviation
+}
+
+// NewTripUpdateFeedBuilder returns a builder with no deviations.
+//
+// If the static message is not nil, trips and stops are validated against it and each delayed trip
+// has a stop time update for every stop from the delayed stop onward. Otherwise each delayed trip
+// has at most one stop time update, and consumers are expected to propagate the delay to the
+// following stops, as described in the GTFS realtime specification.
+func NewTripUpdateFeedBuilder(static *Static) *TripUpdateFeedBuilder {
+	return &TripUpdateFeedBuilder{static: static}
+}
+
+// Add adds deviations to the builder.
+func (b *TripUpdateFeedBuilder) Add(deviations ...TripDeviation) *TripUpdateFeedBuilder {
+	b.deviations = append(b.deviations, deviations...)
+	return b
+}
+
+// DelayTrip adds a deviation in which the trip is delayed from the stop onward.
+func (b *TripUpdateFeedBuilder) DelayTrip(tripID, fromStopID string, delay time.Duration) *TripUpdateFeedBuilder {
+	return b.Add(TripDeviation{TripID: tripID, FromStopID: fromStopID, Delay: delay})
+}
+
+// CancelTrip adds a deviation in which the trip is canceled.
+func (b *TripUpdateFeedBuilder) CancelTrip(tripID string) *TripUpdateFeedBuilder {
+	return b.Add(TripDeviation{TripID: tripID, Canceled: true})
+}
+
+// Build returns a full dataset GTFS realtime message with one trip update entity per deviation, in
+// the order the deviations were added. Delays are rounded down to whole seconds.
+//
+// The ID of each entity is the trip ID, followed by the start date in YYYYMMDD format if it is set.
+// An error is returned if a deviation has no trip ID, if two deviations are for the same trip and
+// start date, or, when the builder has a static message, if a trip or stop is not in the message.
+func (b *TripUpdateFeedBuilder) Build(createdAt time.Time) (*gtfsrt.FeedMessage, error) {
+	feedMessage := &gtfsrt.FeedMessage{
+		Header: &gtfsrt.FeedHeader{
+			GtfsRealtimeVersion: proto.String("2.0"),
+			Incrementality:      gtfsrt.FeedHeader_FULL_DATASET.Enum(),
+			Timestamp:           proto.Uint64(uint64(createdAt.Unix())),
+		},
+	}
+	tripIDToStopTimes, err := b.stopTimesOfDeviatedTrips()
+	if err != nil {
+		return nil, err
+	}
+	seenIDs := map[string]bool{}
+	for i := range b.deviations {
+		deviation := &b.deviations[i]
+		if deviation.TripID == "" {
+			return nil, fmt.Errorf("trip deviation %d has no trip ID", i)
+		}
+		id := deviation.TripID
+		if !deviation.StartDate.IsZero() {
+			id += "_" + deviation.StartDate.Format("20060102")
+		}
+		if seenIDs[id] {
+			return nil, fmt.Errorf("trip deviation %q: trip is repeated", id)
+		}
+		seenIDs[id] = true
+		tripUpdate, err := b.tripUpdate(deviation, tripIDToStopTimes)
+		if err != nil {
+			return nil, fmt.Errorf("trip deviation %q: %w", id, err)
+		}
+		feedMessage.Entity = append(feedMessage.Entity, &gtfsrt.FeedEntity{
+			Id:         proto.String(id),
+			TripUpdate: tripUpdate,
+		})
+	}
+	return feedMessage, nil
+}
+
+// stopTimesOfDeviatedTrips returns the stop times of the trips in the deviations, if the builder has
+// a static message.
+func (b *TripUpdateFeedBuilder) stopTimesOfDeviatedTrips() (map[string][]ScheduledStopTime, error) {
+	if b.static == nil {
+		return nil, nil
+	}
+	tripIDToStopTimes := map[string][]ScheduledStopTime{}
+	for _, deviation := range b.deviations {
+		tripIDToStopTimes[deviation.TripID] = nil
+	}
+	err := b.static.ForEachStopTime(func(trip ScheduledTrip, stopTime ScheduledStopTime) bool {
+		if stopTimes, ok := tripIDToStopTimes[trip.ID]; ok {
+			tripIDToStopTimes[trip.ID] = append(stopTimes, stopTime)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tripIDToStopTimes, nil
+}
+
+func (b *TripUpdateFeedBuilder) tripUpdate(deviation *TripDeviation, tripIDToStopTimes map[string][]ScheduledStopTime) (*gtfsrt.TripUpdate, error) {
+	tripDescriptor := &gtfsrt.TripDescriptor{
+		TripId: proto.String(deviation.TripID),
+	}
+	if !deviation.StartDate.IsZero() {
+		tripDescriptor.StartDate = proto.String(deviation.StartDate.Format("20060102"))
+	}
+	var stopTimes []ScheduledStopTime
+	if b.static != nil {
+		trip := b.static.TripByID(deviation.TripID)
+		if trip == nil {
+			return nil, fmt.Errorf("trip is not in the static message")
+		}
+		if trip.Route != nil {
+			tripDescriptor.RouteId = proto.String(trip.Route.Id)
+		}
+		switch trip.DirectionId {
+		case DirectionID_False:
+			tripDescriptor.DirectionId = proto.Uint32(0)
+		case DirectionID_True:
+			tripDescriptor.DirectionId = proto.Uint32(1)
+		}
+		stopTimes = tripIDToStopTimes[deviation.TripID]
+	}
+	tripUpdate := &gtfsrt.TripUpdate{Trip: tripDescriptor}
+	if deviation.Canceled {
+		tripDescriptor.ScheduleRelationship = gtfsrt.TripDescriptor_CANCELED.Enum()
+		return tripUpdate, nil
+	}
+	delay := int32(deviation.Delay / time.Second)
+	if b.static == nil {
+		if deviation.FromStopID == "" {
+			tripUpdate.Delay = proto.Int32(delay)
+		} else {
+			tripUpdate.StopTimeUpdate = []*gtfsrt.TripUpdate_StopTimeUpdate{
+				newDelayedStopTimeUpdate(deviation.FromStopID, nil, delay),
+			}
+		}
+		return tripUpdate, nil
+	}
+	delayed := deviation.FromStopID == ""
+	for _, stopTime := range stopTimes {
+		if stopTime.Stop == nil {
+			continue
+		}
+		if stopTime.Stop.Id == deviation.FromStopID {
+			delayed = true
+		}
+		if !delayed {
+			continue
+		}
+		stopSequence := uint32(stopTime.StopSequence)
+		tripUpdate.StopTimeUpdate = append(tripUpdate.StopTimeUpdate,
+			newDelayedStopTimeUpdate(stopTime.Stop.Id, &stopSequence, delay))
+	}
+	if !delayed {
+		return nil, fmt.Errorf("stop %q is not on the trip", deviation.FromStopID)
+	}
+	return tripUpdate, nil
+}
+
+func newDelayedStopTimeUpdate(stopID string, stopSequence *uint32, delay int32) *gtfsrt.TripUpdate_StopTimeUpdate {
+	return &gtfsrt.TripUpdate_StopTimeUpdate{
+		StopSequence: stopSequence,
+		StopId:       proto.String(stopID),
+		Arrival:      &gtfsrt.TripUpdate_StopTimeEvent{Delay: proto.Int32(delay)},
+		Departure:    &gtfsrt.TripUpdate_StopTimeEvent{Delay: proto.Int32(delay)},
+	}
+}
@@ -0,0 +1,141 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	gtfsrt "github.com/jamespfennell/gtfs/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTripUpdateFeedBuilder(t *testing.T) {
+	static, err := ParseStatic(newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id\nstop_1\nstop_2\nstop_3",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id,direction_id\nroute_id,service_id,trip_1,1\nroute_id,service_id,trip_2,0",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,arrival_time,departure_time,stop_sequence\n"+
+			"trip_1,stop_1,08:00:00,08:00:00,1\n"+
+			"trip_1,stop_2,08:10:00,08:10:00,2\n"+
+			"trip_1,stop_3,08:20:00,08:20:00,3",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse static feed: %s", err)
+	}
+	startDate := time.Date(2022, 5, 4, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name      string
+		static    *Static
+		deviation TripDeviation
+		want      []StopTimeUpdate
+		wantTrip  TripID
+	}{
+		{
+			name:      "with static",
+			static:    static,
+			deviation: TripDeviation{TripID: "trip_1", StartDate: startDate, FromStopID: "stop_2", Delay: 5 * time.Minute},
+			want: []StopTimeUpdate{
+				delayedStopTimeUpdate("stop_2", ptr(uint32(2)), 5*time.Minute),
+				delayedStopTimeUpdate("stop_3", ptr(uint32(3)), 5*time.Minute),
+			},
+			wantTrip: TripID{
+				ID:           "trip_1",
+				RouteID:      "route_id",
+				DirectionID:  DirectionID_True,
+				HasStartDate: true,
+				StartDate:    startDate,
+			},
+		},
+		{
+			name:      "without static",
+			deviation: TripDeviation{TripID: "trip_1", FromStopID: "stop_2", Delay: -time.Minute},
+			want: []StopTimeUpdate{
+				delayedStopTimeUpdate("stop_2", nil, -time.Minute),
+			},
+			wantTrip: TripID{ID: "trip_1"},
+		},
+		{
+			name:      "canceled",
+			static:    static,
+			deviation: TripDeviation{TripID: "trip_2", Canceled: true},
+			wantTrip: TripID{
+				ID:                   "trip_2",
+				RouteID:              "route_id",
+				DirectionID:          DirectionID_False,
+				ScheduleRelationship: gtfsrt.TripDescriptor_CANCELED,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			feedMessage, err := NewTripUpdateFeedBuilder(tc.static).Add(tc.deviation).Build(time.Unix(0, 0))
+			if err != nil {
+				t.Fatalf("Build() err = %v", err)
+			}
+			b, err := proto.Marshal(feedMessage)
+			if err != nil {
+				t.Fatalf("failed to marshal feed message: %s", err)
+			}
+			realtime, err := ParseRealtime(b, &ParseRealtimeOptions{Strictness: Strictness_Strict})
+			if err != nil {
+				t.Fatalf("failed to parse feed message: %s", err)
+			}
+			if len(realtime.Trips) != 1 {
+				t.Fatalf("len(Trips) = %d, want 1", len(realtime.Trips))
+			}
+			if diff := cmp.Diff(realtime.Trips[0].ID, tc.wantTrip); diff != "" {
+				t.Errorf("trip ID diff: %s", diff)
+			}
+			if diff := cmp.Diff(realtime.Trips[0].StopTimeUpdates, tc.want); diff != "" {
+				t.Errorf("stop time updates diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestTripUpdateFeedBuilder_Invalid(t *testing.T) {
+	static, err := ParseStatic(newZipBuilderWithDefaults().build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse static feed: %s", err)
+	}
+	for _, tc := range []struct {
+		name       string
+		deviations []TripDeviation
+	}{
+		{
+			name:       "no trip ID",
+			deviations: []TripDeviation{{Delay: time.Minute}},
+		},
+		{
+			name:       "repeated trip",
+			deviations: []TripDeviation{{TripID: "trip_id"}, {TripID: "trip_id", Canceled: true}},
+		},
+		{
+			name:       "unknown trip",
+			deviations: []TripDeviation{{TripID: "trip_2"}},
+		},
+		{
+			name:       "stop not on trip",
+			deviations: []TripDeviation{{TripID: "trip_id", FromStopID: "stop_2"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewTripUpdateFeedBuilder(static).Add(tc.deviations...).Build(time.Unix(0, 0)); err == nil {
+				t.Errorf("Build() err = nil, want error")
+			}
+		})
+	}
+}
+
+func delayedStopTimeUpdate(stopID string, stopSequence *uint32, delay time.Duration) StopTimeUpdate {
+	return StopTimeUpdate{
+		StopSequence: stopSequence,
+		StopID:       ptr(stopID),
+		Arrival:      &StopTimeEvent{Delay: ptr(delay)},
+		Departure:    &StopTimeEvent{Delay: ptr(delay)},
+	}
+}
@@ -1,6 +1,7 @@
 package warnings
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/jamespfennell/gtfs/constants"
@@ -26,15 +27,41 @@ type StaticWarning struct {
 }
 
 func NewStaticWarning(csvFile *csv.File, kind StaticWarningKind) StaticWarning {
+	// The CSV reader reuses the slice of the row for the next row, so it is copied.
+	rowContent := append([]string(nil), csvFile.RowContent()...)
 	return StaticWarning{
 		Kind:          kind,
 		File:          csvFile.Name(),
 		RowNumber:     csvFile.RowNumber(),
-		RowContent:    csvFile.RowContent(),
+		RowContent:    rowContent,
 		HeaderContent: csvFile.HeaderContent(),
 	}
 }
 
+// MarshalJSON encodes the warning as a JSON object with the file, row number and row content, the
+// code and message of the kind, and the fields of the kind under the "details" key.
+func (w StaticWarning) MarshalJSON() ([]byte, error) {
+	var code, message string
+	if w.Kind != nil {
+		code, message = w.Kind.Code(), w.Kind.Error()
+	}
+	return json.Marshal(struct {
+		File       constants.StaticFile `json:"file"`
+		RowNumber  int                  `json:"rowNumber"`
+		RowContent []string             `json:"rowContent"`
+		Code       string               `json:"code"`
+		Message    string               `json:"message"`
+		Details    StaticWarningKind    `json:"details"`
+	}{
+		File:       w.File,
+		RowNumber:  w.RowNumber,
+		RowContent: w.RowContent,
+		Code:       code,
+		Message:    message,
+		Details:    w.Kind,
+	})
+}
+
 // StaticWarningKind represents the kind of warning raised during GTFS static parsing.
 //
 // StaticWarningKind satisfies the error interface.
@@ -42,6 +69,12 @@ type StaticWarningKind interface {
 	// Text of the warning message.
 	Error() string // TODO: Message()
 
+	// Stable, machine-readable code for the kind of warning, in snake case.
+	//
+	// Unlike the message, the code does not depend on the details of the warning and does not change
+	// between versions of this package, so it can be used to aggregate warnings across feeds.
+	Code() string
+
 	// TODO: Fatal() ? And convert all parsing errors into warnings
 }
 
@@ -53,6 +86,10 @@ func (w MissingColumns) Error() string {
 	return fmt.Sprintf("csv file is missing columns %s", w.Columns)
 }
 
+func (w MissingColumns) Code() string {
+	return "missing_columns"
+}
+
 type AgencyMissingValues struct {
 	AgencyID string
 	Columns  []string
@@ -61,3 +98,126 @@ type AgencyMissingValues struct {
 func (w AgencyMissingValues) Error() string {
 	return fmt.Sprintf("agency %q is missing values %s", w.AgencyID, w.Columns)
 }
+
+func (w AgencyMissingValues) Code() string {
+	return "agency_missing_values"
+}
+
+type AgencyTimezoneDiffers struct {
+	AgencyID         string
+	Timezone         string
+	ExpectedTimezone string
+}
+
+func (w AgencyTimezoneDiffers) Error() string {
+	return fmt.Sprintf("agency %q has timezone %q which differs from the timezone %q of the first agency", w.AgencyID, w.Timezone, w.ExpectedTimezone)
+}
+
+func (w AgencyTimezoneDiffers) Code() string {
+	return "agency_timezone_differs"
+}
+
+type InvalidForeignID struct {
+	Column string
+	ID     string
+}
+
+func (w InvalidForeignID) Error() string {
+	return fmt.Sprintf("%s %q does not reference an existing entity", w.Column, w.ID)
+}
+
+func (w InvalidForeignID) Code() string {
+	return "invalid_foreign_id"
+}
+
+type StopTimesNotGroupedByTrip struct {
+	TripID string
+}
+
+func (w StopTimesNotGroupedByTrip) Error() string {
+	return fmt.Sprintf("stop times of trip %q are not contiguous; stop_times.txt should be grouped by trip", w.TripID)
+}
+
+func (w StopTimesNotGroupedByTrip) Code() string {
+	return "stop_times_not_grouped_by_trip"
+}
+
+// InvalidGeoJSON is raised if the locations.geojson file cannot be read as GeoJSON. The warning is not
+// associated with a row.
+type InvalidGeoJSON struct {
+	Err string
+}
+
+func (w InvalidGeoJSON) Error() string {
+	return fmt.Sprintf("invalid GeoJSON: %s", w.Err)
+}
+
+func (w InvalidGeoJSON) Code() string {
+	return "invalid_geojson"
+}
+
+// RealtimeWarning is a warning raised during GTFS realtime parsing.
+type RealtimeWarning struct {
+	// Kind of warning
+	Kind RealtimeWarningKind
+	// ID of the feed entity the warning comes from
+	EntityID string
+}
+
+// RealtimeWarningKind represents the kind of warning raised during GTFS realtime parsing.
+//
+// RealtimeWarningKind satisfies the error interface.
+type RealtimeWarningKind interface {
+	// Text of the warning message.
+	Error() string
+}
+
+// MissingRequiredFields is raised if the message is missing required fields but is parsed anyway.
+// The warning is not associated with an entity.
+type MissingRequiredFields struct {
+	Err string
+}
+
+func (w MissingRequiredFields) Error() string {
+	return fmt.Sprintf("message is missing required fields: %s", w.Err)
+}
+
+type DuplicateTrip struct {
+	TripID string
+}
+
+func (w DuplicateTrip) Error() string {
+	return fmt.Sprintf("trip %q appears in multiple trip update entities", w.TripID)
+}
+
+type MissingTripDescriptor struct{}
+
+func (w MissingTripDescriptor) Error() string {
+	return "trip update does not have a trip descriptor"
+}
+
+type InvalidStartDate struct {
+	StartDate string
+}
+
+func (w InvalidStartDate) Error() string {
+	return fmt.Sprintf("trip descriptor has invalid start date %q", w.StartDate)
+}
+
+type InvalidStartTime struct {
+	StartTime string
+}
+
+func (w InvalidStartTime) Error() string {
+	return fmt.Sprintf("trip descriptor has invalid start time %q", w.StartTime)
+}
+
+type UnresolvedInformedEntity struct {
+	// Type of the entity: "agency", "route", "stop" or "trip".
+	EntityType string
+	ID         string
+}
+
+func (w UnresolvedInformedEntity) Error() string {
+	return fmt.Sprintf("alert informed entity references %s %q which is not in the static feed", w.EntityType, w.ID)
+}
@@ -1,7 +1,58 @@
 package warnings
 
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jamespfennell/gtfs/constants"
+)
+
 // Verify that StaticWarningKind satisfies the error interface.
 var (
 	w StaticWarningKind = nil
 	e error             = w
 )
+
+// Verify that RealtimeWarningKind satisfies the error interface.
+var (
+	rw RealtimeWarningKind = nil
+	re error               = rw
+)
+
+func TestStaticWarning_MarshalJSON(t *testing.T) {
+	w := StaticWarning{
+		Kind:          InvalidForeignID{Column: "route_id", ID: "A"},
+		File:          constants.TripsFile,
+		RowNumber:     3,
+		RowContent:    []string{"A", "trip"},
+		HeaderContent: []string{"route_id", "trip_id"},
+	}
+
+	b, err := json.Marshal(w)
+	if err != nil {
+		t.Fatalf("json.Marshal() err = %v", err)
+	}
+
+	want := `{"file":"trips.txt","rowNumber":3,"rowContent":["A","trip"],"code":"invalid_foreign_id",` +
+		`"message":"route_id \"A\" does not reference an existing entity","details":{"Column":"route_id","ID":"A"}}`
+	if string(b) != want {
+		t.Errorf("json.Marshal() got:\n%s\nwant:\n%s", b, want)
+	}
+}
+
+func TestStaticWarningKind_CodesAreUnique(t *testing.T) {
+	codeToKind := map[string]StaticWarningKind{}
+	for _, kind := range []StaticWarningKind{
+		MissingColumns{},
+		AgencyMissingValues{},
+		AgencyTimezoneDiffers{},
+		InvalidForeignID{},
+		StopTimesNotGroupedByTrip{},
+		InvalidGeoJSON{},
+	} {
+		if other, ok := codeToKind[kind.Code()]; ok {
+			t.Errorf("%T and %T have the same code %q", kind, other, kind.Code())
+		}
+		codeToKind[kind.Code()] = kind
+	}
+}